@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	libapp "github.com/ductm54/transfer-track/internal/app"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+// transferFetcher fetches the new transfers of every source address once, implemented by
+// service.TransferService.
+type transferFetcher interface {
+	FetchContext(ctx context.Context) (context.Context, context.CancelFunc)
+	FetchAndStoreTransfersStrict(ctx context.Context) (int, error)
+}
+
+// fetchCommand returns the fetch subcommand, fetching and storing the new transfers of every source
+// address once, for deployments that refresh on an external schedule such as cron.
+func fetchCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "fetch",
+		Usage:  "Fetch and store new transfers once and exit, without serving the API",
+		Action: fetchWith(setupFetcher),
+	}
+}
+
+// setupFetcher sets up the transfer service as the server does, without starting the API or the
+// scheduler.
+func setupFetcher(c *cli.Context, l *zap.SugaredLogger) (transferFetcher, error) {
+	transferService, _, err := setupService(c, false, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return transferService, nil
+}
+
+// fetchWith returns the action of the fetch subcommand, running a single full refresh with the fetcher
+// returned by setup. It fails, exiting with a nonzero code, if the fetch of any address failed; the
+// transfers of the other fetches are still stored.
+func fetchWith(setup func(c *cli.Context, l *zap.SugaredLogger) (transferFetcher, error)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		logger, _, flush, err := libapp.NewLogger(c)
+		if err != nil {
+			return fmt.Errorf("new logger: %w", err)
+		}
+		defer flush()
+
+		zap.ReplaceGlobals(logger)
+		l := logger.Sugar()
+
+		if c.Bool("read-only") {
+			return fmt.Errorf("cannot fetch transfers in read-only mode")
+		}
+
+		fetcher, err := setup(c, l)
+		if err != nil {
+			return err
+		}
+
+		// Stop fetching on interrupt, the refresh run is still recorded as failed
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		ctx, cancel := fetcher.FetchContext(ctx)
+		defer cancel()
+
+		l.Infow("Fetching transfers once")
+
+		inserted, err := fetcher.FetchAndStoreTransfersStrict(ctx)
+		if err != nil {
+			return fmt.Errorf("fetching transfers: %w", err)
+		}
+
+		l.Infow("Fetch completed", "inserted", inserted)
+
+		return nil
+	}
+}
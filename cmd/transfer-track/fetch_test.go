@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	libapp "github.com/ductm54/transfer-track/internal/app"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+// stubFetcher records a single fetch, failing with err.
+type stubFetcher struct {
+	err     error
+	fetches int
+}
+
+func (f *stubFetcher) FetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithCancel(ctx)
+}
+
+func (f *stubFetcher) FetchAndStoreTransfersStrict(context.Context) (int, error) {
+	f.fetches++
+
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	return 3, nil
+}
+
+func TestFetchCommand(t *testing.T) {
+	errFetch := errors.New("fetching 0xa1: unavailable")
+	errSetup := errors.New("connecting to the database")
+
+	tests := []struct {
+		name        string
+		readOnly    bool
+		fetcher     *stubFetcher
+		setupErr    error
+		wantErr     error
+		wantFetches int
+	}{
+		{name: "success", fetcher: &stubFetcher{}, wantFetches: 1},
+		{name: "failed fetch", fetcher: &stubFetcher{err: errFetch}, wantErr: errFetch, wantFetches: 1},
+		{name: "failed setup", fetcher: &stubFetcher{}, setupErr: errSetup, wantErr: errSetup},
+		{name: "read-only", readOnly: true, fetcher: &stubFetcher{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setups := 0
+
+			command := fetchCommand()
+			command.Action = fetchWith(func(*cli.Context, *zap.SugaredLogger) (transferFetcher, error) {
+				setups++
+
+				if tt.setupErr != nil {
+					return nil, tt.setupErr
+				}
+
+				return tt.fetcher, nil
+			})
+
+			app := libapp.NewApp()
+			app.Flags = append(app.Flags, &cli.BoolFlag{Name: "read-only"})
+			app.Commands = []*cli.Command{command}
+
+			args := []string{"transfer-track", "--log-level", "error"}
+			if tt.readOnly {
+				args = append(args, "--read-only")
+			}
+
+			// main exits with a nonzero code whenever the app returns an error
+			err := app.Run(append(args, "fetch"))
+
+			switch {
+			case tt.readOnly:
+				if err == nil || setups != 0 {
+					t.Fatalf("expected read-only mode to fail before setting up, got %v after %d setups", err, setups)
+				}
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("expected the fetch to succeed, got %v", err)
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if tt.fetcher.fetches != tt.wantFetches {
+				t.Fatalf("expected %d fetches, got %d", tt.wantFetches, tt.fetcher.fetches)
+			}
+		})
+	}
+}
@@ -9,14 +9,20 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/ductm54/transfer-track/internal/api"
 	libapp "github.com/ductm54/transfer-track/internal/app"
+	"github.com/ductm54/transfer-track/internal/coingecko"
 	"github.com/ductm54/transfer-track/internal/dbutil"
+	"github.com/ductm54/transfer-track/internal/ens"
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/notify"
 	"github.com/ductm54/transfer-track/internal/scheduler"
 	"github.com/ductm54/transfer-track/internal/server"
 	"github.com/ductm54/transfer-track/internal/service"
 	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/urfave/cli/v2"
@@ -36,18 +42,119 @@ func main() {
 		&libapp.PostgresUser,
 		&libapp.PostgresPassword,
 		&libapp.PostgresDatabase,
+		&libapp.PostgresSchema,
+		&libapp.PostgresReadonlyURL,
 		&libapp.PostgresMigrationPath,
+		&libapp.PostgresSkipMigrations,
+		&libapp.PostgresMaxOpenConns,
+		&libapp.PostgresMaxIdleConns,
+		&libapp.PostgresConnMaxLifetime,
 		&cli.StringFlag{
 			Name:    "bind-addr",
 			Value:   ":8080",
 			Usage:   "HTTP server bind address",
 			EnvVars: []string{"BIND_ADDR"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "cors-origins",
+			Usage:   "Comma-separated origins allowed to call the API from a browser",
+			EnvVars: []string{"CORS_ORIGINS"},
+		},
+		&cli.BoolFlag{
+			Name:    "cors-allow-all",
+			Usage:   "Allow every origin when --cors-origins is empty",
+			EnvVars: []string{"CORS_ALLOW_ALL"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "trusted-proxies",
+			Usage:   "IP addresses or CIDR ranges of proxies whose X-Forwarded-For header gives the client IP",
+			EnvVars: []string{"TRUSTED_PROXIES"},
+		},
+		&cli.Float64Flag{
+			Name:    "rate-limit-rps",
+			Value:   10,
+			Usage:   "Requests per second allowed per client IP on the API (0 disables rate limiting)",
+			EnvVars: []string{"RATE_LIMIT_RPS"},
+		},
+		&cli.IntFlag{
+			Name:    "rate-limit-burst",
+			Value:   20,
+			Usage:   "Burst of requests allowed per client IP on the API",
+			EnvVars: []string{"RATE_LIMIT_BURST"},
+		},
+		&cli.BoolFlag{
+			Name:    "gzip-responses",
+			Value:   true,
+			Usage:   "Compress API responses with gzip for clients accepting it",
+			EnvVars: []string{"GZIP_RESPONSES"},
+		},
+		&cli.BoolFlag{
+			Name:    "read-only",
+			Usage:   "Never write to the database, e.g. for a reporting instance: disables refreshes and mutating API requests",
+			EnvVars: []string{"READ_ONLY"},
+		},
+		&cli.DurationFlag{
+			Name:    "request-timeout",
+			Value:   60 * time.Second,
+			Usage:   "Maximum duration of an API request (0 disables the timeout)",
+			EnvVars: []string{"REQUEST_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:    "long-request-timeout",
+			Value:   10 * time.Minute,
+			Usage:   "Maximum duration of exports, address refreshes and token metadata refreshes (0 disables the timeout)",
+			EnvVars: []string{"LONG_REQUEST_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:    "auto-refresh-timeout",
+			Value:   api.DefaultAutoRefreshTimeout,
+			Usage:   "Time reads of total amounts wait for an automatic refresh of stale data before serving the stale data",
+			EnvVars: []string{"AUTO_REFRESH_TIMEOUT"},
+		},
+		&cli.IntFlag{
+			Name:    "default-range-days",
+			Value:   api.DefaultRangeDays,
+			Usage:   "Number of days before now that reads giving no start_time cover",
+			EnvVars: []string{"DEFAULT_RANGE_DAYS"},
+		},
+		&cli.DurationFlag{
+			Name:    "slow-query-threshold",
+			Usage:   "Log read queries taking at least this long with their query plan (0 disables the log)",
+			EnvVars: []string{"SLOW_QUERY_THRESHOLD"},
+		},
+		&cli.DurationFlag{
+			Name:    "totals-cache-ttl",
+			Value:   storage.DefaultTotalsCacheTTL,
+			Usage:   "Time total amounts are cached, until transfers are inserted in their range (0 disables the cache)",
+			EnvVars: []string{"TOTALS_CACHE_TTL"},
+		},
+		&cli.DurationFlag{
+			Name:    "shutdown-timeout",
+			Value:   10 * time.Second,
+			Usage:   "Time to wait for in-flight HTTP requests on shutdown",
+			EnvVars: []string{"SHUTDOWN_TIMEOUT"},
+		},
+		&cli.StringFlag{
+			Name:    "config-file",
+			Usage:   "YAML or JSON file declaring source addresses, target addresses, tokens and config values applied at startup",
+			EnvVars: []string{"CONFIG_FILE"},
+		},
+		&cli.DurationFlag{
+			Name:    "initial-fetch-timeout",
+			Value:   30 * time.Minute,
+			Usage:   "Timeout of the data fetch performed at startup when the stored data is stale",
+			EnvVars: []string{"INITIAL_FETCH_TIMEOUT"},
+		},
 		&cli.StringFlag{
 			Name:    "etherscan-api-key",
 			Usage:   "Etherscan API key",
 			EnvVars: []string{"ETHERSCAN_API_KEY"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "etherscan-api-keys",
+			Usage:   "Comma-separated additional Etherscan API keys, requests are spread across all keys",
+			EnvVars: []string{"ETHERSCAN_API_KEYS"},
+		},
 		&cli.IntFlag{
 			Name:    "refresh-interval",
 			Value:   1,
@@ -57,17 +164,165 @@ func main() {
 		&cli.StringFlag{
 			Name:    "daily-refresh-time",
 			Value:   "00:00:00",
-			Usage:   "Daily refresh time (HH:MM:SS)",
+			Usage:   "Daily refresh times, a comma-separated list of HH:MM:SS",
 			EnvVars: []string{"DAILY_REFRESH_TIME"},
 		},
+		&cli.DurationFlag{
+			Name:    "scheduler-tick-interval",
+			Value:   scheduler.DefaultTickInterval,
+			Usage:   "Interval at which the scheduler checks whether a daily refresh time has passed",
+			EnvVars: []string{"SCHEDULER_TICK_INTERVAL"},
+		},
 		&cli.IntFlag{
 			Name:    "chain-id",
 			Value:   1,
-			Usage:   "Blockchain chain ID (1 for Ethereum Mainnet)",
+			Usage:   "Blockchain chain ID (1 for Ethereum Mainnet), see GET /api/chains for the chains served by Etherscan",
 			EnvVars: []string{"CHAIN_ID"},
 		},
+		&cli.IntFlag{
+			Name:    "etherscan-rate-limit-retries",
+			Value:   etherscan.DefaultRateLimitRetries,
+			Usage:   "Number of retries when Etherscan reports \"Max rate limit reached\" (0 disables retries)",
+			EnvVars: []string{"ETHERSCAN_RATE_LIMIT_RETRIES"},
+		},
+		&cli.DurationFlag{
+			Name:    "etherscan-rate-limit-cooldown",
+			Value:   etherscan.DefaultRateLimitCooldown,
+			Usage:   "Wait before retrying a rate limited Etherscan request, doubled on every retry",
+			EnvVars: []string{"ETHERSCAN_RATE_LIMIT_COOLDOWN"},
+		},
+		&cli.IntFlag{
+			Name:    "etherscan-page-size",
+			Value:   etherscan.DefaultPageSize,
+			Usage:   "Number of transactions requested per Etherscan page (max 10000)",
+			EnvVars: []string{"ETHERSCAN_PAGE_SIZE"},
+		},
+		&cli.StringFlag{
+			Name:    "etherscan-base-url",
+			Value:   etherscan.DefaultBaseURL,
+			Usage:   "Etherscan API URL, or the URL of an Etherscan-compatible explorer such as Blockscout",
+			EnvVars: []string{"ETHERSCAN_BASE_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "etherscan-proxy-url",
+			Usage:   "HTTP(S) or SOCKS5 proxy for Etherscan requests, e.g. http://proxy.internal:3128",
+			EnvVars: []string{"ETHERSCAN_PROXY_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "etherscan-root-ca-file",
+			Usage:   "PEM file of root CAs trusted for Etherscan requests in addition to the system ones",
+			EnvVars: []string{"ETHERSCAN_ROOT_CA_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:    "etherscan-insecure-skip-verify",
+			Usage:   "Skip the TLS certificate verification of Etherscan requests, for development only",
+			EnvVars: []string{"ETHERSCAN_INSECURE_SKIP_VERIFY"},
+		},
+		&cli.DurationFlag{
+			Name:    "etherscan-request-timeout",
+			Value:   etherscan.DefaultRequestTimeout,
+			Usage:   "Timeout of a single Etherscan HTTP request",
+			EnvVars: []string{"ETHERSCAN_REQUEST_TIMEOUT"},
+		},
+		&cli.IntFlag{
+			Name:    "etherscan-max-idle-conns",
+			Value:   etherscan.DefaultMaxIdleConnsPerHost,
+			Usage:   "Number of idle connections kept open to Etherscan for reuse by the following requests",
+			EnvVars: []string{"ETHERSCAN_MAX_IDLE_CONNS"},
+		},
+		&cli.DurationFlag{
+			Name:    "etherscan-idle-conn-timeout",
+			Value:   etherscan.DefaultIdleConnTimeout,
+			Usage:   "How long an idle connection to Etherscan is kept open",
+			EnvVars: []string{"ETHERSCAN_IDLE_CONN_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:    "fetch-timeout",
+			Value:   service.DefaultFetchTimeout,
+			Usage:   "Minimum deadline of a scheduled or background refresh of all addresses",
+			EnvVars: []string{"FETCH_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:    "fetch-timeout-per-address",
+			Value:   service.DefaultFetchTimeoutPerAddress,
+			Usage:   "Deadline of a scheduled or background refresh per source address, when above fetch-timeout",
+			EnvVars: []string{"FETCH_TIMEOUT_PER_ADDRESS"},
+		},
+		&cli.Int64Flag{
+			Name:    "confirmation-blocks",
+			Value:   service.DefaultConfirmationBlocks,
+			Usage:   "Blocks up to the last fetched block fetched again to delete reorged transfers (0 disables)",
+			EnvVars: []string{"CONFIRMATION_BLOCKS"},
+		},
+		&cli.StringFlag{
+			Name:    "ens-rpc-url",
+			Usage:   "Ethereum Mainnet JSON-RPC URL used to label addresses added without a label with their ENS name",
+			EnvVars: []string{"ENS_RPC_URL"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-zero-value-transfers",
+			Value:   true,
+			Usage:   "Do not store ERC20 transfers of a zero amount",
+			EnvVars: []string{"SKIP_ZERO_VALUE_TRANSFERS"},
+		},
+		&cli.BoolFlag{
+			Name:    "only-known-tokens",
+			Usage:   "Only store ERC20 transfers of tokens added via /api/tokens",
+			EnvVars: []string{"ONLY_KNOWN_TOKENS"},
+		},
+		&cli.BoolFlag{
+			Name:    "auto-add-tokens",
+			Usage:   "Add tokens of fetched ERC20 transfers missing from /api/tokens, using the metadata of the transfers",
+			EnvVars: []string{"AUTO_ADD_TOKENS"},
+		},
+		&cli.StringFlag{
+			Name:    "fetch-mode",
+			Value:   string(service.FetchModeAll),
+			Usage:   "How ERC20 transfers are fetched: \"all\" tokens in one query or each of the \"known-tokens\" separately",
+			EnvVars: []string{"FETCH_MODE"},
+		},
+		&cli.IntFlag{
+			Name:    "fetch-concurrency",
+			Value:   service.DefaultFetchConcurrency,
+			Usage:   "Number of source addresses fetched at the same time by a refresh of all addresses",
+			EnvVars: []string{"FETCH_CONCURRENCY"},
+		},
+		&cli.StringFlag{
+			Name:    "price-source",
+			Usage:   "Price source used to value totals in USD (\"coingecko\"), empty disables USD values",
+			EnvVars: []string{"PRICE_SOURCE"},
+		},
+		&cli.StringFlag{
+			Name:    "coingecko-api-key",
+			Usage:   "CoinGecko demo API key",
+			EnvVars: []string{"COINGECKO_API_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "coingecko-platform",
+			Value:   coingecko.DefaultPlatform,
+			Usage:   "CoinGecko asset platform ID of the chain",
+			EnvVars: []string{"COINGECKO_PLATFORM"},
+		},
+		&cli.StringFlag{
+			Name:    "coingecko-native-coin-id",
+			Value:   coingecko.DefaultNativeCoinID,
+			Usage:   "CoinGecko coin ID of the chain's native coin",
+			EnvVars: []string{"COINGECKO_NATIVE_COIN_ID"},
+		},
+		&cli.StringFlag{
+			Name:    "webhook-url",
+			Usage:   "Webhook (e.g. Slack or Discord) notified when a refresh stores new transfers",
+			EnvVars: []string{"WEBHOOK_URL"},
+		},
+		&cli.IntFlag{
+			Name:    "webhook-min-inserted",
+			Value:   1,
+			Usage:   "Minimum number of new transfers stored by a refresh to notify the webhook",
+			EnvVars: []string{"WEBHOOK_MIN_INSERTED"},
+		},
 	)
 	app.Action = run
+	app.Commands = []*cli.Command{migrateCommand(), fetchCommand()}
 
 	if err := app.Run(os.Args); err != nil {
 		log.Panic(err)
@@ -76,7 +331,7 @@ func main() {
 
 func run(c *cli.Context) error {
 	// Initialize logger
-	logger, _, flush, err := libapp.NewLogger(c)
+	logger, logLevel, flush, err := libapp.NewLogger(c)
 	if err != nil {
 		return fmt.Errorf("new logger: %w", err)
 	}
@@ -86,42 +341,77 @@ func run(c *cli.Context) error {
 	l := logger.Sugar()
 	l.Infow("Transfer Track service starting...")
 
-	// Initialize database
-	db, err := initDB(c)
-	if err != nil {
-		l.Panicw("cannot init DB", "err", err)
+	readOnly := c.Bool("read-only")
+	if readOnly {
+		l.Infow("Running in read-only mode, the database is never written to")
 	}
 
-	// Initialize storage
-	store := storage.New(db, l)
-
-	// Initialize transfer service
-	transferService, err := service.NewTransferService(
-		store,
-		l,
-		c.String("etherscan-api-key"),
-		c.Int("refresh-interval"),
-		c.String("daily-refresh-time"),
-		c.Int("chain-id"),
-	)
+	transferService, store, err := setupService(c, readOnly, l)
 	if err != nil {
-		l.Panicw("cannot create transfer service", "err", err)
+		return err
 	}
 
-	l.Infow("Using Etherscan API v2", "chainID", c.Int("chain-id"))
-
-	// Initialize scheduler
-	sched := scheduler.NewScheduler(transferService, l)
-	sched.Start()
-	defer sched.Stop()
+	sched := startRefreshing(transferService, readOnly,
+		c.Duration("initial-fetch-timeout"), c.Duration("scheduler-tick-interval"), l)
+	if sched != nil {
+		defer sched.Stop()
+	}
 
 	// Initialize API handlers
 	handler := api.NewHandler(transferService, store, l)
+	handler.SetLogLevel(logLevel)
+	handler.SetAutoRefreshTimeout(c.Duration("auto-refresh-timeout"))
+	handler.SetDefaultRangeDays(c.Int("default-range-days"))
+	handler.SetReadOnly(readOnly)
+
+	switch priceSource := c.String("price-source"); priceSource {
+	case "":
+		l.Infow("No price source configured, totals are not valued in USD")
+	case "coingecko":
+		handler.SetPriceProvider(coingecko.NewClient(coingecko.Config{
+			APIKey:       c.String("coingecko-api-key"),
+			Platform:     c.String("coingecko-platform"),
+			NativeCoinID: c.String("coingecko-native-coin-id"),
+		}, l))
+		l.Infow("Valuing totals in USD", "priceSource", priceSource)
+	default:
+		return fmt.Errorf("unsupported price source %q", priceSource)
+	}
 
 	// Initialize HTTP server
 	bindAddr := c.String("bind-addr")
-	srv := server.New(bindAddr)
-	handler.RegisterRoutes(srv.GetEngine())
+
+	srv, err := server.New(bindAddr, server.CORSConfig{
+		AllowOrigins:    c.StringSlice("cors-origins"),
+		AllowAllOrigins: c.Bool("cors-allow-all"),
+	}, c.StringSlice("trusted-proxies"))
+	if err != nil {
+		return fmt.Errorf("creating HTTP server: %w", err)
+	}
+
+	var apiMiddleware []gin.HandlerFunc
+
+	if readOnly {
+		apiMiddleware = append(apiMiddleware, server.ReadOnly(api.ReadOnlySafeRoutes()...))
+	}
+
+	timeout, longTimeout := c.Duration("request-timeout"), c.Duration("long-request-timeout")
+	if timeout > 0 || longTimeout > 0 {
+		apiMiddleware = append(apiMiddleware,
+			server.Timeout(timeout, longTimeout, api.LongRunningRoutes(), api.StreamingRoutes()))
+		l.Infow("Bounding the duration of API requests", "timeout", timeout, "longTimeout", longTimeout)
+	}
+
+	if rps := c.Float64("rate-limit-rps"); rps > 0 {
+		apiMiddleware = append(apiMiddleware, server.RateLimit(rps, c.Int("rate-limit-burst")))
+		l.Infow("Rate limiting API requests per client IP", "rps", rps, "burst", c.Int("rate-limit-burst"))
+	}
+
+	if c.Bool("gzip-responses") {
+		apiMiddleware = append(apiMiddleware, server.Gzip(api.StreamingRoutes()...))
+	}
+
+	handler.RegisterRoutes(srv.GetEngine(), apiMiddleware...)
 
 	// Start HTTP server
 	errCh := make(chan error, 1)
@@ -130,52 +420,379 @@ func run(c *cli.Context) error {
 		errCh <- srv.Run()
 	}()
 
-	// Wait for interrupt signal
+	// Wait for interrupt signal, reloading the configuration on SIGHUP
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	reload := func() {
+		reloadConfig(transferService, sched, c.String("config-file"), l)
+	}
+
+	if readOnly {
+		reload = func() {
+			l.Warnw("Received SIGHUP in read-only mode, nothing to reload")
+		}
+	}
 
 	// Wait for either server error or interrupt signal
-	select {
-	case err := <-errCh:
-		return fmt.Errorf("server error: %w", err)
-	case sig := <-sigCh:
-		l.Infow("Received signal, shutting down", "signal", sig)
+	if err := waitForShutdown(sigCh, errCh, reload, l); err != nil {
+		return err
 	}
 
-	// Perform initial data fetch if needed
-	ctx, cancel := context.WithTimeout(context.Background(), 0)
-	defer cancel()
+	// Disconnect transfer stream clients, which the HTTP server does not track once upgraded, and end
+	// the refresh event streams, which the HTTP server would otherwise wait for until the shutdown timeout
+	transferService.CloseTransferStream()
+	transferService.CloseRefreshProgress()
 
-	shouldRefresh, err := transferService.ShouldRefreshData(ctx)
-	if err == nil && shouldRefresh {
-		l.Infow("Performing initial data fetch")
-		err := transferService.FetchAndStoreTransfers(ctx)
-		if err != nil {
-			l.Warnw("Error performing initial data fetch", "err", err)
+	// Stop the HTTP server, letting in-flight requests finish
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+	defer shutdownCancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		l.Warnw("Error shutting down HTTP server", "err", err)
+	}
+
+	return nil
+}
+
+// setupService connects to the database, migrating it unless readOnly, and creates the transfer service
+// configured with the flags. The config file is applied unless readOnly.
+func setupService(
+	c *cli.Context, readOnly bool, l *zap.SugaredLogger,
+) (*service.TransferService, *storage.Storage, error) {
+	chainName, err := resolveChainName(c.Int("chain-id"), c.String("etherscan-base-url"), l)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Initialize database
+	db, err := initDB(c, readOnly, l)
+	if err != nil {
+		l.Panicw("cannot init DB", "err", err)
+	}
+
+	// Initialize read-only replica if configured
+	replica, err := initReplicaDB(c)
+	if err != nil {
+		l.Panicw("cannot init read-only replica DB", "err", err)
+	}
+
+	if replica != nil {
+		l.Infow("Routing read-only queries to replica")
+	}
+
+	// Initialize storage
+	store := storage.NewWithReplica(db, replica, l)
+	store.SetTotalsCacheTTL(c.Duration("totals-cache-ttl"))
+	store.SetSlowQueryThreshold(c.Duration("slow-query-threshold"))
+	logEmptyState(store, l)
+
+	// Initialize transfer service
+	transferService, err := service.NewTransferService(
+		store,
+		l,
+		etherscan.Config{
+			APIKey:              c.String("etherscan-api-key"),
+			APIKeys:             c.StringSlice("etherscan-api-keys"),
+			ChainID:             c.Int("chain-id"),
+			RateLimitRetries:    c.Int("etherscan-rate-limit-retries"),
+			RateLimitCooldown:   c.Duration("etherscan-rate-limit-cooldown"),
+			PageSize:            c.Int("etherscan-page-size"),
+			RequestTimeout:      c.Duration("etherscan-request-timeout"),
+			BaseURL:             c.String("etherscan-base-url"),
+			ProxyURL:            c.String("etherscan-proxy-url"),
+			RootCAFile:          c.String("etherscan-root-ca-file"),
+			InsecureSkipVerify:  c.Bool("etherscan-insecure-skip-verify"),
+			MaxIdleConnsPerHost: c.Int("etherscan-max-idle-conns"),
+			IdleConnTimeout:     c.Duration("etherscan-idle-conn-timeout"),
+		},
+	)
+	if err != nil {
+		l.Panicw("cannot create transfer service", "err", err)
+	}
+
+	if !readOnly {
+		transferService.SeedStartupConfig(context.Background(), c.Int("refresh-interval"), c.String("daily-refresh-time"))
+
+		if err := transferService.EnsureConfigDefaults(context.Background()); err != nil {
+			l.Panicw("cannot store default config", "err", err)
 		}
 	}
 
+	l.Infow("Using Etherscan API v2", "chainID", c.Int("chain-id"), "chain", chainName)
+
+	transferService.SetFetchTimeout(c.Duration("fetch-timeout"), c.Duration("fetch-timeout-per-address"))
+
+	transferService.SetIngestionFilter(service.IngestionFilter{
+		SkipZeroValue:   c.Bool("skip-zero-value-transfers"),
+		OnlyKnownTokens: c.Bool("only-known-tokens"),
+		AutoAddTokens:   c.Bool("auto-add-tokens"),
+	})
+
+	fetchMode := service.FetchMode(c.String("fetch-mode"))
+	if !fetchMode.IsValid() {
+		return nil, nil, fmt.Errorf("unsupported fetch mode %q", fetchMode)
+	}
+
+	transferService.SetFetchMode(fetchMode)
+	transferService.SetFetchConcurrency(c.Int("fetch-concurrency"))
+	transferService.SetConfirmationBlocks(c.Int64("confirmation-blocks"))
+
+	if ensRPCURL := c.String("ens-rpc-url"); ensRPCURL != "" {
+		transferService.SetENSResolver(ens.NewResolver(ensRPCURL, l))
+		l.Infow("Resolving ENS names of addresses added without a label")
+	}
+
+	// Apply the declared addresses, tokens and configuration values
+	if configFile := c.String("config-file"); configFile != "" && readOnly {
+		l.Warnw("Ignoring config file in read-only mode", "path", configFile)
+	} else if configFile != "" {
+		if err := applyConfigFile(transferService, configFile, l); err != nil {
+			return nil, nil, fmt.Errorf("applying config file: %w", err)
+		}
+	}
+
+	if webhookURL := c.String("webhook-url"); webhookURL != "" {
+		transferService.SetNotifier(notify.NewWebhook(webhookURL, l), c.Int("webhook-min-inserted"))
+		l.Infow("Notifying webhook about new transfers", "minInserted", c.Int("webhook-min-inserted"))
+	}
+
+	return transferService, store, nil
+}
+
+// resolveChainName returns the name of the chain with chainID. Chains that Etherscan does not serve
+// are rejected, but a custom explorer may serve any chain, so they are only warned about and named
+// after their ID.
+func resolveChainName(chainID int, baseURL string, l *zap.SugaredLogger) (string, error) {
+	if chainID <= 0 {
+		return "", fmt.Errorf("invalid chain ID %d, expected a positive integer", chainID)
+	}
+
+	if name, ok := etherscan.ChainName(chainID); ok {
+		return name, nil
+	}
+
+	if baseURL == etherscan.DefaultBaseURL {
+		return "", fmt.Errorf("chain ID %d is not served by Etherscan, see GET /api/chains for the supported chains",
+			chainID)
+	}
+
+	l.Warnw("Unknown chain ID, make sure the explorer serves it", "chainID", chainID, "baseURL", baseURL)
+
+	return fmt.Sprintf("chain %d", chainID), nil
+}
+
+// applyConfigFile loads a declarative config file and reconciles it into the database.
+func applyConfigFile(transferService *service.TransferService, path string, l *zap.SugaredLogger) error {
+	cfg, err := service.LoadDeclarativeConfig(path)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := transferService.ApplyDeclarativeConfig(ctx, cfg); err != nil {
+		return err
+	}
+
+	l.Infow("Applied config file", "path", path, "prune", cfg.Prune)
+
 	return nil
 }
 
-func initDB(c *cli.Context) (*sqlx.DB, error) {
-	db, err := libapp.NewDB(map[string]any{
+// waitForShutdown blocks until a signal other than SIGHUP is received on sigCh, or returns the error of
+// the server received on errCh. Every SIGHUP calls reload.
+func waitForShutdown(sigCh <-chan os.Signal, errCh <-chan error, reload func(), l *zap.SugaredLogger) error {
+	for {
+		select {
+		case err := <-errCh:
+			return fmt.Errorf("server error: %w", err)
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				reload()
+				continue
+			}
+
+			l.Infow("Received signal, shutting down", "signal", sig)
+
+			return nil
+		}
+	}
+}
+
+// scheduleReloader reloads the refresh schedule, implemented by scheduler.Scheduler.
+type scheduleReloader interface {
+	Reload()
+}
+
+// reloadConfig re-applies the config file, if any, and makes the scheduler check the refresh schedule,
+// which may also have been changed in the database. If the file cannot be applied, the error is logged
+// and the service keeps running; the sections applied before the error are kept.
+func reloadConfig(
+	transferService *service.TransferService, sched scheduleReloader, path string, l *zap.SugaredLogger,
+) {
+	if path == "" {
+		l.Infow("Received SIGHUP, reloading refresh schedule")
+	} else {
+		l.Infow("Received SIGHUP, reloading config file and refresh schedule", "path", path)
+
+		if err := applyConfigFile(transferService, path, l); err != nil {
+			l.Errorw("Error reloading config file", "path", path, "err", err)
+		}
+	}
+
+	sched.Reload()
+}
+
+// startRefreshing fetches stale data before serving and starts the scheduler refreshing it, returning
+// the started scheduler. In read-only mode another instance writes the data, so nothing is fetched and
+// it returns nil.
+func startRefreshing(
+	transferService *service.TransferService, readOnly bool, initialFetchTimeout, tickInterval time.Duration,
+	l *zap.SugaredLogger,
+) *scheduler.Scheduler {
+	if readOnly {
+		return nil
+	}
+
+	initialFetch(transferService, initialFetchTimeout, l)
+
+	sched := scheduler.NewScheduler(transferService, tickInterval, l)
+	sched.Start()
+
+	return sched
+}
+
+// initialFetch fetches transfers at startup if the stored data is stale.
+func initialFetch(transferService *service.TransferService, timeout time.Duration, l *zap.SugaredLogger) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shouldRefresh, err := transferService.ShouldRefreshData(ctx)
+	if err != nil {
+		l.Warnw("Error checking if initial data fetch is needed", "err", err)
+		return
+	}
+
+	if !shouldRefresh {
+		l.Infow("Stored data is fresh, skipping initial data fetch")
+		return
+	}
+
+	l.Infow("Performing initial data fetch", "timeout", timeout)
+
+	inserted, err := transferService.RefreshStaleData(ctx)
+	if err != nil {
+		l.Warnw("Error performing initial data fetch", "err", err)
+		return
+	}
+
+	l.Infow("Initial data fetch completed", "inserted", inserted)
+}
+
+// logEmptyState logs a hint when the database has not been seeded yet, so that a fresh
+// deploy returning empty responses is self-explanatory.
+func logEmptyState(store *storage.Storage, l *zap.SugaredLogger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	counts, err := store.GetTableCounts(ctx)
+	if err != nil {
+		l.Warnw("Failed to check database state", "err", err)
+		return
+	}
+
+	if counts.Tokens == 0 {
+		l.Warnw("No tokens catalogued, the ETH seed is missing; add tokens via POST /api/tokens")
+	}
+
+	if counts.SourceAddresses == 0 || counts.TargetAddresses == 0 {
+		l.Warnw("Database has no tracked addresses, all read endpoints will return empty results; "+
+			"seed them via POST /api/source-addresses and POST /api/target-addresses",
+			"sourceAddresses", counts.SourceAddresses,
+			"targetAddresses", counts.TargetAddresses,
+			"tokens", counts.Tokens,
+			"transfers", counts.Transfers)
+	}
+}
+
+func initDB(c *cli.Context, readOnly bool, l *zap.SugaredLogger) (*sqlx.DB, error) {
+	db, err := connectDB(c)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := c.String(libapp.PostgresSchema.Name)
+	if schema != "" {
+		l.Infow("Using database schema", "schema", schema)
+	}
+
+	if readOnly || c.Bool(libapp.PostgresSkipMigrations.Name) {
+		l.Infow("Skipping database migrations")
+		return db, nil
+	}
+
+	_, err = dbutil.RunMigrationUp(db.DB, c.String(libapp.PostgresMigrationPath.Name),
+		c.String(libapp.PostgresDatabase.Name), schema)
+	if err != nil {
+		return nil, fmt.Errorf("running database migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// connectDB connects to the primary database, with the configured schema as search_path.
+func connectDB(c *cli.Context) (*sqlx.DB, error) {
+	specs := map[string]any{
 		"host":     c.String(libapp.PostgresHost.Name),
 		"port":     c.Int(libapp.PostgresPort.Name),
 		"user":     c.String(libapp.PostgresUser.Name),
 		"password": c.String(libapp.PostgresPassword.Name),
 		"dbname":   c.String(libapp.PostgresDatabase.Name),
 		"sslmode":  "disable",
-	})
+	}
+
+	if schema := c.String(libapp.PostgresSchema.Name); schema != "" {
+		if err := dbutil.ValidateSchema(schema); err != nil {
+			return nil, err
+		}
+
+		// Every connection of the pool resolves the unqualified table names in the schema
+		specs["options"] = dbutil.SearchPathOption(schema)
+	}
+
+	db, err := libapp.NewDB(specs, libapp.PoolConfigFromContext(c))
 	if err != nil {
 		return nil, fmt.Errorf("creating database connection: %w", err)
 	}
 
-	_, err = dbutil.RunMigrationUp(db.DB, c.String(libapp.PostgresMigrationPath.Name),
-		c.String(libapp.PostgresDatabase.Name))
+	return db, nil
+}
+
+// initReplicaDB connects to the read-only replica, it returns nil if no replica is configured.
+func initReplicaDB(c *cli.Context) (*sqlx.DB, error) {
+	replicaURL := c.String(libapp.PostgresReadonlyURL.Name)
+	if replicaURL == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	// The replica has the same schema as the primary
+	if schema := c.String(libapp.PostgresSchema.Name); schema != "" {
+		schemaURL, err := dbutil.WithSearchPath(replicaURL, schema)
+		if err != nil {
+			return nil, fmt.Errorf("setting schema of read-only replica URL: %w", err)
+		}
+
+		replicaURL = schemaURL
+	}
+
+	db, err := dbutil.NewDB(replicaURL)
 	if err != nil {
-		return nil, fmt.Errorf("running database migrations: %w", err)
+		return nil, fmt.Errorf("creating read-only replica connection: %w", err)
 	}
 
+	libapp.PoolConfigFromContext(c).Apply(db)
+
 	return db, nil
 }
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	testMigrationPath = "../../migrations"
+	testSource        = "0x00000000000000000000000000000000000000a1"
+)
+
+// newTestService returns a service on a fresh development DB with the default config, tracking the test
+// source address, whose Etherscan finds no transactions. It also returns the number of Etherscan requests.
+func newTestService(t *testing.T) (*service.TransferService, *storage.Storage, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		err := json.NewEncoder(w).Encode(etherscan.Response{
+			Status:  "0",
+			Message: "No transactions found",
+			Result:  json.RawMessage(`[]`),
+		})
+		if err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	logger := zap.NewNop().Sugar()
+	store := storage.New(testutil.NewTestDB(t, testMigrationPath), logger)
+
+	transferService, err := service.NewTransferService(store, logger, etherscan.Config{
+		APIKey:  "test",
+		ChainID: 1,
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := transferService.EnsureConfigDefaults(ctx); err != nil {
+		t.Fatalf("ensuring config defaults: %v", err)
+	}
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	return transferService, store, &requests
+}
+
+func TestInitialFetch(t *testing.T) {
+	transferService, store, requests := newTestService(t)
+	l := zap.NewNop().Sugar()
+
+	// Nothing was fetched yet, so the data is stale
+	initialFetch(transferService, time.Minute, l)
+
+	if requests.Load() == 0 {
+		t.Fatal("expected stale data to be fetched at startup")
+	}
+
+	run, err := store.GetLastRefreshRun(context.Background())
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if run.Status != storage.RefreshRunSucceeded {
+		t.Fatalf("expected the initial fetch to succeed, got %+v", run)
+	}
+
+	// The fetch made the data fresh
+	fetched := requests.Load()
+	initialFetch(transferService, time.Minute, l)
+
+	if got := requests.Load(); got != fetched {
+		t.Fatalf("expected fresh data not to be fetched, got %d more requests", got-fetched)
+	}
+}
+
+func TestInitialFetchTimeout(t *testing.T) {
+	transferService, _, _ := newTestService(t)
+
+	// A timeout too short for any request leaves the data stale without failing startup
+	initialFetch(transferService, time.Nanosecond, zap.NewNop().Sugar())
+
+	stale, err := transferService.ShouldRefreshData(context.Background())
+	if err != nil {
+		t.Fatalf("checking staleness: %v", err)
+	}
+
+	if !stale {
+		t.Fatal("expected the data to stay stale after a timed out fetch")
+	}
+}
+
+func TestStartRefreshingReadOnly(t *testing.T) {
+	transferService, store, requests := newTestService(t)
+
+	// Nothing was fetched yet, but a read-only instance leaves the stale data to the writing instance
+	if sched := startRefreshing(transferService, true, time.Minute, time.Millisecond, zap.NewNop().Sugar()); sched != nil {
+		sched.Stop()
+		t.Fatal("expected no scheduler to be started in read-only mode")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := requests.Load(); got != 0 {
+		t.Fatalf("expected no Etherscan requests in read-only mode, got %d", got)
+	}
+
+	runs, err := store.ListRefreshRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("listing refresh runs: %v", err)
+	}
+
+	if len(runs) != 0 {
+		t.Fatalf("expected no refresh runs in read-only mode, got %d", len(runs))
+	}
+}
+
+func TestStartRefreshing(t *testing.T) {
+	transferService, _, requests := newTestService(t)
+
+	sched := startRefreshing(transferService, false, time.Minute, time.Hour, zap.NewNop().Sugar())
+	if sched == nil {
+		t.Fatal("expected the scheduler to be started")
+	}
+	defer sched.Stop()
+
+	if requests.Load() == 0 {
+		t.Fatal("expected stale data to be fetched before serving")
+	}
+}
+
+func TestResolveChainName(t *testing.T) {
+	tests := []struct {
+		name     string
+		chainID  int
+		baseURL  string
+		want     string
+		wantErr  bool
+		wantWarn bool
+	}{
+		{name: "known chain", chainID: 1, baseURL: etherscan.DefaultBaseURL, want: "Ethereum Mainnet"},
+		{name: "known chain on a custom explorer", chainID: 8453, baseURL: "https://explorer.example", want: "Base Mainnet"},
+		{name: "unknown chain on Etherscan", chainID: 999999, baseURL: etherscan.DefaultBaseURL, wantErr: true},
+		{name: "unknown chain on a custom explorer", chainID: 999999, baseURL: "https://explorer.example",
+			want: "chain 999999", wantWarn: true},
+		{name: "zero chain", chainID: 0, baseURL: "https://explorer.example", wantErr: true},
+		{name: "negative chain", chainID: -1, baseURL: etherscan.DefaultBaseURL, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+
+			name, err := resolveChainName(tt.chainID, tt.baseURL, zap.New(core).Sugar())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if name != tt.want {
+				t.Fatalf("expected chain name %q, got %q", tt.want, name)
+			}
+
+			if warned := logs.Len() > 0; warned != tt.wantWarn {
+				t.Fatalf("expected a warning %v, got %v", tt.wantWarn, logs.All())
+			}
+		})
+	}
+}
+
+// recordingReloader records the refresh cron stored in the database whenever the schedule is reloaded.
+type recordingReloader struct {
+	transferService *service.TransferService
+	crons           []string
+}
+
+func (r *recordingReloader) Reload() {
+	cron, err := r.transferService.GetRefreshCron(context.Background())
+	if err != nil {
+		cron = "error: " + err.Error()
+	}
+
+	r.crons = append(r.crons, cron)
+}
+
+func TestWaitForShutdownReloadsOnSIGHUP(t *testing.T) {
+	transferService, _, _ := newTestService(t)
+	l := zap.NewNop().Sugar()
+	sched := &recordingReloader{transferService: transferService}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	writeConfig := func(content string) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("writing config file: %v", err)
+		}
+	}
+
+	configPath := path
+	reload := func() { reloadConfig(transferService, sched, configPath, l) }
+
+	// waitForSignals delivers signals to waitForShutdown, followed by SIGTERM
+	waitForSignals := func(signals ...os.Signal) {
+		t.Helper()
+
+		sigCh := make(chan os.Signal, len(signals)+1)
+		for _, sig := range signals {
+			sigCh <- sig
+		}
+
+		sigCh <- syscall.SIGTERM
+
+		if err := waitForShutdown(sigCh, make(chan error), reload, l); err != nil {
+			t.Fatalf("waiting for shutdown: %v", err)
+		}
+	}
+
+	// A changed schedule is applied and seen by the scheduler without restarting
+	writeConfig("config:\n  refresh_cron: \"0 */6 * * *\"\n")
+	waitForSignals(syscall.SIGHUP)
+
+	writeConfig("config:\n  refresh_cron: \"30 2 * * *\"\n")
+	waitForSignals(syscall.SIGHUP)
+
+	if want := []string{"0 */6 * * *", "30 2 * * *"}; !slices.Equal(sched.crons, want) {
+		t.Fatalf("expected the scheduler to reload the schedules %q, got %q", want, sched.crons)
+	}
+
+	// An invalid file keeps the current schedule, which the scheduler still reloads
+	writeConfig("config:\n  refresh_cron: \"not a cron\"\n")
+	waitForSignals(syscall.SIGHUP)
+
+	if got := sched.crons[len(sched.crons)-1]; got != "30 2 * * *" {
+		t.Fatalf("expected the invalid file to keep the schedule, got %q", got)
+	}
+
+	// Without a config file, the schedule changed in the database is reloaded
+	configPath = ""
+
+	if err := transferService.UpdateRefreshCron(context.Background(), "15 * * * *"); err != nil {
+		t.Fatalf("updating refresh cron: %v", err)
+	}
+
+	waitForSignals(syscall.SIGHUP, syscall.SIGHUP)
+
+	if got := sched.crons[len(sched.crons)-2:]; !slices.Equal(got, []string{"15 * * * *", "15 * * * *"}) {
+		t.Fatalf("expected every SIGHUP to reload the schedule, got %q", got)
+	}
+}
+
+func TestWaitForShutdown(t *testing.T) {
+	l := zap.NewNop().Sugar()
+	reloads := 0
+	reload := func() { reloads++ }
+
+	sigCh := make(chan os.Signal, 3)
+	sigCh <- syscall.SIGHUP
+	sigCh <- syscall.SIGHUP
+	sigCh <- syscall.SIGINT
+
+	if err := waitForShutdown(sigCh, make(chan error), reload, l); err != nil {
+		t.Fatalf("expected a clean shutdown on SIGINT, got %v", err)
+	}
+
+	if reloads != 2 {
+		t.Fatalf("expected 2 reloads, got %d", reloads)
+	}
+
+	errCh := make(chan error, 1)
+	errCh <- errors.New("address already in use")
+
+	if err := waitForShutdown(make(chan os.Signal), errCh, reload, l); err == nil {
+		t.Fatal("expected the server error to be returned")
+	}
+}
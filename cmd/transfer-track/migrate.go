@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	libapp "github.com/ductm54/transfer-track/internal/app"
+	"github.com/ductm54/transfer-track/internal/dbutil"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/urfave/cli/v2"
+)
+
+// migrateCommand returns the migrate subcommand, managing the schema version of the database with
+// the migrations of --migration-path.
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Manage the database schema version",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "up",
+				Usage: "Apply every pending migration",
+				Action: withMigrate(func(c *cli.Context, m *migrate.Migrate) error {
+					if err := dbutil.MigrateUp(m); err != nil {
+						return err
+					}
+
+					return printMigrationVersion(c, m)
+				}),
+			},
+			{
+				Name:      "down",
+				Usage:     "Roll back the last N applied migrations",
+				ArgsUsage: "[N (default: 1)]",
+				Action: withMigrate(func(c *cli.Context, m *migrate.Migrate) error {
+					n := 1
+					if c.Args().Present() {
+						var err error
+						if n, err = strconv.Atoi(c.Args().First()); err != nil {
+							return fmt.Errorf("invalid number of migrations %q: %w", c.Args().First(), err)
+						}
+					}
+
+					if err := dbutil.MigrateDown(m, n); err != nil {
+						return err
+					}
+
+					return printMigrationVersion(c, m)
+				}),
+			},
+			{
+				Name:   "version",
+				Usage:  "Print the version of the last applied migration",
+				Action: withMigrate(printMigrationVersion),
+			},
+			{
+				Name:      "force",
+				Usage:     "Record V as the last applied migration and clear the dirty flag, without running migrations",
+				ArgsUsage: "V",
+				Action: withMigrate(func(c *cli.Context, m *migrate.Migrate) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expected the migration version to force")
+					}
+
+					version, err := strconv.Atoi(c.Args().First())
+					if err != nil {
+						return fmt.Errorf("invalid migration version %q: %w", c.Args().First(), err)
+					}
+
+					if err := dbutil.ForceVersion(m, version); err != nil {
+						return err
+					}
+
+					return printMigrationVersion(c, m)
+				}),
+			},
+		},
+	}
+}
+
+// withMigrate connects to the database and runs action with a migrate.Migrate of the configured
+// migrations, closing both afterwards.
+func withMigrate(action func(c *cli.Context, m *migrate.Migrate) error) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		db, err := connectDB(c)
+		if err != nil {
+			return err
+		}
+
+		m, err := dbutil.NewMigrate(db.DB, c.String(libapp.PostgresMigrationPath.Name),
+			c.String(libapp.PostgresDatabase.Name), c.String(libapp.PostgresSchema.Name))
+		if err != nil {
+			_ = db.Close()
+			return err
+		}
+
+		// Closing the migrate instance closes the database
+		defer m.Close()
+
+		return action(c, m)
+	}
+}
+
+// printMigrationVersion prints the version of the last applied migration and whether it is dirty.
+func printMigrationVersion(c *cli.Context, m *migrate.Migrate) error {
+	version, dirty, err := dbutil.MigrationVersion(m)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		_, err = fmt.Fprintf(c.App.Writer, "version %d (dirty, fix the failed migration and force a version)\n", version)
+	} else {
+		_, err = fmt.Fprintf(c.App.Writer, "version %d\n", version)
+	}
+
+	return err
+}
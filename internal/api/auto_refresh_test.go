@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+)
+
+// newStaleDataHandler returns a handler with seeded transfers whose data is due for a refresh, and the
+// number of Etherscan requests made. Etherscan finds no transactions, after release is closed if given.
+func newStaleDataHandler(t *testing.T, release <-chan struct{}) (*Handler, http.Handler, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	url := newEtherscanServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		if release != nil {
+			<-release
+		}
+
+		writeNoTransactions(t, w)
+	})
+
+	h, r := newTestHandler(t, url)
+	seedTransfers(t, h, "1000000")
+
+	lastUpdate := time.Now().AddDate(0, -1, 0).Format(time.RFC3339)
+	for _, key := range []string{"last_eth_update", "last_token_update"} {
+		if err := h.store.UpdateConfig(context.Background(), key, lastUpdate); err != nil {
+			t.Fatalf("setting %s: %v", key, err)
+		}
+	}
+
+	return h, r, &requests
+}
+
+// getTotals requests the totals of the seeded transfers with the given auto_refresh parameter, or
+// without it if empty, and returns the response.
+func getTotals(t *testing.T, r http.Handler, autoRefresh string) (*httptest.ResponseRecorder, TotalAmountsResponse) {
+	t.Helper()
+
+	params := testTimeRange()
+	delete(params, "auto_refresh")
+
+	if autoRefresh != "" {
+		params["auto_refresh"] = autoRefresh
+	}
+
+	var (
+		resp *httptest.ResponseRecorder
+		body TotalAmountsResponse
+	)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "get totals with auto_refresh " + autoRefresh,
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   params,
+		Assert: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, recorder)
+			decodeBody(t, recorder, &body)
+
+			resp = recorder
+		},
+	}, r)
+
+	return resp, body
+}
+
+func TestAutoRefreshDisabled(t *testing.T) {
+	_, r, requests := newStaleDataHandler(t, nil)
+
+	resp, body := getTotals(t, r, "false")
+
+	if requests.Load() != 0 {
+		t.Fatalf("expected no refresh with auto_refresh=false, got %d Etherscan requests", requests.Load())
+	}
+
+	if resp.Header().Get(staleDataHeader) != "true" || !body.Meta.Stale {
+		t.Fatal("expected the stale data to be reported")
+	}
+
+	if len(body.Amounts) != 1 {
+		t.Fatalf("expected the stored amounts to be served, got %+v", body.Amounts)
+	}
+}
+
+func TestAutoRefreshEnabled(t *testing.T) {
+	_, r, requests := newStaleDataHandler(t, nil)
+
+	// auto_refresh defaults to true
+	for _, autoRefresh := range []string{"", "true"} {
+		resp, body := getTotals(t, r, autoRefresh)
+
+		if resp.Header().Get(staleDataHeader) != "" || body.Meta.Stale {
+			t.Fatalf("expected fresh data after the auto-refresh with auto_refresh %q", autoRefresh)
+		}
+	}
+
+	if requests.Load() == 0 {
+		t.Fatal("expected the stale data to be refreshed")
+	}
+}
+
+func TestAutoRefreshServesStaleDataOnTimeout(t *testing.T) {
+	release := make(chan struct{})
+	h, r, requests := newStaleDataHandler(t, release)
+	h.SetAutoRefreshTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	resp, body := getTotals(t, r, "true")
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the read not to wait for the slow refresh, took %s", elapsed)
+	}
+
+	if resp.Header().Get(staleDataHeader) != "true" || !body.Meta.Stale {
+		t.Fatal("expected the stale data to be served while the refresh runs")
+	}
+
+	if len(body.Amounts) != 1 {
+		t.Fatalf("expected the stored amounts to be served, got %+v", body.Amounts)
+	}
+
+	if requests.Load() == 0 {
+		t.Fatal("expected a refresh to be started")
+	}
+
+	// Let the refresh finish before the database is dropped
+	close(release)
+
+	job, started, _, err := h.transferService.StartRefreshJob("")
+	if err != nil {
+		t.Fatalf("getting running refresh job: %v", err)
+	}
+
+	if started {
+		t.Fatal("expected the auto-refresh to still be running")
+	}
+
+	if _, finished := h.transferService.WaitRefreshJob(context.Background(), job.ID); !finished {
+		t.Fatalf("expected job %s to finish", job.ID)
+	}
+}
+
+func TestAutoRefreshInvalid(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	params := testTimeRange()
+	params["auto_refresh"] = "sometimes"
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid auto_refresh",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   params,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusBadRequest)(t, resp)
+			assertErrorCode(httputil.CodeInvalidParameter)(t, resp)
+		},
+	}, r)
+}
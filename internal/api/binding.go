@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerFieldNamesOnce makes validation errors report the JSON names of fields.
+var registerFieldNamesOnce sync.Once //nolint:gochecknoglobals
+
+// registerJSONFieldNames makes the validator of gin name fields by their json tag instead of their Go name.
+func registerJSONFieldNames() {
+	registerFieldNamesOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+
+			if name == "" {
+				return field.Name
+			}
+
+			return name
+		})
+	})
+}
+
+// bindJSON binds the JSON request body to obj. If the body cannot be parsed or fails validation,
+// it responds with 400 and the invalid fields, and returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	errResp := bindingError(err)
+	httputil.RespondCommonError(c, http.StatusBadRequest, &errResp)
+
+	return false
+}
+
+// bindingError translates an error of binding a JSON request body into an error response listing the
+// invalid fields.
+func bindingError(err error) httputil.CommonError {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		syntaxErr      *json.SyntaxError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fieldErrs := make([]httputil.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fieldErrs = append(fieldErrs, httputil.FieldError{
+				Field:   fieldPath(fieldErr),
+				Message: validationMessage(fieldErr),
+			})
+		}
+
+		return httputil.CommonError{
+			Code:   httputil.CodeInvalidRequest,
+			Error:  "Invalid request body",
+			Errors: fieldErrs,
+		}
+	case errors.As(err, &typeErr):
+		return httputil.CommonError{
+			Code:  httputil.CodeInvalidRequest,
+			Error: "Invalid request body",
+			Errors: []httputil.FieldError{{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("must be of type %s, got %s", typeErr.Type, typeErr.Value),
+			}},
+		}
+	case errors.Is(err, io.EOF):
+		return httputil.CommonError{
+			Code:  httputil.CodeInvalidRequest,
+			Error: "Request body is empty",
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return httputil.CommonError{
+			Code:  httputil.CodeInvalidRequest,
+			Error: "Malformed JSON: unexpected end of request body",
+		}
+	case errors.As(err, &syntaxErr):
+		return httputil.CommonError{
+			Code:  httputil.CodeInvalidRequest,
+			Error: fmt.Sprintf("Malformed JSON at offset %d: %s", syntaxErr.Offset, syntaxErr.Error()),
+		}
+	default:
+		return httputil.CommonError{
+			Code:  httputil.CodeInvalidRequest,
+			Error: err.Error(),
+		}
+	}
+}
+
+// fieldPath returns the JSON path of the field of a validation error, without the request type,
+// e.g. "addresses[0].address".
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+
+	return path
+}
+
+// validationMessage describes a failed validation of a field.
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	case "oneof":
+		return "must be one of " + fieldErr.Param()
+	default:
+		return fmt.Sprintf("failed the %s validation", fieldErr.Tag())
+	}
+}
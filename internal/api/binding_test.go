@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+)
+
+func TestBindingErrors(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	tests := []struct {
+		msg       string
+		body      string
+		wantError string
+		want      []httputil.FieldError
+	}{
+		{msg: "empty body", body: "", wantError: "Request body is empty"},
+		{msg: "truncated body", body: `{"addresses": [`, wantError: "Malformed JSON: unexpected end of request body"},
+		{msg: "syntax error", body: `{"addresses": x}`, wantError: "Malformed JSON at offset 15"},
+		{
+			msg:       "wrong type",
+			body:      `{"addresses": "0x00000000000000000000000000000000000000a1"}`,
+			wantError: "Invalid request body",
+			want:      []httputil.FieldError{{Field: "addresses", Message: "must be of type []api.AddAddressRequest, got string"}},
+		},
+		{
+			msg:       "missing list",
+			body:      `{}`,
+			wantError: "Invalid request body",
+			want:      []httputil.FieldError{{Field: "addresses", Message: "is required"}},
+		},
+		{
+			msg:       "nested fields",
+			body:      `{"addresses": [{"label": "no address", "category": "` + strings.Repeat("x", 65) + `"}]}`,
+			wantError: "Invalid request body",
+			want: []httputil.FieldError{
+				{Field: "addresses[0].address", Message: "is required"},
+				{Field: "addresses[0].category", Message: "must be at most 64"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.msg,
+			Endpoint: "/api/source-addresses",
+			Method:   http.MethodPost,
+			Body:     []byte(tt.body),
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusBadRequest)(t, resp)
+
+				var body httputil.CommonError
+				decodeBody(t, resp, &body)
+
+				if body.Code != httputil.CodeInvalidRequest || !strings.HasPrefix(body.Error, tt.wantError) {
+					t.Fatalf("%s: expected %s %q, got %s %q", tt.msg, httputil.CodeInvalidRequest, tt.wantError, body.Code, body.Error)
+				}
+
+				if len(body.Errors) != len(tt.want) {
+					t.Fatalf("%s: expected field errors %+v, got %+v", tt.msg, tt.want, body.Errors)
+				}
+
+				for i := range tt.want {
+					if body.Errors[i] != tt.want[i] {
+						t.Fatalf("%s: expected field errors %+v, got %+v", tt.msg, tt.want, body.Errors)
+					}
+				}
+			},
+		}, r)
+	}
+}
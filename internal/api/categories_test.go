@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+)
+
+func TestAddAddressCategoryAndTags(t *testing.T) {
+	_, r := newTestHandler(t, "")
+
+	body, err := json.Marshal(AddAddressesRequest{Addresses: []AddAddressRequest{{
+		Address:  testSource,
+		Label:    "source",
+		Category: " Exchange ",
+		Tags:     []string{"Hot", "hot", " cold "},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "add categorized source address",
+		Endpoint: "/api/source-addresses",
+		Method:   http.MethodPost,
+		Body:     body,
+		Assert:   httputil.AssertCode(http.StatusCreated),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "list categorized source address",
+		Endpoint: "/api/source-addresses",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var addresses []struct {
+				Category string   `json:"category"`
+				Tags     []string `json:"tags"`
+			}
+			decodeBody(t, resp, &addresses)
+
+			if len(addresses) != 1 {
+				t.Fatalf("expected 1 source address, got %d", len(addresses))
+			}
+
+			if addresses[0].Category != "exchange" || !slices.Equal(addresses[0].Tags, []string{"hot", "cold"}) {
+				t.Fatalf("expected the normalized category exchange and tags [hot cold], got %+v", addresses[0])
+			}
+		},
+	}, r)
+}
+
+func TestGetTotalAmountsByCategory(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000")
+
+	// The seeded source is uncategorized until it is tagged as an exchange
+	body, err := json.Marshal(AddAddressesRequest{Addresses: []AddAddressRequest{{
+		Address:  testSource,
+		Category: "exchange",
+		Tags:     []string{"hot"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "categorize source address",
+		Endpoint: "/api/source-addresses",
+		Method:   http.MethodPost,
+		Body:     body,
+		Assert:   httputil.AssertCode(http.StatusCreated),
+	}, r)
+
+	tests := []struct {
+		name    string
+		filter  map[string]string
+		amounts int
+	}{
+		{name: "category", filter: map[string]string{"category": "exchange"}, amounts: 1},
+		{name: "category in any case", filter: map[string]string{"category": " Exchange"}, amounts: 1},
+		{name: "other category", filter: map[string]string{"category": "vault"}, amounts: 0},
+		{name: "tag", filter: map[string]string{"tag": "hot"}, amounts: 1},
+		{name: "category and other tag", filter: map[string]string{"category": "exchange", "tag": "cold"}, amounts: 0},
+	}
+
+	for _, tt := range tests {
+		params := testTimeRange()
+		for key, value := range tt.filter {
+			params[key] = value
+		}
+
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.name,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   params,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusOK)(t, resp)
+
+				var totals TotalAmountsResponse
+				decodeBody(t, resp, &totals)
+
+				if len(totals.Amounts) != tt.amounts {
+					t.Fatalf("expected %d amounts, got %+v", tt.amounts, totals.Amounts)
+				}
+			},
+		}, r)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"go.uber.org/zap"
+)
+
+func TestGetChains(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	transferService, err := service.NewTransferService(nil, logger, etherscan.Config{
+		APIKey:  "test",
+		ChainID: 8453,
+	})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	r := newTestRouter(NewHandler(transferService, nil, logger))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "list the supported chains",
+		Endpoint: "/api/chains",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body ChainsResponse
+			decodeBody(t, resp, &body)
+
+			if body.ChainID != 8453 {
+				t.Fatalf("expected the configured chain 8453, got %d", body.ChainID)
+			}
+
+			if len(body.Chains) != len(etherscan.SupportedChains()) {
+				t.Fatalf("expected every supported chain, got %v", body.Chains)
+			}
+		},
+	}, r)
+}
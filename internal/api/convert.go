@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/pkg/convert"
+	"github.com/gin-gonic/gin"
+)
+
+// weiAmountPattern matches an amount in Wei: an optionally negative integer of at most 78 digits,
+// the length of the largest uint256.
+var weiAmountPattern = regexp.MustCompile(`^-?[0-9]{1,78}$`)
+
+// ConvertAmountRequest is an amount in Wei to normalize with the decimals of its token.
+type ConvertAmountRequest struct {
+	Amount string `json:"amount" binding:"required" example:"1500000000000000000"`
+	// Decimals is a pointer since tokens can have 0 decimals.
+	Decimals *int `json:"decimals" binding:"required" example:"18"`
+}
+
+// ConvertRequest represents a request to normalize up to 1000 amounts in Wei.
+type ConvertRequest struct {
+	Amounts []ConvertAmountRequest `json:"amounts" binding:"required,min=1,max=1000,dive"`
+}
+
+// ConvertAmount handles the request to normalize an amount in Wei.
+//
+// @Summary      Normalize an amount in Wei
+// @Description  Divides an integer amount in Wei by 10^decimals exactly, as the normalized amounts of the
+// @Description  other endpoints are.
+// @Tags         convert
+// @Produce      json
+// @Param        amount query string true "Amount in Wei, an integer of at most 78 digits"
+// @Param        decimals query int true "Decimals of the token"
+// @Success      200 {object} ConvertedAmount
+// @Failure      400 {object} httputil.CommonError
+// @Router       /convert [get]
+func (h *Handler) ConvertAmount(c *gin.Context) {
+	decimals, err := strconv.Atoi(c.Query("decimals"))
+	if err != nil || !service.IsValidDecimals(decimals) {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid decimals, expected an integer between 0 and %d", service.MaxTokenDecimals)
+
+		return
+	}
+
+	converted, ok := convertAmount(c.Query("amount"), decimals)
+	if !ok {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid amount, expected an integer of at most 78 digits")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, converted)
+}
+
+// ConvertAmounts handles the request to normalize many amounts in Wei.
+//
+// @Summary      Normalize many amounts in Wei
+// @Description  Normalizes up to 1000 amounts like GET /convert, in the order of the request. If any amount
+// @Description  is invalid, none is converted and the invalid ones are listed in the errors.
+// @Tags         convert
+// @Accept       json
+// @Produce      json
+// @Param        request body ConvertRequest true "Request body"
+// @Success      200 {object} ConvertResponse
+// @Failure      400 {object} httputil.CommonError
+// @Router       /convert [post]
+func (h *Handler) ConvertAmounts(c *gin.Context) {
+	var req ConvertRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	resp := ConvertResponse{Amounts: make([]ConvertedAmount, 0, len(req.Amounts))}
+
+	var fieldErrs []httputil.FieldError
+
+	for i, amount := range req.Amounts {
+		if !service.IsValidDecimals(*amount.Decimals) {
+			fieldErrs = append(fieldErrs, httputil.FieldError{
+				Field:   fmt.Sprintf("amounts[%d].decimals", i),
+				Message: fmt.Sprintf("must be between 0 and %d", service.MaxTokenDecimals),
+			})
+
+			continue
+		}
+
+		converted, ok := convertAmount(amount.Amount, *amount.Decimals)
+		if !ok {
+			fieldErrs = append(fieldErrs, httputil.FieldError{
+				Field:   fmt.Sprintf("amounts[%d].amount", i),
+				Message: "must be an integer of at most 78 digits",
+			})
+
+			continue
+		}
+
+		resp.Amounts = append(resp.Amounts, converted)
+	}
+
+	if len(fieldErrs) > 0 {
+		httputil.RespondCommonError(c, http.StatusBadRequest, &httputil.CommonError{
+			Code:   httputil.CodeInvalidRequest,
+			Error:  "Invalid request body",
+			Errors: fieldErrs,
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// convertAmount normalizes an amount in Wei with the given decimals, which must be valid.
+// It returns false if the amount is not an integer of at most 78 digits.
+func convertAmount(amount string, decimals int) (ConvertedAmount, bool) {
+	if !weiAmountPattern.MatchString(amount) {
+		return ConvertedAmount{}, false
+	}
+
+	normalized, err := convert.NormalizeWei(amount, decimals)
+	if err != nil {
+		return ConvertedAmount{}, false
+	}
+
+	return ConvertedAmount{
+		Amount:           amount,
+		Decimals:         decimals,
+		NormalizedAmount: normalized,
+	}, true
+}
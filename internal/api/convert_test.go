@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"go.uber.org/zap"
+)
+
+func TestConvertAmount(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	maxUint256 := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+	tests := []struct {
+		name     string
+		amount   string
+		decimals string
+		want     string
+		wantCode httputil.ErrorCode
+	}{
+		{name: "ether", amount: "1500000000000000000", decimals: "18", want: "1.5"},
+		{name: "no decimals", amount: "42", decimals: "0", want: "42"},
+		{name: "fraction", amount: "1", decimals: "6", want: "0.000001"},
+		{name: "negative", amount: "-2500000", decimals: "6", want: "-2.5"},
+		{name: "zero", amount: "0", decimals: "18", want: "0"},
+		{
+			name:     "largest uint256",
+			amount:   maxUint256,
+			decimals: "18",
+			want:     "115792089237316195423570985008687907853269984665640564039457.584007913129639935",
+		},
+		{name: "too many digits", amount: maxUint256 + "0", decimals: "18", wantCode: httputil.CodeInvalidParameter},
+		{name: "decimal point", amount: "1.5", decimals: "18", wantCode: httputil.CodeInvalidParameter},
+		{name: "exponent", amount: "1e18", decimals: "18", wantCode: httputil.CodeInvalidParameter},
+		{name: "missing amount", decimals: "18", wantCode: httputil.CodeInvalidParameter},
+		{name: "missing decimals", amount: "1", wantCode: httputil.CodeInvalidParameter},
+		{name: "negative decimals", amount: "1", decimals: "-1", wantCode: httputil.CodeInvalidParameter},
+		{
+			name:     "too many decimals",
+			amount:   "1",
+			decimals: strconv.Itoa(service.MaxTokenDecimals + 1),
+			wantCode: httputil.CodeInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.name,
+			Endpoint: "/api/convert",
+			Method:   http.MethodGet,
+			Params:   map[string]string{"amount": tt.amount, "decimals": tt.decimals},
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+
+				if tt.wantCode != "" {
+					httputil.AssertCode(http.StatusBadRequest)(t, resp)
+					assertErrorCode(tt.wantCode)(t, resp)
+
+					return
+				}
+
+				httputil.AssertCode(http.StatusOK)(t, resp)
+
+				var converted ConvertedAmount
+				decodeBody(t, resp, &converted)
+
+				if converted.NormalizedAmount != tt.want || converted.Amount != tt.amount {
+					t.Fatalf("expected %s normalized to %s, got %+v", tt.amount, tt.want, converted)
+				}
+			},
+		}, r)
+	}
+}
+
+// convertRequest returns the body of a POST /api/convert request of amounts with decimals.
+func convertRequest(t *testing.T, amounts []string, decimals []int) []byte {
+	t.Helper()
+
+	req := ConvertRequest{Amounts: make([]ConvertAmountRequest, 0, len(amounts))}
+	for i, amount := range amounts {
+		req.Amounts = append(req.Amounts, ConvertAmountRequest{Amount: amount, Decimals: &decimals[i]})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestConvertAmounts(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "valid amounts",
+		Endpoint: "/api/convert",
+		Method:   http.MethodPost,
+		Body:     convertRequest(t, []string{"1500000", "1", "7"}, []int{6, 18, 0}),
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var converted ConvertResponse
+			decodeBody(t, resp, &converted)
+
+			want := []string{"1.5", "0.000000000000000001", "7"}
+			if len(converted.Amounts) != len(want) {
+				t.Fatalf("expected %d amounts, got %+v", len(want), converted.Amounts)
+			}
+
+			// Amounts are converted in the order of the request
+			for i, amount := range converted.Amounts {
+				if amount.NormalizedAmount != want[i] {
+					t.Fatalf("expected amount %d to be %s, got %+v", i, want[i], amount)
+				}
+			}
+		},
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid amounts",
+		Endpoint: "/api/convert",
+		Method:   http.MethodPost,
+		Body:     convertRequest(t, []string{"1", "1.5", "2"}, []int{6, 6, service.MaxTokenDecimals + 1}),
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusBadRequest)(t, resp)
+
+			var body httputil.CommonError
+			decodeBody(t, resp, &body)
+
+			// None is converted and every invalid amount is listed
+			if body.Code != httputil.CodeInvalidRequest || len(body.Errors) != 2 ||
+				body.Errors[0].Field != "amounts[1].amount" || body.Errors[1].Field != "amounts[2].decimals" {
+				t.Fatalf("expected the invalid amount and decimals to be listed, got %+v", body)
+			}
+		},
+	}, r)
+
+	tooMany := make([]string, 1001)
+	decimals := make([]int, len(tooMany))
+
+	for i := range tooMany {
+		tooMany[i] = "1"
+	}
+
+	bodies := map[string][]byte{
+		"no amounts":       []byte(`{"amounts": []}`),
+		"missing decimals": []byte(`{"amounts": [{"amount": "1"}]}`),
+		"too many amounts": convertRequest(t, tooMany, decimals),
+		"malformed":        []byte(strings.Repeat("{", 3)),
+	}
+
+	for name, body := range bodies {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      name,
+			Endpoint: "/api/convert",
+			Method:   http.MethodPost,
+			Body:     body,
+			Assert:   httputil.AssertCode(http.StatusBadRequest),
+		}, r)
+	}
+}
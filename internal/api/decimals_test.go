@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+// tokenRequest returns the JSON body of a request setting the decimals of the test token.
+func tokenRequest(t *testing.T, decimals int) []byte {
+	t.Helper()
+
+	body, err := json.Marshal(AddTokenRequest{Address: testToken, Symbol: "TKN", Name: "Token", Decimals: &decimals})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestAddTokenDecimals(t *testing.T) {
+	for _, decimals := range []int{0, 6, 18} {
+		t.Run(fmt.Sprint(decimals), func(t *testing.T) {
+			_, r := newTestHandler(t, "")
+
+			httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+				Msg:      fmt.Sprintf("%d decimals", decimals),
+				Endpoint: "/api/tokens",
+				Method:   http.MethodPost,
+				Body:     tokenRequest(t, decimals),
+				Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					httputil.AssertCode(http.StatusCreated)(t, resp)
+
+					var token storage.Token
+					decodeBody(t, resp, &token)
+
+					if token.Decimals != decimals {
+						t.Fatalf("expected %d decimals, got %d", decimals, token.Decimals)
+					}
+				},
+			}, r)
+		})
+	}
+}
+
+func TestInvalidTokenDecimals(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, decimals := range []int{-1, 100} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      fmt.Sprintf("add with %d decimals", decimals),
+			Endpoint: "/api/tokens",
+			Method:   http.MethodPost,
+			Body:     tokenRequest(t, decimals),
+			Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+		}, r)
+
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      fmt.Sprintf("update to %d decimals", decimals),
+			Endpoint: "/api/tokens/1",
+			Method:   http.MethodPatch,
+			Body:     []byte(fmt.Sprintf(`{"decimals": %d}`, decimals)),
+			Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+		}, r)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// assertAround fails unless got is within a minute of want, leaving room for the time a test takes.
+func assertAround(t *testing.T, got, want time.Time) {
+	t.Helper()
+
+	if d := got.Sub(want); d < -time.Minute || d > time.Minute {
+		t.Fatalf("expected about %s, got %s", want, got)
+	}
+}
+
+func TestDefaultRangeDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		want       int
+	}{
+		{name: "week", configured: 7, want: 7},
+		{name: "quarter", configured: 90, want: 90},
+		{name: "zero", configured: 0, want: DefaultRangeDays},
+		{name: "negative", configured: -1, want: DefaultRangeDays},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, nil, zap.NewNop().Sugar())
+			h.SetDefaultRangeDays(tt.configured)
+
+			if h.defaultRangeDays != tt.want {
+				t.Fatalf("expected %d days, got %d", tt.want, h.defaultRangeDays)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/transfers", nil)
+
+			filter, errResp := h.parseTotalAmountsFilter(c)
+			if errResp != nil {
+				t.Fatalf("expected no error, got %+v", errResp)
+			}
+
+			assertAround(t, filter.StartTime, time.Now().AddDate(0, 0, -tt.want))
+			assertAround(t, filter.EndTime, time.Now())
+		})
+	}
+
+	// A block range alone applies no time range at all
+	h := NewHandler(nil, nil, zap.NewNop().Sugar())
+	h.SetDefaultRangeDays(7)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/transfers?start_block=1&end_block=2", nil)
+
+	filter, errResp := h.parseTotalAmountsFilter(c)
+	if errResp != nil {
+		t.Fatalf("expected no error, got %+v", errResp)
+	}
+
+	if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+		t.Fatalf("expected no time range for a block range, got %s to %s", filter.StartTime, filter.EndTime)
+	}
+}
+
+func TestGetTotalAmountsDefaultRange(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h)
+
+	// A transfer 3 days ago is within a default range of a week but not of a day
+	if _, err := h.store.AddTransfersBatch(t.Context(), []*storage.Transfer{{
+		Hash:         "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber:  2000,
+		Timestamp:    time.Now().AddDate(0, 0, -3),
+		FromAddress:  testSource,
+		ToAddress:    testTarget,
+		TokenAddress: testToken,
+		Amount:       "1000000",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	tests := []struct {
+		days    int
+		amounts int
+	}{
+		{days: 1, amounts: 0},
+		{days: 7, amounts: 1},
+		{days: 90, amounts: 1},
+	}
+
+	for _, tt := range tests {
+		h.SetDefaultRangeDays(tt.days)
+
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "default range",
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   map[string]string{"auto_refresh": "false"},
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				// The response tells which default applied
+				if body.Meta.DefaultRangeDays != tt.days || body.StartTime == nil {
+					t.Fatalf("expected a default range of %d days, got %+v", tt.days, body)
+				}
+
+				assertAround(t, time.Unix(*body.StartTime, 0), time.Now().AddDate(0, 0, -tt.days))
+
+				if len(body.Amounts) != tt.amounts {
+					t.Fatalf("expected %d amounts within %d days, got %+v", tt.amounts, tt.days, body.Amounts)
+				}
+			}),
+		}, r)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "explicit range",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   testTimeRange(),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if body.Meta.DefaultRangeDays != 0 {
+				t.Fatalf("expected no default range with a start_time, got %d days", body.Meta.DefaultRangeDays)
+			}
+		}),
+	}, r)
+}
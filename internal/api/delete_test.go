@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+)
+
+// assertDeleted returns an AssertFn checking a 200 response reporting want deleted transfers.
+func assertDeleted(want int64) httputil.AssertFn {
+	return func(t *testing.T, resp *httptest.ResponseRecorder) {
+		t.Helper()
+		httputil.AssertCode(http.StatusOK)(t, resp)
+
+		var body DeleteTransfersResponse
+		decodeBody(t, resp, &body)
+
+		if body.Deleted != want {
+			t.Fatalf("expected %d deleted transfers, got %d", want, body.Deleted)
+		}
+	}
+}
+
+func TestDeleteTransfersRejectsInvalidFilters(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	tests := []struct {
+		msg    string
+		params map[string]string
+		code   httputil.ErrorCode
+	}{
+		{msg: "no filter", code: httputil.CodeInvalidParameter},
+		{msg: "unconfirmed", params: map[string]string{"confirm": "yes"}, code: httputil.CodeInvalidParameter},
+		{msg: "invalid start", params: map[string]string{"start_time": "yesterday"}, code: httputil.CodeInvalidTimeRange},
+		{msg: "invalid token", params: map[string]string{"token_address": "0x1234"}, code: httputil.CodeInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.msg,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodDelete,
+			Params:   tt.params,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusBadRequest)(t, resp)
+				assertErrorCode(tt.code)(t, resp)
+			},
+		}, r)
+	}
+}
+
+func TestDeleteTransfers(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1", "2", "3", "4")
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "time range",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodDelete,
+		Params: map[string]string{
+			"start_time": fmt.Sprint(testTime.Add(time.Hour).Unix()),
+			"end_time":   fmt.Sprint(testTime.Add(2 * time.Hour).Unix()),
+		},
+		Assert: assertDeleted(2),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "other token",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodDelete,
+		Params:   map[string]string{"token_address": testSource},
+		Assert:   assertDeleted(0),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "confirmed",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodDelete,
+		Params:   map[string]string{"confirm": "all"},
+		Assert:   assertDeleted(2),
+	}, r)
+}
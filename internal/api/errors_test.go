@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondUpstreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   httputil.ErrorCode
+	}{
+		{
+			name:   "rate limited",
+			err:    fmt.Errorf("fetching ETH transfers: %w: %w", service.ErrEtherscanFetch, etherscan.ErrRateLimited),
+			status: http.StatusServiceUnavailable,
+			code:   httputil.CodeUpstreamRateLimited,
+		},
+		{
+			name:   "etherscan failure",
+			err:    fmt.Errorf("fetching ETH transfers: %w: %w", service.ErrEtherscanFetch, errors.New("timeout")),
+			status: http.StatusBadGateway,
+			code:   httputil.CodeUpstreamError,
+		},
+		{
+			name:   "metadata lookup failure",
+			err:    fmt.Errorf("%w 0xabc: %w", service.ErrTokenMetadataLookup, errors.New("timeout")),
+			status: http.StatusBadGateway,
+			code:   httputil.CodeUpstreamError,
+		},
+		{
+			name:   "storage failure",
+			err:    errors.New("storing ETH transfers batch: connection reset"),
+			status: http.StatusInternalServerError,
+			code:   httputil.CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(resp)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/transfers/refresh/0xabc", nil)
+
+			respondUpstreamError(c, tt.err, "Failed to refresh transfers")
+
+			httputil.AssertCode(tt.status)(t, resp)
+
+			var body httputil.CommonError
+			decodeBody(t, resp, &body)
+
+			if body.Code != tt.code {
+				t.Fatalf("expected code %s, got %s", tt.code, body.Code)
+			}
+		})
+	}
+}
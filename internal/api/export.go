@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// Export formats supported by the export endpoint.
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+// ndjsonFlushInterval is the number of rows written between flushes of an NDJSON export.
+const ndjsonFlushInterval = 100
+
+// totalAmountsCSVHeader is the header row of the total amounts CSV export.
+var totalAmountsCSVHeader = []string{
+	"token_address", "symbol", "name", "decimals", "total_amount", "normalized_amount",
+}
+
+// ExportTransfers handles the request to export transfer data in the requested format.
+//
+// @Summary      Export total amounts as CSV or raw transfers as NDJSON
+// @Tags         transfers
+// @Produce      text/csv,application/x-ndjson
+// @Param        format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Param        start_time query string false "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago unless only blocks are given)"
+// @Param        end_time query string false "End of the time range, Unix timestamp in seconds (default: now unless only blocks are given)"
+// @Param        start_block query int false "First block of the block range"
+// @Param        end_block query int false "Last block of the block range"
+// @Param        direction query string false "Transfers to count" Enums(source_to_target, inflow, outflow, among_sources) default(source_to_target)
+// @Param        min_amount query string false "Minimum normalized amount of a transfer"
+// @Param        max_amount query string false "Maximum normalized amount of a transfer"
+// @Param        include_unknown query bool false "Also count transfers of tokens missing from the tokens table"
+// @Param        normalized_only query bool false "Leave the raw total_amount column empty (CSV only)"
+// @Param        token_address query []string false "Only count transfers of these tokens" collectionFormat(multi)
+// @Param        from_address query []string false "Only count transfers from these senders" collectionFormat(multi)
+// @Param        to_address query []string false "Only count transfers to these recipients" collectionFormat(multi)
+// @Param        category query string false "Only count transfers of which the source or target address has this category (CSV only)"
+// @Param        tag query string false "Only count transfers of which the source or target address has this tag (CSV only)"
+// @Param        auto_refresh query bool false "Refresh stale data before exporting CSV, waiting a few seconds at most" default(true)
+// @Success      200 {file} file "CSV or NDJSON file"
+// @Header       200 {string} X-Data-Stale "true if the data is due for a refresh that did not complete in time"
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/export [get]
+func (h *Handler) ExportTransfers(c *gin.Context) {
+	switch format := c.DefaultQuery("format", exportFormatCSV); format {
+	case exportFormatCSV:
+		h.exportTotalAmountsCSV(c)
+	case exportFormatNDJSON:
+		h.exportTransfersNDJSON(c)
+	default:
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Unsupported export format %q, expected %s or %s", format, exportFormatCSV, exportFormatNDJSON)
+	}
+}
+
+// exportTotalAmountsCSV streams the total amounts as CSV.
+func (h *Handler) exportTotalAmountsCSV(c *gin.Context) {
+	filter, errResp := h.parseTotalAmountsFilter(c)
+	if errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	// Refresh data if needed, the staleness is reported in a header
+	if _, errResp := h.refreshDataIfNeeded(c); errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	amounts, err := h.store.GetTotalAmounts(c, filter)
+	if err != nil {
+		h.logger.Errorw("Error getting total amounts", "err", err)
+		respondReadError(c, err, "Failed to get total amounts")
+
+		return
+	}
+
+	if err := normalizeAmounts(amounts); err != nil {
+		h.logger.Errorw("Error normalizing total amounts", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to normalize total amounts")
+
+		return
+	}
+
+	filename := fmt.Sprintf("total-amounts-%s.csv", exportRangeLabel(filter))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+
+	if err := w.Write(totalAmountsCSVHeader); err != nil {
+		h.logger.Warnw("Error writing CSV header", "err", err)
+		return
+	}
+
+	for _, amount := range amounts {
+		// Metadata of unknown tokens is written as empty fields
+		var symbol, name, decimals string
+
+		if amount.Symbol != nil {
+			symbol = *amount.Symbol
+		}
+
+		if amount.Name != nil {
+			name = *amount.Name
+		}
+
+		if amount.Decimals != nil {
+			decimals = strconv.Itoa(*amount.Decimals)
+		}
+
+		err := w.Write([]string{
+			amount.TokenAddress,
+			symbol,
+			name,
+			decimals,
+			amount.TotalAmount,
+			amount.NormalizedAmount,
+		})
+		if err != nil {
+			h.logger.Warnw("Error writing CSV row", "err", err, "token", amount.TokenAddress)
+			return
+		}
+
+		// Flush every row so the response is streamed instead of buffered
+		w.Flush()
+		c.Writer.Flush()
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		h.logger.Warnw("Error flushing CSV export", "err", err)
+	}
+}
+
+// exportTransfersNDJSON streams the raw transfers as newline-delimited JSON.
+// The X-Total-Count header carries the number of transfers that will be streamed.
+func (h *Handler) exportTransfersNDJSON(c *gin.Context) {
+	filter, errResp := h.parseTotalAmountsFilter(c)
+	if errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	tokenAddress := c.Query("token_address")
+	if tokenAddress != "" && !service.IsValidAddress(tokenAddress) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidAddress,
+			"Invalid token_address, expected 0x followed by 40 hex characters")
+
+		return
+	}
+
+	// Use the request context so an aborted download stops the query
+	ctx := c.Request.Context()
+
+	total, cursor, err := h.store.GetTransfersForExport(ctx, storage.TransferFilter{
+		StartTime:    filter.StartTime,
+		EndTime:      filter.EndTime,
+		StartBlock:   filter.StartBlock,
+		EndBlock:     filter.EndBlock,
+		TokenAddress: tokenAddress,
+	})
+	if err != nil {
+		h.logger.Errorw("Error getting transfers for export", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to export transfers")
+
+		return
+	}
+
+	defer func() {
+		if closeErr := cursor.Close(); closeErr != nil {
+			h.logger.Warnw("Error closing transfer cursor", "err", closeErr)
+		}
+	}()
+
+	filename := fmt.Sprintf("transfers-%s.ndjson", exportRangeLabel(filter))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+
+	err = cursor.ForEach(func(transfer storage.ExportedTransfer) error {
+		if err := encoder.Encode(transfer); err != nil {
+			return fmt.Errorf("encoding transfer: %w", err)
+		}
+
+		written++
+		if written%ndjsonFlushInterval == 0 {
+			c.Writer.Flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		h.logger.Warnw("Error streaming transfers export", "err", err, "written", written, "total", total)
+		return
+	}
+
+	c.Writer.Flush()
+}
+
+// exportRangeLabel describes the time range of the filter, or its block range if no time range is set,
+// for use in export file names.
+func exportRangeLabel(filter storage.TotalAmountsFilter) string {
+	if filter.StartTime.IsZero() && filter.EndTime.IsZero() {
+		return fmt.Sprintf("blocks-%d-%d", filter.StartBlock, filter.EndBlock)
+	}
+
+	return fmt.Sprintf("%d-%d", filter.StartTime.Unix(), filter.EndTime.Unix())
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestExportTotalAmountsCSV(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000", "500000")
+
+	params := testTimeRange()
+	params["format"] = exportFormatCSV
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "csv export",
+		Endpoint: "/api/transfers/export",
+		Method:   http.MethodGet,
+		Params:   params,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			if !strings.HasPrefix(resp.Header().Get("Content-Disposition"), "attachment; filename=") {
+				t.Fatalf("expected an attachment, got Content-Disposition %q", resp.Header().Get("Content-Disposition"))
+			}
+
+			rows, err := csv.NewReader(resp.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("parsing CSV: %v", err)
+			}
+
+			if len(rows) != 2 {
+				t.Fatalf("expected a header and a data row, got %v", rows)
+			}
+
+			if !slices.Equal(rows[0], totalAmountsCSVHeader) {
+				t.Fatalf("expected header %v, got %v", totalAmountsCSVHeader, rows[0])
+			}
+
+			want := []string{testToken, "TKN", "Token", "6", "1500000", "1.5"}
+			if !slices.Equal(rows[1], want) {
+				t.Fatalf("expected row %v, got %v", want, rows[1])
+			}
+		},
+	}, r)
+}
+
+func TestExportTransfersNDJSON(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	transfers := seedTransfers(t, h, "1", "2", "3")
+
+	params := testTimeRange()
+	params["format"] = exportFormatNDJSON
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "ndjson export",
+		Endpoint: "/api/transfers/export",
+		Method:   http.MethodGet,
+		Params:   params,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			if total := resp.Header().Get("X-Total-Count"); total != "3" {
+				t.Fatalf("expected X-Total-Count 3, got %q", total)
+			}
+
+			scanner := bufio.NewScanner(resp.Body)
+			i := 0
+
+			for ; scanner.Scan(); i++ {
+				var transfer storage.ExportedTransfer
+				if err := json.Unmarshal(scanner.Bytes(), &transfer); err != nil {
+					t.Fatalf("decoding line %d: %v", i, err)
+				}
+
+				if i >= len(transfers) || transfer.Hash != transfers[i].Hash {
+					t.Fatalf("line %d: unexpected transfer %s", i, transfer.Hash)
+				}
+			}
+
+			if i != len(transfers) {
+				t.Fatalf("expected %d lines, got %d", len(transfers), i)
+			}
+		},
+	}, r)
+}
@@ -3,61 +3,243 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
 	"github.com/ductm54/transfer-track/internal/service"
 	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/pkg/convert"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+const (
+	defaultConfigHistoryLimit  = 100
+	maxConfigHistoryLimit      = 1000
+	defaultRefreshHistoryLimit = 20
+	maxRefreshHistoryLimit     = 1000
+)
+
+// emptyReasonHeader is set on list responses that contain no items.
+const emptyReasonHeader = "X-Empty-Reason"
+
+// staleDataHeader is set on responses served from data that is due for a refresh.
+const staleDataHeader = "X-Data-Stale"
+
+// unavailableRetryAfter is the Retry-After header value, in seconds, of responses to requests
+// failing because the database is unreachable or Etherscan rate limits the requests.
+const unavailableRetryAfter = "5"
+
+// DefaultAutoRefreshTimeout is how long reads wait for an automatic refresh of stale data
+// before responding with the stale data.
+const DefaultAutoRefreshTimeout = 5 * time.Second
+
+// DefaultRangeDays is the default number of days of the time range of reads that give no start_time.
+const DefaultRangeDays = 30
+
+// Idempotency of POST /api/transfers/refresh.
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// Reasons reported when a list or aggregation endpoint has no data.
+const (
+	emptyReasonNoSourceAddresses = "no source addresses configured, add them via POST /api/source-addresses"
+	emptyReasonNoTargetAddresses = "no target addresses configured, add them via POST /api/target-addresses"
+	emptyReasonNoTokens          = "no tokens catalogued, add them via POST /api/tokens"
+	emptyReasonNoTransfers       = "no transfers stored yet, trigger a refresh via POST /api/transfers/refresh"
+	emptyReasonNoMatches         = "no transfers of catalogued tokens in the selected range and direction"
+)
+
+// ResponseMeta carries additional information about a response.
+type ResponseMeta struct {
+	EmptyReason string `json:"empty_reason,omitempty"`
+	// Stale is set when the data is due for a refresh that did not complete before responding.
+	Stale bool `json:"stale,omitempty"`
+	// DefaultRangeDays is the number of days of the default time range, set when it applied because
+	// no start_time was given.
+	DefaultRangeDays int `json:"default_range_days,omitempty"`
+}
+
+// PriceProvider provides historical USD prices of tokens.
+type PriceProvider interface {
+	PriceAt(ctx context.Context, tokenAddress string, t time.Time) (float64, error)
+}
+
 // Handler handles API requests.
 type Handler struct {
 	transferService *service.TransferService
 	store           *storage.Storage
 	logger          *zap.SugaredLogger
+	priceProvider   PriceProvider
+	logLevel        *zap.AtomicLevel
+	// autoRefreshTimeout bounds the wait for automatic refreshes of stale data.
+	autoRefreshTimeout time.Duration
+	// readOnly disables the automatic refresh of stale data.
+	readOnly bool
+	// defaultRangeDays is the number of days of the time range of reads that give no start_time.
+	defaultRangeDays int
 }
 
 // NewHandler creates a new Handler.
 func NewHandler(transferService *service.TransferService, store *storage.Storage, logger *zap.SugaredLogger) *Handler {
+	registerJSONFieldNames()
+
 	return &Handler{
 		transferService: transferService,
 		store:           store,
 		logger:          logger,
+
+		autoRefreshTimeout: DefaultAutoRefreshTimeout,
+		defaultRangeDays:   DefaultRangeDays,
+	}
+}
+
+// SetPriceProvider sets the provider used to value total amounts in USD.
+// Without a provider, total amounts are returned without USD values.
+func (h *Handler) SetPriceProvider(priceProvider PriceProvider) {
+	h.priceProvider = priceProvider
+}
+
+// SetAutoRefreshTimeout sets how long reads wait for an automatic refresh of stale data.
+// The refresh keeps running in the background after the timeout, and 0 does not wait at all.
+func (h *Handler) SetAutoRefreshTimeout(timeout time.Duration) {
+	h.autoRefreshTimeout = timeout
+}
+
+// SetDefaultRangeDays sets the number of days before now from which reads that give no start_time
+// aggregate transfers. Non-positive values fall back to DefaultRangeDays.
+func (h *Handler) SetDefaultRangeDays(days int) {
+	if days <= 0 {
+		days = DefaultRangeDays
+	}
+
+	h.defaultRangeDays = days
+}
+
+// defaultStartTime returns the start of the default time range of reads that give no start_time.
+func (h *Handler) defaultStartTime() time.Time {
+	return time.Now().AddDate(0, 0, -h.defaultRangeDays)
+}
+
+// SetReadOnly disables the automatic refresh of stale data, for instances that must not write to the
+// database. Stale data is still flagged.
+func (h *Handler) SetReadOnly(readOnly bool) {
+	h.readOnly = readOnly
+}
+
+// SetLogLevel sets the level of the logger that can be changed through the API.
+// Without a level, the log level endpoints respond with 404.
+func (h *Handler) SetLogLevel(level zap.AtomicLevel) {
+	h.logLevel = &level
+}
+
+// LongRunningRoutes returns the routes that may legitimately run for longer than other requests,
+// because they stream large responses, wait on many Etherscan or ENS lookups or download token lists.
+func LongRunningRoutes() []string {
+	return []string{
+		"/api/transfers/export",
+		"/api/transfers/refresh/:address",
+		"/api/tokens/refresh-metadata",
+		"/api/tokens/:id/refresh-metadata",
+		"/api/source-addresses/import",
+		"/api/tokens/import",
+	}
+}
+
+// ReadOnlySafeRoutes returns the routes that are allowed in read-only mode despite their method,
+// because they never write to the database.
+func ReadOnlySafeRoutes() []string {
+	return []string{
+		"/api/convert",
+	}
+}
+
+// StreamingRoutes returns the routes that keep the connection open to push events, which must
+// neither be bounded by a request timeout nor compressed.
+func StreamingRoutes() []string {
+	return []string{
+		"/api/transfers/stream",
+		"/api/transfers/refresh/events",
 	}
 }
 
-// RegisterRoutes registers API routes.
-func (h *Handler) RegisterRoutes(r *gin.Engine) {
-	api := r.Group("/api")
+// RegisterRoutes registers API routes, applying the given middleware to all of them.
+func (h *Handler) RegisterRoutes(r *gin.Engine, middleware ...gin.HandlerFunc) {
+	api := r.Group("/api", middleware...)
 	{
 		// Transfer endpoints
 		api.GET("/transfers", h.GetTotalAmounts)
+		api.DELETE("/transfers", h.DeleteTransfers)
+		api.GET("/transfers/export", h.ExportTransfers)
+		api.GET("/transfers/summary", h.GetTransfersSummary)
+		api.GET("/transfers/net", h.GetNetAmounts)
+		api.GET("/transfers/stream", h.StreamTransfers)
 		api.POST("/transfers/refresh", h.RefreshTransfers)
+		api.GET("/transfers/refresh/plan", h.GetRefreshPlan)
+		api.GET("/transfers/refresh/last", h.GetLastRefreshRun)
+		api.GET("/transfers/refresh/history", h.GetRefreshHistory)
+		api.GET("/transfers/refresh/events", h.StreamRefreshEvents)
+		api.GET("/transfers/refresh/:jobID", h.GetRefreshJob)
+		api.POST("/transfers/refresh/:address", h.RefreshAddressTransfers)
 
 		// Source address endpoints
 		api.GET("/source-addresses", h.GetSourceAddresses)
+		api.GET("/source-addresses/:id", h.GetSourceAddress)
 		api.POST("/source-addresses", h.AddSourceAddress)
+		api.POST("/source-addresses/import", h.ImportAddresses)
+		api.DELETE("/source-addresses", h.DeleteSourceAddresses)
 		api.DELETE("/source-addresses/:id", h.DeleteSourceAddress)
 
 		// Target address endpoints
 		api.GET("/target-addresses", h.GetTargetAddresses)
+		api.GET("/target-addresses/:id", h.GetTargetAddress)
 		api.POST("/target-addresses", h.AddTargetAddress)
+		api.DELETE("/target-addresses", h.DeleteTargetAddresses)
 		api.DELETE("/target-addresses/:id", h.DeleteTargetAddress)
 
 		// Token endpoints
 		api.GET("/tokens", h.GetTokens)
+		api.GET("/tokens/uncatalogued", h.GetUncataloguedTokens)
+		api.GET("/tokens/:id", h.GetToken)
+		api.PUT("/tokens/:id", h.UpdateToken)
+		api.PATCH("/tokens/:id", h.UpdateToken)
 		api.POST("/tokens", h.AddToken)
+		api.POST("/tokens/import", h.ImportTokenList)
+		api.POST("/tokens/refresh-metadata", h.RefreshAllTokenMetadata)
+		api.POST("/tokens/:id/refresh-metadata", h.RefreshTokenMetadata)
 		api.DELETE("/tokens/:id", h.DeleteToken)
 
+		// Stats and chain endpoints
+		api.GET("/stats", h.GetStats)
+		api.GET("/chains", h.GetChains)
+
+		// Conversion endpoints
+		api.GET("/convert", h.ConvertAmount)
+		api.POST("/convert", h.ConvertAmounts)
+
 		// Config endpoints
 		api.GET("/config", h.GetConfig)
+		api.GET("/config/history", h.GetConfigHistory)
 		api.PUT("/config/refresh-interval", h.UpdateRefreshInterval)
 		api.PUT("/config/daily-refresh-time", h.UpdateDailyRefreshTime)
+		api.PUT("/config/refresh-cron", h.UpdateRefreshCron)
+		api.GET("/config/log-level", h.GetLogLevel)
+		api.PUT("/config/log-level", h.UpdateLogLevel)
+
+		// API documentation
+		registerDocsRoutes(api)
 	}
 }
 
@@ -73,364 +255,1826 @@ func parseTimeParam(timeStr string, defaultTime time.Time) (time.Time, error) {
 	epoch, err := strconv.ParseInt(timeStr, 10, 64)
 
 	if err != nil {
-		return time.Time{}, fmt.Errorf("parsing timestamp as integer: %w", err)
+		return time.Time{}, fmt.Errorf("parsing timestamp as integer: %w", err)
+	}
+
+	return time.Unix(epoch, 0), nil
+}
+
+// normalizeAmounts sets the normalized amount (total amount / 10^decimals) of each token amount.
+// Amounts of tokens with unknown decimals are left unnormalized, and amounts already normalized
+// by the query are kept.
+func normalizeAmounts(amounts []storage.TokenAmount) error {
+	for i := range amounts {
+		if amounts[i].Decimals == nil || amounts[i].NormalizedAmount != "" {
+			continue
+		}
+
+		normalized, err := convert.NormalizeWei(amounts[i].TotalAmount, *amounts[i].Decimals)
+		if err != nil {
+			return fmt.Errorf("normalizing amount of token %s: %w", amounts[i].TokenAddress, err)
+		}
+
+		amounts[i].NormalizedAmount = normalized
+	}
+
+	return nil
+}
+
+// totalsEmptyReason explains why the total amounts aggregation returned no data.
+// warnings lists the missing address configuration the direction depends on.
+func (h *Handler) totalsEmptyReason(
+	ctx context.Context, direction storage.Direction,
+) (reason string, warnings []string) {
+	counts, err := h.store.GetTableCounts(ctx)
+	if err != nil {
+		h.logger.Warnw("Error getting table counts", "err", err)
+		return emptyReasonNoMatches, nil
+	}
+
+	if counts.SourceAddresses == 0 && direction.UsesSourceAddresses() {
+		warnings = append(warnings, emptyReasonNoSourceAddresses)
+	}
+
+	if counts.TargetAddresses == 0 && direction.UsesTargetAddresses() {
+		warnings = append(warnings, emptyReasonNoTargetAddresses)
+	}
+
+	if len(warnings) > 0 {
+		h.logger.Warnw("Total amounts requested without address configuration",
+			"direction", direction,
+			"warnings", warnings)
+	}
+
+	return totalsEmptyReasonFromCounts(counts, direction), warnings
+}
+
+// totalsEmptyReasonFromCounts picks the first missing prerequisite of the aggregation.
+func totalsEmptyReasonFromCounts(counts *storage.TableCounts, direction storage.Direction) string {
+	switch {
+	case counts.SourceAddresses == 0 && direction.UsesSourceAddresses():
+		return emptyReasonNoSourceAddresses
+	case counts.TargetAddresses == 0 && direction.UsesTargetAddresses():
+		return emptyReasonNoTargetAddresses
+	case counts.Tokens == 0:
+		return emptyReasonNoTokens
+	case counts.Transfers == 0:
+		return emptyReasonNoTransfers
+	default:
+		return emptyReasonNoMatches
+	}
+}
+
+// respondReadError responds to a failed read with 503 and a Retry-After header if the database
+// is temporarily unreachable, and with 500 and msg otherwise.
+func respondReadError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, storage.ErrUnavailable) {
+		c.Header("Retry-After", unavailableRetryAfter)
+		httputil.RespondError(c, http.StatusServiceUnavailable, httputil.CodeUnavailable,
+			"Database temporarily unavailable, please retry")
+
+		return
+	}
+
+	httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, msg)
+}
+
+// respondUpstreamError responds to a request that failed on Etherscan with 503 and a Retry-After header
+// if Etherscan kept rate limiting the requests, and with 502 otherwise. Other errors respond with 500
+// and msg.
+func respondUpstreamError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, etherscan.ErrRateLimited):
+		c.Header("Retry-After", unavailableRetryAfter)
+		httputil.RespondError(c, http.StatusServiceUnavailable, httputil.CodeUpstreamRateLimited,
+			"Etherscan rate limit reached, please retry later")
+	case errors.Is(err, service.ErrEtherscanFetch), errors.Is(err, service.ErrTokenMetadataLookup):
+		httputil.RespondErrorf(c, http.StatusBadGateway, httputil.CodeUpstreamError, "%s: Etherscan request failed", msg)
+	default:
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, msg)
+	}
+}
+
+// getByID handles a request to get a single record by the ID path parameter.
+// It responds with 404 if the record does not exist.
+func getByID[T any](
+	h *Handler,
+	c *gin.Context,
+	getFunc func(ctx context.Context, id int64) (*T, error),
+	recordType string,
+) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidID, "Invalid ID format")
+
+		return
+	}
+
+	record, err := getFunc(c, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httputil.RespondErrorf(c, http.StatusNotFound, httputil.CodeNotFound, "%s not found", recordType)
+
+		return
+	}
+
+	if err != nil {
+		h.logger.Errorw(fmt.Sprintf("Error getting %s", strings.ToLower(recordType)), "err", err, "id", id)
+		respondReadError(c, err, "Failed to get "+strings.ToLower(recordType))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// refreshDataIfNeeded refreshes the data if it is due for a refresh, unless the request disables it
+// with auto_refresh=false or the handler is read-only. The refresh runs as a background job that is waited for up to
+// autoRefreshTimeout. It returns whether the data is stale, i.e. due for a refresh that did not
+// complete in time, and sets the staleDataHeader accordingly. A non-nil error response is returned
+// if auto_refresh is invalid.
+func (h *Handler) refreshDataIfNeeded(c *gin.Context) (bool, *httputil.CommonError) {
+	autoRefresh, err := strconv.ParseBool(c.DefaultQuery("auto_refresh", "true"))
+	if err != nil {
+		return false, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid auto_refresh, expected true or false",
+		}
+	}
+
+	shouldRefresh, err := h.transferService.ShouldRefreshData(c)
+	if err != nil {
+		h.logger.Warnw("Error checking if data should be refreshed", "err", err)
+		return false, nil
+	}
+
+	if !shouldRefresh {
+		return false, nil
+	}
+
+	if autoRefresh && !h.readOnly && h.waitForRefresh(c) {
+		return false, nil
+	}
+
+	c.Header(staleDataHeader, "true")
+
+	return true, nil
+}
+
+// waitForRefresh starts a job running the due refresh, or joins the running one, and waits up to
+// autoRefreshTimeout for it to finish. It returns whether the refresh completed successfully.
+func (h *Handler) waitForRefresh(ctx context.Context) bool {
+	job, started, err := h.transferService.StartStaleRefreshJob()
+	if err != nil {
+		h.logger.Errorw("Error starting auto-refresh", "err", err)
+		return false
+	}
+
+	if started {
+		h.logger.Infow("Auto-refreshing stale data", "jobID", job.ID)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, h.autoRefreshTimeout)
+	defer cancel()
+
+	job, finished := h.transferService.WaitRefreshJob(waitCtx, job.ID)
+	if !finished {
+		h.logger.Infow("Auto-refresh still running, serving stale data", "jobID", job.ID)
+		return false
+	}
+
+	if job.Status != service.RefreshJobDone {
+		h.logger.Warnw("Auto-refresh failed, serving stale data", "jobID", job.ID, "err", job.Error)
+		return false
+	}
+
+	return true
+}
+
+// parseTotalAmountsFilter parses the time range, block range, amount band, address, category and tag query
+// parameters of the total amounts endpoints. It returns a non-nil error response if a parameter is invalid.
+func (h *Handler) parseTotalAmountsFilter(c *gin.Context) (storage.TotalAmountsFilter, *httputil.CommonError) {
+	startBlock, err := parseBlockParam(c.Query("start_block"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid start_block, expected a positive block number",
+		}
+	}
+
+	endBlock, err := parseBlockParam(c.Query("end_block"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid end_block, expected a positive block number",
+		}
+	}
+
+	if startBlock > 0 && endBlock > 0 && startBlock > endBlock {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid block range, start_block must not be greater than end_block",
+		}
+	}
+
+	// Default to the default range if not specified, unless only a block range is given
+	var defaultStartTime, defaultEndTime time.Time
+	if (startBlock == 0 && endBlock == 0) || c.Query("start_time") != "" || c.Query("end_time") != "" {
+		defaultStartTime = h.defaultStartTime()
+		defaultEndTime = time.Now()
+	}
+
+	startTime, err := parseTimeParam(c.Query("start_time"), defaultStartTime)
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidTimeRange,
+			Error: "Invalid start_time format, expected Unix timestamp (seconds since epoch) or RFC3339",
+		}
+	}
+
+	endTime, err := parseTimeParam(c.Query("end_time"), defaultEndTime)
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidTimeRange,
+			Error: "Invalid end_time format, expected Unix timestamp (seconds since epoch) or RFC3339",
+		}
+	}
+
+	minAmount, err := parseAmountParam(c.Query("min_amount"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid min_amount, expected a non-negative decimal number",
+		}
+	}
+
+	maxAmount, err := parseAmountParam(c.Query("max_amount"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid max_amount, expected a non-negative decimal number",
+		}
+	}
+
+	if minAmount != nil && maxAmount != nil && minAmount.GreaterThan(*maxAmount) {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid amount range, min_amount must not be greater than max_amount",
+		}
+	}
+
+	includeUnknown, err := strconv.ParseBool(c.DefaultQuery("include_unknown", "false"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid include_unknown, expected true or false",
+		}
+	}
+
+	normalizedOnly, err := strconv.ParseBool(c.DefaultQuery("normalized_only", "false"))
+	if err != nil {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid normalized_only, expected true or false",
+		}
+	}
+
+	direction := storage.Direction(c.DefaultQuery("direction", string(storage.DirectionSourceToTarget)))
+	if !direction.IsValid() {
+		return storage.TotalAmountsFilter{}, &httputil.CommonError{
+			Code:  httputil.CodeInvalidParameter,
+			Error: "Invalid direction, expected source_to_target, inflow, outflow or among_sources",
+		}
+	}
+
+	addressParams := make(map[string][]string, 3)
+
+	for _, param := range []string{"token_address", "from_address", "to_address"} {
+		addresses := c.QueryArray(param)
+		for _, address := range addresses {
+			if !service.IsValidAddress(address) {
+				return storage.TotalAmountsFilter{}, &httputil.CommonError{
+					Code:  httputil.CodeInvalidAddress,
+					Error: fmt.Sprintf("Invalid %s %q, expected 0x followed by 40 hex characters", param, address),
+				}
+			}
+		}
+
+		addressParams[param] = addresses
+	}
+
+	filter := storage.TotalAmountsFilter{
+		StartTime:      startTime,
+		EndTime:        endTime,
+		StartBlock:     startBlock,
+		EndBlock:       endBlock,
+		Direction:      direction,
+		IncludeUnknown: includeUnknown,
+		NormalizedOnly: normalizedOnly,
+		TokenAddresses: addressParams["token_address"],
+		FromAddresses:  addressParams["from_address"],
+		ToAddresses:    addressParams["to_address"],
+		Category:       service.NormalizeAddressCategory(c.Query("category")),
+		Tag:            service.NormalizeAddressCategory(c.Query("tag")),
+	}
+
+	if minAmount != nil {
+		filter.MinAmount = minAmount.String()
+	}
+
+	if maxAmount != nil {
+		filter.MaxAmount = maxAmount.String()
+	}
+
+	return filter, nil
+}
+
+// parseBlockParam parses a positive block number parameter.
+// If the string is empty, it returns 0.
+func parseBlockParam(blockStr string) (int64, error) {
+	if blockStr == "" {
+		return 0, nil
+	}
+
+	block, err := strconv.ParseInt(blockStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing block number: %w", err)
+	}
+
+	if block <= 0 {
+		return 0, fmt.Errorf("block number %d is not positive", block)
+	}
+
+	return block, nil
+}
+
+// parseAmountParam parses a non-negative decimal amount parameter.
+// If the string is empty, it returns nil.
+func parseAmountParam(amountStr string) (*decimal.Decimal, error) {
+	if amountStr == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	amount, err := decimal.NewFromString(amountStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing amount: %w", err)
+	}
+
+	if amount.IsNegative() {
+		return nil, fmt.Errorf("amount %s is negative", amountStr)
+	}
+
+	return &amount, nil
+}
+
+// GetTotalAmounts handles the request to get total amounts.
+//
+// @Summary      Get total amounts per token
+// @Tags         transfers
+// @Produce      json
+// @Param        start_time query string false "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago unless only blocks are given)"
+// @Param        end_time query string false "End of the time range, Unix timestamp in seconds (default: now unless only blocks are given)"
+// @Param        start_block query int false "First block of the block range"
+// @Param        end_block query int false "Last block of the block range"
+// @Param        direction query string false "Transfers to count" Enums(source_to_target, inflow, outflow, among_sources) default(source_to_target)
+// @Param        min_amount query string false "Minimum normalized amount of a transfer"
+// @Param        max_amount query string false "Maximum normalized amount of a transfer"
+// @Param        include_unknown query bool false "Also count transfers of tokens missing from the tokens table"
+// @Param        normalized_only query bool false "Only return normalized amounts, computed in the database, omitting the raw total_amount"
+// @Param        token_address query []string false "Only count transfers of these tokens" collectionFormat(multi)
+// @Param        from_address query []string false "Only count transfers from these senders" collectionFormat(multi)
+// @Param        to_address query []string false "Only count transfers to these recipients" collectionFormat(multi)
+// @Param        category query string false "Only count transfers of which the source or target address has this category"
+// @Param        tag query string false "Only count transfers of which the source or target address has this tag"
+// @Param        auto_refresh query bool false "Refresh stale data before responding, waiting a few seconds at most" default(true)
+// @Success      200 {object} TotalAmountsResponse
+// @Header       200 {string} X-Data-Stale "true if the data is due for a refresh that did not complete in time"
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers [get]
+func (h *Handler) GetTotalAmounts(c *gin.Context) {
+	filter, errResp := h.parseTotalAmountsFilter(c)
+	if errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	// Refresh data if needed
+	stale, errResp := h.refreshDataIfNeeded(c)
+	if errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	// Get total amounts
+	amounts, err := h.store.GetTotalAmounts(c, filter)
+	if err != nil {
+		h.logger.Errorw("Error getting total amounts", "err", err)
+		respondReadError(c, err, "Failed to get total amounts")
+		return
+	}
+
+	// Calculate normalized amounts
+	if err := normalizeAmounts(amounts); err != nil {
+		h.logger.Errorw("Error normalizing total amounts", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to normalize total amounts")
+
+		return
+	}
+
+	// Value at the end of the time range, or now for block ranges
+	priceTime := filter.EndTime
+	if priceTime.IsZero() {
+		priceTime = time.Now()
+	}
+
+	h.fillUSDValues(c.Request.Context(), amounts, priceTime)
+
+	var (
+		meta     = ResponseMeta{Stale: stale}
+		warnings []string
+	)
+
+	if c.Query("start_time") == "" && !filter.StartTime.IsZero() {
+		meta.DefaultRangeDays = h.defaultRangeDays
+	}
+
+	if len(amounts) == 0 {
+		amounts = []storage.TokenAmount{}
+		meta.EmptyReason, warnings = h.totalsEmptyReason(c, filter.Direction)
+	}
+
+	// Create response with the applied ranges
+	response := TotalAmountsResponse{
+		StartBlock: filter.StartBlock,
+		EndBlock:   filter.EndBlock,
+		Direction:  filter.Direction,
+		Amounts:    amounts,
+		Meta:       meta,
+		Warnings:   warnings,
+	}
+
+	if !filter.StartTime.IsZero() {
+		startTime := filter.StartTime.Unix()
+		response.StartTime = &startTime
+	}
+
+	if !filter.EndTime.IsZero() {
+		endTime := filter.EndTime.Unix()
+		response.EndTime = &endTime
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTransfersSummary handles the request to get total amounts bucketed over time.
+//
+// @Summary      Get total amounts per token bucketed over time
+// @Tags         transfers
+// @Produce      json
+// @Param        start_time query string false "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago)"
+// @Param        end_time query string false "End of the time range, Unix timestamp in seconds (default: now)"
+// @Param        interval query string false "Bucket size" Enums(day, week, month) default(day)
+// @Success      200 {object} TransfersSummaryResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/summary [get]
+func (h *Handler) GetTransfersSummary(c *gin.Context) {
+	// Default to the default range if not specified
+	startTime, err := parseTimeParam(c.Query("start_time"), h.defaultStartTime())
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid start_time format, expected Unix timestamp (seconds since epoch)")
+
+		return
+	}
+
+	endTime, err := parseTimeParam(c.Query("end_time"), time.Now())
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid end_time format, expected Unix timestamp (seconds since epoch)")
+
+		return
+	}
+
+	interval := storage.Interval(c.DefaultQuery("interval", string(storage.IntervalDay)))
+	if !interval.IsValid() {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid interval, expected day, week or month")
+
+		return
+	}
+
+	amounts, err := h.store.GetAmountsBucketed(c, startTime, endTime, interval)
+	if err != nil {
+		h.logger.Errorw("Error getting bucketed amounts", "err", err)
+		respondReadError(c, err, "Failed to get bucketed amounts")
+
+		return
+	}
+
+	for i := range amounts {
+		normalized, err := convert.NormalizeWei(amounts[i].TotalAmount, amounts[i].Decimals)
+		if err != nil {
+			h.logger.Errorw("Error normalizing bucketed amounts", "err", err)
+			httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+				"Failed to normalize bucketed amounts")
+
+			return
+		}
+
+		amounts[i].NormalizedAmount = normalized
+	}
+
+	if amounts == nil {
+		amounts = []storage.BucketedAmount{}
+	}
+
+	c.JSON(http.StatusOK, TransfersSummaryResponse{
+		StartTime: startTime.Unix(),
+		EndTime:   endTime.Unix(),
+		Interval:  interval,
+		Buckets:   amounts,
+	})
+}
+
+// GetNetAmounts handles the request to get the net flow of each token into the target addresses.
+//
+// @Summary      Get the net flow of each token into the target addresses
+// @Tags         transfers
+// @Produce      json
+// @Param        start_time query string false "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago)"
+// @Param        end_time query string false "End of the time range, Unix timestamp in seconds (default: now)"
+// @Success      200 {object} NetAmountsResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/net [get]
+func (h *Handler) GetNetAmounts(c *gin.Context) {
+	// Default to the default range if not specified
+	startTime, err := parseTimeParam(c.Query("start_time"), h.defaultStartTime())
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid start_time format, expected Unix timestamp (seconds since epoch)")
+
+		return
+	}
+
+	endTime, err := parseTimeParam(c.Query("end_time"), time.Now())
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid end_time format, expected Unix timestamp (seconds since epoch)")
+
+		return
+	}
+
+	amounts, err := h.store.GetNetAmounts(c, startTime, endTime)
+	if err != nil {
+		h.logger.Errorw("Error getting net amounts", "err", err)
+		respondReadError(c, err, "Failed to get net amounts")
+
+		return
+	}
+
+	for i := range amounts {
+		normalized, err := convert.NormalizeWei(amounts[i].NetAmount, amounts[i].Decimals)
+		if err != nil {
+			h.logger.Errorw("Error normalizing net amounts", "err", err)
+			httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+				"Failed to normalize net amounts")
+
+			return
+		}
+
+		amounts[i].NormalizedNetAmount = normalized
+	}
+
+	if amounts == nil {
+		amounts = []storage.NetAmount{}
+	}
+
+	c.JSON(http.StatusOK, NetAmountsResponse{
+		StartTime: startTime.Unix(),
+		EndTime:   endTime.Unix(),
+		Amounts:   amounts,
+	})
+}
+
+// DeleteTransfers handles the request to delete stored transfers by time range and token.
+// Deleting every transfer requires confirm=all.
+//
+// @Summary      Delete stored transfers
+// @Tags         transfers
+// @Produce      json
+// @Param        start_time query string false "Start of the time range, Unix timestamp in seconds or RFC3339"
+// @Param        end_time query string false "End of the time range, Unix timestamp in seconds or RFC3339"
+// @Param        token_address query string false "Only delete transfers of this token"
+// @Param        confirm query string false "Must be all to delete every transfer" Enums(all)
+// @Success      200 {object} DeleteTransfersResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /transfers [delete]
+func (h *Handler) DeleteTransfers(c *gin.Context) {
+	startTime, err := parseTimeParam(c.Query("start_time"), time.Time{})
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid start_time format, expected Unix timestamp (seconds since epoch) or RFC3339")
+
+		return
+	}
+
+	endTime, err := parseTimeParam(c.Query("end_time"), time.Time{})
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid end_time format, expected Unix timestamp (seconds since epoch) or RFC3339")
+
+		return
+	}
+
+	tokenAddress := c.Query("token_address")
+	if tokenAddress != "" && !service.IsValidAddress(tokenAddress) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidAddress,
+			"Invalid token_address, expected 0x followed by 40 hex characters")
+
+		return
+	}
+
+	filter := storage.TransferFilter{
+		StartTime:    startTime,
+		EndTime:      endTime,
+		TokenAddress: tokenAddress,
+	}
+
+	if filter.IsEmpty() && c.Query("confirm") != "all" {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"No filter given, pass confirm=all to delete every transfer")
+
+		return
+	}
+
+	deleted, err := h.store.DeleteTransfers(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Errorw("Error deleting transfers", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete transfers")
+
+		return
+	}
+
+	h.logger.Infow("Deleted transfers",
+		"startTime", startTime,
+		"endTime", endTime,
+		"tokenAddress", tokenAddress,
+		"deleted", deleted)
+
+	c.JSON(http.StatusOK, DeleteTransfersResponse{Deleted: deleted})
+}
+
+// fillUSDValues values the normalized amounts in USD at the given time if a price provider is set.
+// Tokens without a price are left without a USD value.
+func (h *Handler) fillUSDValues(ctx context.Context, amounts []storage.TokenAmount, at time.Time) {
+	if h.priceProvider == nil {
+		return
+	}
+
+	if now := time.Now(); at.After(now) {
+		at = now
+	}
+
+	for i := range amounts {
+		if amounts[i].NormalizedAmount == "" {
+			continue
+		}
+
+		price, err := h.priceProvider.PriceAt(ctx, amounts[i].TokenAddress, at)
+		if err != nil {
+			h.logger.Warnw("Error getting token price", "token", amounts[i].TokenAddress, "err", err)
+			continue
+		}
+
+		normalized, err := decimal.NewFromString(amounts[i].NormalizedAmount)
+		if err != nil {
+			h.logger.Warnw("Error parsing normalized amount",
+				"token", amounts[i].TokenAddress,
+				"amount", amounts[i].NormalizedAmount,
+				"err", err)
+
+			continue
+		}
+
+		amounts[i].USDValue = normalized.Mul(decimal.NewFromFloat(price)).StringFixed(2)
+	}
+}
+
+// RefreshTransfers handles the request to refresh transfers.
+// The refresh runs as a background job; if one is already running its job is returned.
+// A repeated Idempotency-Key header returns the response of the first request with the key.
+//
+// @Summary      Start a refresh of all transfers in the background
+// @Tags         refresh
+// @Produce      json
+// @Param        Idempotency-Key header string false "Key identifying retries of the same request"
+// @Success      202 {object} RefreshStartedResponse
+// @Header       202 {string} Idempotent-Replayed "true if the response is replayed for a repeated key"
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /transfers/refresh [post]
+func (h *Handler) RefreshTransfers(c *gin.Context) {
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid %s header, expected at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+
+		return
+	}
+
+	job, started, replayed, err := h.transferService.StartRefreshJob(idempotencyKey)
+	if err != nil {
+		h.logger.Errorw("Error starting refresh job", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to start refresh")
+
+		return
+	}
+
+	if replayed {
+		c.Header(idempotentReplayedHeader, "true")
+	}
+
+	message := "Refresh started"
+	if !started {
+		message = "Refresh already running"
+	}
+
+	c.JSON(http.StatusAccepted, RefreshStartedResponse{
+		Message: message,
+		Job:     job,
+	})
+}
+
+// GetRefreshJob handles the request to get the status of a refresh job.
+//
+// @Summary      Get the status of a refresh job
+// @Tags         refresh
+// @Produce      json
+// @Param        jobID path string true "Refresh job ID"
+// @Success      200 {object} service.RefreshJob
+// @Failure      404 {object} httputil.CommonError
+// @Router       /transfers/refresh/{jobID} [get]
+func (h *Handler) GetRefreshJob(c *gin.Context) {
+	job, ok := h.transferService.GetRefreshJob(c.Param("jobID"))
+	if !ok {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "Refresh job not found")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetLastRefreshRun handles the request to get the outcome of the most recent refresh.
+//
+// @Summary      Get the most recently started refresh
+// @Tags         refresh
+// @Produce      json
+// @Success      200 {object} storage.RefreshRun
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/refresh/last [get]
+func (h *Handler) GetLastRefreshRun(c *gin.Context) {
+	run, err := h.transferService.GetLastRefreshRun(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "No refresh recorded yet")
+
+			return
+		}
+
+		h.logger.Errorw("Error getting last refresh run", "err", err)
+		respondReadError(c, err, "Failed to get last refresh run")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetRefreshHistory handles the request to list the most recent refreshes.
+//
+// @Summary      List the most recently started refreshes
+// @Tags         refresh
+// @Produce      json
+// @Param        limit query int false "Maximum number of refreshes" minimum(1) maximum(1000) default(20)
+// @Success      200 {array} storage.RefreshRun
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/refresh/history [get]
+func (h *Handler) GetRefreshHistory(c *gin.Context) {
+	limit := defaultRefreshHistoryLimit
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxRefreshHistoryLimit {
+			httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+				"Invalid limit, expected an integer between 1 and %d", maxRefreshHistoryLimit)
+
+			return
+		}
+	}
+
+	runs, err := h.transferService.ListRefreshRuns(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Errorw("Error listing refresh runs", "err", err)
+		respondReadError(c, err, "Failed to list refresh runs")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRefreshPlan handles the request to preview the Etherscan requests of a full refresh.
+//
+// @Summary      Preview the Etherscan requests of a refresh
+// @Tags         refresh
+// @Produce      json
+// @Success      200 {object} service.RefreshPlan
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/refresh/plan [get]
+func (h *Handler) GetRefreshPlan(c *gin.Context) {
+	plan, err := h.transferService.PlanRefresh(c.Request.Context())
+	if err != nil {
+		h.logger.Errorw("Error planning refresh", "err", err)
+		respondReadError(c, err, "Failed to plan refresh")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// RefreshAddressTransfers handles the request to refresh transfers for a single tracked address.
+//
+// @Summary      Refresh the transfers of a tracked address
+// @Tags         refresh
+// @Produce      json
+// @Param        address path string true "Tracked source or target address"
+// @Success      200 {object} AddressRefreshResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      502 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /transfers/refresh/{address} [post]
+func (h *Handler) RefreshAddressTransfers(c *gin.Context) {
+	address := c.Param("address")
+	if !service.IsValidAddress(address) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidAddress,
+			"Invalid address, expected 0x followed by 40 hex characters")
+
+		return
+	}
+
+	tokens, err := h.transferService.FetchAndStoreForAddress(c.Request.Context(), address)
+	if err != nil {
+		if errors.Is(err, service.ErrAddressNotTracked) {
+			httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound,
+				"Address is not a tracked source or target address")
+
+			return
+		}
+
+		h.logger.Errorw("Error refreshing transfers for address", "address", address, "err", err)
+		respondUpstreamError(c, err, "Failed to refresh transfers")
+
+		return
+	}
+
+	inserted := 0
+	for _, token := range tokens {
+		inserted += token.Inserted
+	}
+
+	c.JSON(http.StatusOK, AddressRefreshResponse{
+		Message:  "Transfers refreshed successfully",
+		Address:  strings.ToLower(address),
+		Inserted: inserted,
+		Tokens:   tokens,
+	})
+}
+
+// GetSourceAddresses handles the request to get source addresses.
+//
+// @Summary      List source addresses
+// @Tags         source-addresses
+// @Produce      json
+// @Success      200 {array} storage.SourceAddress
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /source-addresses [get]
+func (h *Handler) GetSourceAddresses(c *gin.Context) {
+	addresses, err := h.store.GetSourceAddresses(c)
+	if err != nil {
+		h.logger.Errorw("Error getting source addresses", "err", err)
+		respondReadError(c, err, "Failed to get source addresses")
+
+		return
+	}
+
+	if len(addresses) == 0 {
+		addresses = []storage.SourceAddress{}
+		c.Header(emptyReasonHeader, emptyReasonNoSourceAddresses)
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// GetSourceAddress handles the request to get a source address by ID.
+//
+// @Summary      Get a source address
+// @Tags         source-addresses
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} storage.SourceAddress
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /source-addresses/{id} [get]
+func (h *Handler) GetSourceAddress(c *gin.Context) {
+	getByID(h, c, h.store.GetSourceAddressByID, "Source address")
+}
+
+// AddAddressRequest represents a request to add a single address.
+type AddAddressRequest struct {
+	Address string `json:"address" binding:"required"`
+	Label   string `json:"label"`
+	// Category classifies the address, e.g. exchange, vault or multisig.
+	Category string   `json:"category" binding:"max=64" example:"exchange"`
+	Tags     []string `json:"tags" binding:"max=20,dive,max=64"`
+}
+
+// AddAddressesRequest represents a request to add multiple addresses.
+type AddAddressesRequest struct {
+	Addresses []AddAddressRequest `json:"addresses" binding:"required,dive"`
+}
+
+// addAddresses is a generic function to add addresses (source or target).
+// It takes a function to add a single address and returns the added addresses.
+// Addresses repeated in the payload are added once with the last labels, and the response
+// reports them in duplicates. Addresses that were already stored are reported in existing.
+func (h *Handler) addAddresses(
+	c *gin.Context,
+	addFunc any,
+	addressType string,
+) {
+	// Create a wrapper function that converts the specific return type to any
+	var addFuncWrapper func(ctx context.Context, address string, labels storage.AddressLabels) (any, bool, error)
+
+	// Type switch to handle different function signatures
+	switch typedAddFunc := addFunc.(type) {
+	case func(ctx context.Context, address string, labels storage.AddressLabels) (*storage.SourceAddress, bool, error):
+		addFuncWrapper = func(ctx context.Context, address string, labels storage.AddressLabels) (any, bool, error) {
+			return typedAddFunc(ctx, address, labels)
+		}
+	case func(ctx context.Context, address string, labels storage.AddressLabels) (*storage.TargetAddress, bool, error):
+		addFuncWrapper = func(ctx context.Context, address string, labels storage.AddressLabels) (any, bool, error) {
+			return typedAddFunc(ctx, address, labels)
+		}
+	default:
+		h.logger.Errorw("Invalid function type passed to addAddresses", "type", fmt.Sprintf("%T", addFunc))
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Try to bind as array first
+	var reqMulti AddAddressesRequest
+	if !bindJSON(c, &reqMulti) {
+		return
+	}
+
+	invalidAddresses := make([]string, 0)
+
+	for _, addr := range reqMulti.Addresses {
+		if !service.IsValidAddress(addr.Address) {
+			invalidAddresses = append(invalidAddresses, addr.Address)
+		}
+	}
+
+	if len(invalidAddresses) > 0 {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidAddress,
+			"Invalid %s addresses, expected 0x followed by 40 hex characters: %s",
+			addressType, strings.Join(invalidAddresses, ", "))
+
+		return
+	}
+
+	// Deduplicate normalized addresses, keeping the first position and the last labels
+	order := make([]string, 0, len(reqMulti.Addresses))
+	labels := make(map[string]storage.AddressLabels, len(reqMulti.Addresses))
+	duplicates := make([]string, 0)
+
+	for _, addr := range reqMulti.Addresses {
+		address := strings.ToLower(addr.Address)
+
+		if _, ok := labels[address]; ok {
+			duplicates = append(duplicates, address)
+		} else {
+			order = append(order, address)
+		}
+
+		labels[address] = storage.AddressLabels{Label: addr.Label, Category: addr.Category, Tags: addr.Tags}
+	}
+
+	// Preallocate with the capacity of the number of addresses
+	addedAddresses := make([]any, 0, len(order))
+	existing := make([]string, 0)
+
+	for _, address := range order {
+		added, inserted, err := addFuncWrapper(c, address, labels[address])
+		if err != nil {
+			h.logger.Warnw(fmt.Sprintf("Error adding %s address", addressType),
+				"address", address, "err", err)
+			// Continue with other addresses
+			continue
+		}
+
+		if !inserted {
+			existing = append(existing, address)
+		}
+
+		addedAddresses = append(addedAddresses, added)
+	}
+
+	if len(addedAddresses) == 0 {
+		httputil.RespondErrorf(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to add any %s addresses", addressType)
+		return
+	}
+
+	c.JSON(http.StatusCreated, AddAddressesResponse{
+		Addresses:  addedAddresses,
+		Duplicates: duplicates,
+		Existing:   existing,
+	})
+}
+
+// AddSourceAddress handles the request to add a source address or multiple source addresses.
+//
+// @Summary      Add or relabel source addresses
+// @Tags         source-addresses
+// @Accept       json
+// @Produce      json
+// @Param        request body AddAddressesRequest true "Request body"
+// @Success      201 {object} AddAddressesResponse{addresses=[]storage.SourceAddress}
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /source-addresses [post]
+func (h *Handler) AddSourceAddress(c *gin.Context) {
+	h.addAddresses(c, h.transferService.AddSourceAddress, "source")
+}
+
+// deleteAddress is a generic function to delete an address (source or target).
+// It takes a function to delete a single address by ID.
+func (h *Handler) deleteAddress(
+	c *gin.Context,
+	deleteFunc func(ctx context.Context, id int64) error,
+	addressType string,
+) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidID, "Invalid ID format")
+		return
+	}
+
+	err = deleteFunc(c, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httputil.RespondErrorf(c, http.StatusNotFound, httputil.CodeNotFound,
+			"%s address %d not found", addressType, id)
+
+		return
+	}
+
+	if err != nil {
+		h.logger.Errorw(fmt.Sprintf("Error deleting %s address", addressType),
+			"err", err, "id", id)
+		httputil.RespondErrorf(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to delete %s address", addressType)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("%s address deleted successfully", addressType),
+	})
+}
+
+// DeleteAddressesRequest represents a request to delete multiple addresses by ID and/or address.
+type DeleteAddressesRequest struct {
+	IDs       []int64  `json:"ids"`
+	Addresses []string `json:"addresses"`
+}
+
+// deleteAddresses is a generic function to delete addresses (source or target) in bulk.
+// It reports how many addresses were deleted and which of the requested ones were not found.
+func (h *Handler) deleteAddresses(
+	c *gin.Context,
+	deleteByIDs func(ctx context.Context, ids []int64) ([]int64, error),
+	deleteByAddresses func(ctx context.Context, addresses []string) ([]string, error),
+	addressType string,
+) {
+	var req DeleteAddressesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 && len(req.Addresses) == 0 {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			"At least one of ids or addresses is required")
+		return
+	}
+
+	deletedIDs, err := deleteByIDs(c, req.IDs)
+	if err != nil {
+		h.logger.Errorw(fmt.Sprintf("Error deleting %s addresses by id", addressType), "err", err)
+		httputil.RespondErrorf(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to delete %s addresses", addressType)
+
+		return
+	}
+
+	deletedAddresses, err := deleteByAddresses(c, req.Addresses)
+	if err != nil {
+		h.logger.Errorw(fmt.Sprintf("Error deleting %s addresses by address", addressType), "err", err)
+		httputil.RespondErrorf(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to delete %s addresses", addressType)
+
+		return
+	}
+
+	foundIDs := make(map[int64]struct{}, len(deletedIDs))
+	for _, id := range deletedIDs {
+		foundIDs[id] = struct{}{}
+	}
+
+	notFoundIDs := make([]int64, 0)
+
+	for _, id := range req.IDs {
+		if _, ok := foundIDs[id]; !ok {
+			notFoundIDs = append(notFoundIDs, id)
+		}
+	}
+
+	foundAddresses := make(map[string]struct{}, len(deletedAddresses))
+	for _, address := range deletedAddresses {
+		foundAddresses[address] = struct{}{}
+	}
+
+	notFoundAddresses := make([]string, 0)
+
+	for _, address := range req.Addresses {
+		if _, ok := foundAddresses[strings.ToLower(address)]; !ok {
+			notFoundAddresses = append(notFoundAddresses, address)
+		}
+	}
+
+	c.JSON(http.StatusOK, DeleteAddressesResponse{
+		Deleted:           len(deletedIDs) + len(deletedAddresses),
+		NotFoundIDs:       notFoundIDs,
+		NotFoundAddresses: notFoundAddresses,
+	})
+}
+
+// DeleteSourceAddresses handles the request to delete multiple source addresses by ID and/or address.
+//
+// @Summary      Delete source addresses by ID or address
+// @Tags         source-addresses
+// @Accept       json
+// @Produce      json
+// @Param        request body DeleteAddressesRequest true "Request body"
+// @Success      200 {object} DeleteAddressesResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /source-addresses [delete]
+func (h *Handler) DeleteSourceAddresses(c *gin.Context) {
+	h.deleteAddresses(c, h.store.DeleteSourceAddressesByIDs, h.store.DeleteSourceAddressesByAddresses, "source")
+}
+
+// DeleteSourceAddress handles the request to delete a source address.
+//
+// @Summary      Delete a source address
+// @Tags         source-addresses
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /source-addresses/{id} [delete]
+func (h *Handler) DeleteSourceAddress(c *gin.Context) {
+	h.deleteAddress(c, h.store.DeleteSourceAddress, "Source")
+}
+
+// GetTargetAddresses handles the request to get target addresses.
+//
+// @Summary      List target addresses
+// @Tags         target-addresses
+// @Produce      json
+// @Success      200 {array} storage.TargetAddress
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /target-addresses [get]
+func (h *Handler) GetTargetAddresses(c *gin.Context) {
+	addresses, err := h.store.GetTargetAddresses(c)
+	if err != nil {
+		h.logger.Errorw("Error getting target addresses", "err", err)
+		respondReadError(c, err, "Failed to get target addresses")
+		return
+	}
+
+	if len(addresses) == 0 {
+		addresses = []storage.TargetAddress{}
+		c.Header(emptyReasonHeader, emptyReasonNoTargetAddresses)
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// GetTargetAddress handles the request to get a target address by ID.
+//
+// @Summary      Get a target address
+// @Tags         target-addresses
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} storage.TargetAddress
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /target-addresses/{id} [get]
+func (h *Handler) GetTargetAddress(c *gin.Context) {
+	getByID(h, c, h.store.GetTargetAddressByID, "Target address")
+}
+
+// AddTargetAddress handles the request to add a target address or multiple target addresses.
+//
+// @Summary      Add or relabel target addresses
+// @Tags         target-addresses
+// @Accept       json
+// @Produce      json
+// @Param        request body AddAddressesRequest true "Request body"
+// @Success      201 {object} AddAddressesResponse{addresses=[]storage.TargetAddress}
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /target-addresses [post]
+func (h *Handler) AddTargetAddress(c *gin.Context) {
+	h.addAddresses(c, h.transferService.AddTargetAddress, "target")
+}
+
+// DeleteTargetAddresses handles the request to delete multiple target addresses by ID and/or address.
+//
+// @Summary      Delete target addresses by ID or address
+// @Tags         target-addresses
+// @Accept       json
+// @Produce      json
+// @Param        request body DeleteAddressesRequest true "Request body"
+// @Success      200 {object} DeleteAddressesResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /target-addresses [delete]
+func (h *Handler) DeleteTargetAddresses(c *gin.Context) {
+	h.deleteAddresses(c, h.store.DeleteTargetAddressesByIDs, h.store.DeleteTargetAddressesByAddresses, "target")
+}
+
+// DeleteTargetAddress handles the request to delete a target address.
+//
+// @Summary      Delete a target address
+// @Tags         target-addresses
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /target-addresses/{id} [delete]
+func (h *Handler) DeleteTargetAddress(c *gin.Context) {
+	h.deleteAddress(c, h.store.DeleteTargetAddress, "Target")
+}
+
+// AddTokenRequest represents a request to add a token.
+// Missing symbol, name and decimals are looked up on Etherscan.
+type AddTokenRequest struct {
+	Address string `json:"address" binding:"required"`
+	Symbol  string `json:"symbol"`
+	Name    string `json:"name"`
+	// Decimals is a pointer since tokens can have 0 decimals. Omitted decimals are looked up.
+	Decimals *int `json:"decimals"`
+}
+
+// GetTokens handles the request to get tokens.
+//
+// @Summary      List tokens
+// @Tags         tokens
+// @Produce      json
+// @Success      200 {array} storage.Token
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /tokens [get]
+func (h *Handler) GetTokens(c *gin.Context) {
+	tokens, err := h.store.GetTokens(c)
+	if err != nil {
+		h.logger.Errorw("Error getting tokens", "err", err)
+		respondReadError(c, err, "Failed to get tokens")
+
+		return
+	}
+
+	if len(tokens) == 0 {
+		tokens = []storage.Token{}
+		c.Header(emptyReasonHeader, emptyReasonNoTokens)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// GetUncataloguedTokens handles the request to list the tokens seen in transfers that are not catalogued.
+//
+// @Summary      List tokens seen in transfers but missing from the tokens
+// @Tags         tokens
+// @Produce      json
+// @Success      200 {array} storage.UncataloguedToken
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /tokens/uncatalogued [get]
+func (h *Handler) GetUncataloguedTokens(c *gin.Context) {
+	tokens, err := h.store.GetUncataloguedTokens(c)
+	if err != nil {
+		h.logger.Errorw("Error getting uncatalogued tokens", "err", err)
+		respondReadError(c, err, "Failed to get uncatalogued tokens")
+
+		return
 	}
 
-	return time.Unix(epoch, 0), nil
+	if tokens == nil {
+		tokens = []storage.UncataloguedToken{}
+	}
+
+	c.JSON(http.StatusOK, tokens)
 }
 
-// normalizeAmount converts a string amount to a normalized amount based on decimals.
-// It returns the normalized amount as a string.
-func normalizeAmount(amount string, decimals int) string {
-	// Create a big.Float from the string amount
-	bigAmount, success := new(big.Float).SetString(amount)
-	if !success {
-		return "0"
+// GetToken handles the request to get a token by ID.
+//
+// @Summary      Get a token
+// @Tags         tokens
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} storage.Token
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /tokens/{id} [get]
+func (h *Handler) GetToken(c *gin.Context) {
+	getByID(h, c, h.store.GetTokenByID, "Token")
+}
+
+// AddToken handles the request to add a token.
+//
+// @Summary      Add a token
+// @Tags         tokens
+// @Accept       json
+// @Produce      json
+// @Param        request body AddTokenRequest true "Request body"
+// @Success      201 {object} storage.Token
+// @Failure      400 {object} httputil.CommonError
+// @Failure      409 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /tokens [post]
+func (h *Handler) AddToken(c *gin.Context) {
+	var req AddTokenRequest
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	// Calculate divisor (10^decimals)
-	divisor := new(big.Float).SetInt(new(big.Int).Exp(
-		big.NewInt(10),
-		big.NewInt(int64(decimals)),
-		nil,
-	))
+	if !service.IsValidAddress(req.Address) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidAddress,
+			"Invalid token address, expected 0x followed by 40 hex characters")
 
-	// Divide amount by divisor
-	normalizedAmount := new(big.Float).Quo(bigAmount, divisor)
+		return
+	}
 
-	// Convert to string with appropriate precision
-	return normalizedAmount.Text('f', decimals)
-}
+	if req.Decimals != nil && !service.IsValidDecimals(*req.Decimals) {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid decimals, expected an integer between 0 and %d", service.MaxTokenDecimals)
 
-// refreshDataIfNeeded checks if data should be refreshed and refreshes it if needed.
-func (h *Handler) refreshDataIfNeeded(ctx context.Context) {
-	shouldRefresh, err := h.transferService.ShouldRefreshData(ctx)
-	if err != nil {
-		h.logger.Warnw("Error checking if data should be refreshed", "err", err)
 		return
 	}
 
-	if !shouldRefresh {
+	// Fill in missing metadata, decimals default to 18 if they cannot be looked up
+	meta := h.transferService.FillTokenMetadata(c, req.Address, service.TokenMetadata{
+		Symbol:   req.Symbol,
+		Name:     req.Name,
+		Decimals: req.Decimals,
+	})
+
+	if meta.Symbol == "" {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			"Token symbol could not be looked up, please supply it")
+
 		return
 	}
 
-	h.logger.Infow("Auto-refreshing data before getting total amounts")
+	token, err := h.store.AddToken(c, req.Address, meta.Symbol, meta.Name, *meta.Decimals)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		httputil.RespondError(c, http.StatusConflict, httputil.CodeTokenExists, "Token already exists")
 
-	// Create a new context with timeout for the refresh operation
-	refreshCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+		return
+	}
 
-	err = h.transferService.FetchAndStoreTransfers(refreshCtx)
 	if err != nil {
-		h.logger.Errorw("Error refreshing data", "err", err)
-		// Continue with potentially stale data
+		h.logger.Errorw("Error adding token", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to add token")
+
+		return
 	}
-}
 
-// GetTotalAmounts handles the request to get total amounts.
-func (h *Handler) GetTotalAmounts(c *gin.Context) {
-	// Parse time range parameters
-	startTimeStr := c.Query("start_time")
-	endTimeStr := c.Query("end_time")
+	c.JSON(http.StatusCreated, token)
+}
 
-	// Default to last 30 days if not specified
-	defaultStartTime := time.Now().AddDate(0, -1, 0) // 1 month ago
-	defaultEndTime := time.Now()
+// UpdateTokenRequest represents a request to update a token's metadata.
+// Omitted fields are left unchanged.
+type UpdateTokenRequest struct {
+	Symbol   *string `json:"symbol"`
+	Name     *string `json:"name"`
+	Decimals *int    `json:"decimals"`
+}
 
-	startTime, err := parseTimeParam(startTimeStr, defaultStartTime)
+// UpdateToken handles the request to update a token's symbol, name and decimals.
+//
+// @Summary      Update the metadata of a token
+// @Tags         tokens
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID"
+// @Param        request body UpdateTokenRequest true "Request body"
+// @Success      200 {object} storage.Token
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /tokens/{id} [put]
+// @Router       /tokens/{id} [patch]
+func (h *Handler) UpdateToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid start_time format, expected Unix timestamp (seconds since epoch) or RFC3339",
-		})
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidID, "Invalid ID format")
+
 		return
 	}
 
-	endTime, err := parseTimeParam(endTimeStr, defaultEndTime)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid end_time format, expected Unix timestamp (seconds since epoch) or RFC3339",
-		})
+	var req UpdateTokenRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Refresh data if needed
-	h.refreshDataIfNeeded(c)
+	if req.Symbol != nil && *req.Symbol == "" {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Token symbol must not be empty")
 
-	// Get total amounts
-	amounts, err := h.store.GetTotalAmounts(c, startTime, endTime)
-	if err != nil {
-		h.logger.Errorw("Error getting total amounts", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get total amounts"})
 		return
 	}
 
-	// Calculate normalized amounts
-	for i := range amounts {
-		amounts[i].NormalizedAmount = normalizeAmount(amounts[i].TotalAmount, amounts[i].Decimals)
-	}
+	if req.Decimals != nil && !service.IsValidDecimals(*req.Decimals) {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid decimals, expected an integer between 0 and %d", service.MaxTokenDecimals)
 
-	// Create response with timestamps
-	response := gin.H{
-		"start_time": startTime.Unix(),
-		"end_time":   endTime.Unix(),
-		"amounts":    amounts,
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
+	token, err := h.store.UpdateToken(c, id, req.Symbol, req.Name, req.Decimals)
+	if errors.Is(err, sql.ErrNoRows) {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "Token not found")
+
+		return
+	}
 
-// RefreshTransfers handles the request to refresh transfers.
-func (h *Handler) RefreshTransfers(c *gin.Context) {
-	err := h.transferService.FetchAndStoreTransfers(c)
 	if err != nil {
-		h.logger.Errorw("Error refreshing transfers", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh transfers"})
+		h.logger.Errorw("Error updating token", "err", err, "id", id)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update token")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transfers refreshed successfully"})
+	c.JSON(http.StatusOK, token)
 }
 
-// GetSourceAddresses handles the request to get source addresses.
-func (h *Handler) GetSourceAddresses(c *gin.Context) {
-	addresses, err := h.store.GetSourceAddresses(c)
+// RefreshTokenMetadata handles the request to re-sync the symbol, name and decimals of a token with Etherscan.
+//
+// @Summary      Refresh the metadata of a token
+// @Tags         tokens
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} service.TokenMetadataRefresh
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      502 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /tokens/{id}/refresh-metadata [post]
+func (h *Handler) RefreshTokenMetadata(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		h.logger.Errorw("Error getting source addresses", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get source addresses"})
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidID, "Invalid ID format")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, addresses)
-}
-
-// AddAddressRequest represents a request to add a single address.
-type AddAddressRequest struct {
-	Address string `json:"address" binding:"required"`
-	Label   string `json:"label"`
-}
+	refresh, err := h.transferService.RefreshTokenMetadata(c, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "Token not found")
 
-// AddAddressesRequest represents a request to add multiple addresses.
-type AddAddressesRequest struct {
-	Addresses []struct {
-		Address string `json:"address" binding:"required"`
-		Label   string `json:"label"`
-	} `json:"addresses" binding:"required"`
-}
+		return
+	}
 
-// addAddresses is a generic function to add addresses (source or target).
-// It takes a function to add a single address and returns the added addresses.
-func (h *Handler) addAddresses(
-	c *gin.Context,
-	addFunc any,
-	addressType string,
-) {
-	// Create a wrapper function that converts the specific return type to any
-	var addFuncWrapper func(ctx context.Context, address, label string) (any, error)
+	if errors.Is(err, etherscan.ErrTokenNotFound) {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound,
+			"No transfers of the token found on Etherscan to read its metadata from")
 
-	// Type switch to handle different function signatures
-	switch typedAddFunc := addFunc.(type) {
-	case func(ctx context.Context, address, label string) (*storage.SourceAddress, error):
-		addFuncWrapper = func(ctx context.Context, address, label string) (any, error) {
-			return typedAddFunc(ctx, address, label)
-		}
-	case func(ctx context.Context, address, label string) (*storage.TargetAddress, error):
-		addFuncWrapper = func(ctx context.Context, address, label string) (any, error) {
-			return typedAddFunc(ctx, address, label)
-		}
-	default:
-		h.logger.Errorw("Invalid function type passed to addAddresses", "type", fmt.Sprintf("%T", addFunc))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
 
-	// Try to bind as array first
-	var reqMulti AddAddressesRequest
-	if err := c.ShouldBindJSON(&reqMulti); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if errors.Is(err, service.ErrTokenMetadataLookup) {
+		h.logger.Warnw("Error looking up token metadata", "err", err, "id", id)
+		respondUpstreamError(c, err, "Failed to look up token metadata")
+
 		return
 	}
 
-	// Preallocate with the capacity of the number of addresses
-	addedAddresses := make([]any, 0, len(reqMulti.Addresses))
-
-	for _, addr := range reqMulti.Addresses {
-		address, err := addFuncWrapper(c, addr.Address, addr.Label)
-		if err != nil {
-			h.logger.Warnw(fmt.Sprintf("Error adding %s address", addressType),
-				"address", addr.Address, "err", err)
-			// Continue with other addresses
-			continue
-		}
+	if err != nil {
+		h.logger.Errorw("Error refreshing token metadata", "err", err, "id", id)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh token metadata")
 
-		addedAddresses = append(addedAddresses, address)
+		return
 	}
 
-	if len(addedAddresses) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to add any %s addresses", addressType),
-		})
+	c.JSON(http.StatusOK, refresh)
+}
+
+// RefreshAllTokenMetadata handles the request to re-sync the metadata of every token with Etherscan.
+//
+// @Summary      Refresh the metadata of all tokens
+// @Tags         tokens
+// @Produce      json
+// @Success      200 {object} TokenMetadataRefreshResponse
+// @Failure      500 {object} httputil.CommonError
+// @Router       /tokens/refresh-metadata [post]
+func (h *Handler) RefreshAllTokenMetadata(c *gin.Context) {
+	refreshes, err := h.transferService.RefreshAllTokenMetadata(c)
+	if err != nil {
+		h.logger.Errorw("Error refreshing token metadata", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh token metadata")
+
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"addresses": addedAddresses})
-}
+	response := TokenMetadataRefreshResponse{Tokens: refreshes}
 
-// AddSourceAddress handles the request to add a source address or multiple source addresses.
-func (h *Handler) AddSourceAddress(c *gin.Context) {
-	h.addAddresses(c, h.store.AddSourceAddress, "source")
+	for _, refresh := range refreshes {
+		if len(refresh.Changed) > 0 {
+			response.Changed++
+		}
+
+		if refresh.Error != "" {
+			response.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-// deleteAddress is a generic function to delete an address (source or target).
-// It takes a function to delete a single address by ID.
-func (h *Handler) deleteAddress(
-	c *gin.Context,
-	deleteFunc func(ctx context.Context, id int64) error,
-	addressType string,
-) {
+// DeleteToken handles the request to delete a token.
+//
+// @Summary      Delete a token
+// @Tags         tokens
+// @Produce      json
+// @Param        id path int true "ID"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /tokens/{id} [delete]
+func (h *Handler) DeleteToken(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
-		return
-	}
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidID, "Invalid ID format")
 
-	err = deleteFunc(c, id)
-	if err != nil {
-		h.logger.Errorw(fmt.Sprintf("Error deleting %s address", addressType),
-			"err", err, "id", id)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to delete %s address", addressType),
-		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("%s address deleted successfully", addressType),
-	})
-}
+	err = h.store.DeleteToken(c, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httputil.RespondErrorf(c, http.StatusNotFound, httputil.CodeNotFound, "Token %d not found", id)
 
-// DeleteSourceAddress handles the request to delete a source address.
-func (h *Handler) DeleteSourceAddress(c *gin.Context) {
-	h.deleteAddress(c, h.store.DeleteSourceAddress, "Source")
-}
+		return
+	}
 
-// GetTargetAddresses handles the request to get target addresses.
-func (h *Handler) GetTargetAddresses(c *gin.Context) {
-	addresses, err := h.store.GetTargetAddresses(c)
 	if err != nil {
-		h.logger.Errorw("Error getting target addresses", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get target addresses"})
+		h.logger.Errorw("Error deleting token", "err", err, "id", id)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete token")
+
 		return
 	}
 
-	c.JSON(http.StatusOK, addresses)
-}
-
-// AddTargetAddress handles the request to add a target address or multiple target addresses.
-func (h *Handler) AddTargetAddress(c *gin.Context) {
-	h.addAddresses(c, h.store.AddTargetAddress, "target")
+	c.JSON(http.StatusOK, MessageResponse{Message: "Token deleted successfully"})
 }
 
-// DeleteTargetAddress handles the request to delete a target address.
-func (h *Handler) DeleteTargetAddress(c *gin.Context) {
-	h.deleteAddress(c, h.store.DeleteTargetAddress, "Target")
+// secretConfigKeys are config keys whose values are never returned by the API.
+var secretConfigKeys = map[string]struct{}{
+	"etherscan_api_key": {},
 }
 
-// AddTokenRequest represents a request to add a token.
-type AddTokenRequest struct {
-	Address  string `json:"address" binding:"required"`
-	Symbol   string `json:"symbol" binding:"required"`
-	Name     string `json:"name"`
-	Decimals int    `json:"decimals"`
-}
+// redactedConfigValue replaces the value of a non-empty secret config key.
+const redactedConfigValue = "********"
 
-// GetTokens handles the request to get tokens.
-func (h *Handler) GetTokens(c *gin.Context) {
-	tokens, err := h.store.GetTokens(c)
+// GetConfig handles the request to get configuration.
+// It returns the typed known fields and every config row in the config map.
+//
+// @Summary      Get the configuration
+// @Tags         config
+// @Produce      json
+// @Success      200 {object} ConfigResponse
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /config [get]
+func (h *Handler) GetConfig(c *gin.Context) {
+	// Get refresh interval
+	refreshInterval, err := h.transferService.GetRefreshInterval(c)
 	if err != nil {
-		h.logger.Errorw("Error getting tokens", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tokens"})
+		h.logger.Errorw("Error getting refresh interval", "err", err)
+		respondReadError(c, err, "Failed to get refresh interval")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, tokens)
-}
+	// Get daily refresh time
+	dailyRefreshTime, err := h.transferService.GetDailyRefreshTime(c)
+	if err != nil {
+		h.logger.Errorw("Error getting daily refresh time", "err", err)
+		respondReadError(c, err, "Failed to get daily refresh time")
 
-// AddToken handles the request to add a token.
-func (h *Handler) AddToken(c *gin.Context) {
-	var req AddTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allConfig, err := h.store.GetAllConfig(c)
+	if err != nil {
+		h.logger.Errorw("Error getting all config", "err", err)
+		respondReadError(c, err, "Failed to get config")
 
 		return
 	}
 
-	// Default decimals to 18 if not specified
-	if req.Decimals == 0 {
-		req.Decimals = 18
+	for key, value := range allConfig {
+		if _, ok := secretConfigKeys[key]; ok && value != "" {
+			allConfig[key] = redactedConfigValue
+		}
 	}
 
-	token, err := h.store.AddToken(c, req.Address, req.Symbol, req.Name, req.Decimals)
+	refreshCron, err := h.transferService.GetRefreshCron(c)
 	if err != nil {
-		h.logger.Errorw("Error adding token", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add token"})
+		h.logger.Errorw("Error getting refresh cron", "err", err)
+		respondReadError(c, err, "Failed to get refresh cron")
 
 		return
 	}
 
-	c.JSON(http.StatusCreated, token)
+	c.JSON(http.StatusOK, ConfigResponse{
+		MinRefreshIntervalHours: refreshInterval,
+		DailyRefreshTime:        dailyRefreshTime,
+		RefreshCron:             refreshCron,
+		Config:                  allConfig,
+	})
 }
 
-// DeleteToken handles the request to delete a token.
-func (h *Handler) DeleteToken(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+// GetChains handles the request to list the chains served by Etherscan.
+//
+// @Summary      List the supported chains
+// @Tags         config
+// @Produce      json
+// @Success      200 {object} ChainsResponse
+// @Router       /chains [get]
+func (h *Handler) GetChains(c *gin.Context) {
+	c.JSON(http.StatusOK, ChainsResponse{
+		ChainID: h.transferService.ChainID(),
+		Chains:  etherscan.SupportedChains(),
+	})
+}
 
+// GetStats handles the request to get an overview of the stored data and the refresh configuration.
+//
+// @Summary      Get the stored data and refresh configuration
+// @Tags         config
+// @Produce      json
+// @Success      200 {object} StatsResponse
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /stats [get]
+func (h *Handler) GetStats(c *gin.Context) {
+	stats, err := h.store.GetStats(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		h.logger.Errorw("Error getting stats", "err", err)
+		respondReadError(c, err, "Failed to get stats")
 
 		return
 	}
 
-	err = h.store.DeleteToken(c, id)
+	refreshInterval, err := h.transferService.GetRefreshInterval(c)
 	if err != nil {
-		h.logger.Errorw("Error deleting token", "err", err, "id", id)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete token"})
+		h.logger.Errorw("Error getting refresh interval", "err", err)
+		respondReadError(c, err, "Failed to get refresh interval")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Token deleted successfully"})
+	dailyRefreshTime, err := h.transferService.GetDailyRefreshTime(c)
+	if err != nil {
+		h.logger.Errorw("Error getting daily refresh time", "err", err)
+		respondReadError(c, err, "Failed to get daily refresh time")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, StatsResponse{
+		Stats:                   *stats,
+		MinRefreshIntervalHours: refreshInterval,
+		DailyRefreshTime:        dailyRefreshTime,
+	})
 }
 
-// GetConfig handles the request to get configuration.
-func (h *Handler) GetConfig(c *gin.Context) {
-	// Get refresh interval
-	refreshInterval, err := h.transferService.GetRefreshInterval(c)
+// GetConfigHistory handles the request to list configuration changes.
+//
+// @Summary      List configuration changes
+// @Tags         config
+// @Produce      json
+// @Param        key query string false "Only list changes of this key"
+// @Param        start_time query string false "Only list changes made at or after this Unix timestamp in seconds"
+// @Param        end_time query string false "Only list changes made at or before this Unix timestamp in seconds"
+// @Param        limit query int false "Maximum number of changes" minimum(1) maximum(1000) default(100)
+// @Success      200 {array} storage.ConfigHistory
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      503 {object} httputil.CommonError
+// @Router       /config/history [get]
+func (h *Handler) GetConfigHistory(c *gin.Context) {
+	startTime, err := parseTimeParam(c.Query("start_time"), time.Time{})
 	if err != nil {
-		h.logger.Warnw("Error getting refresh interval", "err", err)
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid start_time format, expected Unix timestamp (seconds since epoch)")
 
-		refreshInterval = 1 // Default to 1 hour
+		return
 	}
 
-	// Get daily refresh time
-	dailyRefreshTime, err := h.transferService.GetDailyRefreshTime(c)
+	endTime, err := parseTimeParam(c.Query("end_time"), time.Time{})
 	if err != nil {
-		h.logger.Warnw("Error getting daily refresh time", "err", err)
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidTimeRange,
+			"Invalid end_time format, expected Unix timestamp (seconds since epoch)")
+
+		return
+	}
+
+	limit := defaultConfigHistoryLimit
 
-		dailyRefreshTime = "00:00:00" // Default to midnight
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxConfigHistoryLimit {
+			httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+				"Invalid limit, expected an integer between 1 and %d", maxConfigHistoryLimit)
+
+			return
+		}
 	}
 
-	config := gin.H{
-		"min_refresh_interval_hours": refreshInterval,
-		"daily_refresh_time":         dailyRefreshTime,
+	history, err := h.store.GetConfigHistory(c, storage.ConfigHistoryFilter{
+		Key:       c.Query("key"),
+		StartTime: startTime,
+		EndTime:   endTime,
+		Limit:     limit,
+	})
+	if err != nil {
+		h.logger.Errorw("Error getting config history", "err", err)
+		respondReadError(c, err, "Failed to get config history")
+
+		return
 	}
 
-	c.JSON(http.StatusOK, config)
+	c.JSON(http.StatusOK, history)
 }
 
 // UpdateRefreshIntervalRequest represents a request to update the refresh interval.
@@ -439,23 +2083,38 @@ type UpdateRefreshIntervalRequest struct {
 }
 
 // UpdateRefreshInterval handles the request to update the refresh interval.
+//
+// @Summary      Update the minimum refresh interval
+// @Tags         config
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateRefreshIntervalRequest true "Request body"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /config/refresh-interval [put]
 func (h *Handler) UpdateRefreshInterval(c *gin.Context) {
 	var req UpdateRefreshIntervalRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	err := h.transferService.UpdateRefreshInterval(c, req.Hours)
+	if errors.Is(err, service.ErrInvalidConfigValue) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
 	if err != nil {
 		h.logger.Errorw("Error updating refresh interval", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update refresh interval"})
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to update refresh interval")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Refresh interval updated successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Refresh interval updated successfully"})
 }
 
 // UpdateDailyRefreshTimeRequest represents a request to update the daily refresh time.
@@ -464,21 +2123,139 @@ type UpdateDailyRefreshTimeRequest struct {
 }
 
 // UpdateDailyRefreshTime handles the request to update the daily refresh time.
+//
+// @Summary      Update the daily refresh times
+// @Tags         config
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateDailyRefreshTimeRequest true "Request body"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /config/daily-refresh-time [put]
 func (h *Handler) UpdateDailyRefreshTime(c *gin.Context) {
 	var req UpdateDailyRefreshTimeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	err := h.transferService.UpdateDailyRefreshTime(c, req.Time)
+	if errors.Is(err, service.ErrInvalidConfigValue) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
 	if err != nil {
 		h.logger.Errorw("Error updating daily refresh time", "err", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update daily refresh time"})
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal,
+			"Failed to update daily refresh time")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Daily refresh time updated successfully"})
+}
+
+// UpdateRefreshCronRequest represents a request to update the refresh cron expression.
+type UpdateRefreshCronRequest struct {
+	// Cron is a five-field cron expression, or empty to fall back to the daily refresh times.
+	Cron string `json:"cron"`
+}
+
+// UpdateRefreshCron handles the request to update the refresh cron expression.
+//
+// @Summary      Update the refresh cron expression
+// @Tags         config
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateRefreshCronRequest true "Request body"
+// @Success      200 {object} MessageResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Router       /config/refresh-cron [put]
+func (h *Handler) UpdateRefreshCron(c *gin.Context) {
+	var req UpdateRefreshCronRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	err := h.transferService.UpdateRefreshCron(c, req.Cron)
+	if errors.Is(err, service.ErrInvalidConfigValue) {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	if err != nil {
+		h.logger.Errorw("Error updating refresh cron", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update refresh cron")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Refresh cron updated successfully"})
+}
+
+// GetLogLevel handles the request to get the current log level.
+//
+// @Summary      Get the log level
+// @Tags         config
+// @Produce      json
+// @Success      200 {object} LogLevelResponse
+// @Failure      404 {object} httputil.CommonError
+// @Router       /config/log-level [get]
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	if h.logLevel == nil {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "Log level is not adjustable")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Daily refresh time updated successfully"})
+	c.JSON(http.StatusOK, LogLevelResponse{Level: h.logLevel.Level().String()})
+}
+
+// UpdateLogLevelRequest is the request body of PUT /api/config/log-level.
+type UpdateLogLevelRequest struct {
+	// Level is the minimum level of logged messages.
+	Level string `json:"level" binding:"required" enums:"debug,info,warn,error" example:"debug"`
+}
+
+// UpdateLogLevel handles the request to change the log level at runtime.
+// The change is not persisted, so the --log-level flag applies again after a restart.
+//
+// @Summary      Update the log level
+// @Tags         config
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateLogLevelRequest true "Request body"
+// @Success      200 {object} LogLevelResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      404 {object} httputil.CommonError
+// @Router       /config/log-level [put]
+func (h *Handler) UpdateLogLevel(c *gin.Context) {
+	if h.logLevel == nil {
+		httputil.RespondError(c, http.StatusNotFound, httputil.CodeNotFound, "Log level is not adjustable")
+
+		return
+	}
+
+	var req UpdateLogLevelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil || level > zapcore.ErrorLevel {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid log level %q, expected debug, info, warn or error", req.Level)
+
+		return
+	}
+
+	previous := h.logLevel.Level()
+	h.logLevel.SetLevel(level)
+	h.logger.Infow("Log level updated", "previous", previous, "level", level)
+
+	c.JSON(http.StatusOK, LogLevelResponse{Level: level.String()})
 }
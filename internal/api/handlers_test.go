@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const testMigrationPath = "../../migrations"
+
+// newTestHandler returns a handler on a fresh development DB with the default config, as on a first
+// startup, and its routes registered.
+// Etherscan requests go to etherscanURL, which may be empty for tests that make none.
+func newTestHandler(t *testing.T, etherscanURL string) (*Handler, *gin.Engine) {
+	t.Helper()
+
+	logger := zap.NewNop().Sugar()
+	store := storage.New(testutil.NewTestDB(t, testMigrationPath), logger)
+
+	transferService, err := service.NewTransferService(store, logger, etherscan.Config{
+		APIKey:  "test",
+		ChainID: 1,
+		BaseURL: etherscanURL,
+	})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	if err := transferService.EnsureConfigDefaults(context.Background()); err != nil {
+		t.Fatalf("ensuring config defaults: %v", err)
+	}
+
+	h := NewHandler(transferService, store, logger)
+
+	return h, newTestRouter(h)
+}
+
+// newUnreachableDBHandler returns a handler without a service on a database that refuses every
+// connection, to test the responses to database failures without a database server.
+func newUnreachableDBHandler(t *testing.T) (*Handler, *gin.Engine) {
+	t.Helper()
+
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test password=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	h := NewHandler(nil, storage.New(db, zap.NewNop().Sugar()), zap.NewNop().Sugar())
+
+	return h, newTestRouter(h)
+}
+
+// newTestRouter returns a router with the routes of h registered. Handlers created without a service
+// or store can serve the requests rejected before using them.
+func newTestRouter(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	return r
+}
+
+// Addresses of the transfers seeded by seedTransfers.
+const (
+	testSource = "0x00000000000000000000000000000000000000a1"
+	testTarget = "0x00000000000000000000000000000000000000b2"
+	testToken  = "0x00000000000000000000000000000000000000c3"
+)
+
+// testTime is the time of the first transfer seeded by seedTransfers.
+var testTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testTimeRange returns the start_time and end_time query parameters of a range around the seeded transfers.
+func testTimeRange() map[string]string {
+	return map[string]string{
+		"start_time":   fmt.Sprint(testTime.Add(-time.Hour).Unix()),
+		"end_time":     fmt.Sprint(testTime.Add(24 * time.Hour).Unix()),
+		"auto_refresh": "false",
+	}
+}
+
+// seedTransfers catalogues the test token with 6 decimals, tracks the test source and target addresses
+// and stores a transfer of each amount from the source to the target, one block and one hour apart
+// from testTime.
+func seedTransfers(t *testing.T, h *Handler, amounts ...string) []*storage.Transfer {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := h.store.AddToken(ctx, testToken, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	if _, _, err := h.store.AddSourceAddress(ctx, testSource, storage.AddressLabels{Label: "source"}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if _, _, err := h.store.AddTargetAddress(ctx, testTarget, storage.AddressLabels{Label: "target"}); err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	transfers := make([]*storage.Transfer, 0, len(amounts))
+
+	for i, amount := range amounts {
+		transfers = append(transfers, &storage.Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    testTime.Add(time.Duration(i) * time.Hour),
+			FromAddress:  testSource,
+			ToAddress:    testTarget,
+			TokenAddress: testToken,
+			Amount:       amount,
+		})
+	}
+
+	if _, err := h.store.AddTransfersBatch(ctx, transfers); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	return transfers
+}
+
+// decodeBody decodes the JSON body of resp into v.
+func decodeBody(t *testing.T, resp *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(resp.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", resp.Body.String(), err)
+	}
+}
+
+func TestDeleteSourceAddressesMixedBatch(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := context.Background()
+
+	byID, _, err := h.store.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a1", storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	_, _, err = h.store.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a2", storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	const (
+		missingID      = 999999
+		missingAddress = "0x00000000000000000000000000000000000000ff"
+	)
+
+	body, err := json.Marshal(DeleteAddressesRequest{
+		IDs:       []int64{byID.ID, missingID},
+		Addresses: []string{"0x00000000000000000000000000000000000000A2", missingAddress},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "delete found and not found source addresses",
+		Endpoint: "/api/source-addresses",
+		Method:   http.MethodDelete,
+		Body:     body,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var result DeleteAddressesResponse
+			decodeBody(t, resp, &result)
+
+			if result.Deleted != 2 {
+				t.Fatalf("expected 2 deleted, got %d", result.Deleted)
+			}
+
+			if !slices.Equal(result.NotFoundIDs, []int64{missingID}) {
+				t.Fatalf("expected not found ids [%d], got %v", missingID, result.NotFoundIDs)
+			}
+
+			if !slices.Equal(result.NotFoundAddresses, []string{missingAddress}) {
+				t.Fatalf("expected not found addresses [%s], got %v", missingAddress, result.NotFoundAddresses)
+			}
+		},
+	}, r)
+
+	remaining, err := h.store.GetSourceAddresses(ctx)
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	if len(remaining) != 0 {
+		t.Fatalf("expected no source addresses left, got %+v", remaining)
+	}
+}
+
+func TestGetByID(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := context.Background()
+
+	token, err := h.store.AddToken(ctx, "0x00000000000000000000000000000000000000c3", "TKN", "Token", 6)
+	if err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	source, _, err := h.store.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a1", storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	target, _, err := h.store.AddTargetAddress(ctx, "0x00000000000000000000000000000000000000b2", storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	const missingID = 999999
+
+	for resource, id := range map[string]int64{"tokens": token.ID, "source-addresses": source.ID, "target-addresses": target.ID} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource + " found",
+			Endpoint: fmt.Sprintf("/api/%s/%d", resource, id),
+			Method:   http.MethodGet,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusOK)(t, resp)
+
+				var body struct {
+					ID int64 `json:"id"`
+				}
+				decodeBody(t, resp, &body)
+
+				if body.ID != id {
+					t.Fatalf("%s: expected id %d, got %d", resource, id, body.ID)
+				}
+			},
+		}, r)
+
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource + " not found",
+			Endpoint: fmt.Sprintf("/api/%s/%d", resource, missingID),
+			Method:   http.MethodGet,
+			Assert:   httputil.AssertCode(http.StatusNotFound),
+		}, r)
+	}
+}
+
+func TestUpdateToken(t *testing.T) {
+	h, r := newTestHandler(t, "")
+
+	token, err := h.store.AddToken(context.Background(), "0x00000000000000000000000000000000000000c3", "TKN", "Token", 6)
+	if err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/tokens/%d", token.ID)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "patch name",
+		Endpoint: endpoint,
+		Method:   http.MethodPatch,
+		Body:     []byte(`{"name": "Renamed"}`),
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var updated storage.Token
+			decodeBody(t, resp, &updated)
+
+			if updated.Name != "Renamed" || updated.Symbol != "TKN" || updated.Decimals != 6 {
+				t.Fatalf("expected only the name updated, got %+v", updated)
+			}
+		},
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "no-op update",
+		Endpoint: endpoint,
+		Method:   http.MethodPut,
+		Body:     []byte(`{}`),
+		Assert:   httputil.AssertCode(http.StatusOK),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "missing token",
+		Endpoint: fmt.Sprintf("/api/tokens/%d", token.ID+1000),
+		Method:   http.MethodPatch,
+		Body:     []byte(`{"symbol": "NEW"}`),
+		Assert:   httputil.AssertCode(http.StatusNotFound),
+	}, r)
+}
+
+func TestGetConfig(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := context.Background()
+
+	if err := h.store.UpdateConfig(ctx, "min_refresh_interval_hours", "4"); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	if err := h.store.UpdateConfig(ctx, "etherscan_api_key", "secret"); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "get config",
+		Endpoint: "/api/config",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var config ConfigResponse
+			decodeBody(t, resp, &config)
+
+			if config.MinRefreshIntervalHours != 4 || config.Config["min_refresh_interval_hours"] != "4" {
+				t.Fatalf("expected the refresh interval 4 in the typed field and the map, got %+v", config)
+			}
+
+			if _, ok := config.Config["last_eth_update"]; !ok {
+				t.Fatalf("expected every config key in the map, got %v", config.Config)
+			}
+
+			if config.Config["etherscan_api_key"] != redactedConfigValue {
+				t.Fatalf("expected the API key to be redacted, got %q", config.Config["etherscan_api_key"])
+			}
+		},
+	}, r)
+}
+
+func TestDeleteByID(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := context.Background()
+
+	token, err := h.store.AddToken(ctx, testToken, "TKN", "Token", 6)
+	if err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	source, _, err := h.store.AddSourceAddress(ctx, testSource, storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	target, _, err := h.store.AddTargetAddress(ctx, testTarget, storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	for resource, id := range map[string]int64{"tokens": token.ID, "source-addresses": source.ID, "target-addresses": target.ID} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource + " found",
+			Endpoint: fmt.Sprintf("/api/%s/%d", resource, id),
+			Method:   http.MethodDelete,
+			Assert:   httputil.AssertCode(http.StatusOK),
+		}, r)
+
+		// Deleting again finds nothing
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource + " not found",
+			Endpoint: fmt.Sprintf("/api/%s/%d", resource, id),
+			Method:   http.MethodDelete,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusNotFound)(t, resp)
+				assertErrorCode(httputil.CodeNotFound)(t, resp)
+			},
+		}, r)
+	}
+}
+
+func TestDeleteByIDDatabaseError(t *testing.T) {
+	_, r := newUnreachableDBHandler(t)
+
+	for _, resource := range []string{"tokens", "source-addresses", "target-addresses"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource,
+			Endpoint: "/api/" + resource + "/1",
+			Method:   http.MethodDelete,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusInternalServerError)(t, resp)
+				assertErrorCode(httputil.CodeInternal)(t, resp)
+			},
+		}, r)
+	}
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImportSize caps the request body of an address import, including the multipart encoding.
+	maxImportSize = 1 << 20
+	// maxImportRows caps the number of addresses of an import.
+	maxImportRows = 5000
+	// importTagSeparator separates the tags in the tags column of an import.
+	importTagSeparator = ";"
+	// byteOrderMark is written at the start of CSV files by spreadsheet applications such as Excel.
+	byteOrderMark = "\ufeff"
+)
+
+// importColumns are the columns a header row of an import file may name.
+var importColumns = []string{"address", "label", "category", "tags"}
+
+// Outcomes of the rows of an address import.
+const (
+	importStatusAdded   = "added"
+	importStatusUpdated = "updated"
+	importStatusInvalid = "invalid"
+	importStatusFailed  = "failed"
+)
+
+// importRow is an address read from an import file.
+type importRow struct {
+	line    int
+	address string
+	labels  storage.AddressLabels
+}
+
+// ImportAddresses handles the request to import source or target addresses from a CSV file.
+//
+// @Summary      Import addresses from a CSV file
+// @Description  The file has the columns address and label, optionally preceded by a header row naming them.
+// @Description  A header row may add the columns category and tags, with tags separated by semicolons.
+// @Tags         source-addresses
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "CSV file of at most 1 MiB and 5000 addresses"
+// @Param        type formData string false "Type of the imported addresses" Enums(source, target) default(source)
+// @Success      200 {object} ImportAddressesResponse
+// @Failure      400 {object} httputil.CommonError
+// @Failure      413 {object} httputil.CommonError
+// @Router       /source-addresses/import [post]
+func (h *Handler) ImportAddresses(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			httputil.RespondErrorf(c, http.StatusRequestEntityTooLarge, httputil.CodeInvalidRequest,
+				"Import file too large, expected at most %d bytes", maxImportSize)
+
+			return
+		}
+
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			"Missing file, expected a CSV file in the file field of a multipart/form-data body")
+
+		return
+	}
+
+	var addFunc func(ctx context.Context, address string, labels storage.AddressLabels) (bool, error)
+
+	addressType := c.DefaultPostForm("type", "source")
+
+	switch addressType {
+	case "source":
+		addFunc = func(ctx context.Context, address string, labels storage.AddressLabels) (bool, error) {
+			_, inserted, err := h.transferService.AddSourceAddress(ctx, address, labels)
+			return inserted, err
+		}
+	case "target":
+		addFunc = func(ctx context.Context, address string, labels storage.AddressLabels) (bool, error) {
+			_, inserted, err := h.transferService.AddTargetAddress(ctx, address, labels)
+			return inserted, err
+		}
+	default:
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidParameter,
+			"Invalid type %q, expected source or target", addressType)
+
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.Errorw("Error opening import file", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to read import file")
+
+		return
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			h.logger.Warnw("Error closing import file", "err", closeErr)
+		}
+	}()
+
+	rows, err := readImportRows(file)
+	if err != nil {
+		httputil.RespondErrorf(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid CSV file: %s", err)
+		return
+	}
+
+	response := ImportAddressesResponse{
+		Type: addressType,
+		Rows: make([]ImportAddressRow, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		result := ImportAddressRow{Line: row.line, Address: row.address}
+
+		if !service.IsValidAddress(row.address) {
+			result.Status = importStatusInvalid
+			result.Error = "expected 0x followed by 40 hex characters"
+			response.Invalid++
+			response.Rows = append(response.Rows, result)
+
+			continue
+		}
+
+		result.Address = strings.ToLower(row.address)
+
+		inserted, err := addFunc(c, row.address, row.labels)
+
+		switch {
+		case err != nil:
+			h.logger.Warnw("Error importing address", "address", row.address, "line", row.line, "err", err)
+			result.Status = importStatusFailed
+			result.Error = "failed to store the address"
+			response.Failed++
+		case inserted:
+			result.Status = importStatusAdded
+			response.Added++
+		default:
+			result.Status = importStatusUpdated
+			response.Updated++
+		}
+
+		response.Rows = append(response.Rows, result)
+	}
+
+	h.logger.Infow("Imported addresses",
+		"type", response.Type,
+		"added", response.Added,
+		"updated", response.Updated,
+		"invalid", response.Invalid,
+		"failed", response.Failed)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ImportTokenList handles the request to import the tokens of a token list into the catalogue.
+//
+// @Summary      Import tokens from a token list
+// @Description  Imports the tokens on the configured chain of a token list in the format of tokenlists.org,
+// @Description  such as the Uniswap default list, uploaded as file or downloaded from url. New tokens are
+// @Description  added and the symbol, name and decimals of catalogued tokens updated, so importing the same
+// @Description  list again changes nothing.
+// @Tags         tokens
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file false "Token list JSON file of at most 16 MiB"
+// @Param        url formData string false "http or https URL of the token list, if no file is uploaded"
+// @Success      200 {object} service.TokenListImport
+// @Failure      400 {object} httputil.CommonError
+// @Failure      413 {object} httputil.CommonError
+// @Failure      500 {object} httputil.CommonError
+// @Failure      502 {object} httputil.CommonError
+// @Router       /tokens/import [post]
+func (h *Handler) ImportTokenList(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxTokenListSize+maxImportSize)
+
+	list, err := h.readTokenList(c)
+
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		httputil.RespondErrorf(c, http.StatusRequestEntityTooLarge, httputil.CodeInvalidRequest,
+			"Token list too large, expected at most %d bytes", service.MaxTokenListSize)
+
+		return
+	case errors.Is(err, service.ErrInvalidTokenList):
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+
+		return
+	case errors.Is(err, service.ErrTokenListFetch):
+		h.logger.Warnw("Error downloading token list", "err", err)
+		httputil.RespondError(c, http.StatusBadGateway, httputil.CodeUpstreamError, "Failed to download the token list")
+
+		return
+	case err != nil:
+		h.logger.Errorw("Error reading token list", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to read the token list")
+
+		return
+	}
+
+	result, err := h.transferService.ImportTokenList(c, list)
+	if err != nil {
+		h.logger.Errorw("Error importing token list", "err", err)
+		httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to import the token list")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// readTokenList reads the token list of an import request, from the uploaded file if any, else from
+// the url field. It returns an error matching service.ErrInvalidTokenList if neither is given.
+func (h *Handler) readTokenList(c *gin.Context) (*service.TokenList, error) {
+	fileHeader, err := c.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		listURL := c.PostForm("url")
+		if listURL == "" {
+			return nil, fmt.Errorf("%w: expected a file or url field in a multipart/form-data body",
+				service.ErrInvalidTokenList)
+		}
+
+		return service.FetchTokenList(c, listURL)
+	}
+
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: expected a multipart/form-data body", service.ErrInvalidTokenList)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening token list: %w", err)
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			h.logger.Warnw("Error closing token list", "err", closeErr)
+		}
+	}()
+
+	return service.ParseTokenList(file)
+}
+
+// readImportRows reads the addresses of an import file. The first row is a header row if it names any
+// of the importColumns, in any order; without one, the columns are address and label. Rows without an
+// address are skipped.
+func readImportRows(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"address": 0, "label": 1}
+
+	var rows []importRow
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if first {
+			record[0] = strings.TrimPrefix(record[0], byteOrderMark)
+
+			if header, ok := importHeader(record); ok {
+				if _, ok := header["address"]; !ok {
+					return nil, errors.New("header row without an address column")
+				}
+
+				columns = header
+
+				continue
+			}
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		row := importRow{
+			line:    line,
+			address: importField(record, columns, "address"),
+			labels: storage.AddressLabels{
+				Label:    importField(record, columns, "label"),
+				Category: importField(record, columns, "category"),
+			},
+		}
+
+		if tags := importField(record, columns, "tags"); tags != "" {
+			row.labels.Tags = strings.Split(tags, importTagSeparator)
+		}
+
+		if row.address == "" {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("more than %d addresses", maxImportRows)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// importHeader returns the column indexes of record by lowercase name, and whether record is a header
+// row naming any of the importColumns.
+func importHeader(record []string) (map[string]int, bool) {
+	columns := make(map[string]int, len(record))
+	isHeader := false
+
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		columns[name] = i
+
+		if slices.Contains(importColumns, name) {
+			isHeader = true
+		}
+	}
+
+	return columns, isHeader
+}
+
+// importField returns the trimmed value of the named column of a record, empty if the column is missing.
+func importField(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"go.uber.org/zap"
+)
+
+func TestReadImportRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []importRow
+		wantErr bool
+	}{
+		{
+			name: "without header",
+			csv:  testSource + ",source\n",
+			want: []importRow{{line: 1, address: testSource}},
+		},
+		{
+			name: "header",
+			csv:  "address,label\n" + testSource + ",source\n",
+			want: []importRow{{line: 2, address: testSource}},
+		},
+		{
+			name: "header with a byte order mark",
+			csv:  byteOrderMark + "address,label\n" + testSource + ",source\n",
+			want: []importRow{{line: 2, address: testSource}},
+		},
+		{
+			name: "header in another order",
+			csv:  "Label, Address,category\nsource," + testSource + ",vault\n",
+			want: []importRow{{line: 2, address: testSource}},
+		},
+		{
+			name: "byte order mark without header",
+			csv:  byteOrderMark + testSource + ",source\n",
+			want: []importRow{{line: 1, address: testSource}},
+		},
+		{name: "header without address column", csv: "label,category\nsource,vault\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readImportRows(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("reading rows: %v", err)
+			}
+
+			if !slices.EqualFunc(rows, tt.want, func(a, b importRow) bool {
+				return a.line == b.line && a.address == b.address
+			}) {
+				t.Fatalf("expected rows %+v, got %+v", tt.want, rows)
+			}
+
+			if rows[0].labels.Label != "source" {
+				t.Fatalf("expected the label source, got %q", rows[0].labels.Label)
+			}
+		})
+	}
+}
+
+// postMultipart posts a multipart/form-data body of fields to endpoint, uploading content as the
+// file field if fileName is not empty.
+func postMultipart(
+	t *testing.T, r http.Handler, endpoint string, fields map[string]string, fileName, content string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fileName != "" {
+		file, err := form.CreateFormFile("file", fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, endpoint, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestImportAddresses(t *testing.T) {
+	_, r := newTestHandler(t, "")
+
+	csv := "address,label,category,tags\n" +
+		testSource + ",source,Exchange,hot;cold\n" +
+		"0x1234,broken,,\n" +
+		testTarget + ",other,,\n"
+
+	resp := postMultipart(t, r, "/api/source-addresses/import", nil, "addresses.csv", csv)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	var result ImportAddressesResponse
+	decodeBody(t, resp, &result)
+
+	if result.Type != "source" || result.Added != 2 || result.Invalid != 1 || result.Updated != 0 || result.Failed != 0 {
+		t.Fatalf("expected 2 added and 1 invalid source addresses, got %+v", result)
+	}
+
+	wantStatuses := []string{importStatusAdded, importStatusInvalid, importStatusAdded}
+	if len(result.Rows) != len(wantStatuses) || result.Rows[1].Error == "" {
+		t.Fatalf("expected the invalid row to be reported with an error, got %+v", result.Rows)
+	}
+
+	for i, row := range result.Rows {
+		if row.Line != i+2 || row.Status != wantStatuses[i] {
+			t.Fatalf("expected line %d to be %s, got %+v", i+2, wantStatuses[i], row)
+		}
+	}
+}
+
+func TestImportTokenListPrivateURL(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	// The list server listens on loopback, which imports must not reach
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no request to a loopback address")
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, listURL := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/", "file:///etc/passwd"} {
+		resp := postMultipart(t, r, "/api/tokens/import", map[string]string{"url": listURL}, "", "")
+
+		httputil.AssertCode(http.StatusBadRequest)(t, resp)
+		assertErrorCode(httputil.CodeInvalidRequest)(t, resp)
+	}
+}
+
+func TestImportTokenList(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h)
+
+	const (
+		newToken   = "0x00000000000000000000000000000000000000e1"
+		otherChain = "0x00000000000000000000000000000000000000e2"
+	)
+
+	// The catalogued token is renamed, the new token added, and the tokens of another chain and the
+	// invalid tokens skipped
+	list := `{"name": "Test", "tokens": [
+		{"chainId": 1, "address": "` + testToken + `", "symbol": "TKN2", "name": "Token", "decimals": 6},
+		{"chainId": 1, "address": "0x` + strings.ToUpper(newToken[2:]) + `", "symbol": "NEW", "name": "New", "decimals": 18},
+		{"chainId": 10, "address": "` + otherChain + `", "symbol": "OP", "name": "Other", "decimals": 18},
+		{"chainId": 1, "address": "0x1234", "symbol": "BAD", "name": "Bad", "decimals": 18}
+	]}`
+
+	want := []service.TokenListImport{
+		{ChainID: 1, Added: 1, Updated: 1, Skipped: 2},
+		// Importing the same list again changes nothing
+		{ChainID: 1, Unchanged: 2, Skipped: 2},
+	}
+
+	for _, want := range want {
+		resp := postMultipart(t, r, "/api/tokens/import", nil, "tokens.json", list)
+		httputil.AssertCode(http.StatusOK)(t, resp)
+
+		var result service.TokenListImport
+		decodeBody(t, resp, &result)
+
+		if result.ChainID != want.ChainID || result.Added != want.Added || result.Updated != want.Updated ||
+			result.Unchanged != want.Unchanged || result.Skipped != want.Skipped {
+			t.Fatalf("expected %+v, got %+v", want, result)
+		}
+
+		if len(result.Invalid) != 1 || result.Invalid[0].Index != 3 {
+			t.Fatalf("expected the invalid token to be reported, got %+v", result.Invalid)
+		}
+	}
+
+	tokens, err := h.store.GetTokens(t.Context())
+	if err != nil {
+		t.Fatalf("getting tokens: %v", err)
+	}
+
+	symbols := make(map[string]string, len(tokens))
+	for _, token := range tokens {
+		symbols[token.Address] = token.Symbol
+	}
+
+	if len(symbols) != 2 || symbols[testToken] != "TKN2" || symbols[newToken] != "NEW" {
+		t.Fatalf("expected only the tokens of the current chain to be stored, got %v", symbols)
+	}
+}
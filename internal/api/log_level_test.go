@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// assertLogLevel returns an AssertFn checking a 200 response reporting level.
+func assertLogLevel(level string) httputil.AssertFn {
+	return func(t *testing.T, resp *httptest.ResponseRecorder) {
+		t.Helper()
+		httputil.AssertCode(http.StatusOK)(t, resp)
+
+		var body LogLevelResponse
+		decodeBody(t, resp, &body)
+
+		if body.Level != level {
+			t.Fatalf("expected log level %s, got %s", level, body.Level)
+		}
+	}
+}
+
+func TestUpdateLogLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	core, logs := observer.New(level)
+	logger := zap.New(core).Sugar()
+
+	h := NewHandler(nil, nil, logger)
+	h.SetLogLevel(level)
+	r := newTestRouter(h)
+
+	// debugLogged reports whether a debug message is logged at the current level
+	debugLogged := func() bool {
+		logs.TakeAll()
+		logger.Debugw("Fetching page")
+
+		return logs.FilterMessage("Fetching page").Len() == 1
+	}
+
+	setLevel := func(level string) {
+		t.Helper()
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "set log level " + level,
+			Endpoint: "/api/config/log-level",
+			Method:   http.MethodPut,
+			Body:     []byte(`{"level":"` + level + `"}`),
+			Assert:   assertLogLevel(level),
+		}, r)
+	}
+
+	if debugLogged() {
+		t.Fatal("expected no debug logs at the info level")
+	}
+
+	setLevel("debug")
+
+	if !debugLogged() {
+		t.Fatal("expected debug logs after setting the debug level")
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "get log level",
+		Endpoint: "/api/config/log-level",
+		Method:   http.MethodGet,
+		Assert:   assertLogLevel("debug"),
+	}, r)
+
+	setLevel("info")
+
+	if debugLogged() {
+		t.Fatal("expected no debug logs after setting the info level back")
+	}
+
+	for _, invalid := range []string{`{"level":"verbose"}`, `{"level":"fatal"}`, `{}`} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "invalid log level " + invalid,
+			Endpoint: "/api/config/log-level",
+			Method:   http.MethodPut,
+			Body:     []byte(invalid),
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusBadRequest)(t, resp)
+			},
+		}, r)
+	}
+
+	if got := level.Level(); got != zapcore.InfoLevel {
+		t.Fatalf("expected invalid levels to keep the info level, got %s", got)
+	}
+}
+
+func TestLogLevelNotAdjustable(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      method + " log level without an adjustable level",
+			Endpoint: "/api/config/log-level",
+			Method:   method,
+			Body:     []byte(`{"level":"debug"}`),
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusNotFound)(t, resp)
+				assertErrorCode(httputil.CodeNotFound)(t, resp)
+			},
+		}, r)
+	}
+}
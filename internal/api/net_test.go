@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestGetNetAmountsInvalidTimeRange(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, param := range []string{"start_time", "end_time"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "invalid " + param,
+			Endpoint: "/api/transfers/net",
+			Method:   http.MethodGet,
+			Params:   map[string]string{param: "yesterday"},
+			Assert:   assertErrorCode(httputil.CodeInvalidTimeRange),
+		}, r)
+	}
+}
+
+func TestGetNetAmounts(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000")
+
+	// The target sends more than it received
+	if _, err := h.store.AddTransfersBatch(t.Context(), []*storage.Transfer{{
+		Hash:         "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber:  2000,
+		Timestamp:    testTime.Add(time.Hour),
+		FromAddress:  testTarget,
+		ToAddress:    "0x00000000000000000000000000000000000000d4",
+		TokenAddress: testToken,
+		Amount:       "3500000",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "net outflow",
+		Endpoint: "/api/transfers/net",
+		Method:   http.MethodGet,
+		Params:   testTimeRange(),
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body struct {
+				Amounts []storage.NetAmount `json:"amounts"`
+			}
+			decodeBody(t, resp, &body)
+
+			if len(body.Amounts) != 1 {
+				t.Fatalf("expected the net amount of the test token, got %+v", body.Amounts)
+			}
+
+			got := body.Amounts[0]
+			if got.NetAmount != "-2500000" || got.NormalizedNetAmount != "-2.5" {
+				t.Fatalf("expected a net amount of -2.5, got %+v", got)
+			}
+
+			if got.Inflow != "1000000" || got.Outflow != "3500000" {
+				t.Fatalf("expected an inflow of 1000000 and an outflow of 3500000, got %+v", got)
+			}
+		},
+	}, r)
+}
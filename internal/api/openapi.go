@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ductm54/transfer-track/internal/docs"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+//go:generate go tool swag init --generalInfo openapi.go --dir ./,../storage,../service,../httputil,../etherscan --output ../docs --outputTypes go,json,yaml
+
+// @title        Transfer Track API
+// @version      1.0
+// @description  Tracks ETH and ERC20 transfers from source addresses to target addresses via Etherscan.
+// @BasePath     /api
+
+// openAPIPath is the path of the raw OpenAPI document, relative to the API group.
+const openAPIPath = "/openapi.json"
+
+// registerDocsRoutes serves the OpenAPI document and a Swagger UI rendering it.
+func registerDocsRoutes(api *gin.RouterGroup) {
+	specURL := api.BasePath() + openAPIPath
+
+	api.GET(openAPIPath, getOpenAPI)
+	api.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, api.BasePath()+"/docs/index.html")
+	})
+	api.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL(specURL)))
+}
+
+// getOpenAPI handles the request to get the OpenAPI document of the API.
+func getOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(docs.SwaggerInfo.ReadDoc()))
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+)
+
+// pathParamRegexp matches the gin path parameters, e.g. ":id".
+var pathParamRegexp = regexp.MustCompile(`:(\w+)`)
+
+func TestGetOpenAPI(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	var spec struct {
+		Swagger  string                                `json:"swagger"`
+		BasePath string                                `json:"basePath"`
+		Paths    map[string]map[string]json.RawMessage `json:"paths"`
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "openapi.json",
+		Endpoint: "/api/openapi.json",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			if contentType := resp.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+				t.Fatalf("expected a JSON document, got %s", contentType)
+			}
+
+			decodeBody(t, resp, &spec)
+		},
+	}, r)
+
+	if spec.Swagger != "2.0" || spec.BasePath != "/api" {
+		t.Fatalf("expected a Swagger 2.0 document based at /api, got %q at %q", spec.Swagger, spec.BasePath)
+	}
+
+	// Every API route is documented, except the documentation itself
+	checked := 0
+
+	for _, route := range r.Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/api")
+		if !ok || path == openAPIPath || strings.HasPrefix(path, "/docs") {
+			continue
+		}
+
+		path = pathParamRegexp.ReplaceAllString(path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s %s is not documented", route.Method, route.Path)
+		}
+
+		checked++
+	}
+
+	if checked == 0 {
+		t.Fatal("expected API routes to check")
+	}
+}
+
+func TestDocsRedirect(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "docs",
+		Endpoint: "/api/docs",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			httputil.AssertCode(http.StatusMovedPermanently)(t, resp)
+
+			if location := resp.Header().Get("Location"); location != "/api/docs/index.html" {
+				t.Fatalf("expected a redirect to the Swagger UI, got %q", location)
+			}
+		},
+	}, r)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+// stubPriceProvider returns fixed prices and records the times it was asked for.
+type stubPriceProvider struct {
+	prices map[string]float64
+	times  []time.Time
+}
+
+func (p *stubPriceProvider) PriceAt(_ context.Context, tokenAddress string, t time.Time) (float64, error) {
+	p.times = append(p.times, t)
+
+	price, ok := p.prices[strings.ToLower(tokenAddress)]
+	if !ok {
+		return 0, errors.New("price not found")
+	}
+
+	return price, nil
+}
+
+func TestFillUSDValues(t *testing.T) {
+	const (
+		priced   = "0x00000000000000000000000000000000000000c3"
+		unpriced = "0x00000000000000000000000000000000000000c4"
+		unknown  = "0x00000000000000000000000000000000000000c5"
+	)
+
+	provider := &stubPriceProvider{prices: map[string]float64{priced: 2.5, unknown: 1}}
+	h := NewHandler(nil, nil, zap.NewNop().Sugar())
+	h.SetPriceProvider(provider)
+
+	amounts := []storage.TokenAmount{
+		{TokenAddress: priced, NormalizedAmount: "1.5"},
+		{TokenAddress: unpriced, NormalizedAmount: "3"},
+		// Tokens of unknown decimals have no normalized amount to value
+		{TokenAddress: unknown, TotalAmount: "100"},
+	}
+
+	future := time.Now().Add(24 * time.Hour)
+	h.fillUSDValues(context.Background(), amounts, future)
+
+	want := []string{"3.75", "", ""}
+	for i, amount := range amounts {
+		if amount.USDValue != want[i] {
+			t.Fatalf("token %s: expected USD value %q, got %q", amount.TokenAddress, want[i], amount.USDValue)
+		}
+	}
+
+	if len(provider.times) != 2 {
+		t.Fatalf("expected 2 price lookups, got %d", len(provider.times))
+	}
+
+	// Prices of the future are looked up at the current time instead
+	if provider.times[0].After(time.Now()) {
+		t.Fatalf("expected the price time to be clamped to now, got %s", provider.times[0])
+	}
+}
+
+func TestFillUSDValuesWithoutProvider(t *testing.T) {
+	h := NewHandler(nil, nil, zap.NewNop().Sugar())
+	amounts := []storage.TokenAmount{{TokenAddress: testToken, NormalizedAmount: "1"}}
+
+	h.fillUSDValues(context.Background(), amounts, time.Now())
+
+	if amounts[0].USDValue != "" {
+		t.Fatalf("expected no USD value without a price provider, got %q", amounts[0].USDValue)
+	}
+}
+
+func TestGetTotalAmountsUSDValue(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000", "500000")
+
+	provider := &stubPriceProvider{prices: map[string]float64{testToken: 2}}
+	h.SetPriceProvider(provider)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "usd value",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   testTimeRange(),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if len(body.Amounts) != 1 || body.Amounts[0].USDValue != "3.00" {
+				t.Fatalf("expected a USD value of 3.00, got %+v", body.Amounts)
+			}
+		}),
+	}, r)
+
+	// Valued at the end of the time range
+	wantTime := testTime.Add(24 * time.Hour)
+	if len(provider.times) != 1 || !provider.times[0].Equal(wantTime) {
+		t.Fatalf("expected the price at %s, got %v", wantTime, provider.times)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/server"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestReadOnlyRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	NewHandler(nil, nil, zap.NewNop().Sugar()).RegisterRoutes(r, server.ReadOnly(ReadOnlySafeRoutes()...))
+
+	rejected := []struct {
+		method   string
+		endpoint string
+	}{
+		{http.MethodDelete, "/api/transfers"},
+		{http.MethodPost, "/api/transfers/refresh"},
+		{http.MethodPost, "/api/source-addresses"},
+		{http.MethodPost, "/api/source-addresses/import"},
+		{http.MethodDelete, "/api/target-addresses/1"},
+		{http.MethodPut, "/api/tokens/1"},
+		{http.MethodPatch, "/api/tokens/1"},
+		{http.MethodPost, "/api/tokens/import"},
+		{http.MethodPut, "/api/config/refresh-interval"},
+		{http.MethodPut, "/api/config/log-level"},
+	}
+
+	for _, tt := range rejected {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.method + " " + tt.endpoint,
+			Endpoint: tt.endpoint,
+			Method:   tt.method,
+			Body:     []byte(`{}`),
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusMethodNotAllowed)(t, resp)
+				assertErrorCode(httputil.CodeReadOnly)(t, resp)
+			},
+		}, r)
+	}
+
+	// Safe routes and reads reach their handler: an invalid conversion is rejected by the handler and
+	// the log level endpoints are disabled without a level
+	allowed := []struct {
+		method   string
+		endpoint string
+		code     int
+	}{
+		{http.MethodPost, "/api/convert", http.StatusBadRequest},
+		{http.MethodGet, "/api/config/log-level", http.StatusNotFound},
+	}
+
+	for _, tt := range allowed {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.method + " " + tt.endpoint,
+			Endpoint: tt.endpoint,
+			Method:   tt.method,
+			Body:     []byte(`{`),
+			Assert:   httputil.AssertCode(tt.code),
+		}, r)
+	}
+}
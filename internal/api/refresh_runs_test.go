@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestGetLastRefreshRun(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := context.Background()
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "no refresh recorded",
+		Endpoint: "/api/transfers/refresh/last",
+		Method:   http.MethodGet,
+		Assert:   assertErrorCode(httputil.CodeNotFound),
+	}, r)
+
+	started := testTime
+	finished := started.Add(90 * time.Second)
+
+	id, err := h.store.StartRefreshRun(ctx, started)
+	if err != nil {
+		t.Fatalf("starting refresh run: %v", err)
+	}
+
+	if err := h.store.FinishRefreshRun(ctx, storage.RefreshRun{
+		ID:              id,
+		Status:          storage.RefreshRunFailed,
+		FinishedAt:      &finished,
+		SourceAddresses: 2,
+		Inserted:        5,
+		Error:           "fetching transfers: unavailable",
+		FailedAddresses: []string{testSource},
+	}); err != nil {
+		t.Fatalf("finishing refresh run: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "finished refresh",
+		Endpoint: "/api/transfers/refresh/last",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var run storage.RefreshRun
+			decodeBody(t, resp, &run)
+
+			if run.ID != id || run.Status != storage.RefreshRunFailed || run.Inserted != 5 ||
+				run.SourceAddresses != 2 || len(run.FailedAddresses) != 1 {
+				t.Fatalf("unexpected refresh run %+v", run)
+			}
+
+			if run.DurationSeconds == nil || *run.DurationSeconds != 90 {
+				t.Fatalf("expected a duration of 90 seconds, got %v", run.DurationSeconds)
+			}
+		},
+	}, r)
+}
+
+func TestGetRefreshHistoryInvalidLimit(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, limit := range []string{"0", "-1", "1001", "ten"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "limit " + limit,
+			Endpoint: "/api/transfers/refresh/history",
+			Method:   http.MethodGet,
+			Params:   map[string]string{"limit": limit},
+			Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+		}, r)
+	}
+}
+
+func TestGetRefreshHistory(t *testing.T) {
+	h, r := newTestHandler(t, "")
+
+	ids := make([]int64, 0, 3)
+
+	for i := range 3 {
+		id, err := h.store.StartRefreshRun(context.Background(), testTime.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatalf("starting refresh run: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		msg    string
+		params map[string]string
+		want   []int64
+	}{
+		{msg: "default limit", want: []int64{ids[2], ids[1], ids[0]}},
+		{msg: "limit", params: map[string]string{"limit": "2"}, want: []int64{ids[2], ids[1]}},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.msg,
+			Endpoint: "/api/transfers/refresh/history",
+			Method:   http.MethodGet,
+			Params:   tt.params,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				httputil.AssertCode(http.StatusOK)(t, resp)
+
+				var runs []storage.RefreshRun
+				decodeBody(t, resp, &runs)
+
+				got := make([]int64, 0, len(runs))
+				for _, run := range runs {
+					got = append(got, run.ID)
+				}
+
+				if !slices.Equal(got, tt.want) {
+					t.Fatalf("expected the runs %v, got %v", tt.want, got)
+				}
+			},
+		}, r)
+	}
+}
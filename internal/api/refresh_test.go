@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+// newEtherscanServer returns the URL of a test server answering every Etherscan request with handler.
+func newEtherscanServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+// writeNoTransactions writes Etherscan's response to a query matching no transactions.
+func writeNoTransactions(t *testing.T, w http.ResponseWriter) {
+	t.Helper()
+
+	err := json.NewEncoder(w).Encode(etherscan.Response{
+		Status:  "0",
+		Message: "No transactions found",
+		Result:  json.RawMessage(`[]`),
+	})
+	if err != nil {
+		t.Errorf("writing response: %v", err)
+	}
+}
+
+func TestRefreshAddressTransfersInvalidAddress(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid address",
+		Endpoint: "/api/transfers/refresh/0x1234",
+		Method:   http.MethodPost,
+		Assert:   httputil.AssertCode(http.StatusBadRequest),
+	}, r)
+}
+
+func TestRefreshAddressTransfers(t *testing.T) {
+	url := newEtherscanServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		writeNoTransactions(t, w)
+	})
+
+	h, r := newTestHandler(t, url)
+
+	if _, _, err := h.store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "tracked address",
+		Endpoint: "/api/transfers/refresh/" + testSource,
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body AddressRefreshResponse
+			decodeBody(t, resp, &body)
+
+			if body.Address != testSource || body.Inserted != 0 {
+				t.Fatalf("expected no transfers inserted for %s, got %+v", testSource, body)
+			}
+		},
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "untracked address",
+		Endpoint: "/api/transfers/refresh/" + testTarget,
+		Method:   http.MethodPost,
+		Assert:   httputil.AssertCode(http.StatusNotFound),
+	}, r)
+}
+
+func TestRefreshAddressTransfersEtherscanFailure(t *testing.T) {
+	url := newEtherscanServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	h, r := newTestHandler(t, url)
+
+	if _, _, err := h.store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "etherscan failure",
+		Endpoint: "/api/transfers/refresh/" + testSource,
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusBadGateway)(t, resp)
+			assertErrorCode(httputil.CodeUpstreamError)(t, resp)
+		},
+	}, r)
+}
+
+func TestRefreshTransfersIdempotencyKey(t *testing.T) {
+	var requests atomic.Int32
+
+	url := newEtherscanServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		writeNoTransactions(t, w)
+	})
+
+	h, r := newTestHandler(t, url)
+	ctx := context.Background()
+
+	if _, _, err := h.store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	// refresh posts a refresh with key and returns the response and its job
+	refresh := func(key string) (*httptest.ResponseRecorder, service.RefreshJob) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/transfers/refresh", nil)
+		req.Header.Set(idempotencyKeyHeader, key)
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		httputil.AssertCode(http.StatusAccepted)(t, resp)
+
+		var body RefreshStartedResponse
+		decodeBody(t, resp, &body)
+
+		return resp, body.Job
+	}
+
+	first, job := refresh("client-retry")
+	if first.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("expected the first request of a key not to be replayed")
+	}
+
+	if _, finished := h.transferService.WaitRefreshJob(ctx, job.ID); !finished {
+		t.Fatalf("expected job %s to finish", job.ID)
+	}
+
+	fetched := requests.Load()
+	if fetched == 0 {
+		t.Fatal("expected the refresh to fetch transfers")
+	}
+
+	// The retry gets the same, now finished, job without fetching again
+	repeated, repeatedJob := refresh("client-retry")
+	if repeated.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Fatalf("expected the %s header on the repeated request", idempotentReplayedHeader)
+	}
+
+	if repeatedJob.ID != job.ID || repeatedJob.Status != service.RefreshJobDone {
+		t.Fatalf("expected the finished job %s to be returned, got %+v", job.ID, repeatedJob)
+	}
+
+	if got := requests.Load(); got != fetched {
+		t.Fatalf("expected the repeated key not to fetch again, got %d more requests", got-fetched)
+	}
+
+	runs, err := h.store.ListRefreshRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("listing refresh runs: %v", err)
+	}
+
+	if len(runs) != 1 {
+		t.Fatalf("expected a single refresh run, got %d", len(runs))
+	}
+}
+
+func TestRefreshTransfersTooLongIdempotencyKey(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/transfers/refresh", nil)
+	req.Header.Set(idempotencyKeyHeader, strings.Repeat("k", maxIdempotencyKeyLength+1))
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	httputil.AssertCode(http.StatusBadRequest)(t, resp)
+	assertErrorCode(httputil.CodeInvalidParameter)(t, resp)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// MessageResponse is the response of endpoints that only report success.
+type MessageResponse struct {
+	Message string `json:"message" example:"Token deleted successfully"`
+}
+
+// TotalAmountsResponse is the response of GET /api/transfers.
+type TotalAmountsResponse struct {
+	// StartTime and EndTime are Unix timestamps, omitted when no time range is applied.
+	StartTime *int64 `json:"start_time,omitempty"`
+	EndTime   *int64 `json:"end_time,omitempty"`
+	// StartBlock and EndBlock are the block range, when given.
+	StartBlock int64                 `json:"start_block,omitempty"`
+	EndBlock   int64                 `json:"end_block,omitempty"`
+	Direction  storage.Direction     `json:"direction" swaggertype:"string" enums:"source_to_target,inflow,outflow,among_sources"`
+	Amounts    []storage.TokenAmount `json:"amounts"`
+	Meta       ResponseMeta          `json:"meta"`
+	// Warnings lists the missing configuration when no amounts are returned.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// TransfersSummaryResponse is the response of GET /api/transfers/summary.
+type TransfersSummaryResponse struct {
+	StartTime int64                    `json:"start_time"`
+	EndTime   int64                    `json:"end_time"`
+	Interval  storage.Interval         `json:"interval" swaggertype:"string" enums:"day,week,month"`
+	Buckets   []storage.BucketedAmount `json:"buckets"`
+}
+
+// NetAmountsResponse is the response of GET /api/transfers/net.
+type NetAmountsResponse struct {
+	StartTime int64               `json:"start_time"`
+	EndTime   int64               `json:"end_time"`
+	Amounts   []storage.NetAmount `json:"amounts"`
+}
+
+// StreamedTransfer is a newly stored transfer pushed by GET /api/transfers/stream.
+type StreamedTransfer struct {
+	Hash         string    `json:"hash"`
+	BlockNumber  int64     `json:"block_number"`
+	Timestamp    time.Time `json:"timestamp"`
+	FromAddress  string    `json:"from_address"`
+	ToAddress    string    `json:"to_address"`
+	TokenAddress string    `json:"token_address"`
+	Amount       string    `json:"amount"`
+	Type         string    `json:"type" enums:"normal,internal"`
+}
+
+// TransferStreamMessage is a WebSocket message of GET /api/transfers/stream, sent for each stored batch
+// of transfers of which some match the filter of the connection.
+type TransferStreamMessage struct {
+	Transfers []StreamedTransfer `json:"transfers"`
+}
+
+// DeleteTransfersResponse is the response of DELETE /api/transfers.
+type DeleteTransfersResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// RefreshStartedResponse is the response of POST /api/transfers/refresh.
+type RefreshStartedResponse struct {
+	Message string             `json:"message" example:"Refresh started"`
+	Job     service.RefreshJob `json:"job"`
+}
+
+// AddressRefreshResponse is the response of POST /api/transfers/refresh/:address.
+type AddressRefreshResponse struct {
+	Message  string                      `json:"message" example:"Transfers refreshed successfully"`
+	Address  string                      `json:"address"`
+	Inserted int                         `json:"inserted"`
+	Tokens   []service.TokenFetchSummary `json:"tokens"`
+}
+
+// AddAddressesResponse is the response of adding source or target addresses.
+type AddAddressesResponse struct {
+	// Addresses are the added or updated addresses.
+	Addresses []any `json:"addresses"`
+	// Duplicates are the addresses that were requested more than once.
+	Duplicates []string `json:"duplicates"`
+	// Existing are the addresses that were already tracked and had their label updated.
+	Existing []string `json:"existing"`
+}
+
+// ImportAddressesResponse is the response of importing addresses from a CSV file.
+type ImportAddressesResponse struct {
+	// Type is the type of the imported addresses, source or target.
+	Type string `json:"type" enums:"source,target"`
+	// Added, Updated, Invalid and Failed count the rows of each status.
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Invalid int `json:"invalid"`
+	Failed  int `json:"failed"`
+	// Rows is the outcome of each row with an address, in file order.
+	Rows []ImportAddressRow `json:"rows"`
+}
+
+// ImportAddressRow is the outcome of importing a row of a CSV file.
+type ImportAddressRow struct {
+	// Line is the line of the row in the file.
+	Line    int    `json:"line"`
+	Address string `json:"address"`
+	// Status is added for new addresses, updated for addresses that were already stored, invalid for
+	// malformed addresses and failed if the address could not be stored.
+	Status string `json:"status" enums:"added,updated,invalid,failed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DeleteAddressesResponse is the response of deleting source or target addresses in bulk.
+type DeleteAddressesResponse struct {
+	Deleted           int      `json:"deleted"`
+	NotFoundIDs       []int64  `json:"not_found_ids"`
+	NotFoundAddresses []string `json:"not_found_addresses"`
+}
+
+// ConfigResponse is the response of GET /api/config.
+type ConfigResponse struct {
+	MinRefreshIntervalHours int    `json:"min_refresh_interval_hours"`
+	DailyRefreshTime        string `json:"daily_refresh_time" example:"00:00:00"`
+	RefreshCron             string `json:"refresh_cron"`
+	// Config has every stored config value by key, with secret values redacted.
+	Config map[string]string `json:"config"`
+}
+
+// StatsResponse is the response of GET /api/stats.
+type StatsResponse struct {
+	storage.Stats
+	MinRefreshIntervalHours int    `json:"min_refresh_interval_hours"`
+	DailyRefreshTime        string `json:"daily_refresh_time" example:"00:00:00"`
+}
+
+// TokenMetadataRefreshResponse is the response of POST /api/tokens/refresh-metadata.
+type TokenMetadataRefreshResponse struct {
+	// Changed is the number of tokens of which any field was updated.
+	Changed int `json:"changed"`
+	// Failed is the number of tokens of which the metadata could not be looked up.
+	Failed int                            `json:"failed"`
+	Tokens []service.TokenMetadataRefresh `json:"tokens"`
+}
+
+// ChainsResponse is the response of GET /api/chains.
+type ChainsResponse struct {
+	// ChainID is the chain transfers are fetched from.
+	ChainID int               `json:"chain_id" example:"1"`
+	Chains  []etherscan.Chain `json:"chains"`
+}
+
+// ConvertedAmount is an amount in Wei normalized with the decimals of its token.
+type ConvertedAmount struct {
+	Amount           string `json:"amount" example:"1500000000000000000"`
+	Decimals         int    `json:"decimals" example:"18"`
+	NormalizedAmount string `json:"normalized_amount" example:"1.5"`
+}
+
+// ConvertResponse is the response of POST /api/convert, in the order of the request.
+type ConvertResponse struct {
+	Amounts []ConvertedAmount `json:"amounts"`
+}
+
+// LogLevelResponse is the response of the log level endpoints.
+type LogLevelResponse struct {
+	Level string `json:"level" example:"info"`
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestGetStats(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1", "2")
+
+	ethUpdate := testTime.Add(48 * time.Hour)
+	if err := h.store.UpdateConfig(context.Background(), "last_eth_update", ethUpdate.Format(time.RFC3339)); err != nil {
+		t.Fatalf("setting last ETH update: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "stats",
+		Endpoint: "/api/stats",
+		Method:   http.MethodGet,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var stats StatsResponse
+			decodeBody(t, resp, &stats)
+
+			want := storage.TableCounts{SourceAddresses: 1, TargetAddresses: 1, Tokens: 2, Transfers: 2}
+			if stats.TableCounts != want {
+				t.Errorf("table counts = %+v, want %+v", stats.TableCounts, want)
+			}
+
+			if stats.LastETHUpdate == nil || !stats.LastETHUpdate.Equal(ethUpdate) {
+				t.Errorf("last ETH update = %v, want %v", stats.LastETHUpdate, ethUpdate)
+			}
+
+			if stats.MinRefreshIntervalHours != 1 || stats.DailyRefreshTime != "00:00:00" {
+				t.Errorf("refresh config = %d hours, daily at %q, want the defaults",
+					stats.MinRefreshIntervalHours, stats.DailyRefreshTime)
+			}
+		},
+	}, r)
+}
+
+func TestGetStatsDatabaseUnavailable(t *testing.T) {
+	_, r := newUnreachableDBHandler(t)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "database unavailable",
+		Endpoint: "/api/stats",
+		Method:   http.MethodGet,
+		Assert:   httputil.AssertCode(http.StatusServiceUnavailable),
+	}, r)
+}
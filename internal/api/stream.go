@@ -0,0 +1,214 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/internal/stream"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamWriteTimeout bounds the time to send a message to a stream client.
+	streamWriteTimeout = 10 * time.Second
+	// streamPingInterval is how often stream clients are pinged to detect dead connections.
+	streamPingInterval = 30 * time.Second
+	// streamPongTimeout is how long a stream client may take to answer a ping.
+	streamPongTimeout = streamPingInterval + streamWriteTimeout
+	// refreshEventsKeepAlive is how often a comment is sent on an idle refresh event stream, so that
+	// proxies keep the connection open.
+	refreshEventsKeepAlive = 15 * time.Second
+	// streamReadLimit bounds the size of messages read from stream clients, which are not expected to send any.
+	streamReadLimit = 512
+)
+
+// streamUpgrader upgrades transfer stream requests to WebSocket connections. Any origin is accepted:
+// the stream exposes the same data as the other endpoints, and the CORS middleware rejects disallowed
+// origins when configured.
+var streamUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// StreamTransfers handles the request to stream newly stored transfers over a WebSocket.
+//
+// @Summary      Stream newly stored transfers over a WebSocket
+// @Description  Upgrades to a WebSocket that receives a TransferStreamMessage for each stored batch of
+// @Description  transfers matching the filters. Clients that fall behind are disconnected with close code 1013.
+// @Tags         transfers
+// @Param        token_address query []string false "Only stream transfers of these tokens" collectionFormat(multi)
+// @Param        address query []string false "Only stream transfers from or to these addresses" collectionFormat(multi)
+// @Success      101 {object} TransferStreamMessage
+// @Failure      400 {object} httputil.CommonError
+// @Router       /transfers/stream [get]
+func (h *Handler) StreamTransfers(c *gin.Context) {
+	filter, errResp := parseStreamFilter(c)
+	if errResp != nil {
+		httputil.RespondCommonError(c, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	// Subscribe before the handshake completes, so the client receives every transfer stored after it
+	sub := h.transferService.SubscribeTransfers(filter)
+	defer h.transferService.UnsubscribeTransfers(sub)
+
+	// Upgrade responds with an error itself if the request is not a valid WebSocket handshake
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Debugw("Failed to upgrade transfer stream", "err", err)
+		return
+	}
+
+	defer conn.Close()
+
+	gone := make(chan struct{})
+	go readStream(conn, gone)
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		case transfers, ok := <-sub.Transfers():
+			if !ok {
+				closeStream(conn, sub)
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+			if err := conn.WriteJSON(newTransferStreamMessage(transfers)); err != nil {
+				h.logger.Debugw("Failed to write to transfer stream", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// StreamRefreshEvents handles the request to stream the progress of refreshes as Server-Sent Events.
+//
+// @Summary      Stream refresh progress as Server-Sent Events
+// @Description  Streams a text/event-stream of the progress of refreshes from now on. The name of each event
+// @Description  is its RefreshProgress event, and its data the RefreshProgress as JSON. The stream ends when
+// @Description  the server shuts down.
+// @Tags         transfers
+// @Produce      text/event-stream
+// @Success      200 {object} service.RefreshProgress
+// @Router       /transfers/refresh/events [get]
+func (h *Handler) StreamRefreshEvents(c *gin.Context) {
+	events, unsubscribe := h.transferService.SubscribeRefreshProgress()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Keep reverse proxies such as nginx from buffering the events
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(refreshEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx := c.Request.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The client is gone
+			return
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				// The server is shutting down
+				return
+			}
+
+			c.SSEvent(string(event.Event), event)
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+// parseStreamFilter parses the token_address and address query parameters of the transfer stream.
+// It returns a non-nil error response if an address is invalid.
+func parseStreamFilter(c *gin.Context) (stream.Filter, *httputil.CommonError) {
+	addressParams := make(map[string][]string, 2)
+
+	for _, param := range []string{"token_address", "address"} {
+		addresses := c.QueryArray(param)
+		for _, address := range addresses {
+			if !service.IsValidAddress(address) {
+				return stream.Filter{}, &httputil.CommonError{
+					Code:  httputil.CodeInvalidAddress,
+					Error: fmt.Sprintf("Invalid %s %q, expected 0x followed by 40 hex characters", param, address),
+				}
+			}
+		}
+
+		addressParams[param] = addresses
+	}
+
+	return stream.NewFilter(addressParams["token_address"], addressParams["address"]), nil
+}
+
+// readStream reads from a stream client until the connection fails or the client stops answering
+// pings, then closes gone. Messages sent by the client are discarded.
+func readStream(conn *websocket.Conn, gone chan<- struct{}) {
+	defer close(gone)
+
+	conn.SetReadLimit(streamReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+	})
+
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// closeStream tells a stream client why its subscription ended: it fell too far behind and may
+// reconnect, or the server is shutting down.
+func closeStream(conn *websocket.Conn, sub *stream.Subscription) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "Server shutting down")
+	if sub.Dropped() {
+		msg = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Client too slow, reconnect")
+	}
+
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(streamWriteTimeout))
+}
+
+func newTransferStreamMessage(transfers []*storage.Transfer) TransferStreamMessage {
+	msg := TransferStreamMessage{Transfers: make([]StreamedTransfer, 0, len(transfers))}
+	for _, transfer := range transfers {
+		msg.Transfers = append(msg.Transfers, StreamedTransfer{
+			Hash:         transfer.Hash,
+			BlockNumber:  transfer.BlockNumber,
+			Timestamp:    transfer.Timestamp,
+			FromAddress:  transfer.FromAddress,
+			ToAddress:    transfer.ToAddress,
+			TokenAddress: transfer.TokenAddress,
+			Amount:       transfer.Amount,
+			Type:         transfer.Type,
+		})
+	}
+
+	return msg
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// dialStream opens a transfer stream of the router served by a test server, with the given query.
+func dialStream(t *testing.T, r http.Handler, query string) *websocket.Conn {
+	t.Helper()
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/transfers/stream?" + query
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dialing transfer stream: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func TestStreamTransfersInvalidFilter(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid address",
+		Endpoint: "/api/transfers/stream",
+		Method:   http.MethodGet,
+		Params:   map[string]string{"address": "0x1234"},
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusBadRequest)(t, resp)
+			assertErrorCode(httputil.CodeInvalidAddress)(t, resp)
+		},
+	}, r)
+}
+
+func TestStreamTransfers(t *testing.T) {
+	const hash = "0x00000000000000000000000000000000000000000000000000000000000000aa"
+
+	url := newEtherscanServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "txlist" {
+			writeNoTransactions(t, w)
+			return
+		}
+
+		err := json.NewEncoder(w).Encode(etherscan.Response{
+			Status:  "1",
+			Message: "OK",
+			Result: mustMarshal(t, []etherscan.ETHTransaction{{
+				BlockNumber: "100",
+				TimeStamp:   strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10),
+				Hash:        hash,
+				From:        testSource,
+				To:          testTarget,
+				Value:       "1000",
+				IsError:     "0",
+			}}),
+		})
+		if err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	})
+
+	h, r := newTestHandler(t, url)
+
+	if _, _, err := h.store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	// The stream is subscribed once the handshake completes
+	conn := dialStream(t, r, "address="+testTarget)
+
+	if _, err := h.transferService.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("fetching transfers: %v", err)
+	}
+
+	var msg TransferStreamMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading stream message: %v", err)
+	}
+
+	if len(msg.Transfers) != 1 || msg.Transfers[0].Hash != hash || msg.Transfers[0].ToAddress != testTarget {
+		t.Fatalf("expected the fetched transfer, got %+v", msg.Transfers)
+	}
+
+	// Shutting down closes the stream with going away
+	h.transferService.CloseTransferStream()
+
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("expected the stream to be closed with code %d, got %v", websocket.CloseGoingAway, err)
+	}
+}
+
+func TestStreamRefreshEventsEndsOnClose(t *testing.T) {
+	// The event stream does not use the database
+	unreachable, _ := newUnreachableDBHandler(t)
+	logger := zap.NewNop().Sugar()
+
+	transferService, err := service.NewTransferService(unreachable.store, logger, etherscan.Config{APIKey: "test", ChainID: 1})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	srv := httptest.NewServer(newTestRouter(NewHandler(transferService, unreachable.store, logger)))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/transfers/refresh/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("requesting refresh events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Shutting down ends the stream, without the client going away
+	transferService.CloseRefreshProgress()
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("expected the stream to end, got %v", err)
+	}
+}
+
+// mustMarshal returns v encoded as JSON.
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func TestStreamRefreshEvents(t *testing.T) {
+	url := newEtherscanServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		writeNoTransactions(t, w)
+	})
+
+	h, r := newTestHandler(t, url)
+
+	if _, _, err := h.store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/transfers/refresh/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("requesting refresh events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The stream is subscribed once the headers are sent
+	if _, err := h.transferService.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("refreshing: %v", err)
+	}
+
+	var events []string
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		event, ok := strings.CutPrefix(scanner.Text(), "event:")
+		if !ok {
+			continue
+		}
+
+		events = append(events, event)
+		if event == string(service.RefreshProgressFinished) {
+			break
+		}
+	}
+
+	if len(events) == 0 || events[0] != string(service.RefreshProgressStarted) ||
+		events[len(events)-1] != string(service.RefreshProgressFinished) {
+		t.Fatalf("expected the events of a refresh from start to finish, got %v (%v)", events, scanner.Err())
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/service"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Tokens of the metadata tests: Etherscan reports other decimals for testToken, the same metadata
+// for unchangedToken and does not know unknownToken.
+const (
+	unchangedToken = "0x00000000000000000000000000000000000000c4"
+	unknownToken   = "0x00000000000000000000000000000000000000c5"
+)
+
+// newTokenMetadataHandler returns a handler of a database with the tokens of the metadata tests and an
+// Etherscan stub answering their token info lookups.
+func newTokenMetadataHandler(t *testing.T) (*Handler, http.Handler, map[string]*storage.Token) {
+	t.Helper()
+
+	onEtherscan := map[string]etherscan.ERC20Transaction{
+		testToken:      {TokenSymbol: "USDC", TokenName: "USD Coin", TokenDecimal: "6", ContractAddress: testToken},
+		unchangedToken: {TokenSymbol: "DAI", TokenName: "Dai", TokenDecimal: "18", ContractAddress: unchangedToken},
+	}
+
+	url := newEtherscanServer(t, func(w http.ResponseWriter, r *http.Request) {
+		tx, ok := onEtherscan[strings.ToLower(r.URL.Query().Get("contractaddress"))]
+		if !ok {
+			writeNoTransactions(t, w)
+			return
+		}
+
+		raw, _ := json.Marshal([]etherscan.ERC20Transaction{tx})
+		if err := json.NewEncoder(w).Encode(etherscan.Response{Status: "1", Message: "OK", Result: raw}); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	})
+
+	h, r := newTestHandler(t, url)
+	tokens := make(map[string]*storage.Token)
+
+	for _, token := range []struct {
+		address, symbol, name string
+		decimals              int
+	}{
+		{address: testToken, symbol: "USDC", name: "USD Coin", decimals: 18},
+		{address: unchangedToken, symbol: "DAI", name: "Dai", decimals: 18},
+		{address: unknownToken, symbol: "UNK", name: "Unknown", decimals: 9},
+	} {
+		added, err := h.store.AddToken(context.Background(), token.address, token.symbol, token.name, token.decimals)
+		if err != nil {
+			t.Fatalf("adding token: %v", err)
+		}
+
+		tokens[token.address] = added
+	}
+
+	return h, r, tokens
+}
+
+func TestRefreshTokenMetadata(t *testing.T) {
+	h, r, tokens := newTokenMetadataHandler(t)
+
+	refreshEndpoint := func(address string) string {
+		return "/api/tokens/" + strconv.FormatInt(tokens[address].ID, 10) + "/refresh-metadata"
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "decimals differing from Etherscan are updated",
+		Endpoint: refreshEndpoint(testToken),
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body service.TokenMetadataRefresh
+			decodeBody(t, resp, &body)
+
+			if !slices.Equal(body.Changed, []string{"decimals"}) || body.Token.Decimals != 6 {
+				t.Fatalf("expected the decimals to change to 6, got %+v", body)
+			}
+		},
+	}, r)
+
+	token, err := h.store.GetTokenByID(context.Background(), tokens[testToken].ID)
+	if err != nil {
+		t.Fatalf("getting token: %v", err)
+	}
+
+	if token.Decimals != 6 || token.Symbol != "USDC" || token.Name != "USD Coin" {
+		t.Fatalf("expected the stored token to have 6 decimals and the same symbol and name, got %+v", token)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "metadata matching Etherscan is left unchanged",
+		Endpoint: refreshEndpoint(unchangedToken),
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body service.TokenMetadataRefresh
+			decodeBody(t, resp, &body)
+
+			if len(body.Changed) != 0 || body.Token.Decimals != 18 {
+				t.Fatalf("expected no change, got %+v", body)
+			}
+		},
+	}, r)
+
+	for _, tc := range []struct {
+		msg      string
+		endpoint string
+		status   int
+		code     httputil.ErrorCode
+	}{
+		{
+			msg:      "token unknown to Etherscan",
+			endpoint: refreshEndpoint(unknownToken),
+			status:   http.StatusNotFound,
+			code:     httputil.CodeNotFound,
+		},
+		{
+			msg:      "missing token",
+			endpoint: "/api/tokens/999999/refresh-metadata",
+			status:   http.StatusNotFound,
+			code:     httputil.CodeNotFound,
+		},
+	} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tc.msg,
+			Endpoint: tc.endpoint,
+			Method:   http.MethodPost,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(tc.status)(t, resp)
+				assertErrorCode(tc.code)(t, resp)
+			},
+		}, r)
+	}
+}
+
+func TestRefreshAllTokenMetadata(t *testing.T) {
+	_, r, _ := newTokenMetadataHandler(t)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "refresh the metadata of all tokens",
+		Endpoint: "/api/tokens/refresh-metadata",
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body TokenMetadataRefreshResponse
+			decodeBody(t, resp, &body)
+
+			if body.Changed != 1 {
+				t.Fatalf("expected 1 changed token, got %d", body.Changed)
+			}
+
+			refreshes := make(map[string]service.TokenMetadataRefresh)
+			for _, refresh := range body.Tokens {
+				refreshes[refresh.Token.Address] = refresh
+			}
+
+			if changed := refreshes[testToken]; !slices.Equal(changed.Changed, []string{"decimals"}) ||
+				changed.Token.Decimals != 6 {
+				t.Fatalf("expected the decimals of %s to change to 6, got %+v", testToken, changed)
+			}
+
+			if unchanged := refreshes[unchangedToken]; len(unchanged.Changed) != 0 || unchanged.Error != "" {
+				t.Fatalf("expected %s to be unchanged, got %+v", unchangedToken, unchanged)
+			}
+
+			// Tokens that cannot be looked up are reported without failing the others
+			if failed := refreshes[unknownToken]; failed.Error == "" || failed.Token.Decimals != 9 {
+				t.Fatalf("expected the lookup of %s to fail and leave it unchanged, got %+v", unknownToken, failed)
+			}
+
+			if body.Failed == 0 {
+				t.Fatal("expected the failed lookups to be counted")
+			}
+		},
+	}, r)
+}
+
+func TestRefreshTokenMetadataInvalidID(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid token ID",
+		Endpoint: "/api/tokens/abc/refresh-metadata",
+		Method:   http.MethodPost,
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusBadRequest)(t, resp)
+			assertErrorCode(httputil.CodeInvalidID)(t, resp)
+		},
+	}, r)
+}
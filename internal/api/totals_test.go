@@ -0,0 +1,484 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+// withParams returns the test time range with params added.
+func withParams(params map[string]string) map[string]string {
+	merged := testTimeRange()
+	for key, value := range params {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// assertTotals returns an AssertFn checking a 200 response whose amounts have check applied.
+func assertTotals(check func(t *testing.T, body TotalAmountsResponse)) httputil.AssertFn {
+	return func(t *testing.T, resp *httptest.ResponseRecorder) {
+		t.Helper()
+		httputil.AssertCode(http.StatusOK)(t, resp)
+
+		var body TotalAmountsResponse
+		decodeBody(t, resp, &body)
+		check(t, body)
+	}
+}
+
+func TestGetTotalAmountsInvalidDirection(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid direction",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"direction": "sideways"}),
+		Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+	}, r)
+}
+
+func TestGetTotalAmountsDirection(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000")
+
+	// Only inflow counts transfers to the target from untracked senders
+	if _, err := h.store.AddTransfersBatch(t.Context(), []*storage.Transfer{{
+		Hash:         "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber:  2000,
+		Timestamp:    testTime,
+		FromAddress:  "0x00000000000000000000000000000000000000d4",
+		ToAddress:    testTarget,
+		TokenAddress: testToken,
+		Amount:       "500000",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	tests := []struct {
+		direction storage.Direction
+		want      string
+	}{
+		{direction: storage.DirectionSourceToTarget, want: "1"},
+		{direction: storage.DirectionInflow, want: "1.5"},
+		{direction: storage.DirectionOutflow, want: "1"},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      string(tt.direction),
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   withParams(map[string]string{"direction": string(tt.direction)}),
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				if body.Direction != tt.direction {
+					t.Fatalf("expected direction %s, got %s", tt.direction, body.Direction)
+				}
+
+				if len(body.Amounts) != 1 || body.Amounts[0].NormalizedAmount != tt.want {
+					t.Fatalf("expected a total of %s, got %+v", tt.want, body.Amounts)
+				}
+			}),
+		}, r)
+	}
+}
+
+func TestGetTotalAmountsAmongSources(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	ctx := t.Context()
+
+	// A single set of addresses tracked as sources, without any target
+	const otherSource = "0x00000000000000000000000000000000000000a2"
+
+	if _, err := h.store.AddToken(ctx, testToken, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	for _, address := range []string{testSource, otherSource} {
+		if _, _, err := h.store.AddSourceAddress(ctx, address, storage.AddressLabels{}); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	transfers := []struct {
+		from, to, amount string
+	}{
+		{testSource, otherSource, "1000000"},
+		{otherSource, testSource, "500000"},
+		// Leaves the set
+		{testSource, "0x00000000000000000000000000000000000000d4", "2000000"},
+	}
+
+	batch := make([]*storage.Transfer, 0, len(transfers))
+	for i, tr := range transfers {
+		batch = append(batch, &storage.Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    testTime.Add(time.Duration(i) * time.Hour),
+			FromAddress:  tr.from,
+			ToAddress:    tr.to,
+			TokenAddress: testToken,
+			Amount:       tr.amount,
+		})
+	}
+
+	if _, err := h.store.AddTransfersBatch(ctx, batch); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "among sources",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"direction": string(storage.DirectionAmongSources)}),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if body.Direction != storage.DirectionAmongSources || len(body.Warnings) != 0 {
+				t.Fatalf("expected the among_sources direction without warnings, got %+v", body)
+			}
+
+			if len(body.Amounts) != 1 || body.Amounts[0].NormalizedAmount != "1.5" {
+				t.Fatalf("expected a total of 1.5 within the set, got %+v", body.Amounts)
+			}
+		}),
+	}, r)
+}
+
+func TestNormalizeAmounts(t *testing.T) {
+	six, eighteen := 6, 18
+
+	amounts := []storage.TokenAmount{
+		{TokenAddress: testToken, TotalAmount: "1500000", Decimals: &six},
+		{TokenAddress: testSource, TotalAmount: "123456789012345678901234567890", Decimals: &eighteen},
+		// Unknown decimals are left unnormalized
+		{TokenAddress: testTarget, TotalAmount: "7"},
+	}
+
+	if err := normalizeAmounts(amounts); err != nil {
+		t.Fatalf("normalizing amounts: %v", err)
+	}
+
+	want := []string{"1.5", "123456789012.34567890123456789", ""}
+	for i, amount := range amounts {
+		if amount.NormalizedAmount != want[i] {
+			t.Fatalf("token %s: expected %q, got %q", amount.TokenAddress, want[i], amount.NormalizedAmount)
+		}
+	}
+}
+
+func TestNormalizeAmountsKeepsQueryNormalized(t *testing.T) {
+	decimals := 6
+	amounts := []storage.TokenAmount{{TokenAddress: testToken, Decimals: &decimals, NormalizedAmount: "1.5"}}
+
+	if err := normalizeAmounts(amounts); err != nil {
+		t.Fatalf("normalizing amounts: %v", err)
+	}
+
+	if amounts[0].NormalizedAmount != "1.5" {
+		t.Fatalf("expected the amount normalized by the query to be kept, got %q", amounts[0].NormalizedAmount)
+	}
+}
+
+func TestGetTotalAmountsInvalidNormalizedOnly(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid normalized_only",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"normalized_only": "maybe"}),
+		Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+	}, r)
+}
+
+func TestGetTotalAmountsNormalizedOnly(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000", "500000")
+
+	// The amounts normalized in SQL match the ones normalized in Go
+	tests := []struct {
+		normalizedOnly string
+		wantTotal      string
+	}{
+		{normalizedOnly: "false", wantTotal: "1500000"},
+		{normalizedOnly: "true", wantTotal: ""},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "normalized_only " + tt.normalizedOnly,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   withParams(map[string]string{"normalized_only": tt.normalizedOnly}),
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				if len(body.Amounts) != 1 {
+					t.Fatalf("expected 1 amount, got %+v", body.Amounts)
+				}
+
+				amount := body.Amounts[0]
+				if amount.NormalizedAmount != "1.5" || amount.TotalAmount != tt.wantTotal {
+					t.Fatalf("expected a normalized total of 1.5 and a raw total of %q, got %+v", tt.wantTotal, amount)
+				}
+			}),
+		}, r)
+	}
+}
+
+func TestNormalizeAmountsInvalidAmount(t *testing.T) {
+	decimals := 6
+	amounts := []storage.TokenAmount{{TokenAddress: testToken, TotalAmount: "not a number", Decimals: &decimals}}
+
+	if err := normalizeAmounts(amounts); err == nil {
+		t.Fatal("expected an unparseable amount to fail instead of normalizing to 0")
+	}
+}
+
+func TestGetTransfersSummaryInvalidInterval(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid interval",
+		Endpoint: "/api/transfers/summary",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"interval": "year"}),
+		Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+	}, r)
+}
+
+func TestGetTransfersSummary(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000", "500000")
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "daily buckets",
+		Endpoint: "/api/transfers/summary",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"interval": "day"}),
+		Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+			t.Helper()
+			httputil.AssertCode(http.StatusOK)(t, resp)
+
+			var body TransfersSummaryResponse
+			decodeBody(t, resp, &body)
+
+			// Both transfers are on the first day
+			if body.Interval != storage.IntervalDay || len(body.Buckets) != 1 {
+				t.Fatalf("expected a single daily bucket, got %+v", body)
+			}
+
+			bucket := body.Buckets[0]
+			if !bucket.BucketStart.Equal(testTime) || bucket.NormalizedAmount != "1.5" {
+				t.Fatalf("expected 1.5 on %s, got %+v", testTime, bucket)
+			}
+		},
+	}, r)
+}
+
+func TestGetTotalAmountsBlockRange(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	// Blocks 1000 to 1002, one hour apart from testTime
+	seedTransfers(t, h, "1000000", "2000000", "4000000")
+
+	tests := []struct {
+		msg    string
+		params map[string]string
+		want   string
+	}{
+		{
+			msg:    "block only",
+			params: map[string]string{"start_block": "1001", "auto_refresh": "false"},
+			want:   "6",
+		},
+		{
+			msg: "time only",
+			params: withParams(map[string]string{
+				"start_time": fmt.Sprint(testTime.Add(time.Hour).Unix()),
+			}),
+			want: "6",
+		},
+		{
+			msg: "block and time",
+			params: withParams(map[string]string{
+				"start_time":  fmt.Sprint(testTime.Add(time.Hour).Unix()),
+				"start_block": "1000",
+				"end_block":   "1001",
+			}),
+			want: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.msg,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   tt.params,
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				if len(body.Amounts) != 1 || body.Amounts[0].NormalizedAmount != tt.want {
+					t.Fatalf("expected a total of %s, got %+v", tt.want, body.Amounts)
+				}
+			}),
+		}, r)
+	}
+}
+
+func TestGetTotalAmountsInvalidBlockRange(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for msg, params := range map[string]map[string]string{
+		"negative block": {"start_block": "-1"},
+		"not a number":   {"end_block": "latest"},
+		"reversed range": {"start_block": "20", "end_block": "10"},
+	} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      msg,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   params,
+			Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+		}, r)
+	}
+}
+
+func TestGetTotalAmountsInvalidAddressFilter(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, param := range []string{"token_address", "from_address", "to_address"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "invalid " + param,
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   withParams(map[string]string{param: "0x1234"}),
+			Assert:   assertErrorCode(httputil.CodeInvalidAddress),
+		}, r)
+	}
+}
+
+func TestGetTotalAmountsRecipientFilter(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000")
+
+	const otherTarget = "0x00000000000000000000000000000000000000b3"
+
+	if _, _, err := h.store.AddTargetAddress(t.Context(), otherTarget, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	if _, err := h.store.AddTransfersBatch(t.Context(), []*storage.Transfer{{
+		Hash:         "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber:  2000,
+		Timestamp:    testTime,
+		FromAddress:  testSource,
+		ToAddress:    otherTarget,
+		TokenAddress: testToken,
+		Amount:       "500000",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	tests := []struct {
+		msg      string
+		endpoint string
+		want     string
+	}{
+		{msg: "every recipient", endpoint: "/api/transfers", want: "1.5"},
+		{msg: "one recipient", endpoint: "/api/transfers?to_address=" + otherTarget, want: "0.5"},
+		{
+			msg:      "repeated recipients",
+			endpoint: "/api/transfers?to_address=" + otherTarget + "&to_address=" + testTarget,
+			want:     "1.5",
+		},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      tt.msg,
+			Endpoint: tt.endpoint,
+			Method:   http.MethodGet,
+			Params:   testTimeRange(),
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				if len(body.Amounts) != 1 || body.Amounts[0].NormalizedAmount != tt.want {
+					t.Fatalf("expected a total of %s, got %+v", tt.want, body.Amounts)
+				}
+			}),
+		}, r)
+	}
+}
+
+func TestGetTotalAmountsIncludeUnknown(t *testing.T) {
+	h, r := newTestHandler(t, "")
+	seedTransfers(t, h, "1000000")
+
+	const unknownToken = "0x00000000000000000000000000000000000000c9"
+
+	if _, err := h.store.AddTransfersBatch(t.Context(), []*storage.Transfer{{
+		Hash:         "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber:  2000,
+		Timestamp:    testTime,
+		FromAddress:  testSource,
+		ToAddress:    testTarget,
+		TokenAddress: unknownToken,
+		Amount:       "7",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "invalid include_unknown",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"include_unknown": "maybe"}),
+		Assert:   assertErrorCode(httputil.CodeInvalidParameter),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "catalogued tokens only",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   testTimeRange(),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if len(body.Amounts) != 1 || body.Amounts[0].TokenAddress != testToken {
+				t.Fatalf("expected only the catalogued token, got %+v", body.Amounts)
+			}
+		}),
+	}, r)
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "include unknown",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   withParams(map[string]string{"include_unknown": "true"}),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if len(body.Amounts) != 2 {
+				t.Fatalf("expected the catalogued and the unknown token, got %+v", body.Amounts)
+			}
+
+			unknown := body.Amounts[1]
+			if unknown.TokenAddress != unknownToken || unknown.TotalAmount != "7" ||
+				unknown.Decimals != nil || unknown.NormalizedAmount != "" {
+				t.Fatalf("expected the raw total of the unknown token without decimals, got %+v", unknown)
+			}
+		}),
+	}, r)
+}
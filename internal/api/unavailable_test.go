@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+func TestReadsRespondUnavailable(t *testing.T) {
+	// Nothing listens on port 1, so every connection attempt is refused and the reads are retried
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test dbname=test sslmode=disable")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop().Sugar()
+	r := newTestRouter(NewHandler(nil, storage.New(db, logger), logger))
+
+	for _, endpoint := range []string{"/api/source-addresses", "/api/source-addresses/1", "/api/tokens"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      endpoint,
+			Endpoint: endpoint,
+			Method:   http.MethodGet,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusServiceUnavailable)(t, resp)
+				assertErrorCode(httputil.CodeUnavailable)(t, resp)
+
+				if got := resp.Header().Get("Retry-After"); got != unavailableRetryAfter {
+					t.Fatalf("expected Retry-After %s, got %q", unavailableRetryAfter, got)
+				}
+			},
+		}, r)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"go.uber.org/zap"
+)
+
+// invalidAddresses are malformed addresses every endpoint taking an address must reject.
+var invalidAddresses = map[string]string{
+	"too short":      "0x00000000000000000000000000000000000000a",
+	"non-hex":        "0x00000000000000000000000000000000000000g1",
+	"missing prefix": "0000000000000000000000000000000000000000a1",
+}
+
+func assertErrorCode(code httputil.ErrorCode) httputil.AssertFn {
+	return func(t *testing.T, resp *httptest.ResponseRecorder) {
+		t.Helper()
+
+		var body httputil.CommonError
+		decodeBody(t, resp, &body)
+
+		if body.Code != code {
+			t.Fatalf("expected error code %s, got %s", code, body.Code)
+		}
+	}
+}
+
+func TestAddAddressesRejectsInvalidAddresses(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, endpoint := range []string{"/api/source-addresses", "/api/target-addresses"} {
+		for name, address := range invalidAddresses {
+			body, err := json.Marshal(AddAddressesRequest{Addresses: []AddAddressRequest{{Address: address}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+				Msg:      endpoint + " " + name,
+				Endpoint: endpoint,
+				Method:   http.MethodPost,
+				Body:     body,
+				Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					t.Helper()
+					httputil.AssertCode(http.StatusBadRequest)(t, resp)
+					assertErrorCode(httputil.CodeInvalidAddress)(t, resp)
+				},
+			}, r)
+		}
+	}
+}
+
+func TestAddTokenRejectsInvalidAddresses(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for name, address := range invalidAddresses {
+		body, err := json.Marshal(AddTokenRequest{Address: address, Symbol: "TKN"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      name,
+			Endpoint: "/api/tokens",
+			Method:   http.MethodPost,
+			Body:     body,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusBadRequest)(t, resp)
+				assertErrorCode(httputil.CodeInvalidAddress)(t, resp)
+			},
+		}, r)
+	}
+}
+
+func TestAddValidAddresses(t *testing.T) {
+	_, r := newTestHandler(t, "")
+
+	const address = "0x00000000000000000000000000000000000000A1"
+
+	body, err := json.Marshal(AddAddressesRequest{Addresses: []AddAddressRequest{{Address: address}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "valid source address",
+		Endpoint: "/api/source-addresses",
+		Method:   http.MethodPost,
+		Body:     body,
+		Assert:   httputil.AssertCode(http.StatusCreated),
+	}, r)
+
+	decimals := 6
+
+	body, err = json.Marshal(AddTokenRequest{Address: address, Symbol: "TKN", Name: "Token", Decimals: &decimals})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "valid token address",
+		Endpoint: "/api/tokens",
+		Method:   http.MethodPost,
+		Body:     body,
+		Assert:   httputil.AssertCode(http.StatusCreated),
+	}, r)
+}
+
+func TestGetByIDRejectsInvalidID(t *testing.T) {
+	r := newTestRouter(NewHandler(nil, nil, zap.NewNop().Sugar()))
+
+	for _, resource := range []string{"tokens", "source-addresses", "target-addresses"} {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      resource,
+			Endpoint: "/api/" + resource + "/abc",
+			Method:   http.MethodGet,
+			Assert: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				t.Helper()
+				httputil.AssertCode(http.StatusBadRequest)(t, resp)
+				assertErrorCode(httputil.CodeInvalidID)(t, resp)
+			},
+		}, r)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestTotalsEmptyReasonFromCounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		counts    storage.TableCounts
+		direction storage.Direction
+		want      string
+	}{
+		{name: "no addresses", direction: storage.DirectionSourceToTarget, want: emptyReasonNoSourceAddresses},
+		{name: "inflow needs no sources", direction: storage.DirectionInflow, want: emptyReasonNoTargetAddresses},
+		{name: "outflow needs no targets", direction: storage.DirectionOutflow, want: emptyReasonNoSourceAddresses},
+		{
+			name:      "among sources needs no targets",
+			counts:    storage.TableCounts{SourceAddresses: 2},
+			direction: storage.DirectionAmongSources,
+			want:      emptyReasonNoTokens,
+		},
+		{
+			name:      "no targets",
+			counts:    storage.TableCounts{SourceAddresses: 1},
+			direction: storage.DirectionSourceToTarget,
+			want:      emptyReasonNoTargetAddresses,
+		},
+		{
+			name:      "no transfers",
+			counts:    storage.TableCounts{SourceAddresses: 1, TargetAddresses: 1, Tokens: 1},
+			direction: storage.DirectionSourceToTarget,
+			want:      emptyReasonNoTransfers,
+		},
+		{
+			name:      "no matches",
+			counts:    storage.TableCounts{SourceAddresses: 1, TargetAddresses: 1, Tokens: 1, Transfers: 1},
+			direction: storage.DirectionSourceToTarget,
+			want:      emptyReasonNoMatches,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := totalsEmptyReasonFromCounts(&tt.counts, tt.direction); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetTotalAmountsAddressWarnings(t *testing.T) {
+	h, r := newTestHandler(t, "")
+
+	// Zero sources and zero targets
+	tests := []struct {
+		direction storage.Direction
+		want      []string
+	}{
+		{
+			direction: storage.DirectionSourceToTarget,
+			want:      []string{emptyReasonNoSourceAddresses, emptyReasonNoTargetAddresses},
+		},
+		{direction: storage.DirectionInflow, want: []string{emptyReasonNoTargetAddresses}},
+		{direction: storage.DirectionOutflow, want: []string{emptyReasonNoSourceAddresses}},
+	}
+
+	for _, tt := range tests {
+		httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+			Msg:      "zero sources and targets, " + string(tt.direction),
+			Endpoint: "/api/transfers",
+			Method:   http.MethodGet,
+			Params:   withParams(map[string]string{"direction": string(tt.direction)}),
+			Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+				t.Helper()
+
+				if !slices.Equal(body.Warnings, tt.want) {
+					t.Fatalf("expected warnings %q, got %q", tt.want, body.Warnings)
+				}
+
+				if body.Meta.EmptyReason != tt.want[0] {
+					t.Fatalf("expected empty reason %q, got %q", tt.want[0], body.Meta.EmptyReason)
+				}
+			}),
+		}, r)
+	}
+
+	// With a source but zero targets only the targets are missing
+	if _, _, err := h.store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	httputil.RunHTTPTestCase(t, httputil.HTTPTestCase{
+		Msg:      "zero targets",
+		Endpoint: "/api/transfers",
+		Method:   http.MethodGet,
+		Params:   testTimeRange(),
+		Assert: assertTotals(func(t *testing.T, body TotalAmountsResponse) {
+			t.Helper()
+
+			if want := []string{emptyReasonNoTargetAddresses}; !slices.Equal(body.Warnings, want) {
+				t.Fatalf("expected warnings %q, got %q", want, body.Warnings)
+			}
+		}),
+	}, r)
+}
@@ -8,7 +8,7 @@ import (
 // NewApp creates a new cli App instance with common flags pre-loaded.
 func NewApp() *cli.App {
 	app := cli.NewApp()
-	app.Flags = SentryFlags()
+	app.Flags = append(LogFlags(), SentryFlags()...)
 
 	return app
 }
@@ -21,6 +21,12 @@ const (
 	fatalLevel = "fatal"
 )
 
+// Log formats.
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
 var (
 	// SentryDSN is the CLI flag for Sentry DSN.
 	SentryDSN = cli.StringFlag{ //nolint:gochecknoglobals
@@ -51,6 +57,31 @@ var (
 	}
 )
 
+var (
+	// LogLevel is the CLI flag for the minimum level of logged messages.
+	LogLevel = cli.StringFlag{ //nolint:gochecknoglobals
+		Name:    "log-level",
+		EnvVars: []string{"LOG_LEVEL"},
+		Usage:   "minimum level of logged messages (debug, info, warn, error)",
+		Value:   "debug",
+	}
+	// LogFormat is the CLI flag for the format of logged messages.
+	LogFormat = cli.StringFlag{ //nolint:gochecknoglobals
+		Name:    "log-format",
+		EnvVars: []string{"LOG_FORMAT"},
+		Usage:   "format of logged messages (console, json)",
+		Value:   logFormatConsole,
+	}
+)
+
+// LogFlags returns the CLI flags for logging configuration.
+func LogFlags() []cli.Flag {
+	return []cli.Flag{
+		&LogLevel,
+		&LogFormat,
+	}
+}
+
 // SentryFlags returns the CLI flags for Sentry configuration.
 func SentryFlags() []cli.Flag {
 	return []cli.Flag{
@@ -76,7 +107,12 @@ func NewFlusher(s syncer) func() {
 
 // NewLogger creates a new logger instance.
 // The type of logger instance will be different with different application running modes.
-func newLogger(c *cli.Context) (*zap.Logger, zap.AtomicLevel) {
+func newLogger(c *cli.Context) (*zap.Logger, zap.AtomicLevel, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.String(LogLevel.Name))); err != nil {
+		return nil, zap.AtomicLevel{}, fmt.Errorf("invalid log level %q: %w", c.String(LogLevel.Name), err)
+	}
+
 	writers := []io.Writer{os.Stdout}
 	logAddr := c.String(CCLogAddress.Name)
 	logName := c.String(CCLogName.Name)
@@ -90,24 +126,39 @@ func newLogger(c *cli.Context) (*zap.Logger, zap.AtomicLevel) {
 	}
 
 	w := io.MultiWriter(writers...)
-	atom := zap.NewAtomicLevelAt(zap.DebugLevel)
+	atom := zap.NewAtomicLevelAt(level)
 
 	config := zap.NewProductionEncoderConfig()
 	config.EncodeTime = zapcore.RFC3339TimeEncoder
 	config.CallerKey = "caller"
 
-	encoder := zapcore.NewConsoleEncoder(config)
+	var encoder zapcore.Encoder
+
+	switch format := c.String(LogFormat.Name); format {
+	case logFormatConsole:
+		encoder = zapcore.NewConsoleEncoder(config)
+	case logFormatJSON:
+		encoder = zapcore.NewJSONEncoder(config)
+	default:
+		return nil, zap.AtomicLevel{}, fmt.Errorf("invalid log format %q, expected %s or %s",
+			format, logFormatConsole, logFormatJSON)
+	}
+
 	cc := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(w), atom), zap.AddCaller())
 
-	return cc, atom
+	return cc, atom, nil
 }
 
 // NewLogger creates a new sugared logger and a flush function. The flush function should be
 // called by consumer before quitting application.
 // This function should be use most of the time unless
 // the application requires extensive performance, in this case use NewLogger.
+// The returned level can be changed to adjust the logged messages at runtime.
 func NewLogger(c *cli.Context) (*zap.Logger, zap.AtomicLevel, func(), error) {
-	logger, atom := newLogger(c)
+	logger, atom, err := newLogger(c)
+	if err != nil {
+		return nil, atom, nil, err
+	}
 
 	// init sentry if flag dsn exists
 	if len(c.String(SentryDSN.Name)) != 0 {
@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ductm54/transfer-track/internal/dbutil"
 	"github.com/jmoiron/sqlx"
@@ -45,14 +46,76 @@ var (
 		EnvVars: []string{"POSTGRES_DATABASE"},
 		Value:   "go_project_template",
 	}
+	// PostgresSchema is the CLI flag for the PostgreSQL schema of the tables.
+	PostgresSchema = cli.StringFlag{ //nolint:gochecknoglobals
+		Name:    "db-schema",
+		Usage:   "PostgreSQL schema of the tables, created by the migrations if missing (default: public)",
+		EnvVars: []string{"DB_SCHEMA"},
+	}
+	// PostgresReadonlyURL is the CLI flag for an optional read-only replica connection URL.
+	PostgresReadonlyURL = cli.StringFlag{ //nolint:gochecknoglobals
+		Name:    "postgres-readonly-url",
+		Usage:   "PostgresSQL read-only replica URL used for queries, falls back to the primary if empty",
+		EnvVars: []string{"POSTGRES_READONLY_URL"},
+	}
 	// PostgresMigrationPath is the CLI flag for database migration path.
 	PostgresMigrationPath = cli.StringFlag{ //nolint:gochecknoglobals
 		Name:    "migration-path",
 		Value:   "migrations",
 		EnvVars: []string{"MIGRATION_PATH"},
 	}
+	// PostgresSkipMigrations is the CLI flag to skip running migrations at startup.
+	PostgresSkipMigrations = cli.BoolFlag{ //nolint:gochecknoglobals
+		Name:    "skip-migrations",
+		Usage:   "Do not run database migrations at startup, for when they are applied out-of-band",
+		EnvVars: []string{"SKIP_MIGRATIONS"},
+	}
+	// PostgresMaxOpenConns is the CLI flag for the maximum number of open database connections.
+	PostgresMaxOpenConns = cli.IntFlag{ //nolint:gochecknoglobals
+		Name:    "db-max-open-conns",
+		Usage:   "Maximum number of open database connections (0 means unlimited)",
+		EnvVars: []string{"DB_MAX_OPEN_CONNS"},
+		Value:   25, //nolint:gomnd
+	}
+	// PostgresMaxIdleConns is the CLI flag for the maximum number of idle database connections.
+	PostgresMaxIdleConns = cli.IntFlag{ //nolint:gochecknoglobals
+		Name:    "db-max-idle-conns",
+		Usage:   "Maximum number of idle database connections kept in the pool",
+		EnvVars: []string{"DB_MAX_IDLE_CONNS"},
+		Value:   10, //nolint:gomnd
+	}
+	// PostgresConnMaxLifetime is the CLI flag for the maximum lifetime of a database connection.
+	PostgresConnMaxLifetime = cli.DurationFlag{ //nolint:gochecknoglobals
+		Name:    "db-conn-max-lifetime",
+		Usage:   "Maximum time a database connection may be reused (0 means forever)",
+		EnvVars: []string{"DB_CONN_MAX_LIFETIME"},
+		Value:   30 * time.Minute, //nolint:gomnd
+	}
 )
 
+// PoolConfig holds the database connection pool settings.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// PoolConfigFromContext reads the connection pool settings from the cli flags.
+func PoolConfigFromContext(c *cli.Context) PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    c.Int(PostgresMaxOpenConns.Name),
+		MaxIdleConns:    c.Int(PostgresMaxIdleConns.Name),
+		ConnMaxLifetime: c.Duration(PostgresConnMaxLifetime.Name),
+	}
+}
+
+// Apply applies the connection pool settings to db.
+func (p PoolConfig) Apply(db *sqlx.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+}
+
 // PostgresSQLFlags creates new cli flags for PostgreSQL client.
 func PostgresSQLFlags(defaultDB string) []cli.Flag {
 	db := PostgresDatabase
@@ -64,12 +127,18 @@ func PostgresSQLFlags(defaultDB string) []cli.Flag {
 		&PostgresUser,
 		&PostgresPassword,
 		&db,
+		&PostgresSchema,
+		&PostgresReadonlyURL,
 		&PostgresMigrationPath,
+		&PostgresSkipMigrations,
+		&PostgresMaxOpenConns,
+		&PostgresMaxIdleConns,
+		&PostgresConnMaxLifetime,
 	}
 }
 
-// NewDB creates a DB instance from cli flags configuration.
-func NewDB(specs map[string]any) (*sqlx.DB, error) {
+// NewDB creates a DB instance from cli flags configuration with the given connection pool settings.
+func NewDB(specs map[string]any, pool PoolConfig) (*sqlx.DB, error) {
 	const driverName = "postgres"
 
 	connStr := dbutil.FormatDSN(specs)
@@ -79,5 +148,7 @@ func NewDB(specs map[string]any) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
 
+	pool.Apply(db)
+
 	return db, nil
 }
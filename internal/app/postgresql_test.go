@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+// poolConfigOf returns the pool settings parsed from the flags of args.
+func poolConfigOf(t *testing.T, args ...string) PoolConfig {
+	t.Helper()
+
+	var pool PoolConfig
+
+	app := cli.NewApp()
+	app.Flags = PostgresSQLFlags("transfer_track")
+	app.Action = func(c *cli.Context) error {
+		pool = PoolConfigFromContext(c)
+		return nil
+	}
+
+	if err := app.Run(append([]string{"transfer-track"}, args...)); err != nil {
+		t.Fatalf("running app: %v", err)
+	}
+
+	return pool
+}
+
+func TestPoolConfigFromContext(t *testing.T) {
+	defaults := PoolConfig{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: 30 * time.Minute}
+	if got := poolConfigOf(t); got != defaults {
+		t.Fatalf("expected the default pool settings %+v, got %+v", defaults, got)
+	}
+
+	want := PoolConfig{MaxOpenConns: 5, MaxIdleConns: 2, ConnMaxLifetime: time.Minute}
+
+	got := poolConfigOf(t, "--db-max-open-conns", "5", "--db-max-idle-conns", "2", "--db-conn-max-lifetime", "1m")
+	if got != want {
+		t.Fatalf("expected the flag pool settings %+v, got %+v", want, got)
+	}
+}
+
+func TestPoolConfigApply(t *testing.T) {
+	// Opening does not connect, so no database server is needed
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer db.Close()
+
+	PoolConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute}.Apply(db)
+
+	if got := db.Stats().MaxOpenConnections; got != 7 {
+		t.Fatalf("expected 7 max open connections, got %d", got)
+	}
+}
+
+func TestNewDBAppliesPool(t *testing.T) {
+	const addr = "127.0.0.1:5432"
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Skipf("no development database server at %s: %v", addr, err)
+	}
+
+	_ = conn.Close()
+
+	db, err := NewDB(map[string]any{
+		"host":     "127.0.0.1",
+		"port":     5432,
+		"user":     "test",
+		"password": "test",
+		"sslmode":  "disable",
+	}, PoolConfig{MaxOpenConns: 3, MaxIdleConns: 1, ConnMaxLifetime: time.Minute})
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Hold every allowed connection, then release them all
+	conns := make([]*sqlx.Conn, 0, 3)
+	for range 3 {
+		c, err := db.Connx(ctx)
+		if err != nil {
+			t.Fatalf("getting connection: %v", err)
+		}
+
+		conns = append(conns, c)
+	}
+
+	for _, c := range conns {
+		_ = c.Close()
+	}
+
+	stats := db.Stats()
+	if stats.MaxOpenConnections != 3 {
+		t.Fatalf("expected 3 max open connections, got %d", stats.MaxOpenConnections)
+	}
+
+	if stats.Idle != 1 || stats.MaxIdleClosed != 2 {
+		t.Fatalf("expected 1 idle connection kept and 2 closed, got %d idle and %d closed", stats.Idle, stats.MaxIdleClosed)
+	}
+}
@@ -0,0 +1,196 @@
+// Package coingecko provides a client for historical token prices from the CoinGecko API.
+package coingecko
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	baseURL               = "https://api.coingecko.com/api/v3"
+	apiKeyHeader          = "x-cg-demo-api-key"
+	vsCurrency            = "usd"
+	defaultRequestTimeout = 10 * time.Second
+	day                   = 24 * time.Hour
+
+	// nativeTokenAddress is the token address used for the chain's native coin (e.g. ETH).
+	nativeTokenAddress = "0x0000000000000000000000000000000000000000"
+
+	// DefaultPlatform is the CoinGecko asset platform of Ethereum Mainnet.
+	DefaultPlatform = "ethereum"
+	// DefaultNativeCoinID is the CoinGecko coin ID of ETH.
+	DefaultNativeCoinID = "ethereum"
+)
+
+// ErrPriceNotFound is returned when CoinGecko has no price for a token on the requested day.
+var ErrPriceNotFound = errors.New("price not found")
+
+// Config holds the CoinGecko API client configuration.
+type Config struct {
+	APIKey string
+	// Platform is the CoinGecko asset platform ID contract addresses are looked up on.
+	Platform string
+	// NativeCoinID is the CoinGecko coin ID priced for the zero address.
+	NativeCoinID string
+}
+
+// cacheKey identifies a cached price of a token on a UTC day.
+type cacheKey struct {
+	tokenAddress string
+	day          int64
+}
+
+// Client represents a CoinGecko API client.
+// Prices are cached per token and UTC day to limit API calls.
+type Client struct {
+	apiKey       string
+	platform     string
+	nativeCoinID string
+	httpClient   *http.Client
+	baseURL      string
+	logger       *zap.SugaredLogger
+
+	mu    sync.Mutex
+	cache map[cacheKey]float64
+}
+
+// NewClient creates a new CoinGecko API client.
+// Unset platform and native coin ID fall back to their Ethereum Mainnet defaults.
+func NewClient(cfg Config, logger *zap.SugaredLogger) *Client {
+	if cfg.Platform == "" {
+		cfg.Platform = DefaultPlatform
+	}
+
+	if cfg.NativeCoinID == "" {
+		cfg.NativeCoinID = DefaultNativeCoinID
+	}
+
+	return &Client{
+		apiKey:       cfg.APIKey,
+		platform:     cfg.Platform,
+		nativeCoinID: cfg.NativeCoinID,
+		httpClient:   &http.Client{Timeout: defaultRequestTimeout},
+		baseURL:      baseURL,
+		logger:       logger,
+		cache:        make(map[cacheKey]float64),
+	}
+}
+
+// marketChartResponse is the response of the market_chart/range endpoints.
+// Each price is a [unix milliseconds, price] pair.
+type marketChartResponse struct {
+	Prices [][2]float64 `json:"prices"`
+}
+
+// PriceAt returns the USD price of a token on the UTC day of t.
+// The zero address is priced as the configured native coin.
+func (c *Client) PriceAt(ctx context.Context, tokenAddress string, t time.Time) (float64, error) {
+	tokenAddress = strings.ToLower(tokenAddress)
+	dayStart := t.UTC().Truncate(day)
+	key := cacheKey{tokenAddress: tokenAddress, day: dayStart.Unix()}
+
+	c.mu.Lock()
+	price, ok := c.cache[key]
+	c.mu.Unlock()
+
+	if ok {
+		return price, nil
+	}
+
+	price, err := c.fetchPrice(ctx, tokenAddress, dayStart)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = price
+	c.mu.Unlock()
+
+	return price, nil
+}
+
+// fetchPrice fetches the last USD price of a token within the day starting at dayStart.
+func (c *Client) fetchPrice(ctx context.Context, tokenAddress string, dayStart time.Time) (float64, error) {
+	path := fmt.Sprintf("/coins/%s/contract/%s/market_chart/range", url.PathEscape(c.platform), tokenAddress)
+	if tokenAddress == nativeTokenAddress {
+		path = fmt.Sprintf("/coins/%s/market_chart/range", url.PathEscape(c.nativeCoinID))
+	}
+
+	dayEnd := dayStart.Add(day)
+	if now := time.Now(); dayEnd.After(now) {
+		dayEnd = now
+	}
+
+	params := url.Values{}
+	params.Add("vs_currency", vsCurrency)
+	params.Add("from", strconv.FormatInt(dayStart.Unix(), 10))
+	params.Add("to", strconv.FormatInt(dayEnd.Unix(), 10))
+
+	c.logger.Debugw("Fetching token price", "token", tokenAddress, "day", dayStart.Format(time.DateOnly))
+
+	var response marketChartResponse
+	if err := c.doRequest(ctx, path, params, &response); err != nil {
+		return 0, err
+	}
+
+	if len(response.Prices) == 0 {
+		return 0, fmt.Errorf("token %s on %s: %w", tokenAddress, dayStart.Format(time.DateOnly), ErrPriceNotFound)
+	}
+
+	return response.Prices[len(response.Prices)-1][1], nil
+}
+
+// doRequest performs an HTTP request to the CoinGecko API.
+func (c *Client) doRequest(ctx context.Context, path string, params url.Values, result any) error {
+	reqURL := fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.logger.Warnw("Failed to close response body", "err", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPriceNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unmarshaling response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package coingecko
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newTestClient returns a client of a test server serving handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClient(Config{APIKey: "test"}, zap.NewNop().Sugar())
+	c.baseURL = srv.URL
+
+	return c
+}
+
+func TestPriceAtCachesPerDay(t *testing.T) {
+	const token = "0x00000000000000000000000000000000000000C3"
+
+	var requests atomic.Int32
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		wantPath := "/coins/ethereum/contract/0x00000000000000000000000000000000000000c3/market_chart/range"
+		if r.URL.Path != wantPath {
+			t.Errorf("expected path %s, got %s", wantPath, r.URL.Path)
+		}
+
+		if r.Header.Get(apiKeyHeader) != "test" {
+			t.Errorf("expected the API key header to be set")
+		}
+
+		_, _ = w.Write([]byte(`{"prices": [[1704067200000, 1.5], [1704110400000, 2.5]]}`))
+	})
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, at := range []time.Time{day.Add(time.Hour), day.Add(20 * time.Hour)} {
+		price, err := c.PriceAt(context.Background(), token, at)
+		if err != nil {
+			t.Fatalf("getting price: %v", err)
+		}
+
+		// The last price of the day
+		if price != 2.5 {
+			t.Fatalf("expected price 2.5, got %v", price)
+		}
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected prices of the same day to be cached, got %d requests", got)
+	}
+
+	if _, err := c.PriceAt(context.Background(), token, day.Add(24*time.Hour)); err != nil {
+		t.Fatalf("getting price: %v", err)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("expected another request for the next day, got %d requests", got)
+	}
+}
+
+func TestPriceAtNativeCoin(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/coins/ethereum/market_chart/range" {
+			t.Errorf("expected the native coin path, got %s", r.URL.Path)
+		}
+
+		_, _ = w.Write([]byte(`{"prices": [[1704067200000, 2000]]}`))
+	})
+
+	price, err := c.PriceAt(context.Background(), nativeTokenAddress, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("getting price: %v", err)
+	}
+
+	if price != 2000 {
+		t.Fatalf("expected price 2000, got %v", price)
+	}
+}
+
+func TestPriceAtNotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "unknown token",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, `{"error": "coin not found"}`, http.StatusNotFound)
+			},
+		},
+		{
+			name: "no prices",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"prices": []}`))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler)
+
+			_, err := c.PriceAt(context.Background(), "0x00000000000000000000000000000000000000c3", time.Now())
+			if !errors.Is(err, ErrPriceNotFound) {
+				t.Fatalf("expected ErrPriceNotFound, got %v", err)
+			}
+		})
+	}
+}
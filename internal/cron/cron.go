@@ -0,0 +1,235 @@
+// Package cron parses standard five-field cron expressions and computes their next activation.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is returned when a cron expression cannot be parsed.
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// maxSearchYears bounds the search for the next activation, e.g. of "0 0 30 2 *" which never fires.
+const maxSearchYears = 5
+
+// field describes the allowed values of a cron field.
+type field struct {
+	name     string
+	min, max int
+	// names maps lowercase names such as "jan" or "mon" to values.
+	names map[string]int
+}
+
+//nolint:gochecknoglobals
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 are Sunday.
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Schedule is a parsed cron expression. Each field is a bitset of the matching values.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar report whether the day fields are unrestricted. As in cron(8), a day
+	// matches either day field if both are restricted.
+	domStar, dowStar bool
+}
+
+// Parse parses a five-field cron expression (minute, hour, day of month, month, day of week).
+// Fields support "*", values, names of months and weekdays, ranges ("1-5"), steps ("*/4", "0-30/10")
+// and lists ("8,20"). The descriptors @yearly, @monthly, @weekly, @daily and @hourly are supported too.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidExpression, len(fields))
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday as 7 into 0
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	// As in cron(8), fields starting with "*" (e.g. "*/2") count as unrestricted
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return &s, nil
+}
+
+// parseField parses a comma-separated list of ranges into a bitset.
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(expr, ",") {
+		partBits, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+
+		bits |= partBits
+	}
+
+	return bits, nil
+}
+
+// parseRange parses "*", a value or a range, each optionally followed by a step, into a bitset.
+func parseRange(expr string, f field) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	step := 1
+
+	if hasStep {
+		var err error
+
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("%w: invalid step %q in %s field", ErrInvalidExpression, stepExpr, f.name)
+		}
+	}
+
+	var start, end int
+
+	switch lowExpr, highExpr, isRange := strings.Cut(rangeExpr, "-"); {
+	case rangeExpr == "*":
+		start, end = f.min, f.max
+	case isRange:
+		var err error
+
+		if start, err = parseValue(lowExpr, f); err != nil {
+			return 0, err
+		}
+
+		if end, err = parseValue(highExpr, f); err != nil {
+			return 0, err
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("%w: range %q in %s field is reversed", ErrInvalidExpression, rangeExpr, f.name)
+		}
+	default:
+		value, err := parseValue(rangeExpr, f)
+		if err != nil {
+			return 0, err
+		}
+
+		// A value with a step, e.g. "5/15", runs from the value to the end of the field
+		start, end = value, value
+		if hasStep {
+			end = f.max
+		}
+	}
+
+	var bits uint64
+	for value := start; value <= end; value += step {
+		bits |= 1 << uint(value)
+	}
+
+	return bits, nil
+}
+
+// parseValue parses a number or a name within the bounds of the field.
+func parseValue(expr string, f field) (int, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid value %q in %s field", ErrInvalidExpression, expr, f.name)
+	}
+
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%w: %s value %d out of range %d-%d",
+			ErrInvalidExpression, f.name, value, f.min, f.max)
+	}
+
+	return value, nil
+}
+
+// Next returns the first activation strictly after t, in the location of t, or the zero time if the
+// schedule does not fire within the next years (e.g. on February 30th).
+func (s *Schedule) Next(t time.Time) time.Time {
+	// Activations are on whole minutes
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchesDay reports whether the day of t matches the day of month and day of week fields.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
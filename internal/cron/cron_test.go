@@ -0,0 +1,50 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrInvalidExpression) {
+			t.Errorf("Parse(%q): expected ErrInvalidExpression, got %v", expr, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Friday
+	from := time.Date(2024, 3, 15, 10, 20, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2024, 3, 15, 10, 21, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 8,20 * * *", want: time.Date(2024, 3, 15, 20, 0, 0, 0, time.UTC)},
+		{expr: "@daily", want: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * mon-fri", want: time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 jan *", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Either restricted day field matches
+		{expr: "0 0 20 * mon", want: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Fatalf("expected the next activation at %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
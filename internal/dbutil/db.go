@@ -3,14 +3,16 @@ package dbutil
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file" //nolint:go migrate
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // NewDB creates a DB instance from dsn.
@@ -36,9 +38,50 @@ func FormatDSN(props map[string]any) string {
 	return strings.Join(parts, " ")
 }
 
-// RunMigrationUp runs database migrations from the specified folder.
-func RunMigrationUp(db *sql.DB, migrationFolderPath, databaseName string) (*migrate.Migrate, error) {
-	driver, err := migratepostgres.WithInstance(db, &migratepostgres.Config{})
+// schemaNamePattern matches the schema names accepted by ValidateSchema: unquoted lowercase identifiers.
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ValidateSchema checks that schema is a lowercase PostgreSQL identifier, so it can be used in a
+// search_path without quoting.
+func ValidateSchema(schema string) error {
+	if !schemaNamePattern.MatchString(schema) {
+		return fmt.Errorf("invalid schema %q, expected lowercase letters, digits and underscores", schema)
+	}
+
+	return nil
+}
+
+// SearchPathOption returns the value of the options connection parameter setting the search_path
+// of every connection to schema.
+func SearchPathOption(schema string) string {
+	return "-csearch_path=" + schema
+}
+
+// WithSearchPath returns the connection URL dsn with the search_path of its connections set to schema.
+func WithSearchPath(dsn, schema string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parsing connection URL: %w", err)
+	}
+
+	query := u.Query()
+	query.Set("options", SearchPathOption(schema))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// NewMigrate creates a migrate.Migrate applying the migrations of the specified folder to db.
+// A non-empty schemaName is created if missing and holds the migrated tables and the migrations
+// table; the connections of db are expected to have it as search_path.
+func NewMigrate(db *sql.DB, migrationFolderPath, databaseName, schemaName string) (*migrate.Migrate, error) {
+	if schemaName != "" {
+		if _, err := db.Exec(`CREATE SCHEMA IF NOT EXISTS ` + pq.QuoteIdentifier(schemaName)); err != nil {
+			return nil, fmt.Errorf("creating schema %s: %w", schemaName, err)
+		}
+	}
+
+	driver, err := migratepostgres.WithInstance(db, &migratepostgres.Config{SchemaName: schemaName})
 	if err != nil {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
@@ -51,8 +94,18 @@ func RunMigrationUp(db *sql.DB, migrationFolderPath, databaseName string) (*migr
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
-	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return nil, fmt.Errorf("migrate: %w", err)
+	return m, nil
+}
+
+// RunMigrationUp runs database migrations from the specified folder, see NewMigrate.
+func RunMigrationUp(db *sql.DB, migrationFolderPath, databaseName, schemaName string) (*migrate.Migrate, error) {
+	m, err := NewMigrate(db, migrationFolderPath, databaseName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := MigrateUp(m); err != nil {
+		return nil, err
 	}
 
 	return m, nil
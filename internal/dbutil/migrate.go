@@ -0,0 +1,66 @@
+package dbutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// Migrator is the part of migrate.Migrate used to manage the schema version of a database.
+type Migrator interface {
+	Up() error
+	Steps(n int) error
+	Version() (version uint, dirty bool, err error)
+	Force(version int) error
+}
+
+var _ Migrator = (*migrate.Migrate)(nil)
+
+// MigrateUp applies every pending migration. It is a no-op if the database is up to date.
+func MigrateUp(m Migrator) error {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back the last n applied migrations.
+func MigrateDown(m Migrator, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of migrations to roll back %d, expected a positive number", n)
+	}
+
+	if err := m.Steps(-n); err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+
+	return nil
+}
+
+// MigrationVersion returns the version of the last applied migration, 0 if none was applied, and
+// whether it failed halfway, leaving the database dirty.
+func MigrationVersion(m Migrator) (uint, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("getting migration version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// ForceVersion records version as the last applied migration without running any migration and
+// clears the dirty flag, e.g. after fixing a failed migration by hand. -1 records that no migration
+// was applied.
+func ForceVersion(m Migrator, version int) error {
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("forcing migration version: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package dbutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// fakeMigrator records the calls of the migration wrappers and fails them with err.
+type fakeMigrator struct {
+	err     error
+	steps   []int
+	forced  []int
+	version uint
+	dirty   bool
+}
+
+func (f *fakeMigrator) Up() error {
+	return f.err
+}
+
+func (f *fakeMigrator) Steps(n int) error {
+	f.steps = append(f.steps, n)
+	return f.err
+}
+
+func (f *fakeMigrator) Version() (uint, bool, error) {
+	return f.version, f.dirty, f.err
+}
+
+func (f *fakeMigrator) Force(version int) error {
+	f.forced = append(f.forced, version)
+	return f.err
+}
+
+func TestMigrateUp(t *testing.T) {
+	if err := MigrateUp(&fakeMigrator{err: migrate.ErrNoChange}); err != nil {
+		t.Fatalf("expected an up to date database to be a no-op, got %v", err)
+	}
+
+	failure := errors.New("syntax error")
+	if err := MigrateUp(&fakeMigrator{err: failure}); !errors.Is(err, failure) {
+		t.Fatalf("expected the migration failure, got %v", err)
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	m := &fakeMigrator{}
+
+	if err := MigrateDown(m, 2); err != nil {
+		t.Fatalf("migrating down: %v", err)
+	}
+
+	if len(m.steps) != 1 || m.steps[0] != -2 {
+		t.Fatalf("expected 2 steps down, got %v", m.steps)
+	}
+
+	for _, n := range []int{0, -1} {
+		if err := MigrateDown(m, n); err == nil {
+			t.Fatalf("expected %d migrations to roll back to be rejected", n)
+		}
+	}
+
+	if len(m.steps) != 1 {
+		t.Fatalf("expected no migration for invalid numbers, got %v", m.steps)
+	}
+
+	if err := MigrateDown(&fakeMigrator{err: migrate.ErrNoChange}, 1); err == nil {
+		t.Fatal("expected rolling back without applied migrations to fail")
+	}
+}
+
+func TestMigrationVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		m           *fakeMigrator
+		wantVersion uint
+		wantDirty   bool
+		wantErr     bool
+	}{
+		{name: "no migration applied", m: &fakeMigrator{err: migrate.ErrNilVersion}},
+		{name: "applied", m: &fakeMigrator{version: 8}, wantVersion: 8},
+		{name: "dirty", m: &fakeMigrator{version: 9, dirty: true}, wantVersion: 9, wantDirty: true},
+		{name: "failure", m: &fakeMigrator{err: errors.New("connection refused")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, dirty, err := MigrationVersion(tt.m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if version != tt.wantVersion || dirty != tt.wantDirty {
+				t.Fatalf("expected version %d dirty %v, got %d dirty %v", tt.wantVersion, tt.wantDirty, version, dirty)
+			}
+		})
+	}
+}
+
+func TestForceVersion(t *testing.T) {
+	m := &fakeMigrator{}
+
+	if err := ForceVersion(m, -1); err != nil {
+		t.Fatalf("forcing version: %v", err)
+	}
+
+	if len(m.forced) != 1 || m.forced[0] != -1 {
+		t.Fatalf("expected version -1 to be forced, got %v", m.forced)
+	}
+
+	if err := ForceVersion(&fakeMigrator{err: errors.New("connection refused")}, 3); err == nil {
+		t.Fatal("expected the failure to be returned")
+	}
+}
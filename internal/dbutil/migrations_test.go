@@ -0,0 +1,84 @@
+package dbutil_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/dbutil"
+	"github.com/ductm54/transfer-track/internal/testutil"
+)
+
+const testMigrationPath = "../../migrations"
+
+func TestMigrationsRollBackAndReapply(t *testing.T) {
+	ups, err := filepath.Glob(filepath.Join(testMigrationPath, "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest := uint(len(ups))
+
+	testDB := testutil.NewTestDB(t, testMigrationPath)
+
+	var dbName string
+	if err := testDB.Get(&dbName, `SELECT current_database()`); err != nil {
+		t.Fatalf("getting database name: %v", err)
+	}
+
+	// The migrate instance gets its own connections, closing it closes them before the test DB is dropped
+	db, err := dbutil.NewDB(dbutil.FormatDSN(map[string]any{
+		"host":     "127.0.0.1",
+		"port":     5432,
+		"user":     "test",
+		"password": "test",
+		"sslmode":  "disable",
+		"dbname":   dbName,
+	}))
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", dbName, err)
+	}
+
+	m, err := dbutil.NewMigrate(db.DB, testMigrationPath, dbName, "")
+	if err != nil {
+		_ = db.Close()
+		t.Fatalf("creating migrate: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := m.Close(); err != nil {
+			t.Errorf("closing migrate: %v", err)
+		}
+	})
+
+	assertVersion := func(want uint) {
+		t.Helper()
+
+		version, dirty, err := dbutil.MigrationVersion(m)
+		if err != nil {
+			t.Fatalf("getting migration version: %v", err)
+		}
+
+		if version != want || dirty {
+			t.Fatalf("expected clean version %d, got %d dirty %v", want, version, dirty)
+		}
+	}
+
+	assertVersion(latest)
+
+	// Every down migration undoes its up migration
+	if err := dbutil.MigrateDown(m, int(latest)); err != nil {
+		t.Fatalf("migrating down: %v", err)
+	}
+
+	assertVersion(0)
+
+	if err := dbutil.MigrateUp(m); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+
+	assertVersion(latest)
+
+	if err := dbutil.MigrateUp(m); err != nil {
+		t.Fatalf("expected migrating an up to date database to be a no-op, got %v", err)
+	}
+}
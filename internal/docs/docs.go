@@ -0,0 +1,3453 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/chains": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "List the supported chains",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ChainsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/config": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Get the configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConfigResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/daily-refresh-time": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Update the daily refresh times",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateDailyRefreshTimeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "List configuration changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list changes of this key",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list changes made at or after this Unix timestamp in seconds",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list changes made at or before this Unix timestamp in seconds",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of changes",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.ConfigHistory"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/log-level": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Get the log level",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.LogLevelResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Update the log level",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateLogLevelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.LogLevelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/refresh-cron": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Update the refresh cron expression",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateRefreshCronRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/refresh-interval": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Update the minimum refresh interval",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateRefreshIntervalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert": {
+            "get": {
+                "description": "Divides an integer amount in Wei by 10^decimals exactly, as the normalized amounts of the\nother endpoints are.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "convert"
+                ],
+                "summary": "Normalize an amount in Wei",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Amount in Wei, an integer of at most 78 digits",
+                        "name": "amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Decimals of the token",
+                        "name": "decimals",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConvertedAmount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Normalizes up to 1000 amounts like GET /convert, in the order of the request. If any amount\nis invalid, none is converted and the invalid ones are listed in the errors.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "convert"
+                ],
+                "summary": "Normalize many amounts in Wei",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ConvertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConvertResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/source-addresses": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "List source addresses",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.SourceAddress"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "Add or relabel source addresses",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AddAddressesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.AddAddressesResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "addresses": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/storage.SourceAddress"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "Delete source addresses by ID or address",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteAddressesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteAddressesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/source-addresses/import": {
+            "post": {
+                "description": "The file has the columns address and label, optionally preceded by a header row naming them.\nA header row may add the columns category and tags, with tags separated by semicolons.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "Import addresses from a CSV file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file of at most 1 MiB and 5000 addresses",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "source",
+                            "target"
+                        ],
+                        "type": "string",
+                        "default": "source",
+                        "description": "Type of the imported addresses",
+                        "name": "type",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportAddressesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/source-addresses/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "Get a source address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.SourceAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "source-addresses"
+                ],
+                "summary": "Delete a source address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Get the stored data and refresh configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/target-addresses": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "target-addresses"
+                ],
+                "summary": "List target addresses",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.TargetAddress"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "target-addresses"
+                ],
+                "summary": "Add or relabel target addresses",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AddAddressesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.AddAddressesResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "addresses": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/storage.TargetAddress"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "target-addresses"
+                ],
+                "summary": "Delete target addresses by ID or address",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteAddressesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteAddressesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/target-addresses/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "target-addresses"
+                ],
+                "summary": "Get a target address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.TargetAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "target-addresses"
+                ],
+                "summary": "Delete a target address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "List tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Token"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Add a token",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AddTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/import": {
+            "post": {
+                "description": "Imports the tokens on the configured chain of a token list in the format of tokenlists.org,\nsuch as the Uniswap default list, uploaded as file or downloaded from url. New tokens are\nadded and the symbol, name and decimals of catalogued tokens updated, so importing the same\nlist again changes nothing.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Import tokens from a token list",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Token list JSON file of at most 16 MiB",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "http or https URL of the token list, if no file is uploaded",
+                        "name": "url",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TokenListImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/refresh-metadata": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Refresh the metadata of all tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TokenMetadataRefreshResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/uncatalogued": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "List tokens seen in transfers but missing from the tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.UncataloguedToken"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Get a token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Update the metadata of a token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Delete a token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Update the metadata of a token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/{id}/refresh-metadata": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Refresh the metadata of a token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TokenMetadataRefresh"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Get total amounts per token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago unless only blocks are given)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range, Unix timestamp in seconds (default: now unless only blocks are given)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First block of the block range",
+                        "name": "start_block",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last block of the block range",
+                        "name": "end_block",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "source_to_target",
+                            "inflow",
+                            "outflow",
+                            "among_sources"
+                        ],
+                        "type": "string",
+                        "default": "source_to_target",
+                        "description": "Transfers to count",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum normalized amount of a transfer",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum normalized amount of a transfer",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also count transfers of tokens missing from the tokens table",
+                        "name": "include_unknown",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return normalized amounts, computed in the database, omitting the raw total_amount",
+                        "name": "normalized_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers of these tokens",
+                        "name": "token_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers from these senders",
+                        "name": "from_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers to these recipients",
+                        "name": "to_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count transfers of which the source or target address has this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count transfers of which the source or target address has this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Refresh stale data before responding, waiting a few seconds at most",
+                        "name": "auto_refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TotalAmountsResponse"
+                        },
+                        "headers": {
+                            "X-Data-Stale": {
+                                "type": "string",
+                                "description": "true if the data is due for a refresh that did not complete in time"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Delete stored transfers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the time range, Unix timestamp in seconds or RFC3339",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range, Unix timestamp in seconds or RFC3339",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete transfers of this token",
+                        "name": "token_address",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Must be all to delete every transfer",
+                        "name": "confirm",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteTransfersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/export": {
+            "get": {
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Export total amounts as CSV or raw transfers as NDJSON",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago unless only blocks are given)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range, Unix timestamp in seconds (default: now unless only blocks are given)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First block of the block range",
+                        "name": "start_block",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last block of the block range",
+                        "name": "end_block",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "source_to_target",
+                            "inflow",
+                            "outflow",
+                            "among_sources"
+                        ],
+                        "type": "string",
+                        "default": "source_to_target",
+                        "description": "Transfers to count",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum normalized amount of a transfer",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum normalized amount of a transfer",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also count transfers of tokens missing from the tokens table",
+                        "name": "include_unknown",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave the raw total_amount column empty (CSV only)",
+                        "name": "normalized_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers of these tokens",
+                        "name": "token_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers from these senders",
+                        "name": "from_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only count transfers to these recipients",
+                        "name": "to_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count transfers of which the source or target address has this category (CSV only)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count transfers of which the source or target address has this tag (CSV only)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Refresh stale data before exporting CSV, waiting a few seconds at most",
+                        "name": "auto_refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV or NDJSON file",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "X-Data-Stale": {
+                                "type": "string",
+                                "description": "true if the data is due for a refresh that did not complete in time"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/net": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Get the net flow of each token into the target addresses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range, Unix timestamp in seconds (default: now)",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.NetAmountsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Start a refresh of all transfers in the background",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key identifying retries of the same request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.RefreshStartedResponse"
+                        },
+                        "headers": {
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the response is replayed for a repeated key"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/events": {
+            "get": {
+                "description": "Streams a text/event-stream of the progress of refreshes from now on. The name of each event\nis its RefreshProgress event, and its data the RefreshProgress as JSON. The stream ends when\nthe server shuts down.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Stream refresh progress as Server-Sent Events",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RefreshProgress"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "List the most recently started refreshes",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of refreshes",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.RefreshRun"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/last": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Get the most recently started refresh",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.RefreshRun"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/plan": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Preview the Etherscan requests of a refresh",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RefreshPlan"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/{address}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Refresh the transfers of a tracked address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tracked source or target address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AddressRefreshResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/refresh/{jobID}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Get the status of a refresh job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RefreshJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/stream": {
+            "get": {
+                "description": "Upgrades to a WebSocket that receives a TransferStreamMessage for each stored batch of\ntransfers matching the filters. Clients that fall behind are disconnected with close code 1013.",
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Stream newly stored transfers over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only stream transfers of these tokens",
+                        "name": "token_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only stream transfers from or to these addresses",
+                        "name": "address",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/api.TransferStreamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/summary": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Get total amounts per token bucketed over time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the time range, Unix timestamp in seconds (default: --default-range-days ago)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range, Unix timestamp in seconds (default: now)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TransfersSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.CommonError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.AddAddressRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "category": {
+                    "description": "Category classifies the address, e.g. exchange, vault or multisig.",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "exchange"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.AddAddressesRequest": {
+            "type": "object",
+            "required": [
+                "addresses"
+            ],
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.AddAddressRequest"
+                    }
+                }
+            }
+        },
+        "api.AddAddressesResponse": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "description": "Addresses are the added or updated addresses.",
+                    "type": "array",
+                    "items": {}
+                },
+                "duplicates": {
+                    "description": "Duplicates are the addresses that were requested more than once.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "existing": {
+                    "description": "Existing are the addresses that were already tracked and had their label updated.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.AddTokenRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "decimals": {
+                    "description": "Decimals is a pointer since tokens can have 0 decimals. Omitted decimals are looked up.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.AddressRefreshResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Transfers refreshed successfully"
+                },
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TokenFetchSummary"
+                    }
+                }
+            }
+        },
+        "api.ChainsResponse": {
+            "type": "object",
+            "properties": {
+                "chain_id": {
+                    "description": "ChainID is the chain transfers are fetched from.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "chains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/etherscan.Chain"
+                    }
+                }
+            }
+        },
+        "api.ConfigResponse": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "description": "Config has every stored config value by key, with secret values redacted.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "daily_refresh_time": {
+                    "type": "string",
+                    "example": "00:00:00"
+                },
+                "min_refresh_interval_hours": {
+                    "type": "integer"
+                },
+                "refresh_cron": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ConvertAmountRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "decimals"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1500000000000000000"
+                },
+                "decimals": {
+                    "description": "Decimals is a pointer since tokens can have 0 decimals.",
+                    "type": "integer",
+                    "example": 18
+                }
+            }
+        },
+        "api.ConvertRequest": {
+            "type": "object",
+            "required": [
+                "amounts"
+            ],
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/api.ConvertAmountRequest"
+                    }
+                }
+            }
+        },
+        "api.ConvertResponse": {
+            "type": "object",
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ConvertedAmount"
+                    }
+                }
+            }
+        },
+        "api.ConvertedAmount": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1500000000000000000"
+                },
+                "decimals": {
+                    "type": "integer",
+                    "example": 18
+                },
+                "normalized_amount": {
+                    "type": "string",
+                    "example": "1.5"
+                }
+            }
+        },
+        "api.DeleteAddressesRequest": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.DeleteAddressesResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "not_found_addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "not_found_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.DeleteTransfersResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ImportAddressRow": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "description": "Line is the line of the row in the file.",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is added for new addresses, updated for addresses that were already stored, invalid for\nmalformed addresses and failed if the address could not be stored.",
+                    "type": "string",
+                    "enum": [
+                        "added",
+                        "updated",
+                        "invalid",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "api.ImportAddressesResponse": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "Added, Updated, Invalid and Failed count the rows of each status.",
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "Rows is the outcome of each row with an address, in file order.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ImportAddressRow"
+                    }
+                },
+                "type": {
+                    "description": "Type is the type of the imported addresses, source or target.",
+                    "type": "string",
+                    "enum": [
+                        "source",
+                        "target"
+                    ]
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.LogLevelResponse": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string",
+                    "example": "info"
+                }
+            }
+        },
+        "api.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Token deleted successfully"
+                }
+            }
+        },
+        "api.NetAmountsResponse": {
+            "type": "object",
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.NetAmount"
+                    }
+                },
+                "end_time": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RefreshStartedResponse": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "$ref": "#/definitions/service.RefreshJob"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Refresh started"
+                }
+            }
+        },
+        "api.ResponseMeta": {
+            "type": "object",
+            "properties": {
+                "default_range_days": {
+                    "description": "DefaultRangeDays is the number of days of the default time range, set when it applied because\nno start_time was given.",
+                    "type": "integer"
+                },
+                "empty_reason": {
+                    "type": "string"
+                },
+                "stale": {
+                    "description": "Stale is set when the data is due for a refresh that did not complete before responding.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "daily_refresh_time": {
+                    "type": "string",
+                    "example": "00:00:00"
+                },
+                "last_eth_update": {
+                    "description": "LastETHUpdate and LastTokenUpdate are the times of the last successful ETH and ERC20 refresh,\nnil if no refresh succeeded yet.",
+                    "type": "string"
+                },
+                "last_token_update": {
+                    "type": "string"
+                },
+                "min_refresh_interval_hours": {
+                    "type": "integer"
+                },
+                "source_addresses": {
+                    "type": "integer"
+                },
+                "target_addresses": {
+                    "type": "integer"
+                },
+                "tokens": {
+                    "type": "integer"
+                },
+                "transfers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.StreamedTransfer": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "block_number": {
+                    "type": "integer"
+                },
+                "from_address": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "to_address": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "internal"
+                    ]
+                }
+            }
+        },
+        "api.TokenMetadataRefreshResponse": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "description": "Changed is the number of tokens of which any field was updated.",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed is the number of tokens of which the metadata could not be looked up.",
+                    "type": "integer"
+                },
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TokenMetadataRefresh"
+                    }
+                }
+            }
+        },
+        "api.TotalAmountsResponse": {
+            "type": "object",
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.TokenAmount"
+                    }
+                },
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "source_to_target",
+                        "inflow",
+                        "outflow",
+                        "among_sources"
+                    ]
+                },
+                "end_block": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "integer"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.ResponseMeta"
+                },
+                "start_block": {
+                    "description": "StartBlock and EndBlock are the block range, when given.",
+                    "type": "integer"
+                },
+                "start_time": {
+                    "description": "StartTime and EndTime are Unix timestamps, omitted when no time range is applied.",
+                    "type": "integer"
+                },
+                "warnings": {
+                    "description": "Warnings lists the missing configuration when no amounts are returned.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.TransferStreamMessage": {
+            "type": "object",
+            "properties": {
+                "transfers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StreamedTransfer"
+                    }
+                }
+            }
+        },
+        "api.TransfersSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.BucketedAmount"
+                    }
+                },
+                "end_time": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "type": "string",
+                    "enum": [
+                        "day",
+                        "week",
+                        "month"
+                    ]
+                },
+                "start_time": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.UpdateDailyRefreshTimeRequest": {
+            "type": "object",
+            "required": [
+                "time"
+            ],
+            "properties": {
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UpdateLogLevelRequest": {
+            "type": "object",
+            "required": [
+                "level"
+            ],
+            "properties": {
+                "level": {
+                    "description": "Level is the minimum level of logged messages.",
+                    "type": "string",
+                    "enum": [
+                        "debug",
+                        "info",
+                        "warn",
+                        "error"
+                    ],
+                    "example": "debug"
+                }
+            }
+        },
+        "api.UpdateRefreshCronRequest": {
+            "type": "object",
+            "properties": {
+                "cron": {
+                    "description": "Cron is a five-field cron expression, or empty to fall back to the daily refresh times.",
+                    "type": "string"
+                }
+            }
+        },
+        "api.UpdateRefreshIntervalRequest": {
+            "type": "object",
+            "required": [
+                "hours"
+            ],
+            "properties": {
+                "hours": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.UpdateTokenRequest": {
+            "type": "object",
+            "properties": {
+                "decimals": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "etherscan.Chain": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "httputil.CommonError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/httputil.ErrorCode"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors lists the invalid fields of a request body, if known.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httputil.FieldError"
+                    }
+                }
+            }
+        },
+        "httputil.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "INVALID_ID",
+                "INVALID_TIME_RANGE",
+                "INVALID_PARAMETER",
+                "INVALID_ADDRESS",
+                "NOT_FOUND",
+                "TOKEN_EXISTS",
+                "ADDRESS_EXISTS",
+                "RATE_LIMITED",
+                "READ_ONLY",
+                "TIMEOUT",
+                "UNAVAILABLE",
+                "UPSTREAM_ERROR",
+                "UPSTREAM_RATE_LIMITED",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidRequest",
+                "CodeInvalidID",
+                "CodeInvalidTimeRange",
+                "CodeInvalidParameter",
+                "CodeInvalidAddress",
+                "CodeNotFound",
+                "CodeTokenExists",
+                "CodeAddressExists",
+                "CodeRateLimited",
+                "CodeReadOnly",
+                "CodeTimeout",
+                "CodeUnavailable",
+                "CodeUpstreamError",
+                "CodeUpstreamRateLimited",
+                "CodeInternal"
+            ]
+        },
+        "httputil.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Field is the JSON path of the field, e.g. \"addresses[0].address\".",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.AddressRefreshPlan": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "estimated_requests": {
+                    "type": "integer"
+                },
+                "fetches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PlannedFetch"
+                    }
+                },
+                "label": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.FetchKind": {
+            "type": "string",
+            "enum": [
+                "txlist",
+                "txlistinternal",
+                "tokentx"
+            ],
+            "x-enum-varnames": [
+                "FetchKindETH",
+                "FetchKindInternal",
+                "FetchKindERC20"
+            ]
+        },
+        "service.FetchMode": {
+            "type": "string",
+            "enum": [
+                "all",
+                "known-tokens"
+            ],
+            "x-enum-varnames": [
+                "FetchModeAll",
+                "FetchModeKnownTokens"
+            ]
+        },
+        "service.PlannedFetch": {
+            "type": "object",
+            "properties": {
+                "estimated_requests": {
+                    "description": "EstimatedRequests is the expected number of pages requested, at least one.",
+                    "type": "integer"
+                },
+                "expected_transfers": {
+                    "description": "ExpectedTransfers estimates the transfers fetched, from the rate of the stored transfers of the\nlast refresh window and the time since the latest one. It is 0 if no transfers are stored.",
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/service.FetchKind"
+                },
+                "start_block": {
+                    "description": "StartBlock is the block the fetch resumes from, including its confirmation window, 0 if nothing was\nfetched yet.",
+                    "type": "integer"
+                },
+                "token_address": {
+                    "description": "TokenAddress is the token of a tokentx fetch in the known-tokens fetch mode.",
+                    "type": "string"
+                }
+            }
+        },
+        "service.RefreshJob": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/service.RefreshJobStatus"
+                }
+            }
+        },
+        "service.RefreshJobStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "done",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "RefreshJobRunning",
+                "RefreshJobDone",
+                "RefreshJobFailed"
+            ]
+        },
+        "service.RefreshPlan": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.AddressRefreshPlan"
+                    }
+                },
+                "chain_id": {
+                    "type": "integer"
+                },
+                "estimated_requests": {
+                    "type": "integer"
+                },
+                "fetch_mode": {
+                    "$ref": "#/definitions/service.FetchMode"
+                },
+                "min_requests": {
+                    "description": "MinRequests is the number of requests made if every fetch fits in a single page.",
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RefreshProgress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the address being fetched.",
+                    "type": "string"
+                },
+                "addresses": {
+                    "description": "Addresses is the number of source addresses of a started refresh.",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Error is the failure of an address or refresh.",
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "fetched": {
+                    "description": "Fetched is the number of transfers of a fetch to store, and Inserted the number of newly stored ones\nof the fetch, address or refresh.",
+                    "type": "integer"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "Kind and TokenAddress identify the fetch of a page or of stored transfers.",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Page is the 1-based number of a fetched page, and Transactions the number of transactions on it.",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TokenFetchSummary": {
+            "type": "object",
+            "properties": {
+                "fetched": {
+                    "type": "integer"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "token_address": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.TokenListImport": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "Added, Updated and Unchanged count the imported tokens that were new, differed from the stored\ntoken and were updated, or matched the stored token.",
+                    "type": "integer"
+                },
+                "chain_id": {
+                    "description": "ChainID is the chain of which the tokens were imported.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "invalid": {
+                    "description": "Invalid lists the tokens of the chain that were skipped because of an invalid or repeated entry.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TokenListImportError"
+                    }
+                },
+                "skipped": {
+                    "description": "Skipped counts the tokens of other chains and the invalid tokens.",
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TokenListImportError": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "description": "Index is the position of the token in the tokens array of the list.",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TokenMetadataRefresh": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "description": "Changed lists the fields that differed from Etherscan and were updated: symbol, name or decimals.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "description": "Error is set if the metadata could not be looked up, in which case the token is left unchanged.",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is the token after the refresh.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/storage.Token"
+                        }
+                    ]
+                }
+            }
+        },
+        "storage.BucketedAmount": {
+            "type": "object",
+            "properties": {
+                "bucket_start": {
+                    "description": "BucketStart is the start of the bucket in UTC. Weeks start on Monday.",
+                    "type": "string"
+                },
+                "decimals": {
+                    "type": "integer"
+                },
+                "normalized_amount": {
+                    "description": "NormalizedAmount is calculated as TotalAmount / 10^Decimals",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.ConfigHistory": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "new_value": {
+                    "type": "string"
+                },
+                "old_value": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.NetAmount": {
+            "type": "object",
+            "properties": {
+                "decimals": {
+                    "type": "integer"
+                },
+                "inflow": {
+                    "description": "Inflow is the amount received by target addresses, Outflow the amount they sent.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "net_amount": {
+                    "description": "NetAmount is Inflow minus Outflow, negative if more was sent than received.",
+                    "type": "string"
+                },
+                "normalized_net_amount": {
+                    "description": "NormalizedNetAmount is calculated as NetAmount / 10^Decimals",
+                    "type": "string"
+                },
+                "outflow": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.RefreshRun": {
+            "type": "object",
+            "properties": {
+                "duration_seconds": {
+                    "description": "DurationSeconds is nil while the refresh is running.",
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_addresses": {
+                    "description": "FailedAddresses are the source addresses of which any fetch failed.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inserted": {
+                    "description": "Inserted is the number of newly stored transfers.",
+                    "type": "integer"
+                },
+                "source_addresses": {
+                    "description": "SourceAddresses is the number of source addresses fetched.",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.SourceAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.TargetAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Token": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "decimals": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.TokenAmount": {
+            "type": "object",
+            "properties": {
+                "decimals": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "normalized_amount": {
+                    "description": "NormalizedAmount is calculated as TotalAmount / 10^Decimals, empty when the decimals are unknown",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "description": "TotalAmount is the sum of the raw amounts, empty when only the normalized amount is requested",
+                    "type": "string"
+                },
+                "usd_value": {
+                    "description": "USDValue is NormalizedAmount valued in USD, empty when no price is available",
+                    "type": "string"
+                }
+            }
+        },
+        "storage.UncataloguedToken": {
+            "type": "object",
+            "properties": {
+                "last_transfer": {
+                    "type": "string"
+                },
+                "token_address": {
+                    "type": "string"
+                },
+                "transfer_count": {
+                    "type": "integer"
+                }
+            }
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0",
+	Host:             "",
+	BasePath:         "/api",
+	Schemes:          []string{},
+	Title:            "Transfer Track API",
+	Description:      "Tracks ETH and ERC20 transfers from source addresses to target addresses via Etherscan.",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -0,0 +1,282 @@
+// Package ens provides ENS reverse resolution of Ethereum addresses over a JSON-RPC endpoint.
+package ens
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+
+	// registryAddress is the address of the ENS registry on Ethereum Mainnet.
+	registryAddress = "0x00000000000c2e074ec69a0dfb2997ba6c7d2e1e"
+	// reverseSuffix is the name under which reverse records of addresses are stored.
+	reverseSuffix = "addr.reverse"
+
+	// Function selectors of the registry and resolver methods.
+	selectorResolver = "0178b8bf" // resolver(bytes32)
+	selectorName     = "691f3431" // name(bytes32)
+	selectorAddr     = "3b3b57de" // addr(bytes32)
+
+	// wordSize is the size of an ABI-encoded word in bytes.
+	wordSize = 32
+)
+
+// ErrNameNotFound is returned when an address has no verified ENS reverse record.
+var ErrNameNotFound = errors.New("ens name not found")
+
+// Resolver resolves the primary ENS name of addresses via eth_call on a JSON-RPC endpoint.
+type Resolver struct {
+	rpcURL     string
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+	requestID  atomic.Int64
+}
+
+// NewResolver creates a new Resolver querying the Ethereum Mainnet JSON-RPC endpoint at rpcURL.
+func NewResolver(rpcURL string, logger *zap.SugaredLogger) *Resolver {
+	return &Resolver{
+		rpcURL:     rpcURL,
+		httpClient: &http.Client{Timeout: defaultRequestTimeout},
+		logger:     logger,
+	}
+}
+
+// LookupName returns the primary ENS name of address.
+// The name is only returned if it resolves back to address, so an address cannot claim a name it does not own.
+func (r *Resolver) LookupName(ctx context.Context, address string) (string, error) {
+	address = strings.ToLower(address)
+
+	reverseNode := namehash(strings.TrimPrefix(address, "0x") + "." + reverseSuffix)
+
+	name, err := r.resolveString(ctx, reverseNode, selectorName)
+	if err != nil {
+		return "", fmt.Errorf("resolving reverse record: %w", err)
+	}
+
+	if name == "" {
+		return "", ErrNameNotFound
+	}
+
+	// Verify the forward record
+	resolved, err := r.resolveAddress(ctx, namehash(name))
+	if err != nil {
+		return "", fmt.Errorf("resolving forward record of %s: %w", name, err)
+	}
+
+	if resolved != address {
+		r.logger.Debugw("ENS reverse record does not resolve back", "address", address, "name", name, "resolved", resolved)
+		return "", ErrNameNotFound
+	}
+
+	return name, nil
+}
+
+// resolveString calls a resolver method returning a string for node, e.g. name(bytes32).
+// It returns an empty string if node has no resolver.
+func (r *Resolver) resolveString(ctx context.Context, node [32]byte, selector string) (string, error) {
+	resolver, err := r.resolver(ctx, node)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	result, err := r.call(ctx, resolver, selector, node)
+	if err != nil {
+		return "", err
+	}
+
+	return decodeString(result)
+}
+
+// resolveAddress calls addr(bytes32) on the resolver of node.
+// It returns an empty string if node has no resolver or address.
+func (r *Resolver) resolveAddress(ctx context.Context, node [32]byte) (string, error) {
+	resolver, err := r.resolver(ctx, node)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	result, err := r.call(ctx, resolver, selectorAddr, node)
+	if err != nil {
+		return "", err
+	}
+
+	return decodeAddress(result)
+}
+
+// resolver returns the resolver address of node from the registry, or an empty string if none is set.
+func (r *Resolver) resolver(ctx context.Context, node [32]byte) (string, error) {
+	result, err := r.call(ctx, registryAddress, selectorResolver, node)
+	if err != nil {
+		return "", fmt.Errorf("getting resolver: %w", err)
+	}
+
+	return decodeAddress(result)
+}
+
+// rpcRequest is a JSON-RPC 2.0 request.
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response.
+type rpcResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call performs an eth_call of the method with the given selector and a single bytes32 argument.
+func (r *Resolver) call(ctx context.Context, to, selector string, node [32]byte) ([]byte, error) {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      r.requestID.Add(1),
+		Method:  "eth_call",
+		Params: []any{
+			map[string]string{
+				"to":   to,
+				"data": "0x" + selector + hex.EncodeToString(node[:]),
+			},
+			"latest",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			r.logger.Warnw("Failed to close response body", "err", closeErr)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response rpcResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("unmarshaling response: %w", err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("rpc error %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	result, err := hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decoding result: %w", err)
+	}
+
+	return result, nil
+}
+
+// namehash computes the ENS namehash of a name.
+func namehash(name string) [32]byte {
+	var node [32]byte
+
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		labelHash := keccak256([]byte(labels[i]))
+		node = keccak256(node[:], labelHash[:])
+	}
+
+	return node
+}
+
+// keccak256 returns the Keccak-256 hash of the concatenated data.
+func keccak256(data ...[]byte) [32]byte {
+	var hash [32]byte
+
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		_, _ = h.Write(d) // never returns an error
+	}
+
+	copy(hash[:], h.Sum(nil))
+
+	return hash
+}
+
+// decodeAddress decodes an ABI-encoded address. The zero address and an empty result decode to "".
+func decodeAddress(result []byte) (string, error) {
+	if len(result) == 0 {
+		return "", nil
+	}
+
+	if len(result) < wordSize {
+		return "", fmt.Errorf("address result too short: %d bytes", len(result))
+	}
+
+	address := result[wordSize-20 : wordSize]
+	if bytes.Equal(address, make([]byte, 20)) {
+		return "", nil
+	}
+
+	return "0x" + hex.EncodeToString(address), nil
+}
+
+// decodeString decodes an ABI-encoded dynamic string. An empty result decodes to "".
+func decodeString(result []byte) (string, error) {
+	if len(result) == 0 {
+		return "", nil
+	}
+
+	if len(result) < 2*wordSize {
+		return "", fmt.Errorf("string result too short: %d bytes", len(result))
+	}
+
+	offset := binary.BigEndian.Uint64(result[wordSize-8 : wordSize])
+	if offset > uint64(len(result)-wordSize) {
+		return "", fmt.Errorf("string offset %d out of range", offset)
+	}
+
+	length := binary.BigEndian.Uint64(result[offset+wordSize-8 : offset+wordSize])
+	if length > uint64(len(result))-offset-wordSize {
+		return "", fmt.Errorf("string length %d out of range", length)
+	}
+
+	start := offset + wordSize
+
+	return string(result[start : start+length]), nil
+}
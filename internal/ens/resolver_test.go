@@ -0,0 +1,151 @@
+package ens
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const (
+	testAddress  = "0x00000000000000000000000000000000000000a1"
+	testResolver = "0x00000000000000000000000000000000000000e1"
+)
+
+// encodeAddress ABI-encodes address as a word.
+func encodeAddress(t *testing.T, address string) []byte {
+	t.Helper()
+
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil {
+		t.Fatalf("decoding address %s: %v", address, err)
+	}
+
+	word := make([]byte, wordSize)
+	copy(word[wordSize-len(raw):], raw)
+
+	return word
+}
+
+// encodeString ABI-encodes s as a dynamic string.
+func encodeString(s string) []byte {
+	padded := (len(s) + wordSize - 1) / wordSize * wordSize
+
+	result := make([]byte, 2*wordSize+padded)
+	binary.BigEndian.PutUint64(result[wordSize-8:wordSize], wordSize)
+	binary.BigEndian.PutUint64(result[2*wordSize-8:2*wordSize], uint64(len(s)))
+	copy(result[2*wordSize:], s)
+
+	return result
+}
+
+// newRPCServer returns a resolver querying a JSON-RPC stub, whose resolver has the reverse record name
+// and the forward record forward for every node.
+func newRPCServer(t *testing.T, name, forward string) *Resolver {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		if err := json.Unmarshal(req.Params[0], &call); err != nil {
+			t.Errorf("decoding call: %v", err)
+			return
+		}
+
+		var result []byte
+
+		switch selector := strings.TrimPrefix(call.Data, "0x")[:8]; {
+		case call.To == registryAddress && selector == selectorResolver:
+			result = encodeAddress(t, testResolver)
+		case call.To == testResolver && selector == selectorName:
+			result = encodeString(name)
+		case call.To == testResolver && selector == selectorAddr:
+			result = encodeAddress(t, forward)
+		default:
+			t.Errorf("unexpected call of %s on %s", selector, call.To)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  "0x" + hex.EncodeToString(result),
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewResolver(srv.URL, zap.NewNop().Sugar())
+}
+
+func TestLookupName(t *testing.T) {
+	tests := []struct {
+		msg     string
+		name    string
+		forward string
+		want    string
+		wantErr error
+	}{
+		{msg: "verified name", name: "alice.eth", forward: testAddress, want: "alice.eth"},
+		{msg: "no reverse record", name: "", forward: testAddress, wantErr: ErrNameNotFound},
+		{
+			msg:     "forward record of another address",
+			name:    "alice.eth",
+			forward: "0x00000000000000000000000000000000000000b2",
+			wantErr: ErrNameNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.msg, func(t *testing.T) {
+			r := newRPCServer(t, tc.name, tc.forward)
+
+			got, err := r.LookupName(context.Background(), testAddress)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("LookupName error = %v, want %v", err, tc.wantErr)
+			}
+
+			if got != tc.want {
+				t.Errorf("LookupName = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":    "0000000000000000000000000000000000000000000000000000000000000000",
+		"eth": "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+	}
+
+	for name, want := range tests {
+		node := namehash(name)
+		if got := hex.EncodeToString(node[:]); got != want {
+			t.Errorf("namehash(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestDecodeStringOutOfRange(t *testing.T) {
+	result := encodeString("alice.eth")
+	binary.BigEndian.PutUint64(result[2*wordSize-8:2*wordSize], 1000)
+
+	if _, err := decodeString(result); err == nil {
+		t.Error("decodeString of an overlong length succeeded, want an error")
+	}
+}
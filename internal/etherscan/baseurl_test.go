@@ -0,0 +1,69 @@
+package etherscan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewClientWithConfigBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+		wantErr bool
+	}{
+		{baseURL: "", want: DefaultBaseURL},
+		{baseURL: "https://blockscout.example.com/api", want: "https://blockscout.example.com/api"},
+		{baseURL: "http://localhost:4000/api", want: "http://localhost:4000/api"},
+		{baseURL: "ftp://blockscout.example.com/api", wantErr: true},
+		{baseURL: "/api", wantErr: true},
+		{baseURL: "https:///api", wantErr: true},
+		{baseURL: "://missing-scheme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			client, err := NewClientWithConfig(Config{APIKey: "test", BaseURL: tt.baseURL}, zap.NewNop().Sugar())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected base URL %q to be rejected", tt.baseURL)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("creating client: %v", err)
+			}
+
+			if client.baseURL != tt.want {
+				t.Fatalf("expected base URL %q, got %q", tt.want, client.baseURL)
+			}
+		})
+	}
+}
+
+func TestRequestsUseBaseURL(t *testing.T) {
+	const tokenAddress = "0x00000000000000000000000000000000000000c3"
+
+	var path string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		writeResponse(t, w, "1", "OK", []ERC20Transaction{{ContractAddress: tokenAddress, TokenDecimal: "6"}})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := newTestClient(t, Config{BaseURL: srv.URL + "/explorer/api"}, nil)
+
+	if _, err := client.GetTokenInfo(context.Background(), tokenAddress); err != nil {
+		t.Fatalf("getting token info: %v", err)
+	}
+
+	if path != "/explorer/api" {
+		t.Fatalf("expected the request on the base URL path /explorer/api, got %q", path)
+	}
+}
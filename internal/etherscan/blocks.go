@@ -0,0 +1,107 @@
+package etherscan
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	moduleBlock          = "block"
+	actionGetBlockReward = "getblockreward"
+
+	// maxCachedBlockTimestamps bounds the block timestamp cache, about 40 bytes per block.
+	maxCachedBlockTimestamps = 100000
+)
+
+// blockTimestamps caches the timestamps of blocks seen in Etherscan responses.
+type blockTimestamps struct {
+	mu    sync.Mutex
+	times map[int64]time.Time
+}
+
+func newBlockTimestamps() *blockTimestamps {
+	return &blockTimestamps{times: make(map[int64]time.Time)}
+}
+
+// get returns the cached timestamp of a block.
+func (b *blockTimestamps) get(block int64) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.times[block]
+
+	return t, ok
+}
+
+// add caches the timestamp of a block. When the cache is full, an arbitrary block is evicted.
+func (b *blockTimestamps) add(block int64, t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.times[block]; !ok && len(b.times) >= maxCachedBlockTimestamps {
+		for evicted := range b.times {
+			delete(b.times, evicted)
+			break
+		}
+	}
+
+	b.times[block] = t
+}
+
+// addRaw caches the timestamp of a block as returned by Etherscan: decimal strings of the block
+// number and of the Unix timestamp. It returns the parsed timestamp.
+func (b *blockTimestamps) addRaw(blockNumber, unixTimeStamp string) (time.Time, error) {
+	timestamp, err := strconv.ParseInt(unixTimeStamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing timestamp %q: %w", unixTimeStamp, err)
+	}
+
+	t := time.Unix(timestamp, 0)
+
+	// Transactions without a valid block number are not cached, but still have a timestamp
+	if block, err := strconv.ParseInt(blockNumber, 10, 64); err == nil {
+		b.add(block, t)
+	}
+
+	return t, nil
+}
+
+// blockReward is the result of the getblockreward action, of which only the timestamp is used.
+type blockReward struct {
+	BlockNumber string `json:"blockNumber"`
+	TimeStamp   string `json:"timeStamp"`
+}
+
+// BlockTimestamp returns the time a block was mined. Blocks seen in fetched transactions are served
+// from a cache, others are looked up on Etherscan and cached.
+func (c *Client) BlockTimestamp(ctx context.Context, block int64) (time.Time, error) {
+	if t, ok := c.blockTimes.get(block); ok {
+		return t, nil
+	}
+
+	params := url.Values{}
+	params.Add("module", moduleBlock)
+	params.Add("action", actionGetBlockReward)
+	params.Add("blockno", strconv.FormatInt(block, 10))
+	params.Add("chainid", strconv.Itoa(c.chainID))
+
+	var reward blockReward
+	if err := c.doRequestWithRetry(ctx, params, &reward); err != nil {
+		return time.Time{}, fmt.Errorf("getting block %d: %w", block, err)
+	}
+
+	if reward.TimeStamp == "" {
+		return time.Time{}, fmt.Errorf("getting block %d: no timestamp returned", block)
+	}
+
+	t, err := c.blockTimes.addRaw(strconv.FormatInt(block, 10), reward.TimeStamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting block %d: %w", block, err)
+	}
+
+	return t, nil
+}
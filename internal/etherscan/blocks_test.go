@@ -0,0 +1,126 @@
+package etherscan
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockHandler serves the test ETH transactions and the block rewards of other blocks, mined an hour
+// per block after testTime, counting the block reward requests.
+func blockHandler(t *testing.T, transactions []ETHTransaction, rewardCalls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch query.Get("action") {
+		case actionGetBlockReward:
+			rewardCalls.Add(1)
+
+			block, err := strconv.ParseInt(query.Get("blockno"), 10, 64)
+			if err != nil {
+				t.Errorf("parsing block number %q: %v", query.Get("blockno"), err)
+			}
+
+			writeResponse(t, w, "1", "OK", blockReward{
+				BlockNumber: query.Get("blockno"),
+				TimeStamp:   strconv.FormatInt(testTime.Add(time.Duration(block)*time.Hour).Unix(), 10),
+			})
+		default:
+			writeResponse(t, w, "1", "OK", transactions)
+		}
+	}
+}
+
+func TestBlockTimestampCacheHit(t *testing.T) {
+	var rewardCalls atomic.Int32
+
+	transactions := ethTransactions(3)
+	client := newTestClient(t, Config{}, blockHandler(t, transactions, &rewardCalls))
+	ctx := context.Background()
+
+	if _, err := client.GetETHTransfers(ctx, testAddress, testTime.Add(-time.Hour), testTime.Add(time.Hour), 0); err != nil {
+		t.Fatalf("getting ETH transfers: %v", err)
+	}
+
+	// The blocks of the fetched transactions are served from the cache
+	for i, tx := range transactions {
+		block, _ := strconv.ParseInt(tx.BlockNumber, 10, 64)
+
+		got, err := client.BlockTimestamp(ctx, block)
+		if err != nil {
+			t.Fatalf("getting timestamp of block %d: %v", block, err)
+		}
+
+		if want := testTime.Add(time.Duration(i) * time.Second); !got.Equal(want) {
+			t.Fatalf("expected block %d at %s, got %s", block, want, got)
+		}
+	}
+
+	if rewardCalls.Load() != 0 {
+		t.Fatalf("expected cached blocks not to be looked up, got %d requests", rewardCalls.Load())
+	}
+}
+
+func TestBlockTimestampCacheMiss(t *testing.T) {
+	var rewardCalls atomic.Int32
+
+	client := newTestClient(t, Config{}, blockHandler(t, nil, &rewardCalls))
+	ctx := context.Background()
+	want := testTime.Add(500 * time.Hour)
+
+	// The first lookup of a block misses the cache, the following ones hit it
+	for range 3 {
+		got, err := client.BlockTimestamp(ctx, 500)
+		if err != nil {
+			t.Fatalf("getting timestamp of block 500: %v", err)
+		}
+
+		if !got.Equal(want) {
+			t.Fatalf("expected block 500 at %s, got %s", want, got)
+		}
+	}
+
+	if rewardCalls.Load() != 1 {
+		t.Fatalf("expected a single block lookup, got %d", rewardCalls.Load())
+	}
+}
+
+func TestBlockTimestampWithoutTimestamp(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		writeResponse(t, w, "1", "OK", blockReward{BlockNumber: "500"})
+	})
+
+	for range 2 {
+		if _, err := client.BlockTimestamp(context.Background(), 500); err == nil {
+			t.Fatal("expected an error for a block without timestamp")
+		}
+	}
+
+	// Failed lookups are not cached
+	if calls.Load() != 2 {
+		t.Fatalf("expected every lookup to be retried, got %d requests", calls.Load())
+	}
+}
+
+func TestBlockTimestampsBounded(t *testing.T) {
+	cache := newBlockTimestamps()
+
+	for block := range int64(maxCachedBlockTimestamps + 10) {
+		cache.add(block, testTime)
+	}
+
+	if len(cache.times) != maxCachedBlockTimestamps {
+		t.Fatalf("expected the cache to hold %d blocks, got %d", maxCachedBlockTimestamps, len(cache.times))
+	}
+
+	// The latest block is always cached
+	if _, ok := cache.get(maxCachedBlockTimestamps + 9); !ok {
+		t.Fatal("expected the latest block to be cached")
+	}
+}
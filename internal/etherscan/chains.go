@@ -0,0 +1,61 @@
+package etherscan
+
+import (
+	"maps"
+	"slices"
+)
+
+// Chain is a blockchain served by the Etherscan API v2.
+type Chain struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// supportedChains maps the chain IDs served by the Etherscan API v2 to their names.
+var supportedChains = map[int]string{
+	1:        "Ethereum Mainnet",
+	10:       "OP Mainnet",
+	56:       "BNB Smart Chain Mainnet",
+	97:       "BNB Smart Chain Testnet",
+	100:      "Gnosis",
+	137:      "Polygon Mainnet",
+	146:      "Sonic Mainnet",
+	204:      "opBNB Mainnet",
+	252:      "Fraxtal Mainnet",
+	324:      "zkSync Mainnet",
+	1284:     "Moonbeam Mainnet",
+	1285:     "Moonriver Mainnet",
+	5000:     "Mantle Mainnet",
+	8453:     "Base Mainnet",
+	17000:    "Holesky Testnet",
+	42161:    "Arbitrum One Mainnet",
+	42170:    "Arbitrum Nova Mainnet",
+	42220:    "Celo Mainnet",
+	43113:    "Avalanche Fuji Testnet",
+	43114:    "Avalanche C-Chain",
+	59144:    "Linea Mainnet",
+	80002:    "Polygon Amoy Testnet",
+	81457:    "Blast Mainnet",
+	84532:    "Base Sepolia Testnet",
+	421614:   "Arbitrum Sepolia Testnet",
+	534352:   "Scroll Mainnet",
+	11155111: "Sepolia Testnet",
+	11155420: "OP Sepolia Testnet",
+}
+
+// ChainName returns the name of the chain with the given ID and whether Etherscan serves it.
+func ChainName(chainID int) (string, bool) {
+	name, ok := supportedChains[chainID]
+
+	return name, ok
+}
+
+// SupportedChains returns the chains served by the Etherscan API v2, ordered by ID.
+func SupportedChains() []Chain {
+	chains := make([]Chain, 0, len(supportedChains))
+	for _, id := range slices.Sorted(maps.Keys(supportedChains)) {
+		chains = append(chains, Chain{ID: id, Name: supportedChains[id]})
+	}
+
+	return chains
+}
@@ -0,0 +1,43 @@
+package etherscan
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChainName(t *testing.T) {
+	tests := []struct {
+		chainID int
+		want    string
+		wantOK  bool
+	}{
+		{chainID: 1, want: "Ethereum Mainnet", wantOK: true},
+		{chainID: 11155111, want: "Sepolia Testnet", wantOK: true},
+		{chainID: 999999, wantOK: false},
+		{chainID: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		name, ok := ChainName(tt.chainID)
+		if name != tt.want || ok != tt.wantOK {
+			t.Errorf("ChainName(%d) = %q, %v, want %q, %v", tt.chainID, name, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSupportedChains(t *testing.T) {
+	chains := SupportedChains()
+	if len(chains) != len(supportedChains) {
+		t.Fatalf("expected %d chains, got %d", len(supportedChains), len(chains))
+	}
+
+	if !slices.IsSortedFunc(chains, func(a, b Chain) int { return a.ID - b.ID }) {
+		t.Fatalf("expected the chains to be ordered by ID, got %v", chains)
+	}
+
+	for _, chain := range chains {
+		if name, ok := ChainName(chain.ID); !ok || name != chain.Name {
+			t.Errorf("expected chain %d to be named %q, got %q", chain.ID, name, chain.Name)
+		}
+	}
+}
@@ -4,50 +4,117 @@ package etherscan
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 )
 
 const (
-	baseURL               = "https://api.etherscan.io/v2/api"
+	// DefaultBaseURL is the URL of the Etherscan API v2.
+	DefaultBaseURL = "https://api.etherscan.io/v2/api"
+
 	moduleAccount         = "account"
 	actionTxList          = "txlist"
 	actionTokenTx         = "tokentx"
+	actionTxListInternal  = "txlistinternal"
 	defaultStartBlock     = 0
 	defaultEndBlock       = 999999999
 	defaultOffset         = 10000
+	maxOffset             = 10000 // Etherscan rejects larger page sizes
 	defaultPage           = 1
 	maxRequestsPerSecond  = 5
 	requestIntervalMs     = 1000 / maxRequestsPerSecond
 	defaultRequestTimeout = 10 * time.Second
 	defaultChainID        = 1 // Ethereum Mainnet
+
+	// DefaultPageSize is the default number of transactions requested per page.
+	DefaultPageSize = defaultOffset
+	// DefaultRequestTimeout is the default timeout of a single HTTP request.
+	DefaultRequestTimeout = defaultRequestTimeout
+	// DefaultRateLimitRetries is the default number of retries after Etherscan reports a rate limit.
+	DefaultRateLimitRetries = 3
+	// DefaultRateLimitCooldown is the default wait before retrying a rate limited request.
+	DefaultRateLimitCooldown = 2 * time.Second
+
+	rateLimitMessage      = "Max rate limit reached"
+	noTransactionsMessage = "No transactions found"
 )
 
+// ErrTokenNotFound is returned when no metadata can be found for a token contract.
+var ErrTokenNotFound = errors.New("token not found")
+
+// ErrRateLimited is returned when Etherscan rejects a request with "Max rate limit reached"
+// and all retries are exhausted.
+var ErrRateLimited = errors.New("etherscan rate limit reached")
+
+// Config holds the Etherscan API client configuration.
+type Config struct {
+	APIKey string
+	// APIKeys are additional API keys. Requests are spread across all keys, each within its own rate
+	// limit, and a rate limited key is rested while the others are used.
+	APIKeys []string
+	ChainID int
+	// RateLimitRetries is the number of times a request is retried after Etherscan reports
+	// "Max rate limit reached". Zero disables retries.
+	RateLimitRetries int
+	// RateLimitCooldown is the wait before the first retry of a rate limited request,
+	// it doubles on every following retry.
+	RateLimitCooldown time.Duration
+	// PageSize is the number of transactions requested per page, clamped to 10000.
+	PageSize int
+	// RequestTimeout bounds each HTTP request, independently of the deadline of the whole fetch.
+	RequestTimeout time.Duration
+	// BaseURL is the API endpoint, e.g. of a self-hosted Blockscout or another Etherscan-compatible explorer.
+	BaseURL string
+	// ProxyURL is an http, https or socks5 proxy to send requests through. Empty uses the proxy of
+	// the HTTP_PROXY and HTTPS_PROXY environment variables, if any.
+	ProxyURL string
+	// RootCAFile is a PEM file of root CAs trusted in addition to the system ones, e.g. of a TLS
+	// intercepting proxy.
+	RootCAFile string
+	// InsecureSkipVerify disables the verification of TLS certificates. For development only.
+	InsecureSkipVerify bool
+	// MaxIdleConnsPerHost is the number of idle connections kept open to the API between requests.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection to the API is kept open.
+	IdleConnTimeout time.Duration
+}
+
 // Client represents an Etherscan API client.
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	baseURL    string
-	logger     *zap.SugaredLogger
-	lastReq    time.Time
-	chainID    int
+	keys              *keyPool
+	httpClient        *http.Client
+	baseURL           string
+	logger            *zap.SugaredLogger
+	chainID           int
+	rateLimitRetries  int
+	rateLimitCooldown time.Duration
+	rateLimitedCount  atomic.Int64
+	pageSize          int
+	blockTimes        *blockTimestamps
 }
 
 // NewClient creates a new Etherscan API client.
 func NewClient(apiKey string, logger *zap.SugaredLogger) *Client {
-	return &Client{
-		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: defaultRequestTimeout},
-		baseURL:    baseURL,
-		logger:     logger,
-		chainID:    defaultChainID,
+	cfg := Config{
+		APIKey:            apiKey,
+		ChainID:           defaultChainID,
+		RateLimitRetries:  DefaultRateLimitRetries,
+		RateLimitCooldown: DefaultRateLimitCooldown,
+		PageSize:          defaultOffset,
+		RequestTimeout:    defaultRequestTimeout,
+		BaseURL:           DefaultBaseURL,
 	}
+
+	return newClient(cfg, newTransport(cfg), logger)
 }
 
 // NewClientWithChainID creates a new Etherscan API client with a specific chain ID.
@@ -58,6 +125,112 @@ func NewClientWithChainID(apiKey string, logger *zap.SugaredLogger, chainID int)
 	return client
 }
 
+// NewClientWithConfig creates a new Etherscan API client from the given configuration.
+// Unset chain ID, cooldown, page size, request timeout, base URL and connection pool settings fall back
+// to their defaults.
+// It returns an error if the base URL is not an absolute HTTP(S) URL, if the proxy URL is invalid
+// or if the root CA file cannot be loaded.
+func NewClientWithConfig(cfg Config, logger *zap.SugaredLogger) (*Client, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+
+	if err := validateBaseURL(cfg.BaseURL); err != nil {
+		return nil, err
+	}
+
+	transport := newTransport(cfg)
+	if err := configureProxyAndTLS(transport, cfg); err != nil {
+		return nil, err
+	}
+
+	client := newClient(cfg, transport, logger)
+
+	if cfg.InsecureSkipVerify {
+		logger.Warnw("TLS certificate verification of Etherscan requests is disabled, do not use in production")
+	}
+
+	return client, nil
+}
+
+// validateBaseURL checks that baseURL is an absolute HTTP(S) URL.
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("parsing base URL: %w", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid base URL %q, expected an absolute http or https URL", baseURL)
+	}
+
+	return nil
+}
+
+// newClient creates a new Etherscan API client from a configuration with a valid base URL, sending
+// requests with transport.
+func newClient(cfg Config, transport *http.Transport, logger *zap.SugaredLogger) *Client {
+	if cfg.ChainID <= 0 {
+		cfg.ChainID = defaultChainID
+	}
+
+	if cfg.RateLimitCooldown <= 0 {
+		cfg.RateLimitCooldown = DefaultRateLimitCooldown
+	}
+
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+
+	keys := cfg.APIKeys
+	if cfg.APIKey != "" {
+		keys = append([]string{cfg.APIKey}, keys...)
+	}
+
+	client := &Client{
+		keys:              newKeyPool(keys),
+		httpClient:        &http.Client{Timeout: cfg.RequestTimeout, Transport: transport},
+		baseURL:           cfg.BaseURL,
+		logger:            logger,
+		chainID:           cfg.ChainID,
+		rateLimitRetries:  max(cfg.RateLimitRetries, 0),
+		rateLimitCooldown: cfg.RateLimitCooldown,
+		blockTimes:        newBlockTimestamps(),
+	}
+	client.SetPageSize(cfg.PageSize)
+
+	return client
+}
+
+// SetPageSize sets the number of transactions requested per page.
+// Non-positive values fall back to the default and values above Etherscan's maximum are clamped.
+func (c *Client) SetPageSize(pageSize int) {
+	switch {
+	case pageSize <= 0:
+		pageSize = defaultOffset
+	case pageSize > maxOffset:
+		c.logger.Warnw("Etherscan page size too large, clamping", "pageSize", pageSize, "max", maxOffset)
+		pageSize = maxOffset
+	}
+
+	c.pageSize = pageSize
+}
+
+// PageSize returns the number of transactions requested per page.
+func (c *Client) PageSize() int {
+	return c.pageSize
+}
+
+// ChainID returns the chain ID the client queries.
+func (c *Client) ChainID() int {
+	return c.chainID
+}
+
+// RateLimitedCount returns how many responses were rejected by Etherscan with "Max rate limit reached".
+func (c *Client) RateLimitedCount() int64 {
+	return c.rateLimitedCount.Load()
+}
+
 // Response represents the standard response format from Etherscan API.
 type Response struct {
 	Status  string          `json:"status"`
@@ -99,95 +272,96 @@ type ERC20Transaction struct {
 	Confirmations     string `json:"confirmations"`
 }
 
-// fetchTransactions.go contains helper functions to fetch transactions from Etherscan API.
-
-// fetchTransactions is a helper function to fetch ETH transactions from Etherscan API.
-// It handles pagination, filtering by timestamp, and rate limiting.
-func (c *Client) fetchETHTransactions(
-	ctx context.Context,
-	params url.Values,
-	startTime, endTime time.Time,
-) ([]ETHTransaction, error) {
-	// Preallocate with a reasonable initial capacity
-	allTransactions := make([]ETHTransaction, 0, defaultOffset)
-	page := defaultPage
-	offset := defaultOffset
-
-	for {
-		params.Set("page", strconv.Itoa(page))
-		params.Set("offset", strconv.Itoa(offset))
-
-		var transactions []ETHTransaction
-		err := c.doRequest(ctx, params, &transactions)
-
-		if err != nil {
-			return nil, err
-		}
-
-		// Filter by timestamp with preallocated capacity
-		filteredTxs := make([]ETHTransaction, 0, len(transactions))
-
-		for _, tx := range transactions {
-			timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
-			if err != nil {
-				c.logger.Warnw("Failed to parse timestamp", "err", err, "timestamp", tx.TimeStamp)
-				continue
-			}
+// TokenInfo holds the metadata of an ERC20 token.
+type TokenInfo struct {
+	Symbol   string
+	Name     string
+	Decimals int
+}
 
-			txTime := time.Unix(timestamp, 0)
-			if (txTime.After(startTime) || txTime.Equal(startTime)) && (txTime.Before(endTime) || txTime.Equal(endTime)) {
-				filteredTxs = append(filteredTxs, tx)
-			}
-		}
+// InternalTransaction represents an internal transaction (trace) moving ETH from Etherscan API.
+type InternalTransaction struct {
+	BlockNumber     string `json:"blockNumber"`
+	TimeStamp       string `json:"timeStamp"`
+	Hash            string `json:"hash"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Value           string `json:"value"`
+	ContractAddress string `json:"contractAddress"`
+	Type            string `json:"type"`
+	TraceID         string `json:"traceId"`
+	IsError         string `json:"isError"`
+	ErrCode         string `json:"errCode"`
+}
 
-		allTransactions = append(allTransactions, filteredTxs...)
+// transaction is implemented by the transaction types returned by the Etherscan list actions.
+type transaction interface {
+	// unixTimeStamp returns the Unix timestamp of the transaction as returned by Etherscan.
+	unixTimeStamp() string
+	// blockNumber returns the block number of the transaction as returned by Etherscan.
+	blockNumber() string
+}
 
-		// If we got less than the requested offset, we've reached the end
-		if len(transactions) < offset {
-			break
-		}
+func (tx ETHTransaction) unixTimeStamp() string      { return tx.TimeStamp }
+func (tx ERC20Transaction) unixTimeStamp() string    { return tx.TimeStamp }
+func (tx InternalTransaction) unixTimeStamp() string { return tx.TimeStamp }
 
-		page++
-		c.rateLimit() // Rate limit between pagination requests
-	}
+func (tx ETHTransaction) blockNumber() string      { return tx.BlockNumber }
+func (tx ERC20Transaction) blockNumber() string    { return tx.BlockNumber }
+func (tx InternalTransaction) blockNumber() string { return tx.BlockNumber }
 
-	return allTransactions, nil
-}
-
-// fetchERC20Transactions is a helper function to fetch ERC20 transactions from Etherscan API.
-// It handles pagination, filtering by timestamp, and rate limiting.
-func (c *Client) fetchERC20Transactions(
+// fetchTransactions is a helper function to fetch transactions from Etherscan API.
+// It handles pagination, filtering by timestamp, and rate limiting. Transactions with an invalid
+// timestamp are returned as is, since whether they are in range is unknown, for the caller to skip;
+// dropping them would make their transfers look removed. Fetched pages are reported to the PageFunc
+// of ctx, if any.
+func fetchTransactions[T transaction](
 	ctx context.Context,
+	c *Client,
 	params url.Values,
 	startTime, endTime time.Time,
-) ([]ERC20Transaction, error) {
+) ([]T, error) {
 	// Preallocate with a reasonable initial capacity
-	allTransactions := make([]ERC20Transaction, 0, defaultOffset)
+	allTransactions := make([]T, 0, c.pageSize)
 	page := defaultPage
-	offset := defaultOffset
+	offset := c.pageSize
+	onPage := pageFuncFrom(ctx)
 
 	for {
 		params.Set("page", strconv.Itoa(page))
 		params.Set("offset", strconv.Itoa(offset))
 
-		var transactions []ERC20Transaction
-		err := c.doRequest(ctx, params, &transactions)
+		var transactions []T
+		err := c.doRequestWithRetry(ctx, params, &transactions)
+
 		if err != nil {
 			return nil, err
 		}
 
+		if onPage != nil {
+			onPage(page, len(transactions))
+		}
+
 		// Filter by timestamp with preallocated capacity
-		filteredTxs := make([]ERC20Transaction, 0, len(transactions))
+		filteredTxs := make([]T, 0, len(transactions))
+		pastEndTime := false
 
 		for _, tx := range transactions {
-			timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
+			// Caching the block timestamps saves lookups of blocks without timestamp in other payloads
+			txTime, err := c.blockTimes.addRaw(tx.blockNumber(), tx.unixTimeStamp())
 			if err != nil {
-				c.logger.Warnw("Failed to parse timestamp", "err", err, "timestamp", tx.TimeStamp)
+				c.logger.Warnw("Failed to parse timestamp", "err", err, "timestamp", tx.unixTimeStamp())
+				filteredTxs = append(filteredTxs, tx)
+
 				continue
 			}
 
-			txTime := time.Unix(timestamp, 0)
-			if (txTime.After(startTime) || txTime.Equal(startTime)) && (txTime.Before(endTime) || txTime.Equal(endTime)) {
+			if txTime.After(endTime) {
+				pastEndTime = true
+				continue
+			}
+
+			if !txTime.Before(startTime) {
 				filteredTxs = append(filteredTxs, tx)
 			}
 		}
@@ -199,8 +373,13 @@ func (c *Client) fetchERC20Transactions(
 			break
 		}
 
+		// Transactions are sorted ascending, so every following page is past the end time too
+		if pastEndTime {
+			c.logger.Debugw("Reached transactions after end time, stopping pagination", "page", page)
+			break
+		}
+
 		page++
-		c.rateLimit() // Rate limit between pagination requests
 	}
 
 	return allTransactions, nil
@@ -210,8 +389,6 @@ func (c *Client) fetchERC20Transactions(
 func (c *Client) GetETHTransfers(
 	ctx context.Context, address string, startTime, endTime time.Time, startBlock int64,
 ) ([]ETHTransaction, error) {
-	c.rateLimit()
-
 	// If startBlock is not provided, use default
 	if startBlock <= 0 {
 		startBlock = defaultStartBlock
@@ -234,16 +411,13 @@ func (c *Client) GetETHTransfers(
 	params.Add("startblock", strconv.FormatInt(startBlock, 10))
 	params.Add("endblock", strconv.Itoa(endBlock))
 	params.Add("sort", "asc")
-	params.Add("apikey", c.apiKey)
 	params.Add("chainid", strconv.Itoa(c.chainID))
 
-	return c.fetchETHTransactions(ctx, params, startTime, endTime)
+	return fetchTransactions[ETHTransaction](ctx, c, params, startTime, endTime)
 }
 
 // GetERC20Transfers fetches ERC20 token transfers for a specific address and token.
 func (c *Client) GetERC20Transfers(ctx context.Context, address string, tokenAddress string, startTime, endTime time.Time, startBlock int64) ([]ERC20Transaction, error) {
-	c.rateLimit()
-
 	// If startBlock is not provided, use default
 	if startBlock <= 0 {
 		startBlock = defaultStartBlock
@@ -267,7 +441,6 @@ func (c *Client) GetERC20Transfers(ctx context.Context, address string, tokenAdd
 	params.Add("startblock", strconv.FormatInt(startBlock, 10))
 	params.Add("endblock", strconv.Itoa(endBlock))
 	params.Add("sort", "asc")
-	params.Add("apikey", c.apiKey)
 	params.Add("chainid", strconv.Itoa(c.chainID))
 
 	// Add token address if specified
@@ -275,7 +448,145 @@ func (c *Client) GetERC20Transfers(ctx context.Context, address string, tokenAdd
 		params.Add("contractaddress", tokenAddress)
 	}
 
-	return c.fetchERC20Transactions(ctx, params, startTime, endTime)
+	return fetchTransactions[ERC20Transaction](ctx, c, params, startTime, endTime)
+}
+
+// GetInternalTransfers fetches internal transactions (traces) of a specific address,
+// which move ETH through contract calls such as multisig executions.
+func (c *Client) GetInternalTransfers(
+	ctx context.Context, address string, startTime, endTime time.Time, startBlock int64,
+) ([]InternalTransaction, error) {
+	// If startBlock is not provided, use default
+	if startBlock <= 0 {
+		startBlock = defaultStartBlock
+	}
+
+	endBlock := defaultEndBlock
+
+	c.logger.Infow("Fetching internal transfers",
+		"address", address,
+		"startBlock", startBlock,
+		"endBlock", endBlock,
+		"startTime", startTime,
+		"endTime", endTime,
+		"chainID", c.chainID)
+
+	params := url.Values{}
+	params.Add("module", moduleAccount)
+	params.Add("action", actionTxListInternal)
+	params.Add("address", address)
+	params.Add("startblock", strconv.FormatInt(startBlock, 10))
+	params.Add("endblock", strconv.Itoa(endBlock))
+	params.Add("sort", "asc")
+	params.Add("chainid", strconv.Itoa(c.chainID))
+
+	return fetchTransactions[InternalTransaction](ctx, c, params, startTime, endTime)
+}
+
+// GetTokenInfo fetches the symbol, name and decimals of an ERC20 token.
+// The metadata is read from the first transfer of the token, so tokens that were never
+// transferred return ErrTokenNotFound.
+func (c *Client) GetTokenInfo(ctx context.Context, tokenAddress string) (*TokenInfo, error) {
+	c.logger.Infow("Fetching token info", "token", tokenAddress, "chainID", c.chainID)
+
+	params := url.Values{}
+	params.Add("module", moduleAccount)
+	params.Add("action", actionTokenTx)
+	params.Add("contractaddress", tokenAddress)
+	params.Add("page", strconv.Itoa(defaultPage))
+	params.Add("offset", "1")
+	params.Add("sort", "asc")
+	params.Add("chainid", strconv.Itoa(c.chainID))
+
+	var transactions []ERC20Transaction
+	if err := c.doRequestWithRetry(ctx, params, &transactions); err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("token %s: %w", tokenAddress, ErrTokenNotFound)
+	}
+
+	decimals, err := strconv.Atoi(transactions[0].TokenDecimal)
+	if err != nil {
+		return nil, fmt.Errorf("parsing token decimals %q: %w", transactions[0].TokenDecimal, err)
+	}
+
+	return &TokenInfo{
+		Symbol:   transactions[0].TokenSymbol,
+		Name:     transactions[0].TokenName,
+		Decimals: decimals,
+	}, nil
+}
+
+// doRequestWithRetry performs an HTTP request to the Etherscan API with the next available API key.
+// While Etherscan reports that the rate limit is reached, the key is rested for a growing cooldown and
+// the request is retried, right away with another key if there is one.
+func (c *Client) doRequestWithRetry(ctx context.Context, params url.Values, result any) error {
+	cooldown := c.rateLimitCooldown
+
+	for attempt := 0; ; attempt++ {
+		key, err := c.keys.acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for rate limit: %w", err)
+		}
+
+		params.Set("apikey", key.value)
+
+		err = c.doRequest(ctx, params, result)
+		if err == nil || !errors.Is(err, ErrRateLimited) {
+			return err
+		}
+
+		c.rateLimitedCount.Add(1)
+
+		if attempt >= c.rateLimitRetries {
+			return err
+		}
+
+		c.keys.rest(key, cooldown)
+
+		c.logger.Warnw("Etherscan rate limit reached, resting API key before retry",
+			"key", key.label(),
+			"keys", c.keys.size(),
+			"attempt", attempt+1,
+			"maxRetries", c.rateLimitRetries,
+			"cooldown", cooldown,
+			"rateLimitedTotal", c.rateLimitedCount.Load())
+
+		cooldown *= 2
+	}
+}
+
+// isRateLimitResponse reports whether an error response is Etherscan's "Max rate limit reached".
+// Etherscan puts the reason either in the message or in the result field.
+func isRateLimitResponse(response *Response) bool {
+	if strings.Contains(response.Message, rateLimitMessage) {
+		return true
+	}
+
+	result, ok := response.resultMessage()
+
+	return ok && strings.Contains(result, rateLimitMessage)
+}
+
+// resultMessage returns the result field if it is a JSON string rather than an array or object.
+// Etherscan returns the reason of errors such as "Invalid API Key" this way.
+func (r *Response) resultMessage() (string, bool) {
+	var message string
+	if err := json.Unmarshal(r.Result, &message); err != nil {
+		return "", false
+	}
+
+	return message, true
+}
+
+// isNoTransactionsResponse reports whether an error response is Etherscan's "No transactions found",
+// which is returned with an empty result array when a query matches nothing.
+func (r *Response) isNoTransactionsResponse() bool {
+	var result []json.RawMessage
+
+	return r.Message == noTransactionsMessage && json.Unmarshal(r.Result, &result) == nil && len(result) == 0
 }
 
 // doRequest performs an HTTP request to the Etherscan API.
@@ -315,7 +626,19 @@ func (c *Client) doRequest(ctx context.Context, params url.Values, result any) e
 	}
 
 	if response.Status != "1" {
-		return fmt.Errorf("etherscan API error: %s", response.Message)
+		if isRateLimitResponse(&response) {
+			return fmt.Errorf("etherscan API error: %s: %w", response.Message, ErrRateLimited)
+		}
+
+		if message, ok := response.resultMessage(); ok {
+			return fmt.Errorf("etherscan API error: %s: %s", response.Message, message)
+		}
+
+		if !response.isNoTransactionsResponse() {
+			return fmt.Errorf("etherscan API error: %s", response.Message)
+		}
+	} else if message, ok := response.resultMessage(); ok {
+		return fmt.Errorf("etherscan API error: unexpected result: %s", message)
 	}
 
 	if err := json.Unmarshal(response.Result, result); err != nil {
@@ -324,13 +647,3 @@ func (c *Client) doRequest(ctx context.Context, params url.Values, result any) e
 
 	return nil
 }
-
-// rateLimit ensures we don't exceed the rate limit.
-func (c *Client) rateLimit() {
-	elapsed := time.Since(c.lastReq)
-	if elapsed < time.Duration(requestIntervalMs)*time.Millisecond {
-		time.Sleep(time.Duration(requestIntervalMs)*time.Millisecond - elapsed)
-	}
-
-	c.lastReq = time.Now()
-}
@@ -0,0 +1,269 @@
+package etherscan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testAddress = "0x00000000000000000000000000000000000000a1"
+
+// testTime is the time of the transactions served by the test servers.
+var testTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestClient returns a client sending its requests to a test server serving handler.
+// Unset base URL, API key and rate limit cooldown of cfg are set for the test.
+func newTestClient(t *testing.T, cfg Config, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = srv.URL
+	}
+
+	if cfg.APIKey == "" && len(cfg.APIKeys) == 0 {
+		cfg.APIKey = "test"
+	}
+
+	if cfg.RateLimitCooldown == 0 {
+		cfg.RateLimitCooldown = 10 * time.Millisecond
+	}
+
+	client, err := NewClientWithConfig(cfg, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	return client
+}
+
+// writeResponse writes an Etherscan response with the given status, message and result.
+func writeResponse(t *testing.T, w http.ResponseWriter, status, message string, result any) {
+	t.Helper()
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		t.Errorf("encoding result: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(Response{Status: status, Message: message, Result: raw})
+	if err != nil {
+		t.Errorf("writing response: %v", err)
+	}
+}
+
+// writeRateLimited writes Etherscan's response to a rate limited request.
+func writeRateLimited(t *testing.T, w http.ResponseWriter) {
+	t.Helper()
+	writeResponse(t, w, "0", "NOTOK", "Max rate limit reached")
+}
+
+// ethTransactions returns n successful ETH transactions of the test address, one per block from
+// block 100 and one second apart from testTime.
+func ethTransactions(n int) []ETHTransaction {
+	transactions := make([]ETHTransaction, 0, n)
+
+	for i := range n {
+		transactions = append(transactions, ETHTransaction{
+			BlockNumber: strconv.Itoa(100 + i),
+			TimeStamp:   strconv.FormatInt(testTime.Unix()+int64(i), 10),
+			Hash:        fmt.Sprintf("0x%064x", i+1),
+			From:        testAddress,
+			To:          "0x00000000000000000000000000000000000000b2",
+			Value:       "1000",
+			IsError:     "0",
+		})
+	}
+
+	return transactions
+}
+
+func TestRateLimitedRequestBacksOffAndRecovers(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClient(t, Config{RateLimitRetries: 3}, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) <= 2 {
+			writeRateLimited(t, w)
+			return
+		}
+
+		writeResponse(t, w, "1", "OK", ethTransactions(2))
+	})
+
+	start := time.Now()
+
+	transactions, err := client.GetETHTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("expected the rate limited request to recover, got %v", err)
+	}
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", calls.Load())
+	}
+
+	if client.RateLimitedCount() != 2 {
+		t.Fatalf("expected 2 rate limited responses counted, got %d", client.RateLimitedCount())
+	}
+
+	// The key rests 10ms, then 20ms, before the retries
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected the client to back off before retrying, retried after %s", elapsed)
+	}
+}
+
+func TestRateLimitedRequestFailsAfterRetries(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClient(t, Config{RateLimitRetries: 2}, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		writeRateLimited(t, w)
+	})
+
+	_, err := client.GetETHTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(time.Hour), 0)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected the request and 2 retries, got %d requests", calls.Load())
+	}
+}
+
+func TestGetTokenInfo(t *testing.T) {
+	const tokenAddress = "0x00000000000000000000000000000000000000c3"
+
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("contractaddress") != tokenAddress {
+			writeResponse(t, w, "0", "No transactions found", []ERC20Transaction{})
+			return
+		}
+
+		writeResponse(t, w, "1", "OK", []ERC20Transaction{{
+			ContractAddress: tokenAddress,
+			TokenSymbol:     "USDC",
+			TokenName:       "USD Coin",
+			TokenDecimal:    "6",
+		}})
+	})
+
+	info, err := client.GetTokenInfo(context.Background(), tokenAddress)
+	if err != nil {
+		t.Fatalf("getting token info: %v", err)
+	}
+
+	if *info != (TokenInfo{Symbol: "USDC", Name: "USD Coin", Decimals: 6}) {
+		t.Fatalf("unexpected token info %+v", info)
+	}
+
+	_, err = client.GetTokenInfo(context.Background(), "0x00000000000000000000000000000000000000ff")
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound for a token without transfers, got %v", err)
+	}
+}
+
+func TestGetInternalTransfers(t *testing.T) {
+	traces := []InternalTransaction{
+		{
+			BlockNumber: "100",
+			TimeStamp:   strconv.FormatInt(testTime.Unix(), 10),
+			Hash:        fmt.Sprintf("0x%064x", 1),
+			From:        testAddress,
+			To:          "0x00000000000000000000000000000000000000b2",
+			Value:       "1000000000000000000",
+			Type:        "call",
+			TraceID:     "0_1",
+			IsError:     "0",
+		},
+		{
+			BlockNumber: "101",
+			TimeStamp:   strconv.FormatInt(testTime.Unix()+1, 10),
+			Hash:        fmt.Sprintf("0x%064x", 2),
+			From:        testAddress,
+			To:          "0x00000000000000000000000000000000000000b3",
+			Value:       "5",
+			Type:        "call",
+			TraceID:     "0",
+			IsError:     "1",
+			ErrCode:     "Out of gas",
+		},
+	}
+
+	client := newTestClient(t, Config{ChainID: 10}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		want := map[string]string{
+			"module":     "account",
+			"action":     "txlistinternal",
+			"address":    testAddress,
+			"startblock": "100",
+			"chainid":    "10",
+		}
+		for key, value := range want {
+			if got := query.Get(key); got != value {
+				t.Errorf("expected %s=%s, got %q", key, value, got)
+			}
+		}
+
+		writeResponse(t, w, "1", "OK", traces)
+	})
+
+	got, err := client.GetInternalTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("getting internal transfers: %v", err)
+	}
+
+	if len(got) != len(traces) {
+		t.Fatalf("expected %d internal transfers, got %d", len(traces), len(got))
+	}
+
+	for i := range traces {
+		if got[i] != traces[i] {
+			t.Fatalf("expected %+v, got %+v", traces[i], got[i])
+		}
+	}
+}
+
+func TestGetETHTransfersTimeRange(t *testing.T) {
+	transactions := ethTransactions(4)
+	// Out of range on both sides, and without a valid timestamp
+	transactions[0].TimeStamp = strconv.FormatInt(testTime.Add(-2*time.Hour).Unix(), 10)
+	transactions[2].TimeStamp = strconv.FormatInt(testTime.Add(2*time.Hour).Unix(), 10)
+	transactions[3].TimeStamp = "not a timestamp"
+
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, _ *http.Request) {
+		writeResponse(t, w, "1", "OK", transactions)
+	})
+
+	got, err := client.GetETHTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("getting transfers: %v", err)
+	}
+
+	// Transactions with an invalid timestamp are left to the caller, which would otherwise take them
+	// for removed
+	if len(got) != 2 || got[0] != transactions[1] || got[1] != transactions[3] {
+		t.Fatalf("expected the transaction in range and the one without timestamp, got %+v", got)
+	}
+}
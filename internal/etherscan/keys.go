@@ -0,0 +1,108 @@
+package etherscan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// apiKey is an Etherscan API key with its own request budget.
+type apiKey struct {
+	value string
+	// next is the earliest time of the next request with the key.
+	next time.Time
+}
+
+// label identifies the key in logs without revealing it.
+func (k *apiKey) label() string {
+	if len(k.value) <= 4 {
+		return "****"
+	}
+
+	return "****" + k.value[len(k.value)-4:]
+}
+
+// keyPool spreads requests across API keys, keeping each key within maxRequestsPerSecond, so the
+// throughput of the client grows with the number of keys.
+type keyPool struct {
+	mu   sync.Mutex
+	keys []*apiKey
+	// last is the index of the key of the last request, ties are broken in round-robin order after it.
+	last int
+}
+
+// newKeyPool returns a pool of the given keys, ignoring repeated keys. Without keys, requests are
+// made without an API key.
+func newKeyPool(values []string) *keyPool {
+	pool := &keyPool{last: -1}
+	seen := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			pool.keys = append(pool.keys, &apiKey{value: value})
+		}
+	}
+
+	if len(pool.keys) == 0 {
+		pool.keys = []*apiKey{{}}
+	}
+
+	return pool
+}
+
+// acquire reserves the earliest request slot among the keys and waits for it. Concurrent callers are
+// given distinct slots, so the rate limit of every key holds for all requests of the client.
+func (p *keyPool) acquire(ctx context.Context) (*apiKey, error) {
+	p.mu.Lock()
+
+	now := time.Now()
+
+	var key *apiKey
+
+	chosen := 0
+
+	for i := 1; i <= len(p.keys); i++ {
+		candidate := (p.last + i) % len(p.keys)
+		if key == nil || p.keys[candidate].next.Before(key.next) {
+			key, chosen = p.keys[candidate], candidate
+		}
+	}
+
+	slot := key.next
+	if slot.Before(now) {
+		slot = now
+	}
+
+	key.next = slot.Add(time.Duration(requestIntervalMs) * time.Millisecond)
+	p.last = chosen
+	p.mu.Unlock()
+
+	if wait := time.Until(slot); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return key, nil
+}
+
+// rest keeps a rate limited key unused for the cooldown, the other keys serve the requests meanwhile.
+func (p *keyPool) rest(key *apiKey, cooldown time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if until := time.Now().Add(cooldown); key.next.Before(until) {
+		key.next = until
+	}
+}
+
+// size returns the number of keys.
+func (p *keyPool) size() int {
+	return len(p.keys)
+}
@@ -0,0 +1,129 @@
+package etherscan
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyPoolRotatesKeys(t *testing.T) {
+	pool := newKeyPool([]string{"key-a", "key-b", "key-a", "key-c"})
+
+	if pool.size() != 3 {
+		t.Fatalf("expected the repeated key to be ignored, got %d keys", pool.size())
+	}
+
+	start := time.Now()
+
+	var used []string
+
+	for range 6 {
+		key, err := pool.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquiring key: %v", err)
+		}
+
+		used = append(used, key.value)
+	}
+
+	want := []string{"key-a", "key-b", "key-c", "key-a", "key-b", "key-c"}
+	for i := range want {
+		if used[i] != want[i] {
+			t.Fatalf("expected the keys in round-robin order %v, got %v", want, used)
+		}
+	}
+
+	// Every key made two requests, which takes one request interval instead of five
+	interval := time.Duration(requestIntervalMs) * time.Millisecond
+	if elapsed := time.Since(start); elapsed >= 2*interval {
+		t.Fatalf("expected the requests to be spread across the keys within %s, took %s", 2*interval, elapsed)
+	}
+}
+
+func TestKeyPoolWithoutKeys(t *testing.T) {
+	pool := newKeyPool(nil)
+
+	key, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquiring key: %v", err)
+	}
+
+	if pool.size() != 1 || key.value != "" {
+		t.Fatalf("expected a single empty key, got %d keys and %q", pool.size(), key.value)
+	}
+}
+
+func TestKeyPoolAcquireCancelled(t *testing.T) {
+	pool := newKeyPool([]string{"key-a"})
+	pool.rest(pool.keys[0], time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := pool.acquire(ctx); err == nil {
+		t.Fatal("expected waiting for a resting key to stop when the context is done")
+	}
+}
+
+func TestAPIKeyLabel(t *testing.T) {
+	tests := map[string]string{
+		"ABCDEFGH1234": "****1234",
+		"abc":          "****",
+		"":             "****",
+	}
+
+	for value, want := range tests {
+		key := &apiKey{value: value}
+		if got := key.label(); got != want {
+			t.Fatalf("expected the label of %q to be %q, got %q", value, want, got)
+		}
+	}
+}
+
+func TestRateLimitedKeyIsRested(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		uses = make(map[string]int)
+	)
+
+	client := newTestClient(t, Config{
+		APIKey:            "limited",
+		APIKeys:           []string{"spare"},
+		RateLimitRetries:  3,
+		RateLimitCooldown: time.Hour,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("apikey")
+
+		mu.Lock()
+		uses[key]++
+		mu.Unlock()
+
+		if key == "limited" {
+			writeRateLimited(t, w)
+			return
+		}
+
+		writeResponse(t, w, "1", "OK", ethTransactions(1))
+	})
+
+	for range 3 {
+		_, err := client.GetETHTransfers(context.Background(), testAddress,
+			testTime.Add(-time.Hour), testTime.Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("expected the request to be retried with the spare key, got %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if uses["limited"] != 1 || uses["spare"] != 3 {
+		t.Fatalf("expected the rate limited key to rest after its first request, got uses %v", uses)
+	}
+
+	if client.RateLimitedCount() != 1 {
+		t.Fatalf("expected 1 rate limited response, got %d", client.RateLimitedCount())
+	}
+}
@@ -0,0 +1,22 @@
+package etherscan
+
+import "context"
+
+// PageFunc is called after each page fetched by a paginated list request, with the 1-based page
+// number and the number of transactions on the page.
+type PageFunc func(page, transactions int)
+
+type pageFuncKey struct{}
+
+// WithPageFunc returns a copy of ctx with which the paginated list requests of a Client, such as
+// GetETHTransfers, report every fetched page to fn.
+func WithPageFunc(ctx context.Context, fn PageFunc) context.Context {
+	return context.WithValue(ctx, pageFuncKey{}, fn)
+}
+
+// pageFuncFrom returns the PageFunc of ctx, or nil if there is none.
+func pageFuncFrom(ctx context.Context) PageFunc {
+	fn, _ := ctx.Value(pageFuncKey{}).(PageFunc)
+
+	return fn
+}
@@ -0,0 +1,90 @@
+package etherscan
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedHandler serves transactions in the pages requested by the page and offset parameters,
+// counting the requests in calls.
+func pagedHandler[T any](t *testing.T, transactions []T, calls *atomic.Int32) http.HandlerFunc {
+	t.Helper()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			t.Errorf("invalid page: %v", err)
+		}
+
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil {
+			t.Errorf("invalid offset: %v", err)
+		}
+
+		start := min((page-1)*offset, len(transactions))
+		end := min(start+offset, len(transactions))
+
+		writeResponse(t, w, "1", "OK", transactions[start:end])
+	}
+}
+
+func TestPaginationWithSmallPageSize(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestClient(t, Config{PageSize: 2}, pagedHandler(t, ethTransactions(5), &calls))
+
+	if client.PageSize() != 2 {
+		t.Fatalf("expected page size 2, got %d", client.PageSize())
+	}
+
+	transactions, err := client.GetETHTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("getting ETH transfers: %v", err)
+	}
+
+	if len(transactions) != 5 {
+		t.Fatalf("expected 5 transactions, got %d", len(transactions))
+	}
+
+	// Pages of 2, 2 and 1 transactions, the short page ends the pagination
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", calls.Load())
+	}
+}
+
+func TestPageSizeClamped(t *testing.T) {
+	client := newTestClient(t, Config{PageSize: maxOffset + 1}, func(http.ResponseWriter, *http.Request) {})
+
+	if client.PageSize() != maxOffset {
+		t.Fatalf("expected page size clamped to %d, got %d", maxOffset, client.PageSize())
+	}
+}
+
+func TestPaginationStopsAfterEndTime(t *testing.T) {
+	var calls atomic.Int32
+
+	// Transactions are one second apart, so the end time falls within the second page
+	client := newTestClient(t, Config{PageSize: 2}, pagedHandler(t, ethTransactions(10), &calls))
+
+	transactions, err := client.GetETHTransfers(context.Background(), testAddress,
+		testTime.Add(-time.Hour), testTime.Add(2*time.Second), 0)
+	if err != nil {
+		t.Fatalf("getting ETH transfers: %v", err)
+	}
+
+	if len(transactions) != 3 {
+		t.Fatalf("expected the 3 transactions up to the end time, got %d", len(transactions))
+	}
+
+	// Without stopping early, all 5 full pages and an empty one would be requested
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,89 @@
+package etherscan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDoRequestResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+		wantLen int
+	}{
+		{
+			name:    "array result",
+			body:    `{"status": "1", "message": "OK", "result": [{"hash": "0x01"}, {"hash": "0x02"}]}`,
+			wantLen: 2,
+		},
+		{
+			name: "no transactions",
+			body: `{"status": "0", "message": "No transactions found", "result": []}`,
+		},
+		{
+			name:    "string error result",
+			body:    `{"status": "0", "message": "NOTOK", "result": "Invalid API Key"}`,
+			wantErr: "etherscan API error: NOTOK: Invalid API Key",
+		},
+		{
+			name:    "string result of a successful response",
+			body:    `{"status": "1", "message": "OK", "result": "Query Timeout occured"}`,
+			wantErr: "unexpected result: Query Timeout occured",
+		},
+		{
+			name:    "error without a string result",
+			body:    `{"status": "0", "message": "NOTOK", "result": []}`,
+			wantErr: "etherscan API error: NOTOK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, Config{}, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			var result []ETHTransaction
+
+			err := client.doRequest(context.Background(), url.Values{}, &result)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+
+				if errors.Is(err, ErrRateLimited) {
+					t.Fatalf("expected a non rate limit error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result) != tt.wantLen {
+				t.Fatalf("expected %d transactions, got %d", tt.wantLen, len(result))
+			}
+		})
+	}
+}
+
+func TestDoRequestRateLimitedStringResult(t *testing.T) {
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "0", "message": "NOTOK", "result": "Max rate limit reached"}`))
+	})
+
+	var result []ETHTransaction
+
+	err := client.doRequest(context.Background(), url.Values{}, &result)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
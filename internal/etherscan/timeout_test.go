@@ -0,0 +1,82 @@
+package etherscan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// slowHandler answers with no transactions after delay.
+func slowHandler(t *testing.T, delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		writeResponse(t, w, "0", noTransactionsMessage, []ETHTransaction{})
+	}
+}
+
+func TestRequestTimeoutWithoutFetchDeadline(t *testing.T) {
+	client := newTestClient(t, Config{RequestTimeout: 50 * time.Millisecond}, slowHandler(t, time.Second))
+
+	start := time.Now()
+
+	var result []ETHTransaction
+
+	// The fetch has no deadline, the request is still bounded
+	err := client.doRequest(context.Background(), url.Values{}, &result)
+	if err == nil {
+		t.Fatal("expected a request slower than the request timeout to fail")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the request to time out after 50ms, took %s", elapsed)
+	}
+}
+
+func TestRequestTimeoutWithinLongerFetchDeadline(t *testing.T) {
+	client := newTestClient(t, Config{RequestTimeout: 50 * time.Millisecond}, slowHandler(t, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var result []ETHTransaction
+
+	if err := client.doRequest(ctx, url.Values{}, &result); err == nil {
+		t.Fatal("expected the request timeout to apply within a longer fetch deadline")
+	}
+
+	if ctx.Err() != nil {
+		t.Fatal("expected the fetch context to outlive the timed out request")
+	}
+}
+
+func TestFetchDeadlineShorterThanRequestTimeout(t *testing.T) {
+	client := newTestClient(t, Config{RequestTimeout: time.Hour}, slowHandler(t, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var result []ETHTransaction
+
+	err := client.doRequest(ctx, url.Values{}, &result)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the fetch deadline to cancel the request, got %v", err)
+	}
+}
+
+func TestRequestWithinTimeout(t *testing.T) {
+	client := newTestClient(t, Config{RequestTimeout: time.Second}, slowHandler(t, 20*time.Millisecond))
+
+	var result []ETHTransaction
+
+	if err := client.doRequest(context.Background(), url.Values{}, &result); err != nil {
+		t.Fatalf("expected a request within the timeout to succeed, got %v", err)
+	}
+}
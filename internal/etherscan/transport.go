@@ -0,0 +1,109 @@
+package etherscan
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is the default number of idle connections kept open to the API.
+	// The default of net/http, 2, makes concurrent fetches reconnect for most requests.
+	DefaultMaxIdleConnsPerHost = 16
+	// DefaultIdleConnTimeout is the default time an idle connection to the API is kept open.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// newTransport returns the HTTP transport of the client, which keeps up to cfg.MaxIdleConnsPerHost
+// connections to the API open for cfg.IdleConnTimeout between requests. Non-positive values fall back
+// to their defaults.
+func newTransport(cfg Config) *http.Transport {
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.MaxIdleConns = max(transport.MaxIdleConns, cfg.MaxIdleConnsPerHost)
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+
+	return transport
+}
+
+// configureProxyAndTLS applies the proxy and TLS settings of cfg, if any, to transport.
+func configureProxyAndTLS(transport *http.Transport, cfg Config) error {
+	if cfg.ProxyURL != "" {
+		proxyURL, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return err
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.RootCAFile != "" || cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // opt-in for development only
+		}
+	}
+
+	if cfg.RootCAFile != "" {
+		pool, err := loadRootCAs(cfg.RootCAFile)
+		if err != nil {
+			return err
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return nil
+}
+
+// parseProxyURL parses an absolute HTTP(S) or SOCKS5 proxy URL.
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proxy URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q, expected an http, https or socks5 URL", u.Redacted())
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q, missing host", u.Redacted())
+	}
+
+	return u, nil
+}
+
+// loadRootCAs returns the system root CAs extended with the PEM encoded certificates of a file.
+func loadRootCAs(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading root CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in root CA file %s", path)
+	}
+
+	return pool, nil
+}
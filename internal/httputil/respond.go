@@ -0,0 +1,35 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RespondError writes a CommonError with the given status, code and message.
+// Server errors of requests that ran out of time are reported as 504 with CodeTimeout instead,
+// since they are most likely caused by the deadline.
+func RespondError(c *gin.Context, status int, code ErrorCode, msg string) {
+	RespondCommonError(c, status, &CommonError{
+		Code:  code,
+		Error: msg,
+	})
+}
+
+// RespondCommonError writes errResp with the given status, e.g. an error carrying field errors.
+// Like RespondError, it reports server errors of requests that ran out of time as 504 with CodeTimeout.
+func RespondCommonError(c *gin.Context, status int, errResp *CommonError) {
+	if status >= http.StatusInternalServerError && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		status, errResp = http.StatusGatewayTimeout, &CommonError{Code: CodeTimeout, Error: "Request timed out"}
+	}
+
+	c.JSON(status, errResp)
+}
+
+// RespondErrorf is like RespondError but formats the message according to a format specifier.
+func RespondErrorf(c *gin.Context, status int, code ErrorCode, format string, args ...any) {
+	RespondError(c, status, code, fmt.Sprintf(format, args...))
+}
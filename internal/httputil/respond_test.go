@@ -0,0 +1,123 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestContext returns a gin context of a request with ctx and its response recorder.
+func newTestContext(ctx context.Context) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+
+	return c, w
+}
+
+// expiredContext returns a context whose deadline has passed.
+func expiredContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	t.Cleanup(cancel)
+	<-ctx.Done()
+
+	return ctx
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) CommonError {
+	t.Helper()
+
+	var resp CommonError
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error response %q: %v", w.Body.String(), err)
+	}
+
+	return resp
+}
+
+func TestRespondError(t *testing.T) {
+	c, w := newTestContext(context.Background())
+	RespondErrorf(c, http.StatusNotFound, CodeNotFound, "Token %s not found", "0xc3")
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+
+	resp := decodeError(t, w)
+	if resp.Code != CodeNotFound || resp.Error != "Token 0xc3 not found" || resp.Errors != nil {
+		t.Errorf("unexpected error response %+v", resp)
+	}
+}
+
+func TestRespondCommonErrorKeepsFieldErrors(t *testing.T) {
+	fieldErrs := []FieldError{
+		{Field: "addresses[0].address", Message: "must be a 0x-prefixed 20-byte hex address"},
+		{Field: "label", Message: "is required"},
+	}
+
+	c, w := newTestContext(context.Background())
+	RespondCommonError(c, http.StatusBadRequest, &CommonError{
+		Code:   CodeInvalidRequest,
+		Error:  "Invalid request body",
+		Errors: fieldErrs,
+	})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+
+	resp := decodeError(t, w)
+	if resp.Code != CodeInvalidRequest || resp.Error != "Invalid request body" {
+		t.Errorf("unexpected error response %+v", resp)
+	}
+
+	if !slices.Equal(resp.Errors, fieldErrs) {
+		t.Errorf("expected field errors %+v, got %+v", fieldErrs, resp.Errors)
+	}
+}
+
+func TestRespondErrorTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"server error", http.StatusInternalServerError, http.StatusGatewayTimeout, CodeTimeout},
+		{"upstream error", http.StatusBadGateway, http.StatusGatewayTimeout, CodeTimeout},
+		{"client error", http.StatusBadRequest, http.StatusBadRequest, CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext(expiredContext(t))
+			RespondCommonError(c, tt.status, &CommonError{
+				Code:   CodeInvalidRequest,
+				Error:  "failed",
+				Errors: []FieldError{{Field: "label", Message: "is required"}},
+			})
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			resp := decodeError(t, w)
+			if resp.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, resp.Code)
+			}
+
+			if tt.wantCode == CodeTimeout && resp.Errors != nil {
+				t.Errorf("expected no field errors on timeout, got %+v", resp.Errors)
+			}
+		})
+	}
+}
@@ -1,7 +1,56 @@
 // Package httputil provides HTTP utility functions and types.
 package httputil
 
+// ErrorCode is a stable, machine-readable identifier of an error response.
+type ErrorCode string
+
+// Error codes returned in CommonError.Code.
+const (
+	// CodeInvalidRequest is returned when the request body cannot be parsed or fails validation.
+	CodeInvalidRequest ErrorCode = "INVALID_REQUEST"
+	// CodeInvalidID is returned when an ID path parameter is not a valid integer.
+	CodeInvalidID ErrorCode = "INVALID_ID"
+	// CodeInvalidTimeRange is returned when start_time or end_time cannot be parsed.
+	CodeInvalidTimeRange ErrorCode = "INVALID_TIME_RANGE"
+	// CodeInvalidParameter is returned when a query parameter or config value is out of range.
+	CodeInvalidParameter ErrorCode = "INVALID_PARAMETER"
+	// CodeInvalidAddress is returned when an address is not a 0x-prefixed 20-byte hex string.
+	CodeInvalidAddress ErrorCode = "INVALID_ADDRESS"
+	// CodeNotFound is returned when the requested record does not exist.
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeTokenExists is returned when adding a token whose address is already catalogued.
+	CodeTokenExists ErrorCode = "TOKEN_EXISTS"
+	// CodeAddressExists is reserved for adding an address that is already tracked. Adding source and
+	// target addresses currently relabels existing ones instead of failing.
+	CodeAddressExists ErrorCode = "ADDRESS_EXISTS"
+	// CodeRateLimited is returned when a client exceeds the request rate limit.
+	CodeRateLimited ErrorCode = "RATE_LIMITED"
+	// CodeReadOnly is returned for requests that modify data while the service runs in read-only mode.
+	CodeReadOnly ErrorCode = "READ_ONLY"
+	// CodeTimeout is returned when a request does not complete within its deadline.
+	CodeTimeout ErrorCode = "TIMEOUT"
+	// CodeUnavailable is returned when the database is temporarily unreachable, so the request may be retried.
+	CodeUnavailable ErrorCode = "UNAVAILABLE"
+	// CodeUpstreamError is returned when a request to an upstream service such as Etherscan fails.
+	CodeUpstreamError ErrorCode = "UPSTREAM_ERROR"
+	// CodeUpstreamRateLimited is returned when Etherscan keeps rejecting requests with its rate limit
+	// after all retries, so the request may be retried later.
+	CodeUpstreamRateLimited ErrorCode = "UPSTREAM_RATE_LIMITED"
+	// CodeInternal is returned when the server fails to process a valid request.
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+)
+
 // CommonError represents a common error response format.
 type CommonError struct {
-	Error string `json:"error"`
+	Code  ErrorCode `json:"code"`
+	Error string    `json:"error"`
+	// Errors lists the invalid fields of a request body, if known.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a field of a request body is invalid.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "addresses[0].address".
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
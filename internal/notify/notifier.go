@@ -0,0 +1,49 @@
+// Package notify provides notifications about newly stored transfers.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TokenTransfers summarizes the newly stored transfers of a token.
+type TokenTransfers struct {
+	TokenAddress string `json:"token_address"`
+	Symbol       string `json:"symbol,omitempty"`
+	Inserted     int    `json:"inserted"`
+	// Amount is the sum of the new transfers in the token's smallest unit.
+	Amount string `json:"amount"`
+	// NormalizedAmount is Amount / 10^decimals, empty if the token is not catalogued.
+	NormalizedAmount string `json:"normalized_amount,omitempty"`
+}
+
+// Notification describes the transfers stored by a refresh.
+type Notification struct {
+	Inserted int              `json:"inserted"`
+	Tokens   []TokenTransfers `json:"tokens"`
+}
+
+// Summary returns a human-readable one-line description of the notification.
+func (n Notification) Summary() string {
+	tokens := make([]string, 0, len(n.Tokens))
+	for _, token := range n.Tokens {
+		amount, unit := token.NormalizedAmount, token.Symbol
+		if amount == "" {
+			amount, unit = token.Amount, "units"
+		}
+
+		if unit == "" {
+			unit = token.TokenAddress
+		}
+
+		tokens = append(tokens, fmt.Sprintf("%s %s (%d transfers)", amount, unit, token.Inserted))
+	}
+
+	return fmt.Sprintf("Stored %d new transfers: %s", n.Inserted, strings.Join(tokens, ", "))
+}
+
+// Notifier sends notifications about newly stored transfers.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+
+	// DefaultWebhookRetries is the default number of retries of a failed webhook POST.
+	DefaultWebhookRetries = 3
+	// DefaultWebhookRetryDelay is the default wait before retrying a failed webhook POST.
+	DefaultWebhookRetryDelay = time.Second
+)
+
+// webhookPayload is the JSON body posted to the webhook.
+// Text is read by Slack and Content by Discord, other receivers can use the structured fields.
+type webhookPayload struct {
+	Text    string `json:"text"`
+	Content string `json:"content"`
+	Notification
+}
+
+// Webhook posts notifications as JSON to an HTTP webhook such as a Slack or Discord incoming webhook.
+type Webhook struct {
+	url        string
+	httpClient *http.Client
+	retries    int
+	retryDelay time.Duration
+	logger     *zap.SugaredLogger
+}
+
+// NewWebhook creates a new Webhook posting to url.
+func NewWebhook(url string, logger *zap.SugaredLogger) *Webhook {
+	return &Webhook{
+		url:        url,
+		httpClient: &http.Client{Timeout: defaultRequestTimeout},
+		retries:    DefaultWebhookRetries,
+		retryDelay: DefaultWebhookRetryDelay,
+		logger:     logger,
+	}
+}
+
+// Notify posts the notification to the webhook, retrying with a growing delay on failure.
+func (w *Webhook) Notify(ctx context.Context, notification Notification) error {
+	summary := notification.Summary()
+
+	body, err := json.Marshal(webhookPayload{
+		Text:         summary,
+		Content:      summary,
+		Notification: notification,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling webhook payload: %w", err)
+	}
+
+	delay := w.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil || attempt >= w.retries {
+			return err
+		}
+
+		w.logger.Warnw("Webhook POST failed, retrying",
+			"attempt", attempt+1,
+			"maxRetries", w.retries,
+			"delay", delay,
+			"err", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting to retry webhook: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// post performs a single webhook POST.
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			w.logger.Warnw("Failed to close response body", "err", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testNotification is a notification of two tokens, one of them not catalogued.
+var testNotification = Notification{
+	Inserted: 3,
+	Tokens: []TokenTransfers{
+		{
+			TokenAddress:     "0x00000000000000000000000000000000000000c3",
+			Symbol:           "TKN",
+			Inserted:         2,
+			Amount:           "1500000",
+			NormalizedAmount: "1.5",
+		},
+		{TokenAddress: "0x00000000000000000000000000000000000000c4", Inserted: 1, Amount: "7"},
+	},
+}
+
+// newTestWebhook returns a webhook posting to a test server serving handler, retrying without delay.
+func newTestWebhook(t *testing.T, handler http.HandlerFunc) *Webhook {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	w := NewWebhook(srv.URL, zap.NewNop().Sugar())
+	w.retryDelay = time.Millisecond
+
+	return w
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var payload webhookPayload
+
+	w := newTestWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+	})
+
+	if err := w.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("notifying: %v", err)
+	}
+
+	const summary = "Stored 3 new transfers: 1.5 TKN (2 transfers), 7 units (1 transfers)"
+	if payload.Text != summary || payload.Content != summary {
+		t.Fatalf("expected text and content %q, got %q and %q", summary, payload.Text, payload.Content)
+	}
+
+	if payload.Inserted != 3 || len(payload.Tokens) != 2 || payload.Tokens[0].NormalizedAmount != "1.5" {
+		t.Fatalf("expected the structured notification, got %+v", payload.Notification)
+	}
+}
+
+func TestWebhookNotifyRetries(t *testing.T) {
+	var requests atomic.Int32
+
+	w := newTestWebhook(t, func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	})
+
+	if err := w.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("notifying: %v", err)
+	}
+
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("expected 2 retries, got %d requests", got)
+	}
+}
+
+func TestWebhookNotifyFailsAfterRetries(t *testing.T) {
+	var requests atomic.Int32
+
+	w := newTestWebhook(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	})
+
+	if err := w.Notify(context.Background(), testNotification); err == nil {
+		t.Fatal("expected notifying to fail")
+	}
+
+	if got := requests.Load(); got != DefaultWebhookRetries+1 {
+		t.Fatalf("expected %d requests, got %d", DefaultWebhookRetries+1, got)
+	}
+}
+
+func TestWebhookNotifyStopsRetryingOnCancel(t *testing.T) {
+	w := newTestWebhook(t, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	w.retryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := w.Notify(ctx, testNotification); err == nil {
+		t.Fatal("expected notifying to fail once the context is done")
+	}
+}
@@ -5,23 +5,38 @@ import (
 	"context"
 	"time"
 
+	"github.com/ductm54/transfer-track/internal/cron"
 	"github.com/ductm54/transfer-track/internal/service"
 	"go.uber.org/zap"
 )
 
+// DefaultTickInterval is the default interval at which the scheduler checks for due refreshes.
+const DefaultTickInterval = time.Minute
+
 // Scheduler handles scheduled tasks.
 type Scheduler struct {
 	transferService *service.TransferService
+	tickInterval    time.Duration
 	logger          *zap.SugaredLogger
 	stopCh          chan struct{}
+	reloadCh        chan struct{}
 }
 
-// NewScheduler creates a new Scheduler.
-func NewScheduler(transferService *service.TransferService, logger *zap.SugaredLogger) *Scheduler {
+// NewScheduler creates a new Scheduler checking for due refreshes every tickInterval.
+// A non-positive tickInterval falls back to DefaultTickInterval.
+func NewScheduler(
+	transferService *service.TransferService, tickInterval time.Duration, logger *zap.SugaredLogger,
+) *Scheduler {
+	if tickInterval <= 0 {
+		tickInterval = DefaultTickInterval
+	}
+
 	return &Scheduler{
 		transferService: transferService,
+		tickInterval:    tickInterval,
 		logger:          logger,
 		stopCh:          make(chan struct{}),
+		reloadCh:        make(chan struct{}, 1),
 	}
 }
 
@@ -35,20 +50,37 @@ func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
+// Reload makes the scheduler check the refresh schedule right away instead of at its next tick,
+// e.g. after the configuration was reloaded.
+func (s *Scheduler) Reload() {
+	select {
+	case s.reloadCh <- struct{}{}:
+	default:
+		// A check is already pending
+	}
+}
+
 // run runs the scheduler.
 func (s *Scheduler) run() {
-	s.logger.Infow("Starting scheduler")
-
-	// Run immediately on startup
-	s.runDailyUpdate()
+	s.logger.Infow("Starting scheduler", "tickInterval", s.tickInterval)
 
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := time.NewTicker(s.tickInterval)
 	defer ticker.Stop()
 
+	// Refreshes scheduled after lastCheck and up to now are due
+	lastCheck := time.Now()
+
 	for {
 		select {
-		case <-ticker.C:
-			s.checkAndRunDailyUpdate()
+		case now := <-ticker.C:
+			s.checkAndRunDailyUpdate(lastCheck, now)
+			lastCheck = now
+		case <-s.reloadCh:
+			s.logger.Infow("Checking reloaded refresh schedule")
+
+			now := time.Now()
+			s.checkAndRunDailyUpdate(lastCheck, now)
+			lastCheck = now
 		case <-s.stopCh:
 			s.logger.Infow("Stopping scheduler")
 			return
@@ -56,47 +88,106 @@ func (s *Scheduler) run() {
 	}
 }
 
-// checkAndRunDailyUpdate checks if it's time to run the daily update.
-func (s *Scheduler) checkAndRunDailyUpdate() {
+// checkAndRunDailyUpdate runs the daily update if a scheduled refresh passed after lastCheck.
+// The configuration is re-read on every check, so updates through the API apply immediately.
+func (s *Scheduler) checkAndRunDailyUpdate(lastCheck, now time.Time) {
 	ctx := context.Background()
 
-	// Get the configured daily refresh time
-	timeStr, err := s.transferService.GetDailyRefreshTime(ctx)
+	cronExpr, err := s.transferService.GetRefreshCron(ctx)
 	if err != nil {
-		s.logger.Errorw("Error getting daily refresh time", "err", err)
+		s.logger.Errorw("Error getting refresh cron", "err", err)
 		return
 	}
 
-	// Parse the time
-	refreshTime, err := time.Parse("15:04:05", timeStr)
+	// Get the configured daily refresh times, only used without a cron expression
+	var timeStr string
+	if cronExpr == "" {
+		timeStr, err = s.transferService.GetDailyRefreshTime(ctx)
+		if err != nil {
+			s.logger.Errorw("Error getting daily refresh time", "err", err)
+			return
+		}
+	}
+
+	due, err := refreshDue(cronExpr, timeStr, lastCheck, now)
 	if err != nil {
-		s.logger.Errorw("Error parsing daily refresh time", "err", err)
+		s.logger.Errorw("Error parsing refresh schedule", "cron", cronExpr, "dailyRefreshTime", timeStr, "err", err)
 		return
 	}
 
-	// Get current time
-	now := time.Now()
-	currentTime := time.Date(0, 1, 1, now.Hour(), now.Minute(), 0, 0, time.UTC)
-	scheduledTime := time.Date(0, 1, 1, refreshTime.Hour(), refreshTime.Minute(), 0, 0, time.UTC)
-
-	// Check if it's time to run the daily update
-	if currentTime.Equal(scheduledTime) {
+	if due {
 		s.runDailyUpdate()
 	}
 }
 
-// runDailyUpdate runs the daily update.
-func (s *Scheduler) runDailyUpdate() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	defer cancel()
+// refreshDue reports whether a refresh was scheduled after lastCheck and up to now. Refreshes are
+// scheduled by cronExpr if set, else by the comma-separated daily refresh times of dailyRefreshTime.
+func refreshDue(cronExpr, dailyRefreshTime string, lastCheck, now time.Time) (bool, error) {
+	if cronExpr != "" {
+		schedule, err := cron.Parse(cronExpr)
+		if err != nil {
+			return false, err
+		}
 
+		next := schedule.Next(lastCheck)
+
+		return !next.IsZero() && !next.After(now), nil
+	}
+
+	offsets, err := service.ParseDailyRefreshTimes(dailyRefreshTime)
+	if err != nil {
+		return false, err
+	}
+
+	return !nextRunAfter(offsets, lastCheck).After(now), nil
+}
+
+// nextRunAfter returns the first daily run strictly after t, given the run times as offsets from
+// midnight in the location of t. offsets must not be empty.
+func nextRunAfter(offsets []time.Duration, t time.Time) time.Time {
+	var next time.Time
+
+	// The next run is today or, if every time of today has passed, tomorrow
+	for day := 0; day <= 1; day++ {
+		for _, offset := range offsets {
+			// time.Date normalizes the overflowing fields and keeps wall clock times across DST changes
+			run := time.Date(t.Year(), t.Month(), t.Day()+day,
+				int(offset/time.Hour), int(offset%time.Hour/time.Minute), int(offset%time.Minute/time.Second),
+				0, t.Location())
+
+			if run.After(t) && (next.IsZero() || run.Before(next)) {
+				next = run
+			}
+		}
+
+		if !next.IsZero() {
+			break
+		}
+	}
+
+	return next
+}
+
+// runDailyUpdate runs the daily update as a refresh job, so that it never runs alongside a refresh
+// requested through the API. It is skipped if a refresh is already running.
+func (s *Scheduler) runDailyUpdate() {
 	s.logger.Infow("Running daily update")
 
-	err := s.transferService.FetchAndStoreTransfers(ctx)
+	job, started, err := s.transferService.RunRefreshJob(context.Background())
 	if err != nil {
 		s.logger.Errorw("Error running daily update", "err", err)
 		return
 	}
 
-	s.logger.Infow("Daily update completed successfully")
+	if !started {
+		s.logger.Infow("Skipping daily update, a refresh is already running", "jobID", job.ID)
+		return
+	}
+
+	if job.Status == service.RefreshJobFailed {
+		s.logger.Errorw("Error running daily update", "jobID", job.ID, "err", job.Error)
+		return
+	}
+
+	s.logger.Infow("Daily update completed successfully", "jobID", job.ID, "inserted", job.Inserted)
 }
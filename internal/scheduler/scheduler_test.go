@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRunAfterTwoDailyTimes(t *testing.T) {
+	// Refreshes at 06:00:00 and 18:30:00
+	offsets := []time.Duration{6 * time.Hour, 18*time.Hour + 30*time.Minute}
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "before the first time", t: day.Add(time.Hour), want: day.Add(6 * time.Hour)},
+		{name: "at the first time", t: day.Add(6 * time.Hour), want: day.Add(18*time.Hour + 30*time.Minute)},
+		{name: "between the times", t: day.Add(12 * time.Hour), want: day.Add(18*time.Hour + 30*time.Minute)},
+		{name: "after the last time", t: day.Add(20 * time.Hour), want: day.Add(30 * time.Hour)},
+		{name: "end of month", t: time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC), want: time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRunAfter(offsets, tt.t); !got.Equal(tt.want) {
+				t.Fatalf("expected the next run at %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextRunAfterKeepsWallClockAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("loading time zone: %v", err)
+	}
+
+	// Clocks move forward at 02:00 on 2024-03-31, a day of 23 hours
+	offsets := []time.Duration{6 * time.Hour, 18 * time.Hour}
+	evening := time.Date(2024, 3, 30, 20, 0, 0, 0, berlin)
+
+	want := time.Date(2024, 3, 31, 6, 0, 0, 0, berlin)
+	if got := nextRunAfter(offsets, evening); !got.Equal(want) {
+		t.Fatalf("expected the next run at %v, got %v", want, got)
+	}
+}
+
+func TestRefreshDue(t *testing.T) {
+	// A Friday
+	friday := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		cronExpr         string
+		dailyRefreshTime string
+		lastCheck, now   time.Time
+		want             bool
+	}{
+		{
+			name:      "cron activation within the tick",
+			cronExpr:  "30 6 * * *",
+			lastCheck: friday.Add(6*time.Hour + 29*time.Minute),
+			now:       friday.Add(6*time.Hour + 30*time.Minute),
+			want:      true,
+		},
+		{
+			name:      "cron activation after the tick",
+			cronExpr:  "30 6 * * *",
+			lastCheck: friday.Add(6*time.Hour + 28*time.Minute),
+			now:       friday.Add(6*time.Hour + 29*time.Minute),
+			want:      false,
+		},
+		{
+			name:      "cron activation at the last check is not repeated",
+			cronExpr:  "30 6 * * *",
+			lastCheck: friday.Add(6*time.Hour + 30*time.Minute),
+			now:       friday.Add(6*time.Hour + 31*time.Minute),
+			want:      false,
+		},
+		{
+			name:      "cron weekdays skip the weekend",
+			cronExpr:  "0 9 * * mon-fri",
+			lastCheck: friday.Add(24*time.Hour + 8*time.Hour),
+			now:       friday.Add(24*time.Hour + 10*time.Hour),
+			want:      false,
+		},
+		{
+			name:             "cron takes precedence over the daily times",
+			cronExpr:         "0 12 * * *",
+			dailyRefreshTime: "06:00:00",
+			lastCheck:        friday.Add(5 * time.Hour),
+			now:              friday.Add(7 * time.Hour),
+			want:             false,
+		},
+		{
+			name:             "daily time within a long tick",
+			dailyRefreshTime: "06:00:00,18:00:00",
+			lastCheck:        friday.Add(17 * time.Hour),
+			now:              friday.Add(19 * time.Hour),
+			want:             true,
+		},
+		{
+			name:             "no daily time within the tick",
+			dailyRefreshTime: "06:00:00,18:00:00",
+			lastCheck:        friday.Add(7 * time.Hour),
+			now:              friday.Add(8 * time.Hour),
+			want:             false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := refreshDue(tt.cronExpr, tt.dailyRefreshTime, tt.lastCheck, tt.now)
+			if err != nil {
+				t.Fatalf("checking the schedule: %v", err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("expected due %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRefreshDueInvalidSchedule(t *testing.T) {
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	if _, err := refreshDue("0 25 * * *", "", now.Add(-time.Hour), now); err == nil {
+		t.Error("expected an invalid cron expression to fail")
+	}
+
+	if _, err := refreshDue("", "noon", now.Add(-time.Hour), now); err == nil {
+		t.Error("expected invalid daily refresh times to fail")
+	}
+}
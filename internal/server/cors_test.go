@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	allowedOrigin    = "https://dashboard.example.com"
+	disallowedOrigin = "https://evil.example.com"
+)
+
+// newCORSTestEngine returns the engine of a server with corsCfg serving GET /api/ping.
+func newCORSTestEngine(t *testing.T, corsCfg CORSConfig) *gin.Engine {
+	t.Helper()
+
+	s, err := New("127.0.0.1:0", corsCfg, nil)
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+
+	engine := s.GetEngine()
+	engine.GET("/api/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return engine
+}
+
+// requestFrom serves a request of method to /api/ping from origin.
+func requestFrom(engine *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/ping", nil)
+	req.Header.Set("Origin", origin)
+
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "Idempotency-Key")
+	}
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestCORSAllowlist(t *testing.T) {
+	engine := newCORSTestEngine(t, CORSConfig{AllowOrigins: []string{allowedOrigin}, AllowAllOrigins: true})
+
+	resp := requestFrom(engine, http.MethodGet, allowedOrigin)
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+		t.Fatalf("expected the allowed origin to be echoed, got %q", got)
+	}
+
+	resp = requestFrom(engine, http.MethodGet, disallowedOrigin)
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no allowed origin for a disallowed origin, got %q", got)
+	}
+
+	resp = requestFrom(engine, http.MethodOptions, allowedOrigin)
+	if resp.Code >= http.StatusBadRequest {
+		t.Fatalf("expected the preflight to succeed, got %d", resp.Code)
+	}
+
+	if got := resp.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Idempotency-Key") {
+		t.Fatalf("expected the Idempotency-Key header to be allowed, got %q", got)
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	engine := newCORSTestEngine(t, CORSConfig{AllowAllOrigins: true})
+
+	resp := requestFrom(engine, http.MethodGet, disallowedOrigin)
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected every origin to be allowed, got %q", got)
+	}
+}
+
+func TestCORSDisabledByDefault(t *testing.T) {
+	engine := newCORSTestEngine(t, CORSConfig{})
+
+	resp := requestFrom(engine, http.MethodGet, allowedOrigin)
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no cross-origin requests to be allowed, got %q", got)
+	}
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected same-origin handling of the request, got %d", resp.Code)
+	}
+}
+
+func TestCORSInvalidOrigin(t *testing.T) {
+	if _, err := New("127.0.0.1:0", CORSConfig{AllowOrigins: []string{"dashboard.example.com"}}, nil); err == nil {
+		t.Fatal("expected an origin without a scheme to be rejected")
+	}
+}
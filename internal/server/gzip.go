@@ -0,0 +1,13 @@
+package server
+
+import (
+	"github.com/gin-contrib/gzip"
+	"github.com/gin-gonic/gin"
+)
+
+// Gzip compresses responses for clients accepting the gzip encoding. Requests whose path starts with
+// one of excludedRoutes, such as event streams and WebSocket upgrades, are never compressed, so their
+// events reach the client as soon as they are written.
+func Gzip(excludedRoutes ...string) gin.HandlerFunc {
+	return gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths(excludedRoutes))
+}
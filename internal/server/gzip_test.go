@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newGzipEngine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Gzip())
+	engine.GET("/", handler)
+
+	return engine
+}
+
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("expected a gzip body: %v", err)
+	}
+
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decode gzip body: %v", err)
+	}
+
+	return string(decoded)
+}
+
+func TestGzip(t *testing.T) {
+	payload := strings.Repeat(`{"hash":"0xabc","amount":"1.5"}`, 100)
+	engine := newGzipEngine(func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(payload))
+	})
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{name: "gzip", acceptEncoding: "gzip", wantGzip: true},
+		{name: "among encodings", acceptEncoding: "br, gzip;q=0.8", wantGzip: true},
+		{name: "not accepted", acceptEncoding: "", wantGzip: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+
+			if !tt.wantGzip {
+				if enc := w.Header().Get("Content-Encoding"); enc != "" {
+					t.Fatalf("expected no Content-Encoding, got %q", enc)
+				}
+
+				if w.Body.String() != payload {
+					t.Fatalf("expected the uncompressed payload, got %q", w.Body.String())
+				}
+
+				return
+			}
+
+			if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+				t.Fatalf("expected Content-Encoding gzip, got %q", enc)
+			}
+
+			if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Fatalf("expected Vary Accept-Encoding, got %q", vary)
+			}
+
+			if w.Body.Len() >= len(payload) {
+				t.Fatalf("expected the body to be compressed, got %d bytes for a %d byte payload", w.Body.Len(), len(payload))
+			}
+
+			if decoded := gunzip(t, w.Body.Bytes()); decoded != payload {
+				t.Fatalf("expected the decoded body to be the payload, got %q", decoded)
+			}
+		})
+	}
+}
+
+func TestGzipExcludedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Gzip("/events"))
+	engine.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "data: first\n\n")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "" {
+		t.Fatalf("expected no Content-Encoding, got %q", enc)
+	}
+
+	if w.Body.String() != "data: first\n\n" {
+		t.Fatalf("expected the event as is, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// requestLogger logs every request with its method, path, status, latency, client IP and response size.
+// Client errors are logged at warn level and server errors at error level.
+func requestLogger(l *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"latency", time.Since(start),
+			"clientIP", c.ClientIP(),
+			"size", max(c.Writer.Size(), 0),
+		}
+
+		if len(c.Errors) > 0 {
+			fields = append(fields, "errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			l.Errorw("HTTP request", fields...)
+		case status >= http.StatusBadRequest:
+			l.Warnw("HTTP request", fields...)
+		default:
+			l.Infow("HTTP request", fields...)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(requestLogger(zap.New(core).Sugar()))
+	engine.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	engine.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	engine.GET("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	tests := []struct {
+		path   string
+		status int
+		level  zapcore.Level
+	}{
+		{path: "/ok", status: http.StatusOK, level: zapcore.InfoLevel},
+		{path: "/missing", status: http.StatusNotFound, level: zapcore.WarnLevel},
+		{path: "/broken", status: http.StatusInternalServerError, level: zapcore.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path+"?q=1", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 log entry, got %d", len(entries))
+			}
+
+			entry := entries[0]
+			if entry.Level != tt.level || entry.Message != "HTTP request" {
+				t.Fatalf("expected an HTTP request entry at %s level, got %q at %s", tt.level, entry.Message, entry.Level)
+			}
+
+			fields := entry.ContextMap()
+			for _, key := range []string{"method", "path", "status", "latency", "clientIP", "size"} {
+				if _, ok := fields[key]; !ok {
+					t.Fatalf("expected field %q, got %v", key, fields)
+				}
+			}
+
+			if fields["method"] != http.MethodGet || fields["path"] != tt.path || fields["clientIP"] != "192.0.2.1" {
+				t.Fatalf("unexpected fields %v", fields)
+			}
+
+			if fields["status"] != int64(tt.status) {
+				t.Fatalf("expected status %d, got %v", tt.status, fields["status"])
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if size := logs.TakeAll()[0].ContextMap()["size"]; size != int64(len("hello")) {
+		t.Fatalf("expected the response size to be logged, got %v", size)
+	}
+}
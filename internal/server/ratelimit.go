@@ -0,0 +1,96 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitBucketTTL is how long an idle client's bucket is kept before it is forgotten.
+const rateLimitBucketTTL = 10 * time.Minute
+
+// tokenBucket holds the tokens available to a single client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a per-client token bucket rate limiter.
+type rateLimiter struct {
+	rps   float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// allow takes a token from the client's bucket. If none is available, it returns false and
+// the time until the next token is available.
+func (r *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+
+	bucket, ok := r.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: r.burst, last: now}
+		r.buckets[client] = bucket
+	}
+
+	// Refill the bucket for the time elapsed since the last request
+	bucket.tokens = math.Min(r.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*r.rps)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / r.rps * float64(time.Second))
+	}
+
+	bucket.tokens--
+
+	return true, 0
+}
+
+// sweep forgets the buckets of clients that have been idle for rateLimitBucketTTL.
+func (r *rateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < rateLimitBucketTTL {
+		return
+	}
+
+	for client, bucket := range r.buckets {
+		if now.Sub(bucket.last) >= rateLimitBucketTTL {
+			delete(r.buckets, client)
+		}
+	}
+
+	r.lastSweep = now
+}
+
+// RateLimit returns a middleware limiting each client IP to rps requests per second with bursts of
+// up to burst requests. Rejected requests get 429 with a Retry-After header.
+func RateLimit(rps float64, burst int) gin.HandlerFunc {
+	limiter := &rateLimiter{
+		rps:       rps,
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.allow(c.ClientIP(), time.Now())
+		if allowed {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.Abort()
+		httputil.RespondError(c, http.StatusTooManyRequests, httputil.CodeRateLimited, "Too many requests, retry later")
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRateLimitedEngine returns an engine rate limiting /api/ping but not /health.
+func newRateLimitedEngine(rps float64, burst int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.Group("/api", RateLimit(rps, burst)).GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return engine
+}
+
+// get serves a GET request of path from clientIP.
+func get(engine *gin.Engine, path, clientIP string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = clientIP + ":1234"
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestRateLimitExceededAndRecovered(t *testing.T) {
+	const burst = 3
+
+	// A token every 50ms
+	engine := newRateLimitedEngine(20, burst)
+
+	for i := range burst {
+		if resp := get(engine, "/api/ping", "192.0.2.1"); resp.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: expected 200, got %d", i+1, resp.Code)
+		}
+	}
+
+	resp := get(engine, "/api/ping", "192.0.2.1")
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the burst is used, got %d", resp.Code)
+	}
+
+	retryAfter, err := strconv.Atoi(resp.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 {
+		t.Fatalf("expected a Retry-After of at least a second, got %q", resp.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own bucket
+	if resp := get(engine, "/api/ping", "192.0.2.2"); resp.Code != http.StatusOK {
+		t.Fatalf("expected another client not to be limited, got %d", resp.Code)
+	}
+
+	// Routes outside the limited group are exempt
+	if resp := get(engine, "/health", "192.0.2.1"); resp.Code != http.StatusOK {
+		t.Fatalf("expected /health to be exempt, got %d", resp.Code)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if resp := get(engine, "/api/ping", "192.0.2.1"); resp.Code != http.StatusOK {
+		t.Fatalf("expected the client to recover after the window, got %d", resp.Code)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter := &rateLimiter{rps: 2, burst: 2, buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+	now := time.Now()
+
+	for range 2 {
+		if allowed, _ := limiter.allow("client", now); !allowed {
+			t.Fatal("expected requests within the burst to be allowed")
+		}
+	}
+
+	allowed, retryAfter := limiter.allow("client", now)
+	if allowed || retryAfter != 500*time.Millisecond {
+		t.Fatalf("expected a rejection with a token due in 500ms, got %v and %s", allowed, retryAfter)
+	}
+
+	if allowed, _ := limiter.allow("client", now.Add(500*time.Millisecond)); !allowed {
+		t.Fatal("expected a request to be allowed once a token is refilled")
+	}
+
+	// Idle clients are forgotten
+	limiter.allow("other", now.Add(rateLimitBucketTTL+time.Second))
+
+	if _, ok := limiter.buckets["client"]; ok {
+		t.Fatal("expected the bucket of an idle client to be swept")
+	}
+}
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		expectedCode   int
+	}{
+		{name: "untrusted peer", expectedCode: http.StatusTooManyRequests},
+		{name: "trusted proxy", trustedProxies: []string{"192.0.2.0/24"}, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			s, err := New("127.0.0.1:0", CORSConfig{}, tt.trustedProxies)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			engine := s.GetEngine()
+			engine.Group("/api", RateLimit(0.001, 1)).GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			// Each request claims another client IP from the same peer
+			var resp *httptest.ResponseRecorder
+			for i := range 3 {
+				req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i+1))
+
+				resp = httptest.NewRecorder()
+				engine.ServeHTTP(resp, req)
+			}
+
+			if resp.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d", tt.expectedCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestNewInvalidTrustedProxies(t *testing.T) {
+	if _, err := New("127.0.0.1:0", CORSConfig{}, []string{"not-an-ip"}); err == nil {
+		t.Fatal("expected an error for an invalid trusted proxy")
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyMethods are the methods still allowed in read-only mode.
+const readOnlyMethods = "GET, HEAD, OPTIONS"
+
+// ReadOnly rejects requests that may modify data, i.e. any method other than GET, HEAD and OPTIONS,
+// with 405 Method Not Allowed. Requests to safeRoutes, which never modify data, are allowed with any
+// method. Routes are matched by their pattern, such as "/api/convert".
+func ReadOnly(safeRoutes ...string) gin.HandlerFunc {
+	safe := make(map[string]struct{}, len(safeRoutes))
+	for _, route := range safeRoutes {
+		safe[route] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if _, ok := safe[c.FullPath()]; ok {
+			c.Next()
+			return
+		}
+
+		c.Header("Allow", readOnlyMethods)
+		c.Abort()
+		httputil.RespondError(c, http.StatusMethodNotAllowed, httputil.CodeReadOnly,
+			"The service runs in read-only mode")
+	}
+}
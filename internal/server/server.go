@@ -2,7 +2,11 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/pprof"
@@ -10,46 +14,93 @@ import (
 	"go.uber.org/zap"
 )
 
+// readHeaderTimeout bounds how long a client may take to send the request headers.
+const readHeaderTimeout = 10 * time.Second
+
 // Server to serve the service.
 type Server struct {
-	s        *gin.Engine
-	bindAddr string
-	l        *zap.SugaredLogger
+	s          *gin.Engine
+	httpServer *http.Server
+	bindAddr   string
+	l          *zap.SugaredLogger
+}
+
+// CORSConfig configures which origins may call the API from a browser.
+type CORSConfig struct {
+	// AllowOrigins lists the allowed origins, e.g. "https://dashboard.example.com".
+	AllowOrigins []string
+	// AllowAllOrigins allows every origin, it only applies when AllowOrigins is empty.
+	AllowAllOrigins bool
 }
 
 // New returns a new server.
-func New(bindAddr string) *Server {
+// Without allowed origins and without AllowAllOrigins, cross-origin requests are not allowed.
+// The client IP, used by the rate limit and the request log, is only taken from the X-Forwarded-For
+// and X-Real-IP headers of requests from the trustedProxies, IP addresses or CIDR ranges. Without
+// trusted proxies it is always the address of the peer, so clients cannot choose their own IP.
+func New(bindAddr string, corsCfg CORSConfig, trustedProxies []string) (*Server, error) {
+	l := zap.S()
+
 	engine := gin.New()
-	engine.Use(gin.Recovery())
+	// Handlers pass the gin context to storage and service calls, so it must carry the request deadline
+	engine.ContextWithFallback = true
+
+	if err := engine.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	engine.Use(requestLogger(l), gin.Recovery())
 
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	engine.Use(cors.New(config))
+	if len(corsCfg.AllowOrigins) > 0 || corsCfg.AllowAllOrigins {
+		config := cors.DefaultConfig()
+		config.AllowOrigins = corsCfg.AllowOrigins
+		config.AllowAllOrigins = len(corsCfg.AllowOrigins) == 0
+		config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+		config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"}
+
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid CORS config: %w", err)
+		}
+
+		engine.Use(cors.New(config))
+	}
 
 	s := &Server{
-		s:        engine,
+		s: engine,
+		httpServer: &http.Server{
+			Addr:              bindAddr,
+			Handler:           engine,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
 		bindAddr: bindAddr,
-		l:        zap.S(),
+		l:        l,
 	}
 
 	gin.SetMode(gin.ReleaseMode)
 	s.register()
 
-	return s
+	return s, nil
 }
 
-// Run runs server.
+// Run runs server until it is shut down.
 func (s *Server) Run() error {
-	if err := s.s.Run(s.bindAddr); err != nil {
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("run server: %w", err)
 	}
 
 	return nil
 }
 
+// Shutdown stops accepting new connections and waits for in-flight requests to finish
+// until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown server: %w", err)
+	}
+
+	return nil
+}
+
 // GetEngine returns the underlying gin engine.
 func (s *Server) GetEngine() *gin.Engine {
 	return s.s
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	addr := l.Addr().String()
+	if err := l.Close(); err != nil {
+		t.Fatalf("closing listener: %v", err)
+	}
+
+	return addr
+}
+
+// waitListening waits until addr accepts connections.
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("server did not listen on %s", addr)
+}
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+
+	s, err := New(addr, CORSConfig{}, nil)
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+
+	started := make(chan struct{})
+	s.GetEngine().GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.Run() }()
+
+	waitListening(t, addr)
+
+	type result struct {
+		status int
+		err    error
+	}
+
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+
+		_ = resp.Body.Close()
+		inFlight <- result{status: resp.StatusCode}
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutting down: %v", err)
+	}
+
+	if res := <-inFlight; res.err != nil || res.status != http.StatusOK {
+		t.Fatalf("expected the in-flight request to complete, got status %d and error %v", res.status, res.err)
+	}
+
+	if err := <-runErr; err != nil {
+		t.Fatalf("expected Run to return without error after shutdown, got %v", err)
+	}
+
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		_ = conn.Close()
+		t.Fatal("expected the listener to be closed")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+
+	s, err := New(addr, CORSConfig{}, nil)
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.GetEngine().GET("/blocked", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	go func() { _ = s.Run() }()
+
+	waitListening(t, addr)
+
+	go func() {
+		if resp, err := http.Get("http://" + addr + "/blocked"); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err == nil {
+		t.Fatal("expected shutdown to fail when in-flight requests outlast the timeout")
+	}
+}
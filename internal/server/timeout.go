@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds the context of every request by timeout, or by longTimeout for the routes in
+// longRoutes, e.g. streaming exports. The routes in unboundedRoutes, such as event streams, are never
+// bounded. Routes are matched by their pattern, such as "/api/transfers/refresh/:address".
+// A non-positive timeout leaves the requests unbounded.
+func Timeout(timeout, longTimeout time.Duration, longRoutes, unboundedRoutes []string) gin.HandlerFunc {
+	routeTimeouts := make(map[string]time.Duration, len(longRoutes)+len(unboundedRoutes))
+	for _, route := range longRoutes {
+		routeTimeouts[route] = longTimeout
+	}
+
+	for _, route := range unboundedRoutes {
+		routeTimeouts[route] = 0
+	}
+
+	return func(c *gin.Context) {
+		requestTimeout, ok := routeTimeouts[c.FullPath()]
+		if !ok {
+			requestTimeout = timeout
+		}
+
+		if requestTimeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	const timeout, longTimeout = 20 * time.Millisecond, 200 * time.Millisecond
+
+	gin.SetMode(gin.TestMode)
+
+	// slow waits far longer than any timeout for the request to be cancelled, and reports how long it
+	// waited and why it stopped
+	slow := func(c *gin.Context) {
+		start := time.Now()
+
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+
+		elapsed := time.Since(start)
+		c.Header("X-Elapsed", elapsed.String())
+
+		if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+			httputil.RespondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed")
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+
+	engine := gin.New()
+	engine.Use(Timeout(timeout, longTimeout, []string{"/export"}, []string{"/stream"}))
+	engine.GET("/slow", slow)
+	engine.GET("/export", slow)
+	engine.GET("/stream", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("expected unbounded routes to have no deadline")
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		path         string
+		wantDeadline time.Duration
+	}{
+		{path: "/slow", wantDeadline: timeout},
+		{path: "/export", wantDeadline: longTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Server errors of requests that ran out of time are reported as timeouts
+			if w.Code != http.StatusGatewayTimeout {
+				t.Fatalf("expected the slow handler to be cancelled with 504, got %d", w.Code)
+			}
+
+			elapsed, err := time.ParseDuration(w.Header().Get("X-Elapsed"))
+			if err != nil {
+				t.Fatalf("parsing elapsed time: %v", err)
+			}
+
+			if elapsed < tt.wantDeadline || elapsed > tt.wantDeadline+time.Second {
+				t.Fatalf("expected the handler to be cancelled at %s, got %s", tt.wantDeadline, elapsed)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the unbounded route to succeed, got %d", w.Code)
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Timeout(0, 0, nil, nil))
+	engine.GET("/", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("expected no deadline with a non-positive timeout")
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFetchWithoutSourceAddresses(t *testing.T) {
+	store := newTestStore(t)
+	fake := newFakeEtherscan(t)
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	inserted, err := s.FetchAndStoreTransfers(context.Background())
+	if err != nil || inserted != 0 {
+		t.Fatalf("expected nothing fetched without error, got %d inserted and error %v", inserted, err)
+	}
+
+	if n := fake.requestCount("txlist", testSource); n != 0 {
+		t.Fatalf("expected no Etherscan requests without source addresses, got %d", n)
+	}
+}
+
+func TestFetchWithoutTargetAddressesWarns(t *testing.T) {
+	store := newTestStore(t)
+	fake := newFakeEtherscan(t)
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	s.logger = zap.New(core).Sugar()
+
+	if _, _, err := store.AddSourceAddress(context.Background(), testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if _, err := s.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("fetching transfers: %v", err)
+	}
+
+	// The transfers are still fetched
+	if n := fake.requestCount("txlist", testSource); n == 0 {
+		t.Fatal("expected the source address to be fetched without target addresses")
+	}
+
+	warnings := logs.FilterMessageSnippet("No target addresses configured")
+	if warnings.Len() != 1 {
+		t.Fatalf("expected one warning about the missing target addresses, got %d", warnings.Len())
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestFetchConcurrency(t *testing.T) {
+	const (
+		addressCount = 8
+		concurrency  = 3
+	)
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	var sources []string
+
+	for i := range addressCount {
+		address := fmt.Sprintf("0x%040x", 0xa100+i)
+		if _, _, err := store.AddSourceAddress(ctx, address, storage.AddressLabels{}); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+
+		sources = append(sources, address)
+	}
+
+	failing := sources[addressCount/2]
+	fake := newFakeEtherscan(t)
+
+	// Every request is held for a while, so that the requests of the workers overlap
+	var inFlight, maxInFlight atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		if strings.EqualFold(r.URL.Query().Get("address"), failing) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Enough API keys that the rate limit of the client does not serialize the requests
+	s, err := NewTransferService(store, zap.NewNop().Sugar(), etherscan.Config{
+		APIKey:  "test",
+		APIKeys: []string{"test2", "test3", "test4"},
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	s.SetFetchConcurrency(concurrency)
+
+	if _, err := s.FetchAndStoreTransfersStrict(ctx); err == nil {
+		t.Fatal("expected the fetch of the failing address to fail the refresh")
+	}
+
+	if got := maxInFlight.Load(); got != concurrency {
+		t.Errorf("expected at most %d addresses fetched at the same time and the bound reached, got %d",
+			concurrency, got)
+	}
+
+	// The failing address does not stop the fetches of the other addresses
+	for _, address := range sources {
+		if address == failing {
+			continue
+		}
+
+		for _, action := range []string{"txlist", "txlistinternal", "tokentx"} {
+			if got := fake.requestCount(action, address); got != 1 {
+				t.Errorf("expected 1 %s request for %s, got %d", action, address, got)
+			}
+		}
+	}
+
+	run, err := s.GetLastRefreshRun(ctx)
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if run.Status != storage.RefreshRunFailed || !slices.Equal(run.FailedAddresses, []string{failing}) {
+		t.Errorf("expected a failed run of %s, got status %s with failed addresses %v",
+			failing, run.Status, run.FailedAddresses)
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+)
+
+func TestIsValidDecimals(t *testing.T) {
+	tests := map[int]bool{0: true, 6: true, 18: true, MaxTokenDecimals: true, -1: false, MaxTokenDecimals + 1: false, 100: false}
+
+	for decimals, want := range tests {
+		if got := IsValidDecimals(decimals); got != want {
+			t.Errorf("IsValidDecimals(%d) = %v, want %v", decimals, got, want)
+		}
+	}
+}
+
+func TestFillTokenMetadataIgnoresInvalidDecimals(t *testing.T) {
+	const tokenAddress = "0x00000000000000000000000000000000000000c3"
+
+	for _, decimals := range []int{-1, 100} {
+		t.Run(fmt.Sprint(decimals), func(t *testing.T) {
+			s := newTestService(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+				writeEtherscanResponse(t, w, "1", "OK", []etherscan.ERC20Transaction{{
+					ContractAddress: tokenAddress,
+					TokenSymbol:     "SPAM",
+					TokenDecimal:    fmt.Sprint(decimals),
+				}})
+			})
+
+			meta := s.FillTokenMetadata(context.Background(), tokenAddress, TokenMetadata{})
+
+			if meta.Decimals == nil || *meta.Decimals != defaultTokenDecimals {
+				t.Fatalf("expected %d looked up decimals to fall back to %d, got %+v", decimals, defaultTokenDecimals, meta)
+			}
+		})
+	}
+}
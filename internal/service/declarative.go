@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// DeclaredAddress is a source or target address declared in a config file.
+type DeclaredAddress struct {
+	Address  string   `yaml:"address"`
+	Label    string   `yaml:"label"`
+	Category string   `yaml:"category"`
+	Tags     []string `yaml:"tags"`
+}
+
+// labels returns the label, category and tags of the address.
+func (a DeclaredAddress) labels() storage.AddressLabels {
+	return storage.AddressLabels{Label: a.Label, Category: a.Category, Tags: a.Tags}
+}
+
+// DeclaredToken is a token declared in a config file. Missing metadata is looked up on Etherscan.
+type DeclaredToken struct {
+	Address  string `yaml:"address"`
+	Symbol   string `yaml:"symbol"`
+	Name     string `yaml:"name"`
+	Decimals *int   `yaml:"decimals"`
+}
+
+// DeclaredSettings are the configuration values declared in a config file. Unset values are left unchanged.
+type DeclaredSettings struct {
+	MinRefreshIntervalHours *int    `yaml:"min_refresh_interval_hours"`
+	DailyRefreshTime        *string `yaml:"daily_refresh_time"`
+	RefreshCron             *string `yaml:"refresh_cron"`
+}
+
+// DeclarativeConfig describes the tracked addresses, tokens and configuration values of the service.
+// Omitted sections are left unchanged.
+type DeclarativeConfig struct {
+	SourceAddresses []DeclaredAddress `yaml:"source_addresses"`
+	TargetAddresses []DeclaredAddress `yaml:"target_addresses"`
+	Tokens          []DeclaredToken   `yaml:"tokens"`
+	Config          DeclaredSettings  `yaml:"config"`
+	// Prune removes stored addresses and tokens missing from the declared sections. ETH is never pruned.
+	Prune bool `yaml:"prune"`
+}
+
+// LoadDeclarativeConfig reads a declarative config from a YAML (or JSON) file and validates its addresses.
+func LoadDeclarativeConfig(path string) (*DeclarativeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var cfg DeclarativeConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validate checks that every declared address is a valid Ethereum address.
+func (c *DeclarativeConfig) validate() error {
+	var errs []error
+
+	for _, addr := range c.SourceAddresses {
+		if !IsValidAddress(addr.Address) {
+			errs = append(errs, fmt.Errorf("invalid source address %q", addr.Address))
+		}
+	}
+
+	for _, addr := range c.TargetAddresses {
+		if !IsValidAddress(addr.Address) {
+			errs = append(errs, fmt.Errorf("invalid target address %q", addr.Address))
+		}
+	}
+
+	for _, token := range c.Tokens {
+		if !IsValidAddress(token.Address) {
+			errs = append(errs, fmt.Errorf("invalid token address %q", token.Address))
+		}
+
+		if token.Decimals != nil && !IsValidDecimals(*token.Decimals) {
+			errs = append(errs, fmt.Errorf("invalid decimals %d of token %q, expected 0 to %d",
+				*token.Decimals, token.Address, MaxTokenDecimals))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ApplyDeclarativeConfig reconciles the stored addresses, tokens and configuration values with cfg:
+// declared addresses and tokens are upserted and, if cfg.Prune is set, stored ones missing from a
+// declared section are deleted.
+func (s *TransferService) ApplyDeclarativeConfig(ctx context.Context, cfg *DeclarativeConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	if cfg.SourceAddresses != nil {
+		if err := s.applySourceAddresses(ctx, cfg.SourceAddresses, cfg.Prune); err != nil {
+			return err
+		}
+	}
+
+	if cfg.TargetAddresses != nil {
+		if err := s.applyTargetAddresses(ctx, cfg.TargetAddresses, cfg.Prune); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Tokens != nil {
+		if err := s.applyTokens(ctx, cfg.Tokens, cfg.Prune); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Config.MinRefreshIntervalHours != nil {
+		if err := s.UpdateRefreshInterval(ctx, *cfg.Config.MinRefreshIntervalHours); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Config.DailyRefreshTime != nil {
+		if err := s.UpdateDailyRefreshTime(ctx, *cfg.Config.DailyRefreshTime); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Config.RefreshCron != nil {
+		if err := s.UpdateRefreshCron(ctx, *cfg.Config.RefreshCron); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TransferService) applySourceAddresses(ctx context.Context, declared []DeclaredAddress, prune bool) error {
+	keep := make(map[string]bool, len(declared))
+
+	for _, addr := range declared {
+		if _, _, err := s.AddSourceAddress(ctx, addr.Address, addr.labels()); err != nil {
+			return fmt.Errorf("upserting source address: %w", err)
+		}
+
+		keep[strings.ToLower(addr.Address)] = true
+	}
+
+	if !prune {
+		s.logger.Infow("Applied declared source addresses", "upserted", len(declared))
+		return nil
+	}
+
+	stored, err := s.store.GetSourceAddresses(ctx)
+	if err != nil {
+		return fmt.Errorf("getting source addresses: %w", err)
+	}
+
+	var removed []string
+
+	for _, addr := range stored {
+		if !keep[addr.Address] {
+			removed = append(removed, addr.Address)
+		}
+	}
+
+	if len(removed) > 0 {
+		if _, err := s.store.DeleteSourceAddressesByAddresses(ctx, removed); err != nil {
+			return fmt.Errorf("pruning source addresses: %w", err)
+		}
+	}
+
+	s.logger.Infow("Applied declared source addresses", "upserted", len(declared), "pruned", len(removed))
+
+	return nil
+}
+
+func (s *TransferService) applyTargetAddresses(ctx context.Context, declared []DeclaredAddress, prune bool) error {
+	keep := make(map[string]bool, len(declared))
+
+	for _, addr := range declared {
+		if _, _, err := s.AddTargetAddress(ctx, addr.Address, addr.labels()); err != nil {
+			return fmt.Errorf("upserting target address: %w", err)
+		}
+
+		keep[strings.ToLower(addr.Address)] = true
+	}
+
+	if !prune {
+		s.logger.Infow("Applied declared target addresses", "upserted", len(declared))
+		return nil
+	}
+
+	stored, err := s.store.GetTargetAddresses(ctx)
+	if err != nil {
+		return fmt.Errorf("getting target addresses: %w", err)
+	}
+
+	var removed []string
+
+	for _, addr := range stored {
+		if !keep[addr.Address] {
+			removed = append(removed, addr.Address)
+		}
+	}
+
+	if len(removed) > 0 {
+		if _, err := s.store.DeleteTargetAddressesByAddresses(ctx, removed); err != nil {
+			return fmt.Errorf("pruning target addresses: %w", err)
+		}
+	}
+
+	s.logger.Infow("Applied declared target addresses", "upserted", len(declared), "pruned", len(removed))
+
+	return nil
+}
+
+func (s *TransferService) applyTokens(ctx context.Context, declared []DeclaredToken, prune bool) error {
+	stored, err := s.store.GetTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("getting tokens: %w", err)
+	}
+
+	storedByAddress := make(map[string]storage.Token, len(stored))
+	for _, token := range stored {
+		storedByAddress[token.Address] = token
+	}
+
+	keep := make(map[string]bool, len(declared))
+
+	for _, token := range declared {
+		address := strings.ToLower(token.Address)
+		keep[address] = true
+
+		if existing, ok := storedByAddress[address]; ok {
+			// Only overwrite the metadata that is declared
+			var symbol, name *string
+			if token.Symbol != "" {
+				symbol = &token.Symbol
+			}
+
+			if token.Name != "" {
+				name = &token.Name
+			}
+
+			if _, err := s.store.UpdateToken(ctx, existing.ID, symbol, name, token.Decimals); err != nil {
+				return fmt.Errorf("updating token: %w", err)
+			}
+
+			continue
+		}
+
+		meta := s.FillTokenMetadata(ctx, address, TokenMetadata{
+			Symbol:   token.Symbol,
+			Name:     token.Name,
+			Decimals: token.Decimals,
+		})
+
+		if _, err := s.store.AddToken(ctx, address, meta.Symbol, meta.Name, *meta.Decimals); err != nil {
+			return fmt.Errorf("adding token: %w", err)
+		}
+	}
+
+	pruned := 0
+
+	if prune {
+		for _, token := range stored {
+			if keep[token.Address] || token.Address == ethTokenAddress {
+				continue
+			}
+
+			if err := s.store.DeleteToken(ctx, token.ID); err != nil {
+				return fmt.Errorf("pruning token: %w", err)
+			}
+
+			pruned++
+		}
+	}
+
+	s.logger.Infow("Applied declared tokens", "upserted", len(declared), "pruned", pruned)
+
+	return nil
+}
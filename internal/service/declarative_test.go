@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// sampleConfig declares a source, a target and a token with complete metadata, so that applying it
+// makes no Etherscan requests.
+const sampleConfig = `
+source_addresses:
+  - address: "0x00000000000000000000000000000000000000A1"
+    label: Hot wallet
+    category: exchange
+    tags: [hot]
+target_addresses:
+  - address: "0x00000000000000000000000000000000000000b2"
+    label: Treasury
+tokens:
+  - address: "0x00000000000000000000000000000000000000c3"
+    symbol: TKN
+    name: Token
+    decimals: 6
+config:
+  min_refresh_interval_hours: 6
+  daily_refresh_time: "03:30"
+`
+
+// writeConfigFile writes content to a config file in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadDeclarativeConfig(t *testing.T) {
+	cfg, err := LoadDeclarativeConfig(writeConfigFile(t, "config.yaml", sampleConfig))
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	if len(cfg.SourceAddresses) != 1 || cfg.SourceAddresses[0].Label != "Hot wallet" ||
+		len(cfg.SourceAddresses[0].Tags) != 1 {
+		t.Fatalf("unexpected source addresses %+v", cfg.SourceAddresses)
+	}
+
+	if len(cfg.Tokens) != 1 || cfg.Tokens[0].Decimals == nil || *cfg.Tokens[0].Decimals != 6 {
+		t.Fatalf("unexpected tokens %+v", cfg.Tokens)
+	}
+
+	if cfg.Config.MinRefreshIntervalHours == nil || *cfg.Config.MinRefreshIntervalHours != 6 ||
+		cfg.Config.RefreshCron != nil || cfg.Prune {
+		t.Fatalf("unexpected settings %+v, prune %v", cfg.Config, cfg.Prune)
+	}
+}
+
+func TestLoadDeclarativeConfigJSON(t *testing.T) {
+	const content = `{"target_addresses": [{"address": "0x00000000000000000000000000000000000000b2"}], "prune": true}`
+
+	cfg, err := LoadDeclarativeConfig(writeConfigFile(t, "config.json", content))
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	if len(cfg.TargetAddresses) != 1 || cfg.SourceAddresses != nil || !cfg.Prune {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadDeclarativeConfigInvalid(t *testing.T) {
+	const content = `
+source_addresses:
+  - address: "0x1234"
+tokens:
+  - address: "0x00000000000000000000000000000000000000c3"
+    decimals: 100
+`
+
+	_, err := LoadDeclarativeConfig(writeConfigFile(t, "config.yaml", content))
+	if err == nil {
+		t.Fatal("expected an invalid config to fail")
+	}
+
+	for _, want := range []string{`invalid source address "0x1234"`, "invalid decimals 100"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected the error to report %q, got %v", want, err)
+		}
+	}
+
+	if _, err := LoadDeclarativeConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected a missing file to fail")
+	}
+}
+
+func TestApplyDeclarativeConfig(t *testing.T) {
+	store := newTestStore(t)
+	s := newTestService(t, store, noTransactions(t))
+	ctx := context.Background()
+
+	const (
+		stale      = "0x00000000000000000000000000000000000000a9"
+		staleToken = "0x00000000000000000000000000000000000000c9"
+	)
+
+	cfg, err := LoadDeclarativeConfig(writeConfigFile(t, "config.yaml", sampleConfig))
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	if _, _, err := s.AddSourceAddress(ctx, stale, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if _, err := store.AddToken(ctx, staleToken, "OLD", "Old", 18); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	if err := s.ApplyDeclarativeConfig(ctx, cfg); err != nil {
+		t.Fatalf("applying config: %v", err)
+	}
+
+	sources, err := store.GetSourceAddresses(ctx)
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	// Without prune the stored address is kept, and the declared address is stored in lowercase
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 source addresses, got %+v", sources)
+	}
+
+	for _, source := range sources {
+		if source.Address == "0x00000000000000000000000000000000000000a1" &&
+			(source.Label != "Hot wallet" || source.Category != "exchange") {
+			t.Fatalf("expected the declared labels, got %+v", source)
+		}
+	}
+
+	interval, err := s.GetRefreshInterval(ctx)
+	if err != nil || interval != 6 {
+		t.Fatalf("expected a refresh interval of 6 hours, got %d and error %v", interval, err)
+	}
+
+	dailyTime, err := s.GetDailyRefreshTime(ctx)
+	if err != nil || dailyTime != "03:30" {
+		t.Fatalf("expected a daily refresh time of 03:30, got %q and error %v", dailyTime, err)
+	}
+
+	cfg.Prune = true
+	if err := s.ApplyDeclarativeConfig(ctx, cfg); err != nil {
+		t.Fatalf("applying config with prune: %v", err)
+	}
+
+	sources, err = store.GetSourceAddresses(ctx)
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	if len(sources) != 1 || sources[0].Address != "0x00000000000000000000000000000000000000a1" {
+		t.Fatalf("expected only the declared source address to remain, got %+v", sources)
+	}
+
+	tokens, err := store.GetTokens(ctx)
+	if err != nil {
+		t.Fatalf("getting tokens: %v", err)
+	}
+
+	addresses := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		addresses[token.Address] = true
+	}
+
+	if addresses[staleToken] || !addresses["0x00000000000000000000000000000000000000c3"] || !addresses[ethTokenAddress] {
+		t.Fatalf("expected the declared token and ETH to remain, got %+v", tokens)
+	}
+}
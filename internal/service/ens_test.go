@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// stubResolver resolves the names in names, and fails with err for any other address.
+type stubResolver struct {
+	names map[string]string
+	err   error
+}
+
+func (r stubResolver) LookupName(_ context.Context, address string) (string, error) {
+	if name, ok := r.names[address]; ok {
+		return name, nil
+	}
+
+	return "", r.err
+}
+
+func TestResolveENS(t *testing.T) {
+	const named, unnamed = "0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000a2"
+
+	s := newTestService(t, nil, nil)
+
+	if got := s.ResolveENS(context.Background(), named); got != "" {
+		t.Errorf("ResolveENS without a resolver = %q, want empty", got)
+	}
+
+	s.SetENSResolver(stubResolver{names: map[string]string{named: "alice.eth"}, err: errors.New("no name")})
+
+	if got := s.ResolveENS(context.Background(), named); got != "alice.eth" {
+		t.Errorf("ResolveENS(%s) = %q, want alice.eth", named, got)
+	}
+
+	if got := s.ResolveENS(context.Background(), unnamed); got != "" {
+		t.Errorf("ResolveENS of a failed lookup = %q, want empty", got)
+	}
+}
+
+func TestAddAddressENSLabel(t *testing.T) {
+	const (
+		named    = "0x00000000000000000000000000000000000000a1"
+		labelled = "0x00000000000000000000000000000000000000a2"
+		unnamed  = "0x00000000000000000000000000000000000000a3"
+	)
+
+	store := newTestStore(t)
+	s := newTestService(t, store, nil)
+	s.SetENSResolver(stubResolver{
+		names: map[string]string{named: "alice.eth", labelled: "bob.eth"},
+		err:   errors.New("lookup failed"),
+	})
+
+	ctx := context.Background()
+
+	source, _, err := s.AddSourceAddress(ctx, named, storage.AddressLabels{Label: "  "})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if source.Label != "alice.eth" {
+		t.Errorf("source label = %q, want the ENS name alice.eth", source.Label)
+	}
+
+	target, _, err := s.AddTargetAddress(ctx, labelled, storage.AddressLabels{Label: "Treasury"})
+	if err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	if target.Label != "Treasury" {
+		t.Errorf("target label = %q, want the explicit label Treasury", target.Label)
+	}
+
+	// A failed lookup leaves the label empty but still adds the address
+	source, _, err = s.AddSourceAddress(ctx, unnamed, storage.AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address with a failing lookup: %v", err)
+	}
+
+	if source.Label != "" {
+		t.Errorf("source label = %q, want empty after a failed lookup", source.Label)
+	}
+}
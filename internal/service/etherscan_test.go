@@ -0,0 +1,155 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+)
+
+// fakeEtherscan serves the transactions of the Etherscan list actions, filtered by the address,
+// contract address and start block of the request like Etherscan does. All transactions are served in
+// a single page.
+type fakeEtherscan struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	eth      []etherscan.ETHTransaction
+	internal []etherscan.InternalTransaction
+	erc20    []etherscan.ERC20Transaction
+	// fail makes the requests of the actions fail with 503.
+	fail map[string]bool
+	// requests counts the requests of each action by address.
+	requests map[string]int
+}
+
+func newFakeEtherscan(t *testing.T) *fakeEtherscan {
+	return &fakeEtherscan{t: t, fail: make(map[string]bool), requests: make(map[string]int)}
+}
+
+// requestKey identifies the requests of an action for an address in fakeEtherscan.requests.
+func requestKey(action, address string) string {
+	return action + " " + strings.ToLower(address)
+}
+
+// requestCount returns the number of requests of an action for an address.
+func (f *fakeEtherscan) requestCount(action, address string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests[requestKey(action, address)]
+}
+
+// setFailing makes the requests of an action fail, or succeed again.
+func (f *fakeEtherscan) setFailing(action string, failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fail[action] = failing
+}
+
+func (f *fakeEtherscan) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	action := query.Get("action")
+	address := strings.ToLower(query.Get("address"))
+	contract := strings.ToLower(query.Get("contractaddress"))
+	startBlock, _ := strconv.ParseInt(query.Get("startblock"), 10, 64)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests[requestKey(action, address)]++
+
+	if f.fail[action] {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	// matches reports whether a transaction is one Etherscan returns for the request
+	matches := func(from, to, token, block string) bool {
+		number, _ := strconv.ParseInt(block, 10, 64)
+
+		return (address == "" || strings.EqualFold(from, address) || strings.EqualFold(to, address)) &&
+			(contract == "" || strings.EqualFold(token, contract)) &&
+			number >= startBlock
+	}
+
+	var result any
+
+	switch action {
+	case "txlist":
+		var txs []etherscan.ETHTransaction
+
+		for _, tx := range f.eth {
+			if matches(tx.From, tx.To, "", tx.BlockNumber) {
+				txs = append(txs, tx)
+			}
+		}
+
+		result = txs
+	case "txlistinternal":
+		var txs []etherscan.InternalTransaction
+
+		for _, tx := range f.internal {
+			if matches(tx.From, tx.To, "", tx.BlockNumber) {
+				txs = append(txs, tx)
+			}
+		}
+
+		result = txs
+	case "tokentx":
+		var txs []etherscan.ERC20Transaction
+
+		for _, tx := range f.erc20 {
+			if matches(tx.From, tx.To, tx.ContractAddress, tx.BlockNumber) {
+				txs = append(txs, tx)
+			}
+		}
+
+		result = txs
+	default:
+		f.t.Errorf("unexpected Etherscan action %q", action)
+		return
+	}
+
+	writeEtherscanResponse(f.t, w, "1", "OK", result)
+}
+
+// recentTime returns the time of block of the fake transactions, within the last hour, so that
+// fetches of the last month return them.
+func recentTime(block int64) string {
+	return strconv.FormatInt(time.Now().Add(-time.Hour).Unix()+block, 10)
+}
+
+// ethTransfer returns a successful ETH transaction in block.
+func ethTransfer(hash, from, to, value string, block int64) etherscan.ETHTransaction {
+	return etherscan.ETHTransaction{
+		BlockNumber: strconv.FormatInt(block, 10),
+		TimeStamp:   recentTime(block),
+		Hash:        hash,
+		From:        from,
+		To:          to,
+		Value:       value,
+		IsError:     "0",
+	}
+}
+
+// erc20Transfer returns an ERC20 transfer of token in block.
+func erc20Transfer(hash, from, to, token, value string, block int64) etherscan.ERC20Transaction {
+	return etherscan.ERC20Transaction{
+		BlockNumber:     strconv.FormatInt(block, 10),
+		TimeStamp:       recentTime(block),
+		Hash:            hash,
+		From:            from,
+		To:              to,
+		Value:           value,
+		ContractAddress: token,
+		TokenSymbol:     "TKN",
+		TokenName:       "Token",
+		TokenDecimal:    "6",
+	}
+}
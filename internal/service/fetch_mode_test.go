@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestSetFetchMode(t *testing.T) {
+	s := newTestService(t, nil, nil)
+
+	s.SetFetchMode(FetchModeKnownTokens)
+
+	if s.fetchMode != FetchModeKnownTokens {
+		t.Fatalf("expected fetch mode %s, got %s", FetchModeKnownTokens, s.fetchMode)
+	}
+
+	s.SetFetchMode("some-tokens")
+
+	if s.fetchMode != FetchModeAll {
+		t.Fatalf("expected an unsupported fetch mode to fall back to %s, got %s", FetchModeAll, s.fetchMode)
+	}
+}
+
+func TestFetchModes(t *testing.T) {
+	tests := []struct {
+		mode        FetchMode
+		want        []string
+		cursorToken string
+		cursorBlock int64
+	}{
+		// A single query returns the transfers of every token, spam included
+		{mode: FetchModeAll, want: []string{"0x01", "0x02", "0x03"}, cursorToken: storage.AllERC20Tokens, cursorBlock: 102},
+		// Only the catalogued token is queried, with its own cursor
+		{mode: FetchModeKnownTokens, want: []string{"0x01", "0x02"}, cursorToken: knownToken, cursorBlock: 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, store := ingestionFixture(t)
+			s.SetFetchMode(tt.mode)
+
+			ctx := context.Background()
+
+			if _, err := s.FetchAndStoreForAddress(ctx, testSource); err != nil {
+				t.Fatalf("fetching address: %v", err)
+			}
+
+			if got := storedHashes(t, store); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected transfers %v to be stored, got %v", tt.want, got)
+			}
+
+			block, ok, err := store.GetFetchCursor(ctx, testSource, tt.cursorToken, 1)
+			if err != nil || !ok {
+				t.Fatalf("expected a fetch cursor of %s, got found %v and error %v", tt.cursorToken, ok, err)
+			}
+
+			if block != tt.cursorBlock {
+				t.Fatalf("expected the cursor of %s at block %d, got %d", tt.cursorToken, tt.cursorBlock, block)
+			}
+		})
+	}
+}
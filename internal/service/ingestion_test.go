@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// Tokens of the ingestion fixture.
+const (
+	knownToken = "0x00000000000000000000000000000000000000c3"
+	spamToken  = "0x00000000000000000000000000000000000000c9"
+)
+
+// ingestionFixture returns a service tracking the test source whose Etherscan returns a mixed batch:
+// a transfer and a zero-value transfer of a catalogued token, and a transfer of an unknown token.
+func ingestionFixture(t *testing.T) (*TransferService, *storage.Storage) {
+	t.Helper()
+
+	const target = "0x00000000000000000000000000000000000000b2"
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	spam := erc20Transfer("0x03", testSource, target, spamToken, "1000000", 102)
+	spam.TokenSymbol = "SPAM"
+
+	fake := newFakeEtherscan(t)
+	fake.erc20 = []etherscan.ERC20Transaction{
+		erc20Transfer("0x01", testSource, target, knownToken, "5", 100),
+		erc20Transfer("0x02", testSource, target, knownToken, "0", 101),
+		spam,
+	}
+
+	if _, err := store.AddToken(ctx, knownToken, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	return newTestService(t, store, fake.ServeHTTP), store
+}
+
+// storedHashes returns the hashes of the stored transfers in block order.
+func storedHashes(t *testing.T, store *storage.Storage) []string {
+	t.Helper()
+
+	var hashes []string
+	for _, transfer := range storedTransfers(t, store) {
+		hashes = append(hashes, transfer.Hash)
+	}
+
+	return hashes
+}
+
+func TestIngestionFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter IngestionFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"0x01", "0x02", "0x03"}},
+		{name: "skip zero value", filter: IngestionFilter{SkipZeroValue: true}, want: []string{"0x01", "0x03"}},
+		{name: "only known tokens", filter: IngestionFilter{OnlyKnownTokens: true}, want: []string{"0x01", "0x02"}},
+		{
+			name:   "both",
+			filter: IngestionFilter{SkipZeroValue: true, OnlyKnownTokens: true},
+			want:   []string{"0x01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := ingestionFixture(t)
+			s.SetIngestionFilter(tt.filter)
+
+			if _, err := s.FetchAndStoreForAddress(context.Background(), testSource); err != nil {
+				t.Fatalf("fetching address: %v", err)
+			}
+
+			if got := storedHashes(t, store); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected transfers %v to be stored, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIngestionFilterAdvancesCursorPastSkipped(t *testing.T) {
+	s, store := ingestionFixture(t)
+	s.SetIngestionFilter(IngestionFilter{SkipZeroValue: true, OnlyKnownTokens: true})
+
+	if _, err := s.FetchAndStoreForAddress(context.Background(), testSource); err != nil {
+		t.Fatalf("fetching address: %v", err)
+	}
+
+	// The skipped spam transfer in block 102 is not fetched again
+	block, ok, err := store.GetFetchCursor(context.Background(), testSource, storage.AllERC20Tokens, 1)
+	if err != nil || !ok || block != 102 {
+		t.Fatalf("expected the ERC20 cursor at block 102, got %d (found %v, error %v)", block, ok, err)
+	}
+}
+
+// tokensByAddress returns the catalogued tokens keyed by address.
+func tokensByAddress(t *testing.T, store *storage.Storage) map[string]storage.Token {
+	t.Helper()
+
+	tokens, err := store.GetTokens(context.Background())
+	if err != nil {
+		t.Fatalf("getting tokens: %v", err)
+	}
+
+	byAddress := make(map[string]storage.Token, len(tokens))
+	for _, token := range tokens {
+		byAddress[token.Address] = token
+	}
+
+	return byAddress
+}
+
+func TestAutoAddTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    IngestionFilter
+		wantAdded bool
+	}{
+		{name: "auto add", filter: IngestionFilter{AutoAddTokens: true}, wantAdded: true},
+		{name: "disabled", filter: IngestionFilter{}, wantAdded: false},
+		// Unknown tokens are not stored, so there is nothing to add
+		{name: "only known tokens", filter: IngestionFilter{AutoAddTokens: true, OnlyKnownTokens: true}, wantAdded: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := ingestionFixture(t)
+			s.SetIngestionFilter(tt.filter)
+
+			if _, err := s.FetchAndStoreForAddress(context.Background(), testSource); err != nil {
+				t.Fatalf("fetching address: %v", err)
+			}
+
+			tokens := tokensByAddress(t, store)
+
+			spam, added := tokens[spamToken]
+			if added != tt.wantAdded {
+				t.Fatalf("expected the unknown token added: %v, got %v", tt.wantAdded, added)
+			}
+
+			if added && (spam.Symbol != "SPAM" || spam.Name != "Token" || spam.Decimals != 6) {
+				t.Fatalf("expected the unknown token added with its transfer metadata, got %+v", spam)
+			}
+
+			// Catalogued tokens are left alone
+			if known := tokens[knownToken]; known.Symbol != "TKN" || known.Decimals != 6 {
+				t.Fatalf("expected the catalogued token unchanged, got %+v", known)
+			}
+		})
+	}
+}
+
+func TestAddUnknownTokensSanitizesMetadata(t *testing.T) {
+	store := newTestStore(t)
+	s := newTestService(t, store, nil)
+
+	spam := erc20Transfer("0x01", testSource, testSource, spamToken, "1", 100)
+	spam.TokenSymbol = strings.Repeat("€", maxTokenSymbolLength+5)
+	spam.TokenName = strings.Repeat("x", maxTokenNameLength+1)
+	spam.TokenDecimal = "not a number"
+
+	s.addUnknownTokens(context.Background(), map[string]etherscan.ERC20Transaction{spamToken: spam})
+
+	token, ok := tokensByAddress(t, store)[spamToken]
+	if !ok {
+		t.Fatal("expected the token with overlong metadata to be added")
+	}
+
+	if want := strings.Repeat("€", maxTokenSymbolLength); token.Symbol != want {
+		t.Errorf("expected the symbol cut to %d runes, got %q", maxTokenSymbolLength, token.Symbol)
+	}
+
+	if len(token.Name) != maxTokenNameLength {
+		t.Errorf("expected the name cut to %d characters, got %d", maxTokenNameLength, len(token.Name))
+	}
+
+	if token.Decimals != defaultTokenDecimals {
+		t.Errorf("expected unparseable decimals to default to %d, got %d", defaultTokenDecimals, token.Decimals)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{s: "TKN", n: 20, want: "TKN"},
+		{s: "TOKEN", n: 3, want: "TOK"},
+		{s: "€€€", n: 2, want: "€€"},
+		{s: "", n: 3, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// internalTransfer returns an internal transaction of ETH in block.
+func internalTransfer(hash, from, to, value string, block int64) etherscan.InternalTransaction {
+	return etherscan.InternalTransaction{
+		BlockNumber: strconv.FormatInt(block, 10),
+		TimeStamp:   recentTime(block),
+		Hash:        hash,
+		From:        from,
+		To:          to,
+		Value:       value,
+		Type:        "call",
+		IsError:     "0",
+	}
+}
+
+func TestFetchStoresInternalTransfers(t *testing.T) {
+	const target = "0x00000000000000000000000000000000000000b2"
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	failed := internalTransfer("0x02", testSource, target, "2000", 101)
+	failed.IsError = "1"
+
+	creation := internalTransfer("0x03", testSource, "", "3000", 102)
+	creation.Type = "create"
+
+	fake := newFakeEtherscan(t)
+	fake.internal = []etherscan.InternalTransaction{
+		internalTransfer("0x01", testSource, target, "1000", 100),
+		failed,
+		creation,
+	}
+
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if _, err := s.FetchAndStoreForAddress(ctx, testSource); err != nil {
+		t.Fatalf("fetching address: %v", err)
+	}
+
+	transfers := storedTransfers(t, store)
+	if len(transfers) != 1 {
+		t.Fatalf("expected only the successful trace to be stored, got %+v", transfers)
+	}
+
+	got := transfers[0].Transfer
+	if got.Hash != "0x01" || got.Type != storage.TransferTypeInternal || got.TokenAddress != ethTokenAddress ||
+		got.Amount != "1000" || got.ToAddress != target {
+		t.Fatalf("expected an internal ETH transfer of 1000 to the target, got %+v", got)
+	}
+
+	// The cursor covers the failed traces, so they are not fetched again
+	block, ok, err := store.GetFetchCursor(ctx, testSource, storage.InternalTransfers, 1)
+	if err != nil || !ok || block != 102 {
+		t.Fatalf("expected the internal transfers cursor at block 102, got %d (found %v, error %v)", block, ok, err)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/notify"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// stubNotifier records the notifications it is sent and fails with err.
+type stubNotifier struct {
+	notifications []notify.Notification
+	err           error
+}
+
+func (n *stubNotifier) Notify(_ context.Context, notification notify.Notification) error {
+	n.notifications = append(n.notifications, notification)
+
+	return n.err
+}
+
+// notifyFixture returns a service tracking the test source, whose Etherscan returns two transfers of a
+// catalogued 6 decimals token.
+func notifyFixture(t *testing.T) (*TransferService, string) {
+	t.Helper()
+
+	const (
+		target = "0x00000000000000000000000000000000000000b2"
+		token  = "0x00000000000000000000000000000000000000c3"
+	)
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fake := newFakeEtherscan(t)
+	fake.erc20 = []etherscan.ERC20Transaction{
+		erc20Transfer("0x03", testSource, target, token, "1000000", 102),
+		erc20Transfer("0x04", testSource, target, token, "500000", 103),
+	}
+
+	if _, err := store.AddToken(ctx, token, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	return newTestService(t, store, fake.ServeHTTP), token
+}
+
+func TestFetchNotifiesInsertedTransfers(t *testing.T) {
+	s, token := notifyFixture(t)
+
+	notifier := &stubNotifier{}
+	s.SetNotifier(notifier, 2)
+
+	if _, err := s.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("fetching transfers: %v", err)
+	}
+
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(notifier.notifications))
+	}
+
+	notification := notifier.notifications[0]
+	if notification.Inserted != 2 || len(notification.Tokens) != 1 {
+		t.Fatalf("expected 2 transfers of a token, got %+v", notification)
+	}
+
+	got := notification.Tokens[0]
+	if got.TokenAddress != token || got.Symbol != "TKN" || got.Amount != "1500000" || got.NormalizedAmount != "1.5" {
+		t.Fatalf("expected 1.5 TKN, got %+v", got)
+	}
+
+	// Nothing new is stored, so nothing is notified
+	if _, err := s.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("fetching transfers again: %v", err)
+	}
+
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("expected no notification without new transfers, got %d", len(notifier.notifications))
+	}
+}
+
+func TestFetchNotificationThreshold(t *testing.T) {
+	s, _ := notifyFixture(t)
+
+	notifier := &stubNotifier{}
+	s.SetNotifier(notifier, 3)
+
+	if _, err := s.FetchAndStoreTransfers(context.Background()); err != nil {
+		t.Fatalf("fetching transfers: %v", err)
+	}
+
+	if len(notifier.notifications) != 0 {
+		t.Fatalf("expected no notification below the threshold, got %+v", notifier.notifications)
+	}
+}
+
+func TestFetchNotificationFailureIsNotFatal(t *testing.T) {
+	s, _ := notifyFixture(t)
+
+	notifier := &stubNotifier{err: errors.New("webhook unavailable")}
+	s.SetNotifier(notifier, 1)
+
+	inserted, err := s.FetchAndStoreTransfers(context.Background())
+	if err != nil {
+		t.Fatalf("expected a failed notification not to fail the fetch, got %v", err)
+	}
+
+	if inserted != 2 || len(notifier.notifications) != 1 {
+		t.Fatalf("expected 2 transfers stored and notified, got %d and %d notifications",
+			inserted, len(notifier.notifications))
+	}
+}
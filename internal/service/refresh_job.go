@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxRefreshJobs is the number of finished jobs kept for status lookups.
+const maxRefreshJobs = 100
+
+// RefreshIdempotencyTTL is how long the job of an idempotency key is returned for repeated requests
+// with the same key instead of starting another refresh.
+const RefreshIdempotencyTTL = 10 * time.Minute
+
+// RefreshJobStatus is the state of a background refresh job.
+type RefreshJobStatus string
+
+// Refresh job states.
+const (
+	RefreshJobRunning RefreshJobStatus = "running"
+	RefreshJobDone    RefreshJobStatus = "done"
+	RefreshJobFailed  RefreshJobStatus = "failed"
+)
+
+// RefreshJob describes a background refresh of all transfers.
+type RefreshJob struct {
+	ID         string           `json:"id"`
+	Status     RefreshJobStatus `json:"status"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Inserted   int              `json:"inserted"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// idempotentRefresh is the outcome of the first request with an idempotency key.
+type idempotentRefresh struct {
+	jobID     string
+	started   bool
+	expiresAt time.Time
+}
+
+// refreshJobs keeps track of refresh jobs and ensures only one runs at a time.
+type refreshJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*RefreshJob
+	order   []string
+	running *RefreshJob
+	// done holds a channel per job that is closed when the job finishes.
+	done map[string]chan struct{}
+	// keys maps the idempotency keys of recent requests to their outcome.
+	keys map[string]idempotentRefresh
+}
+
+func newRefreshJobs() *refreshJobs {
+	return &refreshJobs{
+		jobs: make(map[string]*RefreshJob),
+		done: make(map[string]chan struct{}),
+		keys: make(map[string]idempotentRefresh),
+	}
+}
+
+// start registers a new running job unless one is already running.
+// It returns a copy of the running job and whether it was newly started.
+// A non-empty idempotency key seen within RefreshIdempotencyTTL replays the outcome of its first
+// request, with the current state of its job, and replayed is true.
+func (r *refreshJobs) start(idempotencyKey string) (job RefreshJob, started, replayed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for key, refresh := range r.keys {
+		if !now.Before(refresh.expiresAt) {
+			delete(r.keys, key)
+		}
+	}
+
+	if refresh, ok := r.keys[idempotencyKey]; ok {
+		// The job may have been forgotten, in which case the key starts a new one
+		if job, ok := r.jobs[refresh.jobID]; ok {
+			return *job, refresh.started, true, nil
+		}
+	}
+
+	job, started, err = r.startLocked()
+	if err == nil && idempotencyKey != "" {
+		r.keys[idempotencyKey] = idempotentRefresh{
+			jobID:     job.ID,
+			started:   started,
+			expiresAt: now.Add(RefreshIdempotencyTTL),
+		}
+	}
+
+	return job, started, false, err
+}
+
+// startLocked is start without an idempotency key. r.mu must be held.
+func (r *refreshJobs) startLocked() (RefreshJob, bool, error) {
+	if r.running != nil {
+		return *r.running, false, nil
+	}
+
+	id, err := newRefreshJobID()
+	if err != nil {
+		return RefreshJob{}, false, err
+	}
+
+	job := &RefreshJob{
+		ID:        id,
+		Status:    RefreshJobRunning,
+		StartedAt: time.Now(),
+	}
+
+	r.jobs[id] = job
+	r.done[id] = make(chan struct{})
+	r.order = append(r.order, id)
+	r.running = job
+
+	// Forget the oldest jobs, the running job is always the newest
+	for len(r.order) > maxRefreshJobs {
+		delete(r.jobs, r.order[0])
+		delete(r.done, r.order[0])
+		r.order = r.order[1:]
+	}
+
+	return *job, true, nil
+}
+
+// finish records the outcome of the running job.
+func (r *refreshJobs) finish(id string, inserted int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Inserted = inserted
+	job.Status = RefreshJobDone
+
+	if err != nil {
+		job.Status = RefreshJobFailed
+		job.Error = err.Error()
+	}
+
+	if r.running != nil && r.running.ID == id {
+		r.running = nil
+	}
+
+	if done, ok := r.done[id]; ok {
+		close(done)
+		delete(r.done, id)
+	}
+}
+
+// get returns a copy of the job with the given ID.
+func (r *refreshJobs) get(id string) (RefreshJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return RefreshJob{}, false
+	}
+
+	return *job, true
+}
+
+// wait returns the job with the given ID once it has finished, or when ctx is done.
+// It returns false if the job does not exist or is still running.
+func (r *refreshJobs) wait(ctx context.Context, id string) (RefreshJob, bool) {
+	r.mu.Lock()
+	done, ok := r.done[id]
+	r.mu.Unlock()
+
+	if ok {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+
+	job, ok := r.get(id)
+	if !ok || job.Status == RefreshJobRunning {
+		return job, false
+	}
+
+	return job, true
+}
+
+// newRefreshJobID returns a random hex job ID.
+func newRefreshJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating job id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// StartRefreshJob starts a background refresh of all transfers.
+// If a refresh job is already running, that job is returned and started is false.
+// Clients retrying a request can pass the same non-empty idempotencyKey: for RefreshIdempotencyTTL,
+// repeated keys return the job and started of the first request, with replayed set, instead of
+// starting another refresh.
+func (s *TransferService) StartRefreshJob(idempotencyKey string) (job RefreshJob, started, replayed bool, err error) {
+	return s.startRefreshJob(idempotencyKey, s.FetchAndStoreTransfers)
+}
+
+// StartStaleRefreshJob is StartRefreshJob, but the job only runs the refresh that is due as reported by
+// ShouldRefreshData, see RefreshStaleData.
+func (s *TransferService) StartStaleRefreshJob() (job RefreshJob, started bool, err error) {
+	job, started, _, err = s.startRefreshJob("", s.RefreshStaleData)
+
+	return job, started, err
+}
+
+// startRefreshJob starts a background job running refresh, see StartRefreshJob.
+func (s *TransferService) startRefreshJob(
+	idempotencyKey string, refresh func(context.Context) (int, error),
+) (job RefreshJob, started, replayed bool, err error) {
+	job, started, replayed, err = s.refreshJobs.start(idempotencyKey)
+	if err != nil || !started || replayed {
+		return job, started, replayed, err
+	}
+
+	go func(id string) {
+		ctx, cancel := s.FetchContext(context.Background())
+		defer cancel()
+
+		inserted, err := refresh(ctx)
+		if err != nil {
+			s.logger.Errorw("Refresh job failed", "jobID", id, "err", err)
+		} else {
+			s.logger.Infow("Refresh job completed", "jobID", id, "inserted", inserted)
+		}
+
+		s.refreshJobs.finish(id, inserted, err)
+	}(job.ID)
+
+	return job, true, false, nil
+}
+
+// RunRefreshJob runs a refresh of all transfers as a refresh job and waits until it finishes or ctx
+// is done, for scheduled refreshes. If a refresh job is already running, no other refresh is started:
+// the running job is returned right away and started is false.
+func (s *TransferService) RunRefreshJob(ctx context.Context) (job RefreshJob, started bool, err error) {
+	return s.runRefreshJob(ctx, s.FetchAndStoreTransfers)
+}
+
+// runRefreshJob is RunRefreshJob running refresh.
+func (s *TransferService) runRefreshJob(
+	ctx context.Context, refresh func(context.Context) (int, error),
+) (job RefreshJob, started bool, err error) {
+	job, started, _, err = s.startRefreshJob("", refresh)
+	if err != nil || !started {
+		return job, started, err
+	}
+
+	job, _ = s.refreshJobs.wait(ctx, job.ID)
+
+	return job, true, nil
+}
+
+// WaitRefreshJob waits until the refresh job with the given ID finishes or ctx is done.
+// It returns the job and whether it has finished.
+func (s *TransferService) WaitRefreshJob(ctx context.Context, id string) (RefreshJob, bool) {
+	return s.refreshJobs.wait(ctx, id)
+}
+
+// GetRefreshJob returns the refresh job with the given ID.
+func (s *TransferService) GetRefreshJob(id string) (RefreshJob, bool) {
+	return s.refreshJobs.get(id)
+}
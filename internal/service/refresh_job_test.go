@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+func TestRefreshJobsSingleFlight(t *testing.T) {
+	jobs := newRefreshJobs()
+
+	first, started, _, err := jobs.start("")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || first.Status != RefreshJobRunning {
+		t.Fatalf("expected a new running job, got started %v and %+v", started, first)
+	}
+
+	second, started, _, err := jobs.start("")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if started || second.ID != first.ID {
+		t.Fatalf("expected the running job %s to be returned, got started %v and %s", first.ID, started, second.ID)
+	}
+
+	jobs.finish(first.ID, 3, nil)
+
+	third, started, _, err := jobs.start("")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || third.ID == first.ID {
+		t.Fatalf("expected a new job after %s finished, got started %v and %s", first.ID, started, third.ID)
+	}
+}
+
+func TestRefreshJobsStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status RefreshJobStatus
+	}{
+		{name: "success", status: RefreshJobDone},
+		{name: "failure", err: errors.New("etherscan unavailable"), status: RefreshJobFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := newRefreshJobs()
+
+			job, _, _, err := jobs.start("")
+			if err != nil {
+				t.Fatalf("starting job: %v", err)
+			}
+
+			running, ok := jobs.get(job.ID)
+			if !ok || running.Status != RefreshJobRunning || running.FinishedAt != nil {
+				t.Fatalf("expected job %s to be running, got %+v", job.ID, running)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			if _, finished := jobs.wait(ctx, job.ID); finished {
+				t.Fatal("expected waiting for a running job to time out")
+			}
+
+			go jobs.finish(job.ID, 5, tt.err)
+
+			finished, ok := jobs.wait(context.Background(), job.ID)
+			if !ok {
+				t.Fatalf("expected job %s to finish", job.ID)
+			}
+
+			if finished.Status != tt.status || finished.FinishedAt == nil || finished.Inserted != 5 {
+				t.Fatalf("expected a %s job with 5 inserted transfers, got %+v", tt.status, finished)
+			}
+
+			if (tt.err != nil) != (finished.Error != "") {
+				t.Fatalf("expected error %v, got %q", tt.err, finished.Error)
+			}
+		})
+	}
+}
+
+func TestRefreshJobsUnknownID(t *testing.T) {
+	jobs := newRefreshJobs()
+
+	if _, ok := jobs.get("missing"); ok {
+		t.Fatal("expected an unknown job not to be found")
+	}
+
+	if _, finished := jobs.wait(context.Background(), "missing"); finished {
+		t.Fatal("expected waiting for an unknown job to report it unfinished")
+	}
+}
+
+func TestRefreshJobsForgetsOldestJobs(t *testing.T) {
+	jobs := newRefreshJobs()
+
+	var first string
+
+	for i := range maxRefreshJobs + 1 {
+		job, _, _, err := jobs.start("")
+		if err != nil {
+			t.Fatalf("starting job: %v", err)
+		}
+
+		if i == 0 {
+			first = job.ID
+		}
+
+		jobs.finish(job.ID, 0, nil)
+	}
+
+	if _, ok := jobs.get(first); ok {
+		t.Fatalf("expected the oldest job %s to be forgotten", first)
+	}
+
+	if len(jobs.jobs) != maxRefreshJobs {
+		t.Fatalf("expected %d jobs to be kept, got %d", maxRefreshJobs, len(jobs.jobs))
+	}
+}
+
+func TestRefreshJobsIdempotencyKey(t *testing.T) {
+	jobs := newRefreshJobs()
+
+	first, started, replayed, err := jobs.start("retry-1")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || replayed {
+		t.Fatalf("expected the first request of a key to start a job, got started %v and replayed %v", started, replayed)
+	}
+
+	jobs.finish(first.ID, 2, nil)
+
+	// A repeated key replays the first request even after its job finished, instead of starting another
+	repeated, started, replayed, err := jobs.start("retry-1")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || !replayed || repeated.ID != first.ID || repeated.Status != RefreshJobDone {
+		t.Fatalf("expected the finished job %s to be replayed, got started %v, replayed %v and %+v",
+			first.ID, started, replayed, repeated)
+	}
+
+	other, started, replayed, err := jobs.start("retry-2")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || replayed || other.ID == first.ID {
+		t.Fatalf("expected another key to start a new job, got started %v, replayed %v and %s", started, replayed, other.ID)
+	}
+
+	// A key joining a running job replays that it did not start it
+	joined, started, _, err := jobs.start("retry-3")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if started || joined.ID != other.ID {
+		t.Fatalf("expected the running job %s to be joined, got started %v and %s", other.ID, started, joined.ID)
+	}
+
+	if _, started, replayed, _ := jobs.start("retry-3"); started || !replayed {
+		t.Fatalf("expected the joined outcome to be replayed, got started %v and replayed %v", started, replayed)
+	}
+
+	jobs.finish(other.ID, 0, nil)
+
+	// Expired keys start a new job
+	jobs.keys["retry-1"] = idempotentRefresh{jobID: first.ID, started: true, expiresAt: time.Now().Add(-time.Second)}
+
+	expired, started, replayed, err := jobs.start("retry-1")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	if !started || replayed || expired.ID == first.ID {
+		t.Fatalf("expected an expired key to start a new job, got started %v, replayed %v and %s",
+			started, replayed, expired.ID)
+	}
+}
+
+func TestRunRefreshJob(t *testing.T) {
+	// The refresh is stubbed, the store is only used to derive the fetch deadline
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test password=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := newTestService(t, storage.New(db, zap.NewNop().Sugar()), noTransactions(t))
+
+	refreshes := 0
+	refresh := func(context.Context) (int, error) {
+		refreshes++
+		return 4, nil
+	}
+
+	job, started, err := s.runRefreshJob(context.Background(), refresh)
+	if err != nil || !started {
+		t.Fatalf("expected a refresh to start, got started %v and error %v", started, err)
+	}
+
+	if job.Status != RefreshJobDone || job.Inserted != 4 || refreshes != 1 {
+		t.Fatalf("expected the finished job after one refresh, got %+v after %d refreshes", job, refreshes)
+	}
+
+	// A refresh requested through the API is running
+	running, _, _, err := s.refreshJobs.start("")
+	if err != nil {
+		t.Fatalf("starting job: %v", err)
+	}
+
+	job, started, err = s.runRefreshJob(context.Background(), refresh)
+	if err != nil || started || job.ID != running.ID {
+		t.Fatalf("expected the running job %s to be returned, got started %v, %+v and error %v",
+			running.ID, started, job, err)
+	}
+
+	if refreshes != 1 {
+		t.Fatalf("expected no refresh alongside the running job, got %d refreshes", refreshes)
+	}
+}
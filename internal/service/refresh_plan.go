@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// refreshWindow is the time range a full refresh fetches transfers in.
+const refreshWindow = 30 * 24 * time.Hour
+
+// FetchKind is a kind of Etherscan list request made for a source address during a refresh.
+type FetchKind string
+
+// Fetch kinds, named after the Etherscan actions they call.
+const (
+	FetchKindETH      FetchKind = "txlist"
+	FetchKindInternal FetchKind = "txlistinternal"
+	FetchKindERC20    FetchKind = "tokentx"
+)
+
+// PlannedFetch describes the paginated Etherscan requests of one kind of fetch of a source address.
+type PlannedFetch struct {
+	Kind FetchKind `json:"kind"`
+	// TokenAddress is the token of a tokentx fetch in the known-tokens fetch mode.
+	TokenAddress string `json:"token_address,omitempty"`
+	// StartBlock is the block the fetch resumes from, including its confirmation window, 0 if nothing was
+	// fetched yet.
+	StartBlock int64 `json:"start_block"`
+	// ExpectedTransfers estimates the transfers fetched, from the rate of the stored transfers of the
+	// last refresh window and the time since the latest one. It is 0 if no transfers are stored.
+	ExpectedTransfers int64 `json:"expected_transfers"`
+	// EstimatedRequests is the expected number of pages requested, at least one.
+	EstimatedRequests int64 `json:"estimated_requests"`
+}
+
+// AddressRefreshPlan describes the Etherscan requests a refresh makes for a source address.
+type AddressRefreshPlan struct {
+	Address           string         `json:"address"`
+	Label             string         `json:"label"`
+	Fetches           []PlannedFetch `json:"fetches"`
+	EstimatedRequests int64          `json:"estimated_requests"`
+}
+
+// RefreshPlan describes the Etherscan requests a full refresh would make, computed without calling Etherscan.
+type RefreshPlan struct {
+	ChainID   int                  `json:"chain_id"`
+	PageSize  int                  `json:"page_size"`
+	FetchMode FetchMode            `json:"fetch_mode"`
+	Addresses []AddressRefreshPlan `json:"addresses"`
+	// MinRequests is the number of requests made if every fetch fits in a single page.
+	MinRequests       int64 `json:"min_requests"`
+	EstimatedRequests int64 `json:"estimated_requests"`
+}
+
+// PlanRefresh computes the Etherscan requests FetchAndStoreTransfers would make for the current source
+// addresses and fetch cursors. The number of pages is estimated from the stored transfers.
+func (s *TransferService) PlanRefresh(ctx context.Context) (*RefreshPlan, error) {
+	sourceAddresses, err := s.store.GetSourceAddresses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting source addresses: %w", err)
+	}
+
+	// An empty token address stands for the fetch of all tokens
+	erc20Tokens := []string{""}
+
+	if s.fetchMode == FetchModeKnownTokens {
+		erc20Tokens, err = s.erc20TokenAddresses(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	plan := &RefreshPlan{
+		ChainID:   s.etherscanAPI.ChainID(),
+		PageSize:  s.etherscanAPI.PageSize(),
+		FetchMode: s.fetchMode,
+		Addresses: make([]AddressRefreshPlan, 0, len(sourceAddresses)),
+	}
+
+	for _, sourceAddr := range sourceAddresses {
+		address := sourceAddr.Address
+		addressPlan := AddressRefreshPlan{
+			Address: address,
+			Label:   sourceAddr.Label,
+		}
+
+		// Same order and fallbacks as FetchAndStoreTransfers
+		type plannedCursor struct {
+			kind         FetchKind
+			tokenAddress string
+			cursorToken  string
+			fallback     func(ctx context.Context) (int64, error)
+		}
+
+		fetches := []plannedCursor{
+			{FetchKindETH, "", ethTokenAddress, func(ctx context.Context) (int64, error) {
+				return s.store.GetLastProcessedBlock(ctx, address, ethTokenAddress)
+			}},
+			{FetchKindInternal, "", storage.InternalTransfers, func(ctx context.Context) (int64, error) {
+				return s.store.GetLastProcessedBlockForInternal(ctx, address)
+			}},
+		}
+
+		for _, tokenAddress := range erc20Tokens {
+			fetch := plannedCursor{FetchKindERC20, "", storage.AllERC20Tokens, func(ctx context.Context) (int64, error) {
+				return s.store.GetLastProcessedBlockForERC20(ctx, address)
+			}}
+
+			if tokenAddress != "" {
+				fetch = plannedCursor{FetchKindERC20, tokenAddress, tokenAddress, func(ctx context.Context) (int64, error) {
+					return s.store.GetLastProcessedBlock(ctx, address, tokenAddress)
+				}}
+			}
+
+			fetches = append(fetches, fetch)
+		}
+
+		for _, fetch := range fetches {
+			activity, err := s.store.GetTransferActivity(ctx, address, fetch.cursorToken, now.Add(-refreshWindow))
+			if err != nil {
+				return nil, err
+			}
+
+			expected := expectedTransfers(activity, now)
+			planned := PlannedFetch{
+				Kind:              fetch.kind,
+				TokenAddress:      fetch.tokenAddress,
+				StartBlock:        s.confirmationStart(s.resumeBlock(ctx, address, fetch.cursorToken, fetch.fallback)),
+				ExpectedTransfers: expected,
+				EstimatedRequests: 1 + expected/int64(plan.PageSize),
+			}
+
+			addressPlan.Fetches = append(addressPlan.Fetches, planned)
+			addressPlan.EstimatedRequests += planned.EstimatedRequests
+			plan.MinRequests++
+		}
+
+		plan.EstimatedRequests += addressPlan.EstimatedRequests
+		plan.Addresses = append(plan.Addresses, addressPlan)
+	}
+
+	return plan, nil
+}
+
+// expectedTransfers extrapolates the rate of the transfers of the last refresh window to the time since
+// the latest stored transfer.
+func expectedTransfers(activity *storage.TransferActivity, now time.Time) int64 {
+	if activity.LastTimestamp == nil || activity.Count == 0 {
+		return 0
+	}
+
+	elapsed := now.Sub(*activity.LastTimestamp)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return int64(float64(activity.Count) * float64(elapsed) / float64(refreshWindow))
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestExpectedTransfers(t *testing.T) {
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		activity storage.TransferActivity
+		want     int64
+	}{
+		{name: "no transfers", activity: storage.TransferActivity{}, want: 0},
+		{name: "none in the window", activity: storage.TransferActivity{LastTimestamp: at(60 * 24 * time.Hour)}, want: 0},
+		{name: "latest in the future", activity: storage.TransferActivity{Count: 10, LastTimestamp: at(-time.Hour)}, want: 0},
+		{name: "a tenth of the window", activity: storage.TransferActivity{Count: 3000, LastTimestamp: at(3 * 24 * time.Hour)}, want: 300},
+		{name: "a whole window", activity: storage.TransferActivity{Count: 50, LastTimestamp: at(refreshWindow)}, want: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedTransfers(&tt.activity, now); got != tt.want {
+				t.Fatalf("expected %d transfers, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanRefresh(t *testing.T) {
+	store := newTestStore(t)
+	fake := newFakeEtherscan(t)
+	s := newTestService(t, store, fake.ServeHTTP)
+	ctx := context.Background()
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{Label: "source"}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	// Only the ETH fetch has a cursor, it resumes from the start of its confirmation window
+	if _, err := store.AddTransfersBatchWithCursor(ctx, nil, storage.FetchCursor{
+		Address:      testSource,
+		TokenAddress: ethTokenAddress,
+		ChainID:      1,
+		BlockNumber:  1000,
+	}); err != nil {
+		t.Fatalf("setting the ETH fetch cursor: %v", err)
+	}
+
+	plan, err := s.PlanRefresh(ctx)
+	if err != nil {
+		t.Fatalf("planning refresh: %v", err)
+	}
+
+	if plan.FetchMode != FetchModeAll || plan.MinRequests != 3 || plan.EstimatedRequests != 3 {
+		t.Fatalf("expected 3 single-page fetches in the all-tokens mode, got %+v", plan)
+	}
+
+	if len(plan.Addresses) != 1 || plan.Addresses[0].Label != "source" {
+		t.Fatalf("expected the plan of the source address, got %+v", plan.Addresses)
+	}
+
+	want := []PlannedFetch{
+		{Kind: FetchKindETH, StartBlock: 1000 - DefaultConfirmationBlocks, EstimatedRequests: 1},
+		{Kind: FetchKindInternal, EstimatedRequests: 1},
+		{Kind: FetchKindERC20, EstimatedRequests: 1},
+	}
+
+	fetches := plan.Addresses[0].Fetches
+	if len(fetches) != len(want) {
+		t.Fatalf("expected %d fetches, got %+v", len(want), fetches)
+	}
+
+	for i := range want {
+		if fetches[i] != want[i] {
+			t.Errorf("fetch %d: expected %+v, got %+v", i, want[i], fetches[i])
+		}
+	}
+
+	// The plan is computed without calling Etherscan
+	for _, kind := range []FetchKind{FetchKindETH, FetchKindInternal, FetchKindERC20} {
+		if n := fake.requestCount(string(kind), testSource); n != 0 {
+			t.Errorf("expected no %s requests, got %d", kind, n)
+		}
+	}
+}
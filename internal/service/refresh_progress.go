@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+)
+
+// refreshProgressBuffer is the number of progress events a subscriber may lag behind before it misses events.
+const refreshProgressBuffer = 256
+
+// RefreshProgressEvent is the kind of a RefreshProgress.
+type RefreshProgressEvent string
+
+// Refresh progress events.
+const (
+	// RefreshProgressStarted is emitted when a full refresh starts, with the number of source addresses.
+	RefreshProgressStarted RefreshProgressEvent = "refresh_started"
+	// RefreshProgressAddressStarted is emitted when the transfers of a source address start being fetched.
+	RefreshProgressAddressStarted RefreshProgressEvent = "address_started"
+	// RefreshProgressPageFetched is emitted for each page fetched from Etherscan.
+	RefreshProgressPageFetched RefreshProgressEvent = "page_fetched"
+	// RefreshProgressStored is emitted when the fetched transfers of a fetch are stored.
+	RefreshProgressStored RefreshProgressEvent = "transfers_stored"
+	// RefreshProgressAddressFinished is emitted when every fetch of a source address finished.
+	RefreshProgressAddressFinished RefreshProgressEvent = "address_finished"
+	// RefreshProgressFinished is emitted when a full refresh finished, with the number of inserted transfers.
+	RefreshProgressFinished RefreshProgressEvent = "refresh_finished"
+)
+
+// RefreshProgress reports the progress of a refresh. Fields that do not apply to the event are omitted.
+type RefreshProgress struct {
+	Event RefreshProgressEvent `json:"event" swaggertype:"string"`
+	Time  time.Time            `json:"time"`
+	// Addresses is the number of source addresses of a started refresh.
+	Addresses int `json:"addresses,omitempty"`
+	// Address is the address being fetched.
+	Address string `json:"address,omitempty"`
+	// Kind and TokenAddress identify the fetch of a page or of stored transfers.
+	Kind         FetchKind `json:"kind,omitempty" swaggertype:"string"`
+	TokenAddress string    `json:"token_address,omitempty"`
+	// Page is the 1-based number of a fetched page, and Transactions the number of transactions on it.
+	Page         int `json:"page,omitempty"`
+	Transactions int `json:"transactions,omitempty"`
+	// Fetched is the number of transfers of a fetch to store, and Inserted the number of newly stored ones
+	// of the fetch, address or refresh.
+	Fetched  int `json:"fetched,omitempty"`
+	Inserted int `json:"inserted,omitempty"`
+	// Error is the failure of an address or refresh.
+	Error string `json:"error,omitempty"`
+}
+
+// refreshProgress fans the progress events of refreshes out to subscribers. Publishing never blocks:
+// a subscriber that falls behind misses events instead.
+type refreshProgress struct {
+	mu          sync.Mutex
+	subscribers map[chan RefreshProgress]struct{}
+	closed      bool
+}
+
+func newRefreshProgress() *refreshProgress {
+	return &refreshProgress{subscribers: make(map[chan RefreshProgress]struct{})}
+}
+
+func (p *refreshProgress) subscribe() (<-chan RefreshProgress, func()) {
+	events := make(chan RefreshProgress, refreshProgressBuffer)
+
+	p.mu.Lock()
+	if p.closed {
+		close(events)
+	} else {
+		p.subscribers[events] = struct{}{}
+	}
+	p.mu.Unlock()
+
+	return events, func() {
+		p.mu.Lock()
+		delete(p.subscribers, events)
+		p.mu.Unlock()
+	}
+}
+
+func (p *refreshProgress) publish(event RefreshProgress) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for events := range p.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// close closes the channels of every subscriber, and of the later subscribers right away.
+func (p *refreshProgress) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	for events := range p.subscribers {
+		delete(p.subscribers, events)
+		close(events)
+	}
+}
+
+// SubscribeRefreshProgress subscribes to the progress events of the refreshes from now on.
+// The returned function ends the subscription. The channel is closed by CloseRefreshProgress.
+func (s *TransferService) SubscribeRefreshProgress() (<-chan RefreshProgress, func()) {
+	return s.refreshProgress.subscribe()
+}
+
+// CloseRefreshProgress ends every subscription to the refresh progress, e.g. on shutdown.
+func (s *TransferService) CloseRefreshProgress() {
+	s.refreshProgress.close()
+}
+
+// reportProgress is the progress callback of refreshes, publishing event to the subscribers.
+func (s *TransferService) reportProgress(event RefreshProgress) {
+	s.refreshProgress.publish(event)
+}
+
+// withFetchProgress returns a copy of ctx with which the Etherscan pages fetched for a fetch of
+// address are reported as progress.
+func (s *TransferService) withFetchProgress(
+	ctx context.Context, address string, kind FetchKind, tokenAddress string,
+) context.Context {
+	return etherscan.WithPageFunc(ctx, func(page, transactions int) {
+		s.reportProgress(RefreshProgress{
+			Event:        RefreshProgressPageFetched,
+			Address:      address,
+			Kind:         kind,
+			TokenAddress: tokenAddress,
+			Page:         page,
+			Transactions: transactions,
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// drainProgress returns the events received so far on events.
+func drainProgress(events <-chan RefreshProgress) []RefreshProgress {
+	var received []RefreshProgress
+
+	for {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		default:
+			return received
+		}
+	}
+}
+
+func TestRefreshProgressSubscriptions(t *testing.T) {
+	progress := newRefreshProgress()
+
+	first, unsubscribeFirst := progress.subscribe()
+	second, unsubscribeSecond := progress.subscribe()
+
+	defer unsubscribeSecond()
+
+	progress.publish(RefreshProgress{Event: RefreshProgressStarted, Addresses: 2})
+	unsubscribeFirst()
+	progress.publish(RefreshProgress{Event: RefreshProgressFinished})
+
+	if events := drainProgress(first); len(events) != 1 || events[0].Event != RefreshProgressStarted {
+		t.Fatalf("expected only the event before unsubscribing, got %+v", events)
+	}
+
+	events := drainProgress(second)
+	if len(events) != 2 || events[1].Event != RefreshProgressFinished {
+		t.Fatalf("expected both events, got %+v", events)
+	}
+
+	if events[0].Time.IsZero() {
+		t.Fatal("expected the time of the event to be set")
+	}
+}
+
+func TestRefreshProgressDoesNotBlock(t *testing.T) {
+	progress := newRefreshProgress()
+
+	events, unsubscribe := progress.subscribe()
+	defer unsubscribe()
+
+	// A subscriber that does not read misses the events past its buffer instead of blocking
+	for range refreshProgressBuffer + 10 {
+		progress.publish(RefreshProgress{Event: RefreshProgressPageFetched})
+	}
+
+	if received := drainProgress(events); len(received) != refreshProgressBuffer {
+		t.Fatalf("expected %d buffered events, got %d", refreshProgressBuffer, len(received))
+	}
+}
+
+func TestRefreshReportsProgress(t *testing.T) {
+	const target = "0x00000000000000000000000000000000000000b2"
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fake := newFakeEtherscan(t)
+	fake.eth = []etherscan.ETHTransaction{ethTransfer("0x01", testSource, target, "1000", 100)}
+
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	events, unsubscribe := s.SubscribeRefreshProgress()
+	defer unsubscribe()
+
+	if _, err := s.FetchAndStoreTransfers(ctx); err != nil {
+		t.Fatalf("refreshing: %v", err)
+	}
+
+	received := drainProgress(events)
+	if len(received) < 2 {
+		t.Fatalf("expected the refresh to report progress, got %+v", received)
+	}
+
+	if first := received[0]; first.Event != RefreshProgressStarted || first.Addresses != 1 {
+		t.Fatalf("expected the refresh of 1 address to start first, got %+v", first)
+	}
+
+	if last := received[len(received)-1]; last.Event != RefreshProgressFinished || last.Inserted != 1 {
+		t.Fatalf("expected the refresh to finish last with 1 inserted transfer, got %+v", last)
+	}
+
+	seen := make(map[RefreshProgressEvent]bool)
+
+	for _, event := range received {
+		seen[event.Event] = true
+
+		if event.Event == RefreshProgressPageFetched && event.Kind == FetchKindETH && event.Transactions != 1 {
+			t.Fatalf("expected the page of ETH transfers to have 1 transaction, got %+v", event)
+		}
+
+		if event.Event == RefreshProgressStored && event.Kind == FetchKindETH && event.Inserted != 1 {
+			t.Fatalf("expected the ETH transfer to be stored, got %+v", event)
+		}
+	}
+
+	for _, event := range []RefreshProgressEvent{
+		RefreshProgressAddressStarted, RefreshProgressPageFetched, RefreshProgressStored, RefreshProgressAddressFinished,
+	} {
+		if !seen[event] {
+			t.Fatalf("expected a %s event, got %+v", event, received)
+		}
+	}
+}
+
+func TestRefreshProgressClose(t *testing.T) {
+	progress := newRefreshProgress()
+
+	events, unsubscribe := progress.subscribe()
+
+	progress.close()
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected closing to close the channel of the subscriber")
+	}
+
+	// Publishing and unsubscribing after closing are no-ops
+	progress.publish(RefreshProgress{Event: RefreshProgressStarted})
+	unsubscribe()
+
+	late, unsubscribeLate := progress.subscribe()
+	defer unsubscribeLate()
+
+	if _, ok := <-late; ok {
+		t.Fatal("expected a subscription after closing to be closed")
+	}
+}
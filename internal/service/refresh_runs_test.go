@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestRefreshRunsRecorded(t *testing.T) {
+	const target = "0x00000000000000000000000000000000000000b2"
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fake := newFakeEtherscan(t)
+	fake.eth = []etherscan.ETHTransaction{ethTransfer("0x01", testSource, target, "1000", 100)}
+
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	if _, err := s.GetLastRefreshRun(ctx); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows before any refresh, got %v", err)
+	}
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if _, err := s.FetchAndStoreTransfers(ctx); err != nil {
+		t.Fatalf("refreshing: %v", err)
+	}
+
+	run, err := s.GetLastRefreshRun(ctx)
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if run.Status != storage.RefreshRunSucceeded || run.SourceAddresses != 1 || run.Inserted != 1 ||
+		run.Error != "" || len(run.FailedAddresses) != 0 {
+		t.Fatalf("expected a succeeded run inserting 1 transfer of 1 address, got %+v", run)
+	}
+
+	if run.FinishedAt == nil || run.DurationSeconds == nil || *run.DurationSeconds < 0 {
+		t.Fatalf("expected the finish time and duration of the run, got %+v", run)
+	}
+
+	// A failed fetch fails the run and records the address
+	fake.setFailing("txlist", true)
+
+	if _, err := s.FetchAndStoreTransfersStrict(ctx); err == nil {
+		t.Fatal("expected the strict refresh to fail")
+	}
+
+	failed, err := s.GetLastRefreshRun(ctx)
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if failed.ID == run.ID || failed.Status != storage.RefreshRunFailed || failed.Error == "" {
+		t.Fatalf("expected a new failed run with its error, got %+v", failed)
+	}
+
+	if !slices.Equal(failed.FailedAddresses, []string{testSource}) {
+		t.Fatalf("expected the failed address %s recorded, got %v", testSource, failed.FailedAddresses)
+	}
+}
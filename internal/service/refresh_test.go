@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+const testSource = "0x00000000000000000000000000000000000000a1"
+
+// noTransactions serves Etherscan's response to a query matching no transactions.
+func noTransactions(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeEtherscanResponse(t, w, "0", "No transactions found", []etherscan.ETHTransaction{})
+	}
+}
+
+// setLastUpdates sets the last ETH and token update times to the given ages.
+func setLastUpdates(t *testing.T, store *storage.Storage, ethAge, tokenAge time.Duration) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	for key, age := range map[string]time.Duration{configKeyLastETHUpdate: ethAge, configKeyLastTokenUpdate: tokenAge} {
+		if err := store.UpdateConfig(ctx, key, time.Now().Add(-age).Format(time.RFC3339)); err != nil {
+			t.Fatalf("setting %s: %v", key, err)
+		}
+	}
+}
+
+func TestShouldRefreshData(t *testing.T) {
+	store := newTestStore(t)
+	s := newTestService(t, store, noTransactions(t))
+	ctx := context.Background()
+
+	if err := s.UpdateRefreshInterval(ctx, 1); err != nil {
+		t.Fatalf("setting refresh interval: %v", err)
+	}
+
+	const fresh, stale = time.Minute, 2 * time.Hour
+
+	tests := []struct {
+		name              string
+		ethAge, tokenAge  time.Duration
+		wantShouldRefresh bool
+	}{
+		{name: "both fresh", ethAge: fresh, tokenAge: fresh, wantShouldRefresh: false},
+		{name: "ETH stale", ethAge: stale, tokenAge: fresh, wantShouldRefresh: true},
+		{name: "tokens stale", ethAge: fresh, tokenAge: stale, wantShouldRefresh: true},
+		{name: "both stale", ethAge: stale, tokenAge: stale, wantShouldRefresh: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLastUpdates(t, store, tt.ethAge, tt.tokenAge)
+
+			shouldRefresh, err := s.ShouldRefreshData(ctx)
+			if err != nil {
+				t.Fatalf("checking staleness: %v", err)
+			}
+
+			if shouldRefresh != tt.wantShouldRefresh {
+				t.Fatalf("expected ShouldRefreshData %v, got %v", tt.wantShouldRefresh, shouldRefresh)
+			}
+		})
+	}
+}
+
+func TestFailedTokenFetchKeepsTokenUpdateTime(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	s := newTestService(t, store, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "tokentx" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		noTransactions(t)(w, r)
+	})
+
+	if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	setLastUpdates(t, store, 2*time.Hour, 2*time.Hour)
+
+	lastTokenUpdate, err := store.GetConfig(ctx, configKeyLastTokenUpdate)
+	if err != nil {
+		t.Fatalf("getting last token update: %v", err)
+	}
+
+	if _, err := s.FetchAndStoreTransfersStrict(ctx); err == nil {
+		t.Fatalf("expected the failed token fetch to fail the refresh")
+	}
+
+	ethUpdate, err := s.getLastUpdateTime(ctx, configKeyLastETHUpdate)
+	if err != nil {
+		t.Fatalf("getting last ETH update: %v", err)
+	}
+
+	if time.Since(ethUpdate) > time.Minute {
+		t.Fatalf("expected the last ETH update to advance, got %s", ethUpdate)
+	}
+
+	tokenUpdate, err := store.GetConfig(ctx, configKeyLastTokenUpdate)
+	if err != nil {
+		t.Fatalf("getting last token update: %v", err)
+	}
+
+	if tokenUpdate != lastTokenUpdate {
+		t.Fatalf("expected the last token update to stay %s, got %s", lastTokenUpdate, tokenUpdate)
+	}
+}
+
+func TestFailedRefreshBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: failedRefreshRetryDelay},
+		{failures: 2, want: 2 * failedRefreshRetryDelay},
+		{failures: 3, want: 4 * failedRefreshRetryDelay},
+		{failures: failedRefreshRuns, want: maxFailedRefreshRetryDelay},
+		{failures: 100, want: maxFailedRefreshRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := failedRefreshBackoff(tt.failures); got != tt.want {
+			t.Errorf("failedRefreshBackoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRetryFailedAddresses(t *testing.T) {
+	const failingSource = "0x00000000000000000000000000000000000000a2"
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fake := newFakeEtherscan(t)
+
+	var failing atomic.Bool
+
+	failing.Store(true)
+
+	s := newTestService(t, store, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() && strings.EqualFold(r.URL.Query().Get("address"), failingSource) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fake.ServeHTTP(w, r)
+	})
+
+	for _, address := range []string{testSource, failingSource} {
+		if _, _, err := store.AddSourceAddress(ctx, address, storage.AddressLabels{}); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	if err := s.UpdateRefreshInterval(ctx, 1); err != nil {
+		t.Fatalf("setting refresh interval: %v", err)
+	}
+
+	setLastUpdates(t, store, 2*time.Hour, 2*time.Hour)
+
+	if _, err := s.RefreshStaleData(ctx); err != nil {
+		t.Fatalf("refreshing stale data: %v", err)
+	}
+
+	failedRun, err := s.GetLastRefreshRun(ctx)
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if failedRun.Status != storage.RefreshRunFailed || !slices.Equal(failedRun.FailedAddresses, []string{failingSource}) {
+		t.Fatalf("expected a failed run of %s, got status %s with failed addresses %v",
+			failingSource, failedRun.Status, failedRun.FailedAddresses)
+	}
+
+	// The failed address is not retried before the backoff elapsed, even though the update times are stale
+	shouldRefresh, err := s.ShouldRefreshData(ctx)
+	if err != nil {
+		t.Fatalf("checking staleness: %v", err)
+	}
+
+	if shouldRefresh {
+		t.Fatal("expected no refresh due right after the failed refresh")
+	}
+
+	finishedAt := failedRun.FinishedAt.Add(-failedRefreshRetryDelay)
+	failedRun.FinishedAt = &finishedAt
+
+	if err := store.FinishRefreshRun(ctx, *failedRun); err != nil {
+		t.Fatalf("backdating failed refresh run: %v", err)
+	}
+
+	failing.Store(false)
+
+	if _, err := s.RefreshStaleData(ctx); err != nil {
+		t.Fatalf("retrying failed addresses: %v", err)
+	}
+
+	// Only the failed address is fetched again
+	for _, action := range []string{"txlist", "txlistinternal", "tokentx"} {
+		if got := fake.requestCount(action, testSource); got != 1 {
+			t.Errorf("expected 1 %s request for %s, got %d", action, testSource, got)
+		}
+
+		if got := fake.requestCount(action, failingSource); got != 1 {
+			t.Errorf("expected the %s request for %s to be retried once, got %d", action, failingSource, got)
+		}
+	}
+
+	retryRun, err := s.GetLastRefreshRun(ctx)
+	if err != nil {
+		t.Fatalf("getting last refresh run: %v", err)
+	}
+
+	if retryRun.ID == failedRun.ID || retryRun.Status != storage.RefreshRunSucceeded || retryRun.SourceAddresses != 1 {
+		t.Fatalf("expected a succeeded retry of 1 address, got %+v", retryRun)
+	}
+
+	// Every address was fetched since the failed refresh, so no other refresh is due
+	shouldRefresh, err = s.ShouldRefreshData(ctx)
+	if err != nil {
+		t.Fatalf("checking staleness: %v", err)
+	}
+
+	if shouldRefresh {
+		t.Fatal("expected no refresh due after the successful retry")
+	}
+}
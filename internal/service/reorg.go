@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultConfirmationBlocks is the default number of blocks up to the fetch cursor that every fetch
+// verifies again, so that transfers reorged out of them are deleted.
+const DefaultConfirmationBlocks = 12
+
+// SetConfirmationBlocks sets the confirmation window: the number of blocks up to the fetch cursor that
+// every fetch fetches again. Stored transfers of the window that Etherscan no longer returns were
+// reorged out of the chain and are deleted. A non-positive value disables the verification.
+func (s *TransferService) SetConfirmationBlocks(blocks int64) {
+	s.confirmationBlocks = max(blocks, 0)
+}
+
+// confirmationStart returns the block a fetch with its cursor at lastBlock starts from: the first block
+// of the confirmation window, or lastBlock if nothing was fetched yet or the window is disabled.
+func (s *TransferService) confirmationStart(lastBlock int64) int64 {
+	if lastBlock <= 0 || s.confirmationBlocks <= 0 {
+		return lastBlock
+	}
+
+	return max(lastBlock-s.confirmationBlocks, 0)
+}
+
+// removeReorgedTransfers deletes the stored transfers of the fetch of address with cursorToken within
+// blocks fromBlock to lastBlock whose transaction is not in fetched, the hashes of every transaction
+// the fetch returned, stored or not. Transfers older than startTime are kept, since the fetch does not
+// return them. Failures are logged and do not fail the fetch.
+func (s *TransferService) removeReorgedTransfers(
+	ctx context.Context, address, cursorToken string, fetched map[string]bool,
+	fromBlock, lastBlock int64, startTime time.Time,
+) {
+	if lastBlock <= 0 || s.confirmationBlocks <= 0 {
+		return
+	}
+
+	stored, err := s.store.GetTransferHashes(ctx, address, cursorToken, fromBlock, lastBlock, startTime)
+	if err != nil {
+		s.logger.Warnw("Failed to get transfers of the confirmation window",
+			"address", address,
+			"token", cursorToken,
+			"err", err)
+
+		return
+	}
+
+	var missing []string
+
+	for _, hash := range stored {
+		if !fetched[strings.ToLower(hash)] {
+			missing = append(missing, hash)
+		}
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	deleted, err := s.store.DeleteTransfersByHash(ctx, address, cursorToken, missing, fromBlock, lastBlock)
+	if err != nil {
+		s.logger.Errorw("Failed to delete reorged transfers",
+			"address", address,
+			"token", cursorToken,
+			"hashes", missing,
+			"err", err)
+
+		return
+	}
+
+	s.logger.Warnw("Deleted transfers no longer returned by Etherscan, their transactions were reorged out",
+		"address", address,
+		"token", cursorToken,
+		"fromBlock", fromBlock,
+		"toBlock", lastBlock,
+		"hashes", missing,
+		"deleted", len(deleted))
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+func TestConfirmationStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocks    int64
+		lastBlock int64
+		want      int64
+	}{
+		{name: "window", blocks: 12, lastBlock: 100, want: 88},
+		{name: "window before genesis", blocks: 12, lastBlock: 5, want: 0},
+		{name: "nothing fetched", blocks: 12, lastBlock: 0, want: 0},
+		{name: "disabled", blocks: 0, lastBlock: 100, want: 100},
+		{name: "negative", blocks: -1, lastBlock: 100, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &TransferService{}
+			s.SetConfirmationBlocks(tt.blocks)
+
+			if got := s.confirmationStart(tt.lastBlock); got != tt.want {
+				t.Fatalf("expected the fetch to start at block %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveReorgedTransfers(t *testing.T) {
+	const target = "0x00000000000000000000000000000000000000b2"
+
+	tests := []struct {
+		name   string
+		blocks int64
+		want   []string
+	}{
+		// 0x02 was reorged out; 0x01 is before the window and 0x03 is still returned, without timestamp
+		{name: "confirmation window", blocks: 12, want: []string{"0x01", "0x03", "0x04"}},
+		{name: "disabled", blocks: 0, want: []string{"0x01", "0x02", "0x03", "0x04"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			ctx := context.Background()
+
+			if _, _, err := store.AddSourceAddress(ctx, testSource, storage.AddressLabels{}); err != nil {
+				t.Fatalf("adding source address: %v", err)
+			}
+
+			fake := newFakeEtherscan(t)
+			fake.eth = []etherscan.ETHTransaction{
+				ethTransfer("0x01", testSource, target, "1", 50),
+				ethTransfer("0x02", testSource, target, "2", 100),
+				ethTransfer("0x03", testSource, target, "3", 101),
+				ethTransfer("0x04", testSource, target, "4", 102),
+			}
+
+			s := newTestService(t, store, fake.ServeHTTP)
+			s.SetConfirmationBlocks(tt.blocks)
+
+			if _, err := s.FetchAndStoreForAddress(ctx, testSource); err != nil {
+				t.Fatalf("fetching address: %v", err)
+			}
+
+			// The refetch no longer returns 0x01, outside of the window, nor 0x02, and returns 0x03 with
+			// a timestamp it cannot parse
+			fake.mu.Lock()
+			fake.eth = slices.Clone(fake.eth[2:])
+			fake.eth[0].TimeStamp = ""
+			fake.mu.Unlock()
+
+			if _, err := s.FetchAndStoreForAddress(ctx, testSource); err != nil {
+				t.Fatalf("fetching address: %v", err)
+			}
+
+			if got := storedHashes(t, store); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected transfers %v to be kept, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDailyRefreshTimes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{value: "00:00:00", want: []time.Duration{0}},
+		{value: "18:30:00,06:00:00", want: []time.Duration{6 * time.Hour, 18*time.Hour + 30*time.Minute}},
+		{value: " 06:00:00 , 18:30:00 ,06:00:00", want: []time.Duration{6 * time.Hour, 18*time.Hour + 30*time.Minute}},
+		{value: "", wantErr: true},
+		{value: "06:00:00,", wantErr: true},
+		{value: "6am", wantErr: true},
+		{value: "24:00:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDailyRefreshTimes(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConfigValue) {
+					t.Fatalf("expected ErrInvalidConfigValue, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsing %q: %v", tt.value, err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected offsets %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatDailyRefreshTimes(t *testing.T) {
+	offsets, err := ParseDailyRefreshTimes("18:30:00, 06:00:05")
+	if err != nil {
+		t.Fatalf("parsing times: %v", err)
+	}
+
+	if got := formatDailyRefreshTimes(offsets); got != "06:00:05,18:30:00" {
+		t.Fatalf("expected the times sorted and normalized, got %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// assertDeadline fails t unless ctx has a deadline about timeout from now.
+func assertDeadline(t *testing.T, ctx context.Context, timeout time.Duration) {
+	t.Helper()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected the fetch context to have a deadline")
+	}
+
+	if remaining := time.Until(deadline); remaining > timeout || remaining < timeout-time.Minute {
+		t.Fatalf("expected a deadline in %s, got %s", timeout, remaining)
+	}
+}
+
+func TestFetchContextPerAddress(t *testing.T) {
+	store := newTestStore(t)
+	s := newTestService(t, store, noTransactions(t))
+	s.SetFetchTimeout(10*time.Minute, 4*time.Minute)
+
+	// Fewer addresses than the minimum covers
+	ctx, cancel := s.FetchContext(context.Background())
+	assertDeadline(t, ctx, 10*time.Minute)
+	cancel()
+
+	for i := range 5 {
+		address := fmt.Sprintf("0x%040x", 0xa1+i)
+		if _, _, err := store.AddSourceAddress(context.Background(), address, storage.AddressLabels{}); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	ctx, cancel = s.FetchContext(context.Background())
+	defer cancel()
+
+	assertDeadline(t, ctx, 20*time.Minute)
+}
+
+func TestFetchContextWithoutDatabase(t *testing.T) {
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test password=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := newTestService(t, storage.New(db, zap.NewNop().Sugar()), noTransactions(t))
+	s.SetFetchTimeout(0, 0)
+
+	// The addresses cannot be counted, so the minimum applies
+	ctx, cancel := s.FetchContext(context.Background())
+	defer cancel()
+
+	assertDeadline(t, ctx, DefaultFetchTimeout)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// ErrTokenMetadataLookup is returned when the metadata of a token cannot be looked up on Etherscan.
+// Tokens that were never transferred also match etherscan.ErrTokenNotFound.
+var ErrTokenMetadataLookup = errors.New("looking up token metadata")
+
+// TokenMetadataRefresh is the outcome of re-syncing the metadata of a token with Etherscan.
+type TokenMetadataRefresh struct {
+	// Token is the token after the refresh.
+	Token storage.Token `json:"token"`
+	// Changed lists the fields that differed from Etherscan and were updated: symbol, name or decimals.
+	Changed []string `json:"changed"`
+	// Error is set if the metadata could not be looked up, in which case the token is left unchanged.
+	Error string `json:"error,omitempty"`
+}
+
+// RefreshTokenMetadata re-syncs the symbol, name and decimals of a token with Etherscan, e.g. to fix
+// decimals entered manually. It returns sql.ErrNoRows if the token does not exist, and an error
+// matching ErrTokenMetadataLookup if the lookup fails.
+func (s *TransferService) RefreshTokenMetadata(ctx context.Context, id int64) (*TokenMetadataRefresh, error) {
+	token, err := s.store.GetTokenByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.etherscanAPI.GetTokenInfo(ctx, token.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrTokenMetadataLookup, token.Address, err)
+	}
+
+	return s.applyTokenMetadata(ctx, *token, info)
+}
+
+// RefreshAllTokenMetadata re-syncs the metadata of every token with Etherscan. Tokens of which the
+// metadata cannot be looked up are left unchanged and reported with an error, so only failures to
+// read or update the tokens table are returned.
+func (s *TransferService) RefreshAllTokenMetadata(ctx context.Context) ([]TokenMetadataRefresh, error) {
+	tokens, err := s.store.GetTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting tokens: %w", err)
+	}
+
+	refreshes := make([]TokenMetadataRefresh, 0, len(tokens))
+
+	for _, token := range tokens {
+		info, err := s.etherscanAPI.GetTokenInfo(ctx, token.Address)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("refreshing token metadata: %w", ctx.Err())
+			}
+
+			s.logger.Warnw("Failed to look up token metadata, leaving token unchanged", "token", token.Address, "err", err)
+			refreshes = append(refreshes, TokenMetadataRefresh{Token: token, Changed: []string{}, Error: err.Error()})
+
+			continue
+		}
+
+		refresh, err := s.applyTokenMetadata(ctx, token, info)
+		if err != nil {
+			return nil, err
+		}
+
+		refreshes = append(refreshes, *refresh)
+	}
+
+	return refreshes, nil
+}
+
+// applyTokenMetadata updates the fields of token that differ from the looked up metadata.
+// Empty symbols and names and out of range decimals are ignored.
+func (s *TransferService) applyTokenMetadata(
+	ctx context.Context, token storage.Token, info *etherscan.TokenInfo,
+) (*TokenMetadataRefresh, error) {
+	var (
+		symbol, name *string
+		decimals     *int
+		changed      = []string{}
+	)
+
+	if infoSymbol := truncateRunes(info.Symbol, maxTokenSymbolLength); infoSymbol != "" && infoSymbol != token.Symbol {
+		symbol = &infoSymbol
+		changed = append(changed, "symbol")
+	}
+
+	if infoName := truncateRunes(info.Name, maxTokenNameLength); infoName != "" && infoName != token.Name {
+		name = &infoName
+		changed = append(changed, "name")
+	}
+
+	if IsValidDecimals(info.Decimals) && info.Decimals != token.Decimals {
+		decimals = &info.Decimals
+		changed = append(changed, "decimals")
+	}
+
+	if len(changed) == 0 {
+		return &TokenMetadataRefresh{Token: token, Changed: changed}, nil
+	}
+
+	updated, err := s.store.UpdateToken(ctx, token.ID, symbol, name, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("updating token metadata: %w", err)
+	}
+
+	s.logger.Infow("Refreshed token metadata",
+		"token", token.Address,
+		"changed", changed,
+		"symbol", updated.Symbol,
+		"name", updated.Name,
+		"decimals", updated.Decimals,
+		"previousDecimals", token.Decimals)
+
+	return &TokenMetadataRefresh{Token: *updated, Changed: changed}, nil
+}
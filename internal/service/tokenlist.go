@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// MaxTokenListSize caps the size of an imported token list.
+	MaxTokenListSize = 16 << 20
+	// tokenListFetchTimeout bounds the download of a token list.
+	tokenListFetchTimeout = 30 * time.Second
+	// maxTokenListRedirects caps the redirects followed by the download of a token list.
+	maxTokenListRedirects = 5
+)
+
+var (
+	// ErrInvalidTokenList is returned when a token list is not valid JSON of the token list schema.
+	ErrInvalidTokenList = errors.New("invalid token list")
+	// ErrTokenListFetch is returned when a token list cannot be downloaded.
+	ErrTokenListFetch = errors.New("fetching token list")
+
+	// errTokenListDestination is returned when a token list URL, or a redirect of it, resolves to an
+	// address that is not allowed.
+	errTokenListDestination = errors.New("destination not allowed")
+)
+
+// tokenListClient downloads token lists from public addresses only.
+var tokenListClient = newTokenListClient(isPublicAddress) //nolint:gochecknoglobals
+
+// sharedAddressSpace is the carrier-grade NAT range, which also holds cloud metadata endpoints.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10") //nolint:gochecknoglobals
+
+// newTokenListClient returns a client downloading token lists that only connects to the addresses
+// allowed reports true for. The check runs on the address being connected to, after its name was
+// resolved, so names resolving to other addresses than checked beforehand cannot get around it. No
+// proxy is used, since the client would then connect to the proxy instead of the checked address.
+func newTokenListClient(allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: tokenListFetchTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errTokenListDestination, address)
+			}
+
+			if addr := addrPort.Addr().Unmap(); !allowed(addr) {
+				return fmt.Errorf("%w: %s", errTokenListDestination, addr)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   tokenListFetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) >= maxTokenListRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxTokenListRedirects)
+			}
+
+			return nil
+		},
+	}
+}
+
+// isPublicAddress reports whether addr is a public unicast address, rejecting the loopback, private,
+// link-local, shared and unspecified addresses, among which are the cloud metadata endpoints.
+func isPublicAddress(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// TokenList is a token list in the format of https://tokenlists.org, such as the Uniswap default list.
+// Only the fields used by the import are decoded.
+type TokenList struct {
+	Name   string           `json:"name"`
+	Tokens []TokenListToken `json:"tokens"`
+}
+
+// TokenListToken is a token of a token list.
+type TokenListToken struct {
+	ChainID int    `json:"chainId"`
+	Address string `json:"address"`
+	Symbol  string `json:"symbol"`
+	Name    string `json:"name"`
+	// Decimals is a pointer since tokens can have 0 decimals.
+	Decimals *int `json:"decimals"`
+}
+
+// TokenListImport is the outcome of importing a token list.
+type TokenListImport struct {
+	// ChainID is the chain of which the tokens were imported.
+	ChainID int `json:"chain_id" example:"1"`
+	// Added, Updated and Unchanged count the imported tokens that were new, differed from the stored
+	// token and were updated, or matched the stored token.
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Skipped counts the tokens of other chains and the invalid tokens.
+	Skipped int `json:"skipped"`
+	// Invalid lists the tokens of the chain that were skipped because of an invalid or repeated entry.
+	Invalid []TokenListImportError `json:"invalid"`
+}
+
+// TokenListImportError describes why a token of a token list was not imported.
+type TokenListImportError struct {
+	// Index is the position of the token in the tokens array of the list.
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+	Error   string `json:"error"`
+}
+
+// ParseTokenList reads a token list of at most MaxTokenListSize bytes. It returns an error matching
+// ErrInvalidTokenList if the list is not valid JSON or lacks its name or tokens. The tokens themselves
+// are validated by ImportTokenList.
+func ParseTokenList(r io.Reader) (*TokenList, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxTokenListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading token list: %w", err)
+	}
+
+	if len(data) > MaxTokenListSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidTokenList, MaxTokenListSize)
+	}
+
+	var raw struct {
+		Name   string            `json:"name"`
+		Tokens *[]TokenListToken `json:"tokens"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		// Type errors name the Go types, which mean nothing to the author of the list
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			if typeErr.Field == "" {
+				return nil, fmt.Errorf("%w: expected an object, got %s", ErrInvalidTokenList, typeErr.Value)
+			}
+
+			return nil, fmt.Errorf("%w: unexpected %s in %s", ErrInvalidTokenList, typeErr.Value, typeErr.Field)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTokenList, err)
+	}
+
+	if raw.Name == "" {
+		return nil, fmt.Errorf("%w: missing name", ErrInvalidTokenList)
+	}
+
+	if raw.Tokens == nil {
+		return nil, fmt.Errorf("%w: missing tokens", ErrInvalidTokenList)
+	}
+
+	return &TokenList{Name: raw.Name, Tokens: *raw.Tokens}, nil
+}
+
+// FetchTokenList downloads and parses the token list at an http or https URL of a public address,
+// following at most maxTokenListRedirects redirects. It returns an error matching ErrInvalidTokenList
+// if the URL or the list is invalid or the URL resolves to a non-public address, and ErrTokenListFetch
+// if the download fails.
+func FetchTokenList(ctx context.Context, listURL string) (*TokenList, error) {
+	parsed, err := url.Parse(listURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: expected an http or https URL", ErrInvalidTokenList)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := tokenListClient.Do(req)
+	if errors.Is(err, errTokenListDestination) {
+		return nil, fmt.Errorf("%w: the URL must resolve to a public address", ErrInvalidTokenList)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenListFetch, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected status %s", ErrTokenListFetch, resp.Status)
+	}
+
+	return ParseTokenList(resp.Body)
+}
+
+// ImportTokenList adds the tokens of the list on the configured chain to the catalogue and updates the
+// symbol, name and decimals of those already catalogued. Importing the same list again changes nothing.
+// Tokens with an invalid address, an empty symbol or unsupported decimals are skipped, as are repeated
+// addresses after their first entry. Only failures to read or write the tokens table are returned.
+func (s *TransferService) ImportTokenList(ctx context.Context, list *TokenList) (*TokenListImport, error) {
+	stored, err := s.store.GetTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting tokens: %w", err)
+	}
+
+	storedByAddress := make(map[string]int, len(stored))
+	for i, token := range stored {
+		storedByAddress[token.Address] = i
+	}
+
+	result := &TokenListImport{ChainID: s.ChainID(), Invalid: []TokenListImportError{}}
+	seen := make(map[string]bool)
+
+	for i, token := range list.Tokens {
+		if token.ChainID != result.ChainID {
+			result.Skipped++
+			continue
+		}
+
+		address := strings.ToLower(token.Address)
+		symbol := truncateRunes(strings.TrimSpace(token.Symbol), maxTokenSymbolLength)
+		name := truncateRunes(strings.TrimSpace(token.Name), maxTokenNameLength)
+
+		var invalid string
+
+		switch {
+		case !IsValidAddress(token.Address):
+			invalid = "invalid address, expected 0x followed by 40 hex characters"
+		case seen[address]:
+			invalid = "repeated address, only its first entry is imported"
+		case symbol == "":
+			invalid = "missing symbol"
+		case token.Decimals == nil || !IsValidDecimals(*token.Decimals):
+			invalid = fmt.Sprintf("invalid decimals, expected an integer between 0 and %d", MaxTokenDecimals)
+		}
+
+		if invalid != "" {
+			result.Skipped++
+			result.Invalid = append(result.Invalid, TokenListImportError{Index: i, Address: token.Address, Error: invalid})
+
+			continue
+		}
+
+		seen[address] = true
+
+		existingIdx, ok := storedByAddress[address]
+		if !ok {
+			if _, err := s.store.AddToken(ctx, address, symbol, name, *token.Decimals); err != nil {
+				return nil, fmt.Errorf("adding token %s: %w", address, err)
+			}
+
+			result.Added++
+
+			continue
+		}
+
+		existing := stored[existingIdx]
+		if existing.Symbol == symbol && existing.Name == name && existing.Decimals == *token.Decimals {
+			result.Unchanged++
+			continue
+		}
+
+		if _, err := s.store.UpdateToken(ctx, existing.ID, &symbol, &name, token.Decimals); err != nil {
+			return nil, fmt.Errorf("updating token %s: %w", address, err)
+		}
+
+		result.Updated++
+	}
+
+	s.logger.Infow("Imported token list",
+		"list", list.Name,
+		"chainID", result.ChainID,
+		"added", result.Added,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"skipped", result.Skipped)
+
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "1.1.1.1", want: true},
+		{addr: "2606:4700:4700::1111", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "fd00:ec2::254"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "100.100.100.200"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "224.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("expected %s public %v, got %v", tt.addr, tt.want, got)
+		}
+	}
+}
+
+// allowTokenListAddresses makes the token lists downloaded by the test reachable only at the addresses
+// allowed reports true for.
+func allowTokenListAddresses(t *testing.T, allowed func(netip.Addr) bool) {
+	t.Helper()
+
+	client := tokenListClient
+	tokenListClient = newTokenListClient(allowed)
+
+	t.Cleanup(func() {
+		tokenListClient = client
+	})
+}
+
+func TestFetchTokenList(t *testing.T) {
+	loopback := netip.MustParseAddr("127.0.0.1")
+	allowTokenListAddresses(t, func(addr netip.Addr) bool { return addr == loopback })
+
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.json":
+			_, _ = w.Write([]byte(`{"name": "Test", "tokens": [{"chainId": 1, "address": "0xa1"}]}`))
+		case "/moved":
+			http.Redirect(w, r, "/list.json", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/elsewhere":
+			// Another loopback address, which the test does not allow
+			http.Redirect(w, r, strings.Replace(srv.URL, "127.0.0.1", "127.0.0.2", 1)+"/list.json", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{path: "/list.json"},
+		{path: "/moved"},
+		{path: "/loop", wantErr: ErrTokenListFetch},
+		{path: "/elsewhere", wantErr: ErrInvalidTokenList},
+		{path: "/missing", wantErr: ErrTokenListFetch},
+	}
+
+	for _, tt := range tests {
+		list, err := FetchTokenList(t.Context(), srv.URL+tt.path)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("expected error %v fetching %s, got %v", tt.wantErr, tt.path, err)
+		}
+
+		if tt.wantErr == nil && (list.Name != "Test" || len(list.Tokens) != 1) {
+			t.Fatalf("expected the token list, got %+v", list)
+		}
+	}
+}
+
+func TestFetchTokenListPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no request to a loopback address")
+	}))
+	t.Cleanup(srv.Close)
+
+	// The host name resolves to loopback too, which is only checked once connecting
+	listURL := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	for _, listURL := range []string{srv.URL, listURL} {
+		if _, err := FetchTokenList(t.Context(), listURL); !errors.Is(err, ErrInvalidTokenList) {
+			t.Fatalf("expected an invalid token list fetching %s, got %v", listURL, err)
+		}
+	}
+}
@@ -3,15 +3,41 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ductm54/transfer-track/internal/cron"
 	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/notify"
 	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/internal/stream"
+	"github.com/ductm54/transfer-track/pkg/convert"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// ethTokenAddress is the token address transfers of ETH are stored under.
+const ethTokenAddress = "0x0000000000000000000000000000000000000000"
+
+// defaultTokenDecimals is used when a token's decimals are neither supplied nor found on Etherscan.
+const defaultTokenDecimals = 18
+
+// MaxTokenDecimals is the largest supported number of token decimals.
+const MaxTokenDecimals = 36
+
+// Maximum lengths of the symbol and name columns of the tokens table.
+const (
+	maxTokenSymbolLength = 20
+	maxTokenNameLength   = 255
+)
+
 // Configuration keys for database storage.
 const (
 	// Database config keys.
@@ -19,230 +45,1258 @@ const (
 	configKeyLastTokenUpdate     = "last_token_update"
 	configKeyDailyRefreshTime    = "daily_refresh_time"
 	configKeyMinRefreshInterval  = "min_refresh_interval_hours"
+	configKeyRefreshCron         = "refresh_cron"
 	defaultMinRefreshIntervalHrs = 1
+	defaultDailyRefreshTime      = "00:00:00"
 )
 
+// configDefaults are the values of the config keys stored on first run, so that reading any of
+// them succeeds. The zero last update times make the first refresh check find the data stale.
+var configDefaults = map[string]string{
+	configKeyLastETHUpdate:      time.Unix(0, 0).UTC().Format(time.RFC3339),
+	configKeyLastTokenUpdate:    time.Unix(0, 0).UTC().Format(time.RFC3339),
+	configKeyDailyRefreshTime:   defaultDailyRefreshTime,
+	configKeyMinRefreshInterval: strconv.Itoa(defaultMinRefreshIntervalHrs),
+	configKeyRefreshCron:        "",
+}
+
+// Default deadlines of a full fetch.
+const (
+	// DefaultFetchTimeout is the default minimum deadline of a full fetch.
+	DefaultFetchTimeout = 30 * time.Minute
+	// DefaultFetchTimeoutPerAddress is the default deadline of a full fetch per source address.
+	DefaultFetchTimeoutPerAddress = 5 * time.Minute
+)
+
+// Backoff of the retries of failed refreshes. The failed addresses of a failed refresh are retried
+// failedRefreshRetryDelay after it, whatever the refresh interval. The delay doubles with every
+// consecutive failed refresh, up to maxFailedRefreshRetryDelay.
+const (
+	failedRefreshRetryDelay    = 5 * time.Minute
+	maxFailedRefreshRetryDelay = 2 * time.Hour
+	// failedRefreshRuns is the number of recent refresh runs looked at for consecutive failures.
+	failedRefreshRuns = 8
+)
+
+// DefaultFetchConcurrency is the default number of source addresses fetched at the same time by a full fetch.
+const DefaultFetchConcurrency = 4
+
+// ensLookupTimeout bounds the ENS lookup of an address added without a label.
+const ensLookupTimeout = 5 * time.Second
+
+// ErrInvalidConfigValue is returned when a configuration value is out of range or malformed.
+var ErrInvalidConfigValue = errors.New("invalid config value")
+
+// hexAddressRegexp matches a 0x-prefixed 20-byte hex Ethereum address.
+var hexAddressRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// IsValidAddress reports whether address is a 0x-prefixed 20-byte hex Ethereum address.
+// Checksums are not verified since addresses are stored lowercase.
+func IsValidAddress(address string) bool {
+	return hexAddressRegexp.MatchString(address)
+}
+
+// IsValidDecimals reports whether decimals is a supported number of token decimals, from 0 to MaxTokenDecimals.
+func IsValidDecimals(decimals int) bool {
+	return decimals >= 0 && decimals <= MaxTokenDecimals
+}
+
+// ErrAddressNotTracked is returned when an address is neither a source nor a target address.
+var ErrAddressNotTracked = errors.New("address not tracked")
+
+// ErrEtherscanFetch is returned when fetching transfers from Etherscan fails, as opposed to storing them.
+// Fetches that failed on the Etherscan rate limit also match etherscan.ErrRateLimited.
+var ErrEtherscanFetch = errors.New("etherscan request failed")
+
+// TokenFetchSummary reports how many transfers of a token were fetched and newly inserted.
+type TokenFetchSummary struct {
+	TokenAddress string `json:"token_address"`
+	Fetched      int    `json:"fetched"`
+	Inserted     int    `json:"inserted"`
+	// insertedAmount is the sum of the inserted transfers in the token's smallest unit.
+	insertedAmount decimal.Decimal
+}
+
+// fetchSummary accumulates TokenFetchSummary values keyed by lowercase token address.
+type fetchSummary map[string]*TokenFetchSummary
+
+func (f fetchSummary) add(tokenAddress string, fetched, inserted int, insertedAmount decimal.Decimal) {
+	tokenAddress = strings.ToLower(tokenAddress)
+
+	summary, ok := f[tokenAddress]
+	if !ok {
+		summary = &TokenFetchSummary{TokenAddress: tokenAddress}
+		f[tokenAddress] = summary
+	}
+
+	summary.Fetched += fetched
+	summary.Inserted += inserted
+	summary.insertedAmount = summary.insertedAmount.Add(insertedAmount)
+}
+
+func (f fetchSummary) merge(other fetchSummary) {
+	for _, summary := range other {
+		f.add(summary.TokenAddress, summary.Fetched, summary.Inserted, summary.insertedAmount)
+	}
+}
+
+func (f fetchSummary) inserted() int {
+	total := 0
+	for _, summary := range f {
+		total += summary.Inserted
+	}
+
+	return total
+}
+
+// list returns the summaries sorted by token address.
+func (f fetchSummary) list() []TokenFetchSummary {
+	summaries := make([]TokenFetchSummary, 0, len(f))
+	for _, summary := range f {
+		summaries = append(summaries, *summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].TokenAddress < summaries[j].TokenAddress
+	})
+
+	return summaries
+}
+
 // TransferService handles the transfer tracking logic.
 type TransferService struct {
 	store        *storage.Storage
 	etherscanAPI *etherscan.Client
 	logger       *zap.SugaredLogger
+	refreshJobs  *refreshJobs
+	// transferStream publishes the newly stored transfers
+	transferStream  *stream.Broker
+	refreshProgress *refreshProgress
+
+	notifier            notify.Notifier
+	notifyMinInserted   int
+	ingestionFilter     IngestionFilter
+	fetchMode           FetchMode
+	fetchConcurrency    int
+	fetchTimeout        time.Duration
+	fetchTimeoutPerAddr time.Duration
+	confirmationBlocks  int64
+	ensResolver         ENSResolver
+}
+
+// ENSResolver resolves the primary ENS name of an address.
+type ENSResolver interface {
+	LookupName(ctx context.Context, address string) (string, error)
+}
+
+// IngestionFilter selects which fetched ERC20 transfers are stored.
+type IngestionFilter struct {
+	// SkipZeroValue drops transfers of a zero amount.
+	SkipZeroValue bool
+	// OnlyKnownTokens drops transfers of tokens that are not in the tokens table, e.g. airdropped spam.
+	OnlyKnownTokens bool
+	// AutoAddTokens adds the tokens of stored transfers missing from the tokens table, using the
+	// token metadata of the transfers, so that totals include them. Unknown tokens are never
+	// stored when OnlyKnownTokens is set, so they are not added either.
+	AutoAddTokens bool
+}
+
+// FetchMode selects how the ERC20 transfers of an address are fetched.
+type FetchMode string
+
+// Fetch modes.
+const (
+	// FetchModeAll fetches the transfers of all tokens in a single paginated query.
+	FetchModeAll FetchMode = "all"
+	// FetchModeKnownTokens fetches the transfers of each token in the tokens table with its own query,
+	// which avoids paging through transfers of spam tokens.
+	FetchModeKnownTokens FetchMode = "known-tokens"
+)
+
+// IsValid reports whether the fetch mode is supported.
+func (m FetchMode) IsValid() bool {
+	return m == FetchModeAll || m == FetchModeKnownTokens
+}
+
+// NewTransferService creates a new TransferService. It does not write to the database, so that it
+// can serve a read-only instance.
+func NewTransferService(
+	store *storage.Storage, logger *zap.SugaredLogger, etherscanCfg etherscan.Config,
+) (*TransferService, error) {
+	// Only use the API key from the environment
+	if etherscanCfg.APIKey == "" {
+		logger.Warnw("No Etherscan API key provided, API calls will likely fail")
+	}
+
+	etherscanClient, err := etherscan.NewClientWithConfig(etherscanCfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating etherscan client: %w", err)
+	}
+
+	logger.Infow("Using chain ID for Etherscan API",
+		"chainID", etherscanClient.ChainID(),
+		"baseURL", etherscanCfg.BaseURL,
+		"rateLimitRetries", etherscanCfg.RateLimitRetries,
+		"rateLimitCooldown", etherscanCfg.RateLimitCooldown)
+
+	return &TransferService{
+		store:        store,
+		etherscanAPI: etherscanClient,
+		logger:       logger,
+		refreshJobs:  newRefreshJobs(),
+
+		transferStream:  stream.NewBroker(stream.DefaultBufferSize),
+		refreshProgress: newRefreshProgress(),
+
+		fetchMode:           FetchModeAll,
+		fetchConcurrency:    DefaultFetchConcurrency,
+		fetchTimeout:        DefaultFetchTimeout,
+		fetchTimeoutPerAddr: DefaultFetchTimeoutPerAddress,
+		confirmationBlocks:  DefaultConfirmationBlocks,
+	}, nil
+}
+
+// ChainID returns the chain ID transfers are fetched from.
+func (s *TransferService) ChainID() int {
+	return s.etherscanAPI.ChainID()
+}
+
+// SubscribeTransfers subscribes to the transfers stored from now on that match filter.
+// The subscription must be ended with UnsubscribeTransfers.
+func (s *TransferService) SubscribeTransfers(filter stream.Filter) *stream.Subscription {
+	return s.transferStream.Subscribe(filter)
+}
+
+// UnsubscribeTransfers ends a subscription of SubscribeTransfers.
+func (s *TransferService) UnsubscribeTransfers(sub *stream.Subscription) {
+	s.transferStream.Unsubscribe(sub)
+}
+
+// CloseTransferStream ends every subscription to the stored transfers, e.g. on shutdown.
+func (s *TransferService) CloseTransferStream() {
+	s.transferStream.Close()
+}
+
+// SetNotifier sets the notifier called after a refresh stores at least minInserted new transfers.
+func (s *TransferService) SetNotifier(notifier notify.Notifier, minInserted int) {
+	s.notifier = notifier
+	s.notifyMinInserted = max(minInserted, 1)
+}
+
+// SetIngestionFilter sets which fetched ERC20 transfers are stored.
+func (s *TransferService) SetIngestionFilter(filter IngestionFilter) {
+	s.ingestionFilter = filter
+}
+
+// SetFetchMode sets how ERC20 transfers are fetched. Unsupported modes fall back to FetchModeAll.
+func (s *TransferService) SetFetchMode(mode FetchMode) {
+	if !mode.IsValid() {
+		mode = FetchModeAll
+	}
+
+	s.fetchMode = mode
+}
+
+// SetFetchConcurrency sets how many source addresses a full fetch fetches at the same time.
+// Requests to Etherscan stay rate limited however many addresses are fetched.
+// Non-positive values fall back to DefaultFetchConcurrency.
+func (s *TransferService) SetFetchConcurrency(concurrency int) {
+	if concurrency <= 0 {
+		concurrency = DefaultFetchConcurrency
+	}
+
+	s.fetchConcurrency = concurrency
+}
+
+// SetENSResolver sets the resolver used to label addresses added without a label.
+func (s *TransferService) SetENSResolver(resolver ENSResolver) {
+	s.ensResolver = resolver
+}
+
+// ResolveENS returns the primary ENS name of address, or an empty string if no resolver is set,
+// the address has no name or the lookup fails. Lookups are bounded by ensLookupTimeout.
+func (s *TransferService) ResolveENS(ctx context.Context, address string) string {
+	if s.ensResolver == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ensLookupTimeout)
+	defer cancel()
+
+	name, err := s.ensResolver.LookupName(ctx, address)
+	if err != nil {
+		s.logger.Debugw("No ENS name resolved", "address", address, "err", err)
+		return ""
+	}
+
+	return name
+}
+
+// NormalizeAddressLabels trims the label, lowercases the category and the tags, and drops empty and
+// repeated tags, so that filtering by category or tag is case-insensitive.
+func NormalizeAddressLabels(labels storage.AddressLabels) storage.AddressLabels {
+	normalized := storage.AddressLabels{
+		Label:    strings.TrimSpace(labels.Label),
+		Category: NormalizeAddressCategory(labels.Category),
+		Tags:     make([]string, 0, len(labels.Tags)),
+	}
+
+	for _, tag := range labels.Tags {
+		tag = NormalizeAddressCategory(tag)
+		if tag != "" && !slices.Contains(normalized.Tags, tag) {
+			normalized.Tags = append(normalized.Tags, tag)
+		}
+	}
+
+	return normalized
+}
+
+// NormalizeAddressCategory normalizes an address category or tag, e.g. of a filter.
+func NormalizeAddressCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// AddSourceAddress adds or updates a source address. An empty label is filled with the address's ENS name,
+// and the category and tags are normalized with NormalizeAddressLabels.
+func (s *TransferService) AddSourceAddress(
+	ctx context.Context, address string, labels storage.AddressLabels,
+) (*storage.SourceAddress, bool, error) {
+	labels = NormalizeAddressLabels(labels)
+	if labels.Label == "" {
+		labels.Label = s.ResolveENS(ctx, address)
+	}
+
+	return s.store.AddSourceAddress(ctx, address, labels)
+}
+
+// AddTargetAddress adds or updates a target address. An empty label is filled with the address's ENS name,
+// and the category and tags are normalized with NormalizeAddressLabels.
+func (s *TransferService) AddTargetAddress(
+	ctx context.Context, address string, labels storage.AddressLabels,
+) (*storage.TargetAddress, bool, error) {
+	labels = NormalizeAddressLabels(labels)
+	if labels.Label == "" {
+		labels.Label = s.ResolveENS(ctx, address)
+	}
+
+	return s.store.AddTargetAddress(ctx, address, labels)
+}
+
+// SetFetchTimeout sets the deadline of a full fetch: perAddress for every source address, but at least minimum.
+// Non-positive values fall back to their defaults.
+func (s *TransferService) SetFetchTimeout(minimum, perAddress time.Duration) {
+	if minimum <= 0 {
+		minimum = DefaultFetchTimeout
+	}
+
+	if perAddress <= 0 {
+		perAddress = DefaultFetchTimeoutPerAddress
+	}
+
+	s.fetchTimeout = minimum
+	s.fetchTimeoutPerAddr = perAddress
+}
+
+// FetchContext returns a context with the deadline of a full fetch derived from the number of source
+// addresses. If the addresses cannot be counted, the minimum deadline is used.
+func (s *TransferService) FetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.fetchTimeout
+
+	sourceAddresses, err := s.store.GetSourceAddresses(ctx)
+	if err != nil {
+		s.logger.Warnw("Error counting source addresses for the fetch deadline", "err", err)
+	} else {
+		timeout = max(timeout, time.Duration(len(sourceAddresses))*s.fetchTimeoutPerAddr)
+	}
+
+	s.logger.Debugw("Derived fetch deadline", "timeout", timeout)
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TokenMetadata describes an ERC20 token. Empty fields are unknown.
+type TokenMetadata struct {
+	Symbol   string
+	Name     string
+	Decimals *int
+}
+
+// FillTokenMetadata fills the missing symbol, name and decimals of a token from Etherscan.
+// Supplied values are never overwritten. If the lookup fails, the supplied values are kept
+// and decimals default to 18.
+func (s *TransferService) FillTokenMetadata(ctx context.Context, address string, meta TokenMetadata) TokenMetadata {
+	if meta.Symbol == "" || meta.Name == "" || meta.Decimals == nil {
+		info, err := s.etherscanAPI.GetTokenInfo(ctx, address)
+		if err != nil {
+			s.logger.Warnw("Failed to look up token metadata, using supplied values", "token", address, "err", err)
+		} else {
+			if meta.Symbol == "" {
+				meta.Symbol = info.Symbol
+			}
+
+			if meta.Name == "" {
+				meta.Name = info.Name
+			}
+
+			if meta.Decimals == nil && IsValidDecimals(info.Decimals) {
+				meta.Decimals = &info.Decimals
+			}
+		}
+	}
+
+	if meta.Decimals == nil {
+		decimals := defaultTokenDecimals
+		meta.Decimals = &decimals
+	}
+
+	return meta
+}
+
+// SeedStartupConfig stores the refresh interval and daily refresh times given at startup, keeping the
+// values set by an operator. A non-positive refreshInterval and an empty dailyRefreshTime are not
+// stored. Failures are logged. It is meant to be called once at startup, unless the database is read-only.
+func (s *TransferService) SeedStartupConfig(ctx context.Context, refreshInterval int, dailyRefreshTime string) {
+	// Seed refresh interval if provided, keeping a value set by an operator
+	if refreshInterval > 0 {
+		err := s.seedConfig(ctx, configKeyMinRefreshInterval, strconv.Itoa(refreshInterval))
+		if err != nil {
+			s.logger.Warnw("Failed to store refresh interval in config", "err", err)
+		}
+	}
+
+	// Seed daily refresh time if provided, keeping a value set by an operator
+	if dailyRefreshTime != "" {
+		// Validate time format
+		offsets, err := ParseDailyRefreshTimes(dailyRefreshTime)
+		if err == nil {
+			err = s.seedConfig(ctx, configKeyDailyRefreshTime, formatDailyRefreshTimes(offsets))
+			if err != nil {
+				s.logger.Warnw("Failed to store daily refresh time in config", "err", err)
+			}
+		} else {
+			s.logger.Warnw("Invalid daily refresh time format", "time", dailyRefreshTime, "err", err)
+		}
+	}
+}
+
+// seedConfig stores a startup value for a config key unless the key has been changed by an operator,
+// e.g. through the API. Values of the migrations, the defaults and earlier startups are replaced, so a
+// changed flag takes effect on the next start.
+func (s *TransferService) seedConfig(ctx context.Context, key, value string) error {
+	seeded, err := s.store.SeedConfig(ctx, key, value)
+	if err != nil {
+		return err
+	}
+
+	if !seeded {
+		s.logger.Infow("Keeping config value set by an operator instead of startup value",
+			"key", key, "startupValue", value)
+	}
+
+	return nil
+}
+
+// EnsureConfigDefaults stores the default value of every config key that is not set yet, keeping
+// the values already set. It is meant to be called once at startup, unless the database is read-only.
+func (s *TransferService) EnsureConfigDefaults(ctx context.Context) error {
+	inserted, err := s.store.EnsureDefaults(ctx, configDefaults)
+	if err != nil {
+		return fmt.Errorf("ensuring config defaults: %w", err)
+	}
+
+	if len(inserted) > 0 {
+		s.logger.Infow("Stored default config", "keys", inserted)
+	}
+
+	return nil
+}
+
+// UpdateRefreshInterval updates the minimum refresh interval in hours.
+func (s *TransferService) UpdateRefreshInterval(ctx context.Context, hours int) error {
+	if hours < 1 {
+		return fmt.Errorf("refresh interval must be at least 1 hour: %w", ErrInvalidConfigValue)
+	}
+
+	err := s.store.UpdateConfig(ctx, configKeyMinRefreshInterval, strconv.Itoa(hours))
+	if err != nil {
+		return fmt.Errorf("updating refresh interval: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshInterval gets the minimum refresh interval in hours.
+func (s *TransferService) GetRefreshInterval(ctx context.Context) (int, error) {
+	value, err := s.store.GetConfig(ctx, configKeyMinRefreshInterval)
+	if err != nil {
+		return 0, fmt.Errorf("getting refresh interval: %w", err)
+	}
+
+	hours, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing refresh interval %q: %w", value, err)
+	}
+
+	return hours, nil
+}
+
+// ParseDailyRefreshTimes parses a comma-separated list of HH:MM:SS daily refresh times into their offsets
+// from midnight, sorted and without duplicates.
+func ParseDailyRefreshTimes(value string) ([]time.Duration, error) {
+	var offsets []time.Duration
+
+	seen := make(map[time.Duration]bool)
+
+	for _, timeStr := range strings.Split(value, ",") {
+		timeStr = strings.TrimSpace(timeStr)
+
+		t, err := time.Parse("15:04:05", timeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q, expected HH:MM:SS: %w", timeStr, ErrInvalidConfigValue)
+		}
+
+		offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+			time.Duration(t.Second())*time.Second
+		if !seen[offset] {
+			seen[offset] = true
+			offsets = append(offsets, offset)
+		}
+	}
+
+	slices.Sort(offsets)
+
+	return offsets, nil
+}
+
+// formatDailyRefreshTimes formats offsets from midnight as a comma-separated list of HH:MM:SS times.
+func formatDailyRefreshTimes(offsets []time.Duration) string {
+	times := make([]string, 0, len(offsets))
+	for _, offset := range offsets {
+		times = append(times, time.Time{}.Add(offset).Format("15:04:05"))
+	}
+
+	return strings.Join(times, ",")
+}
+
+// UpdateDailyRefreshTime updates the daily refresh times, a comma-separated list of HH:MM:SS times.
+func (s *TransferService) UpdateDailyRefreshTime(ctx context.Context, timeStr string) error {
+	offsets, err := ParseDailyRefreshTimes(timeStr)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.UpdateConfig(ctx, configKeyDailyRefreshTime, formatDailyRefreshTimes(offsets))
+	if err != nil {
+		return fmt.Errorf("updating daily refresh time: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyRefreshTime gets the daily refresh times as a comma-separated list of HH:MM:SS times.
+func (s *TransferService) GetDailyRefreshTime(ctx context.Context) (string, error) {
+	value, err := s.store.GetConfig(ctx, configKeyDailyRefreshTime)
+	if err != nil {
+		return "", fmt.Errorf("getting daily refresh time: %w", err)
+	}
+
+	return value, nil
+}
+
+// UpdateRefreshCron updates the cron expression of scheduled refreshes, which takes precedence over
+// the daily refresh times. An empty expression clears it, so the daily refresh times apply again.
+func (s *TransferService) UpdateRefreshCron(ctx context.Context, expr string) error {
+	expr = strings.TrimSpace(expr)
+
+	if expr != "" {
+		if _, err := cron.Parse(expr); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfigValue, err)
+		}
+	}
+
+	err := s.store.UpdateConfig(ctx, configKeyRefreshCron, expr)
+	if err != nil {
+		return fmt.Errorf("updating refresh cron: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshCron gets the cron expression of scheduled refreshes, empty if the daily refresh times apply.
+func (s *TransferService) GetRefreshCron(ctx context.Context) (string, error) {
+	value, err := s.store.GetConfig(ctx, configKeyRefreshCron)
+	if err != nil {
+		return "", fmt.Errorf("getting refresh cron: %w", err)
+	}
+
+	return value, nil
+}
+
+// ShouldRefreshData checks if data should be refreshed based on last update time.
+// Data is stale if either the ETH or the token transfers were updated longer than the
+// refresh interval ago, or if the failed addresses of the last refresh are due for a retry.
+func (s *TransferService) ShouldRefreshData(ctx context.Context) (bool, error) {
+	_, due, err := s.dueRefresh(ctx)
+
+	return due, err
+}
+
+// RefreshStaleData runs the refresh ShouldRefreshData reports as due, if any: a full refresh, or a
+// retry of only the addresses that failed in the last refresh. It returns the number of newly
+// inserted transfers.
+func (s *TransferService) RefreshStaleData(ctx context.Context) (int, error) {
+	scope, due, err := s.dueRefresh(ctx)
+	if err != nil || !due {
+		return 0, err
+	}
+
+	result, err := s.recordedRefresh(ctx, scope)
+
+	return result.inserted, err
+}
+
+// refreshScope is the source addresses a refresh fetches.
+type refreshScope struct {
+	// retryAddresses restricts the refresh to the failed addresses of the last refreshes, nil
+	// fetches every source address.
+	retryAddresses []string
+	// retrySince is the start of the first of the failed refreshes, since when the addresses that
+	// are not retried were fetched.
+	retrySince time.Time
+}
+
+// dueRefresh returns the refresh due for the stored data, if any.
+func (s *TransferService) dueRefresh(ctx context.Context) (refreshScope, bool, error) {
+	// Get refresh interval
+	refreshInterval, err := s.GetRefreshInterval(ctx)
+	if err != nil {
+		return refreshScope{}, false, err
+	}
+
+	interval := time.Duration(refreshInterval) * time.Hour
+
+	failedRuns, err := s.lastFailedRefreshRuns(ctx)
+	if err != nil {
+		s.logger.Warnw("Failed to get last refresh runs, not retrying failed addresses", "err", err)
+	}
+
+	// The last update times are only advanced by successful fetches, so after failed refreshes the
+	// interval is measured from the first of them instead, which fetched the other addresses
+	if len(failedRuns) > 0 {
+		lastRun, firstRun := failedRuns[0], failedRuns[len(failedRuns)-1]
+
+		if time.Since(firstRun.StartedAt) > interval {
+			return refreshScope{}, true, nil
+		}
+
+		if lastRun.FinishedAt == nil || time.Since(*lastRun.FinishedAt) < failedRefreshBackoff(len(failedRuns)) {
+			return refreshScope{}, false, nil
+		}
+
+		// A refresh failing before fetching any address is retried in full
+		if len(lastRun.FailedAddresses) == 0 {
+			return refreshScope{}, true, nil
+		}
+
+		s.logger.Infow("Retrying failed addresses of the last refresh",
+			"runID", lastRun.ID,
+			"failedAddresses", lastRun.FailedAddresses,
+			"consecutiveFailures", len(failedRuns))
+
+		return refreshScope{retryAddresses: lastRun.FailedAddresses, retrySince: firstRun.StartedAt}, true, nil
+	}
+
+	for _, key := range []string{configKeyLastETHUpdate, configKeyLastTokenUpdate} {
+		lastUpdate, err := s.getLastUpdateTime(ctx, key)
+		if err != nil {
+			s.logger.Warnw("Failed to get last update time, assuming refresh is needed", "key", key, "err", err)
+			return refreshScope{}, true, nil // If error, assume refresh is needed
+		}
+
+		// Check if enough time has passed since last update
+		if time.Since(lastUpdate) > interval {
+			return refreshScope{}, true, nil
+		}
+	}
+
+	return refreshScope{}, false, nil
+}
+
+// failedRefreshBackoff returns the delay before retrying after the given number of consecutive failed refreshes.
+func failedRefreshBackoff(failures int) time.Duration {
+	delay := failedRefreshRetryDelay
+
+	for range failures - 1 {
+		if delay >= maxFailedRefreshRetryDelay {
+			break
+		}
+
+		delay *= 2
+	}
+
+	return min(delay, maxFailedRefreshRetryDelay)
+}
+
+// lastFailedRefreshRuns returns the consecutive failed refresh runs up to the last one, newest first.
+// It returns none if the last refresh succeeded or is still running.
+func (s *TransferService) lastFailedRefreshRuns(ctx context.Context) ([]storage.RefreshRun, error) {
+	runs, err := s.store.ListRefreshRuns(ctx, failedRefreshRuns)
+	if err != nil {
+		return nil, fmt.Errorf("listing refresh runs: %w", err)
+	}
+
+	for i, run := range runs {
+		if run.Status != storage.RefreshRunFailed {
+			return runs[:i], nil
+		}
+	}
+
+	return runs, nil
+}
+
+// getLastUpdateTime reads a last update timestamp from the config.
+func (s *TransferService) getLastUpdateTime(ctx context.Context, key string) (time.Time, error) {
+	value, err := s.store.GetConfig(ctx, key)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting last update time: %w", err)
+	}
+
+	lastUpdate, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing last update time %q: %w", value, err)
+	}
+
+	return lastUpdate, nil
+}
+
+// refreshResult is the outcome of a full refresh.
+type refreshResult struct {
+	sourceAddresses int
+	inserted        int
+	// fetchErrs are the errors of the failed fetches. Other fetches are still stored.
+	fetchErrs []error
+	// failedAddresses are the source addresses of which any fetch failed.
+	failedAddresses []string
+}
+
+// FetchAndStoreTransfers fetches and stores transfers for all source addresses and tokens.
+// It returns the number of newly inserted transfers. The refresh is recorded as a refresh run when it
+// starts and updated with its outcome, which fails if any fetch failed. The failed fetches of single
+// addresses are only recorded there, use FetchAndStoreTransfersStrict to get them.
+func (s *TransferService) FetchAndStoreTransfers(ctx context.Context) (int, error) {
+	result, err := s.recordedRefresh(ctx, refreshScope{})
+
+	return result.inserted, err
+}
+
+// FetchAndStoreTransfersStrict is FetchAndStoreTransfers, but it also fails if the fetch of any address
+// failed, with the errors of the failed fetches, like the recorded refresh run. The transfers of the
+// other fetches are still stored.
+func (s *TransferService) FetchAndStoreTransfersStrict(ctx context.Context) (int, error) {
+	result, err := s.recordedRefresh(ctx, refreshScope{})
+
+	return result.inserted, errors.Join(append(result.fetchErrs, err)...)
+}
+
+// recordedRefresh runs a refresh of scope recorded as a refresh run. It returns the outcome of the
+// fetches and the error that stopped the refresh, if any.
+func (s *TransferService) recordedRefresh(ctx context.Context, scope refreshScope) (refreshResult, error) {
+	runID, err := s.store.StartRefreshRun(ctx, time.Now())
+	if err != nil {
+		// The refresh itself is still worth running
+		s.logger.Errorw("Error recording refresh run start", "err", err)
+	}
+
+	result, err := s.fetchAndStoreTransfers(ctx, scope)
+	failure := errors.Join(append(result.fetchErrs, err)...)
+
+	finished := RefreshProgress{Event: RefreshProgressFinished, Inserted: result.inserted}
+	if failure != nil {
+		finished.Error = failure.Error()
+	}
+
+	s.reportProgress(finished)
+
+	if runID != 0 {
+		finishedAt := time.Now()
+		run := storage.RefreshRun{
+			ID:              runID,
+			Status:          storage.RefreshRunSucceeded,
+			FinishedAt:      &finishedAt,
+			SourceAddresses: result.sourceAddresses,
+			Inserted:        result.inserted,
+			FailedAddresses: result.failedAddresses,
+		}
+
+		if failure != nil {
+			run.Status = storage.RefreshRunFailed
+			run.Error = failure.Error()
+		}
+
+		// Record the outcome even if the refresh ran out of time
+		if finishErr := s.store.FinishRefreshRun(context.WithoutCancel(ctx), run); finishErr != nil {
+			s.logger.Errorw("Error recording refresh run outcome", "runID", runID, "err", finishErr)
+		}
+	}
+
+	return result, err
+}
+
+// GetLastRefreshRun returns the most recently started refresh, which may still be running.
+// It returns sql.ErrNoRows if no refresh was recorded.
+func (s *TransferService) GetLastRefreshRun(ctx context.Context) (*storage.RefreshRun, error) {
+	return s.store.GetLastRefreshRun(ctx)
 }
 
-// NewTransferService creates a new TransferService.
-func NewTransferService(
-	store *storage.Storage, logger *zap.SugaredLogger, apiKey string,
-	refreshInterval int, dailyRefreshTime string, chainID ...int,
-) (*TransferService, error) {
-	ctx := context.Background()
+// ListRefreshRuns returns the limit most recently started refreshes, newest first.
+func (s *TransferService) ListRefreshRuns(ctx context.Context, limit int) ([]storage.RefreshRun, error) {
+	return s.store.ListRefreshRuns(ctx, limit)
+}
+
+func (s *TransferService) fetchAndStoreTransfers(ctx context.Context, scope refreshScope) (refreshResult, error) {
+	var result refreshResult
+
+	// Always fetch the latest data for manual refresh
+	s.logger.Infow("Fetching latest transfer data", "retryAddresses", scope.retryAddresses)
+
+	// Get source addresses
+	sourceAddresses, err := s.store.GetSourceAddresses(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting source addresses: %w", err)
+	}
+
+	// Retried addresses that are no longer source addresses are dropped
+	if scope.retryAddresses != nil {
+		sourceAddresses = slices.DeleteFunc(sourceAddresses, func(address storage.SourceAddress) bool {
+			return !slices.ContainsFunc(scope.retryAddresses, func(retry string) bool {
+				return strings.EqualFold(retry, address.Address)
+			})
+		})
+	}
+
+	s.reportProgress(RefreshProgress{Event: RefreshProgressStarted, Addresses: len(sourceAddresses)})
+
+	if len(sourceAddresses) == 0 {
+		s.logger.Infow("No source addresses configured, skipping transfer fetch")
+		return result, nil
+	}
+
+	result.sourceAddresses = len(sourceAddresses)
+
+	// Transfers are still fetched, but none count towards the source to target totals
+	targetAddresses, err := s.store.GetTargetAddresses(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting target addresses: %w", err)
+	}
+
+	if len(targetAddresses) == 0 {
+		s.logger.Warnw("No target addresses configured, fetched transfers will not count towards the totals")
+	}
+
+	// Set time range (last 30 days by default)
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, -1, 0) // 1 month ago
+
+	// Process the source addresses with a bounded number of workers, each address by a single worker
+	workers := min(s.fetchConcurrency, len(sourceAddresses))
+	addressResults := make([]addressFetchResult, len(sourceAddresses))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				addressResults[i] = s.fetchAndStoreSourceAddress(ctx, sourceAddresses[i].Address, startTime, endTime)
+			}
+		}()
+	}
+
+	for i := range sourceAddresses {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	// Merge in the order of the source addresses, so that errors are reported in a stable order
+	summary := make(fetchSummary)
+	ethFailed, tokenFailed := false, false
+
+	for i, addressResult := range addressResults {
+		summary.merge(addressResult.summary)
+
+		if len(addressResult.errs) > 0 {
+			result.fetchErrs = append(result.fetchErrs, addressResult.errs...)
+			result.failedAddresses = append(result.failedAddresses, sourceAddresses[i].Address)
+		}
+
+		ethFailed = ethFailed || addressResult.ethFailed
+		tokenFailed = tokenFailed || addressResult.tokenFailed
+	}
+
+	result.inserted = summary.inserted()
+
+	s.logger.Infow("Finished fetching transfers",
+		"inserted", result.inserted,
+		"failedAddresses", result.failedAddresses,
+		"ethFailed", ethFailed,
+		"tokenFailed", tokenFailed,
+		"etherscanRateLimitedTotal", s.etherscanAPI.RateLimitedCount())
+
+	s.notifyInserted(ctx, summary)
 
-	// Only use the API key from the environment
-	if apiKey == "" {
-		logger.Warnw("No Etherscan API key provided, API calls will likely fail")
+	// Update last update time, only for the kinds of transfers that were fetched for every address
+	// so that failed fetches are retried on the next refresh. After a successful retry, every address
+	// was fetched since the first of the failed refreshes.
+	updatedAt := time.Now()
+	if scope.retryAddresses != nil {
+		updatedAt = scope.retrySince
 	}
 
-	// Store refresh interval if provided
-	if refreshInterval > 0 {
-		err := store.UpdateConfig(ctx, configKeyMinRefreshInterval, strconv.Itoa(refreshInterval))
-		if err != nil {
-			logger.Warnw("Failed to store refresh interval in config", "err", err)
-		}
+	if !ethFailed {
+		s.advanceLastUpdateTime(ctx, configKeyLastETHUpdate, updatedAt)
 	}
 
-	// Store daily refresh time if provided
-	if dailyRefreshTime != "" {
-		// Validate time format
-		_, err := time.Parse("15:04:05", dailyRefreshTime)
-		if err == nil {
-			err = store.UpdateConfig(ctx, configKeyDailyRefreshTime, dailyRefreshTime)
-			if err != nil {
-				logger.Warnw("Failed to store daily refresh time in config", "err", err)
-			}
-		} else {
-			logger.Warnw("Invalid daily refresh time format", "time", dailyRefreshTime, "err", err)
-		}
+	if !tokenFailed {
+		s.advanceLastUpdateTime(ctx, configKeyLastTokenUpdate, updatedAt)
 	}
 
-	// Create Etherscan client with chain ID if provided
-	var etherscanClient *etherscan.Client
+	return result, nil
+}
 
-	if len(chainID) > 0 && chainID[0] > 0 {
-		logger.Infow("Using custom chain ID for Etherscan API", "chainID", chainID[0])
-		etherscanClient = etherscan.NewClientWithChainID(apiKey, logger, chainID[0])
-	} else {
-		logger.Infow("Using default chain ID (1) for Etherscan API")
-		etherscanClient = etherscan.NewClient(apiKey, logger)
+// advanceLastUpdateTime sets a last update timestamp of the config to updatedAt, unless it is already
+// later. Failures are logged.
+func (s *TransferService) advanceLastUpdateTime(ctx context.Context, key string, updatedAt time.Time) {
+	if lastUpdate, err := s.getLastUpdateTime(ctx, key); err == nil && !lastUpdate.Before(updatedAt) {
+		return
 	}
 
-	return &TransferService{
-		store:        store,
-		etherscanAPI: etherscanClient,
-		logger:       logger,
-	}, nil
+	if err := s.store.UpdateConfig(ctx, key, updatedAt.Format(time.RFC3339)); err != nil {
+		s.logger.Errorw("Error updating last update time", "key", key, "err", err)
+	}
 }
 
-// UpdateRefreshInterval updates the minimum refresh interval in hours.
-func (s *TransferService) UpdateRefreshInterval(ctx context.Context, hours int) error {
-	if hours < 1 {
-		return fmt.Errorf("refresh interval must be at least 1 hour")
-	}
+// addressFetchResult is the outcome of fetching the transfers of a single source address.
+type addressFetchResult struct {
+	summary fetchSummary
+	// errs are the errors of the failed fetches of the address. Other fetches are still stored.
+	errs        []error
+	ethFailed   bool
+	tokenFailed bool
+}
 
-	err := s.store.UpdateConfig(ctx, configKeyMinRefreshInterval, strconv.Itoa(hours))
+// fetchAndStoreSourceAddress fetches and stores every kind of transfers of a source address during a
+// full refresh. A failed fetch does not prevent the other kinds from being fetched.
+func (s *TransferService) fetchAndStoreSourceAddress(
+	ctx context.Context, address string, startTime, endTime time.Time,
+) addressFetchResult {
+	result := addressFetchResult{summary: make(fetchSummary)}
+
+	s.reportProgress(RefreshProgress{Event: RefreshProgressAddressStarted, Address: address})
+
+	defer func() {
+		finished := RefreshProgress{
+			Event:    RefreshProgressAddressFinished,
+			Address:  address,
+			Inserted: result.summary.inserted(),
+		}
+		if err := errors.Join(result.errs...); err != nil {
+			finished.Error = err.Error()
+		}
+
+		s.reportProgress(finished)
+	}()
+
+	// Fetch ETH transfers
+	ethSummary, err := s.fetchAndStoreETHTransfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("updating refresh interval: %w", err)
+		s.logger.Errorw("Error fetching ETH transfers", "address", address, "err", err)
+		result.errs = append(result.errs, fmt.Errorf("address %s: %w", address, err))
+		result.ethFailed = true
 	}
 
-	return nil
-}
+	result.summary.merge(ethSummary)
 
-// GetRefreshInterval gets the minimum refresh interval in hours.
-func (s *TransferService) GetRefreshInterval(ctx context.Context) (int, error) {
-	value, err := s.store.GetConfig(ctx, configKeyMinRefreshInterval)
+	// Fetch ETH moved by internal transactions
+	internalSummary, err := s.fetchAndStoreInternalTransfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return defaultMinRefreshIntervalHrs, fmt.Errorf("getting refresh interval: %w", err)
+		s.logger.Errorw("Error fetching internal transfers", "address", address, "err", err)
+		result.errs = append(result.errs, fmt.Errorf("address %s: %w", address, err))
+		result.ethFailed = true
 	}
 
-	hours, err := strconv.Atoi(value)
+	result.summary.merge(internalSummary)
+
+	// Fetch ERC20 transfers, of all tokens or of each known token depending on the fetch mode
+	tokenSummary, err := s.fetchAndStoreERC20Transfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return defaultMinRefreshIntervalHrs, fmt.Errorf("parsing refresh interval: %w", err)
+		s.logger.Errorw("Error fetching ERC20 transfers", "address", address, "err", err)
+		result.errs = append(result.errs, fmt.Errorf("address %s: %w", address, err))
+		result.tokenFailed = true
 	}
 
-	return hours, nil
+	result.summary.merge(tokenSummary)
+
+	return result
 }
 
-// UpdateDailyRefreshTime updates the daily refresh time.
-func (s *TransferService) UpdateDailyRefreshTime(ctx context.Context, timeStr string) error {
-	// Validate time format (HH:MM:SS)
-	_, err := time.Parse("15:04:05", timeStr)
+// FetchAndStoreForAddress fetches and stores ETH and ERC20 transfers for a single tracked address.
+// It returns a per-token summary of fetched and newly inserted transfers.
+func (s *TransferService) FetchAndStoreForAddress(ctx context.Context, address string) ([]TokenFetchSummary, error) {
+	tracked, err := s.store.IsTrackedAddress(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("checking tracked address: %w", err)
+	}
+
+	if !tracked {
+		return nil, ErrAddressNotTracked
+	}
+
+	s.logger.Infow("Fetching latest transfer data for address", "address", address)
+
+	// Use the same time range as the full refresh
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, -1, 0) // 1 month ago
+
+	summary, err := s.fetchAndStoreETHTransfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("invalid time format, expected HH:MM:SS: %w", err)
+		return nil, err
 	}
 
-	err = s.store.UpdateConfig(ctx, configKeyDailyRefreshTime, timeStr)
+	internalSummary, err := s.fetchAndStoreInternalTransfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("updating daily refresh time: %w", err)
+		return nil, err
 	}
 
-	return nil
-}
+	summary.merge(internalSummary)
 
-// GetDailyRefreshTime gets the daily refresh time.
-func (s *TransferService) GetDailyRefreshTime(ctx context.Context) (string, error) {
-	value, err := s.store.GetConfig(ctx, configKeyDailyRefreshTime)
+	tokenSummary, err := s.fetchAndStoreERC20Transfers(ctx, address, startTime, endTime)
 	if err != nil {
-		return "00:00:00", fmt.Errorf("getting daily refresh time: %w", err)
+		return nil, err
 	}
 
-	return value, nil
+	summary.merge(tokenSummary)
+
+	s.logger.Infow("Finished fetching transfers for address", "address", address, "inserted", summary.inserted())
+
+	return summary.list(), nil
 }
 
-// ShouldRefreshData checks if data should be refreshed based on last update time.
-func (s *TransferService) ShouldRefreshData(ctx context.Context) (bool, error) {
-	// Get last update time
-	lastETHUpdateStr, err := s.store.GetConfig(ctx, configKeyLastETHUpdate)
-	if err != nil {
-		s.logger.Warnw("Failed to get last ETH update time, assuming refresh is needed", "err", err)
-		return true, nil // If error, assume refresh is needed
+// notifyInserted notifies about the newly inserted transfers if a notifier is set and the threshold is reached.
+// Notification failures are logged and do not fail the fetch.
+func (s *TransferService) notifyInserted(ctx context.Context, summary fetchSummary) {
+	inserted := summary.inserted()
+	if s.notifier == nil || inserted < s.notifyMinInserted {
+		return
 	}
 
-	lastETHUpdate, err := time.Parse(time.RFC3339, lastETHUpdateStr)
+	tokens, err := s.store.GetTokens(ctx)
 	if err != nil {
-		s.logger.Warnw("Failed to parse last ETH update time, assuming refresh is needed",
-			"err", err, "timeStr", lastETHUpdateStr)
-		return true, nil // If error, assume refresh is needed
+		s.logger.Warnw("Failed to get tokens for notification, sending raw amounts", "err", err)
 	}
 
-	// Get refresh interval
-	refreshInterval, err := s.GetRefreshInterval(ctx)
-	if err != nil {
-		s.logger.Warnw("Failed to get refresh interval, using default", "err", err, "default", defaultMinRefreshIntervalHrs)
-		refreshInterval = defaultMinRefreshIntervalHrs
+	tokensByAddress := make(map[string]storage.Token, len(tokens))
+	for _, token := range tokens {
+		tokensByAddress[strings.ToLower(token.Address)] = token
 	}
 
-	// Check if enough time has passed since last update
-	return time.Since(lastETHUpdate) > time.Duration(refreshInterval)*time.Hour, nil
-}
+	notification := notify.Notification{Inserted: inserted}
 
-// FetchAndStoreTransfers fetches and stores transfers for all source addresses and tokens.
-func (s *TransferService) FetchAndStoreTransfers(ctx context.Context) error {
-	// Always fetch the latest data for manual refresh
-	s.logger.Infow("Fetching latest transfer data")
+	for _, tokenSummary := range summary.list() {
+		if tokenSummary.Inserted == 0 {
+			continue
+		}
 
-	// Get source addresses
-	sourceAddresses, err := s.store.GetSourceAddresses(ctx)
+		tokenTransfers := notify.TokenTransfers{
+			TokenAddress: tokenSummary.TokenAddress,
+			Inserted:     tokenSummary.Inserted,
+			Amount:       tokenSummary.insertedAmount.String(),
+		}
+
+		if token, ok := tokensByAddress[tokenSummary.TokenAddress]; ok {
+			tokenTransfers.Symbol = token.Symbol
+
+			tokenTransfers.NormalizedAmount, err = convert.NormalizeWei(tokenTransfers.Amount, token.Decimals)
+			if err != nil {
+				s.logger.Warnw("Failed to normalize notification amount", "token", token.Address, "err", err)
+			}
+		}
+
+		notification.Tokens = append(notification.Tokens, tokenTransfers)
+	}
+
+	if err := s.notifier.Notify(ctx, notification); err != nil {
+		s.logger.Errorw("Failed to send new transfers notification", "inserted", inserted, "err", err)
+	}
+}
+
+// knownTokens returns the set of lowercase addresses of the catalogued tokens.
+func (s *TransferService) knownTokens(ctx context.Context) (map[string]bool, error) {
+	tokens, err := s.store.GetTokens(ctx)
 	if err != nil {
-		return fmt.Errorf("getting source addresses: %w", err)
+		return nil, fmt.Errorf("getting tokens: %w", err)
 	}
 
-	if len(sourceAddresses) == 0 {
-		s.logger.Infow("No source addresses configured, skipping transfer fetch")
-		return nil
+	known := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		known[strings.ToLower(token.Address)] = true
 	}
 
-	// We don't need to get tokens anymore since we fetch all ERC20 transfers in a single query
+	return known, nil
+}
 
-	// Set time range (last 30 days by default)
-	endTime := time.Now()
-	startTime := endTime.AddDate(0, -1, 0) // 1 month ago
+// addUnknownTokens adds tokens to the tokens table using the token metadata of one of their transfers.
+// Failures are logged, since the transfers are stored either way.
+func (s *TransferService) addUnknownTokens(ctx context.Context, tokens map[string]etherscan.ERC20Transaction) {
+	for address, tx := range tokens {
+		decimals, err := strconv.Atoi(tx.TokenDecimal)
+		if err != nil || !IsValidDecimals(decimals) {
+			s.logger.Warnw("Failed to parse token decimals, using default",
+				"token", address, "decimals", tx.TokenDecimal, "default", defaultTokenDecimals)
 
-	// Process each source address
-	for _, sourceAddr := range sourceAddresses {
-		// Fetch ETH transfers
-		err = s.fetchAndStoreETHTransfers(ctx, sourceAddr.Address, startTime, endTime)
-		if err != nil {
-			s.logger.Errorw("Error fetching ETH transfers", "address", sourceAddr.Address, "err", err)
+			decimals = defaultTokenDecimals
+		}
+
+		// Spam tokens often carry overlong symbols and names
+		symbol := truncateRunes(tx.TokenSymbol, maxTokenSymbolLength)
+		name := truncateRunes(tx.TokenName, maxTokenNameLength)
+
+		_, err = s.store.AddToken(ctx, address, symbol, name, decimals)
+		if errors.Is(err, storage.ErrAlreadyExists) {
 			continue
 		}
 
-		// Fetch all ERC20 transfers in a single query
-		err = s.fetchAndStoreAllERC20Transfers(ctx, sourceAddr.Address, startTime, endTime)
 		if err != nil {
-			s.logger.Errorw("Error fetching ERC20 transfers", "address", sourceAddr.Address, "err", err)
+			s.logger.Warnw("Failed to auto-add token", "token", address, "err", err)
 			continue
 		}
+
+		s.logger.Infow("Auto-added token", "token", address, "symbol", symbol, "decimals", decimals)
+	}
+}
+
+// truncateRunes returns s cut to at most n runes.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
 	}
 
-	// Update last update time
-	now := time.Now().Format(time.RFC3339)
+	return string(runes[:n])
+}
 
-	err = s.store.UpdateConfig(ctx, configKeyLastETHUpdate, now)
+// resumeBlock returns the block to resume fetching from: the fetch cursor of the address and token,
+// or, if there is none yet, the block returned by fallback.
+func (s *TransferService) resumeBlock(
+	ctx context.Context, address, cursorToken string, fallback func(ctx context.Context) (int64, error),
+) int64 {
+	lastBlock, found, err := s.store.GetFetchCursor(ctx, address, cursorToken, s.etherscanAPI.ChainID())
 	if err != nil {
-		s.logger.Errorw("Error updating last ETH update time", "err", err)
+		s.logger.Warnw("Failed to get fetch cursor, falling back to stored transfers",
+			"address", address,
+			"token", cursorToken,
+			"err", err)
 	}
 
-	err = s.store.UpdateConfig(ctx, configKeyLastTokenUpdate, now)
+	if found {
+		return lastBlock
+	}
+
+	lastBlock, err = fallback(ctx)
 	if err != nil {
-		s.logger.Errorw("Error updating last token update time", "err", err)
+		s.logger.Warnw("Failed to get last processed block, starting from block 0",
+			"address", address,
+			"token", cursorToken,
+			"err", err)
+		// Start from block 0 if we couldn't get the last processed block
+		return 0
 	}
 
-	return nil
+	return lastBlock
 }
 
-// fetchAndStoreETHTransfers fetches and stores ETH transfers for a specific address.
-func (s *TransferService) fetchAndStoreETHTransfers(ctx context.Context, address string, startTime, endTime time.Time) error {
-	// ETH token address is 0x0000000000000000000000000000000000000000
-	ethTokenAddress := "0x0000000000000000000000000000000000000000"
+// storeTransfersBatch stores a batch of transfers and, if any block was fetched, advances the fetch cursor
+// of the address and token to the highest fetched block in the same transaction. The newly inserted
+// transfers are published to the subscribers of the transfer stream.
+func (s *TransferService) storeTransfersBatch(
+	ctx context.Context, transfers []*storage.Transfer, address, cursorToken string, highestBlock int64,
+) ([]*storage.Transfer, error) {
+	var (
+		inserted []*storage.Transfer
+		err      error
+	)
+
+	if highestBlock == 0 {
+		inserted, err = s.store.AddTransfersBatchReturningInserted(ctx, transfers)
+	} else {
+		inserted, err = s.store.AddTransfersBatchWithCursor(ctx, transfers, storage.FetchCursor{
+			Address:      address,
+			TokenAddress: cursorToken,
+			ChainID:      s.etherscanAPI.ChainID(),
+			BlockNumber:  highestBlock,
+		})
+	}
 
-	// Get the last processed block for this address and ETH
-	lastBlock, err := s.store.GetLastProcessedBlock(ctx, address, ethTokenAddress)
 	if err != nil {
-		s.logger.Warnw("Failed to get last processed block for ETH, starting from block 0",
-			"address", address,
-			"err", err)
-		// Start from block 0 if we couldn't get the last processed block
-		lastBlock = 0
+		return nil, err
+	}
+
+	s.transferStream.Publish(inserted)
+
+	return inserted, nil
+}
+
+// summarizeBatch summarizes the fetched transfers of a batch and the ones that were newly inserted.
+func (s *TransferService) summarizeBatch(fetched, inserted []*storage.Transfer) fetchSummary {
+	summary := make(fetchSummary)
+	for _, transfer := range fetched {
+		summary.add(transfer.TokenAddress, 1, 0, decimal.Zero)
+	}
+
+	for _, transfer := range inserted {
+		amount, err := decimal.NewFromString(transfer.Amount)
+		if err != nil {
+			s.logger.Warnw("Failed to parse transfer amount", "err", err, "hash", transfer.Hash, "amount", transfer.Amount)
+			amount = decimal.Zero
+		}
+
+		summary.add(transfer.TokenAddress, 0, 1, amount)
 	}
 
+	return summary
+}
+
+// fetchAndStoreETHTransfers fetches and stores ETH transfers for a specific address.
+// It returns a summary of fetched and newly inserted transfers.
+func (s *TransferService) fetchAndStoreETHTransfers(
+	ctx context.Context, address string, startTime, endTime time.Time,
+) (fetchSummary, error) {
+	// Get the last processed block for this address and ETH
+	lastBlock := s.resumeBlock(ctx, address, ethTokenAddress, func(ctx context.Context) (int64, error) {
+		return s.store.GetLastProcessedBlock(ctx, address, ethTokenAddress)
+	})
+
+	// Fetch the confirmation window again to detect reorged transfers
+	fromBlock := s.confirmationStart(lastBlock)
+
 	s.logger.Infow("Fetching ETH transfers",
 		"address", address,
 		"startTime", startTime,
 		"endTime", endTime,
-		"lastProcessedBlock", lastBlock)
+		"lastProcessedBlock", lastBlock,
+		"fromBlock", fromBlock)
+
+	// Fetch ETH transfers starting from the confirmation window of the last processed block
+	fetchCtx := s.withFetchProgress(ctx, address, FetchKindETH, "")
 
-	// Fetch ETH transfers starting from the last processed block
-	transactions, err := s.etherscanAPI.GetETHTransfers(ctx, address, startTime, endTime, lastBlock)
+	transactions, err := s.etherscanAPI.GetETHTransfers(fetchCtx, address, startTime, endTime, fromBlock)
 	if err != nil {
-		return fmt.Errorf("fetching ETH transfers: %w", err)
+		return nil, fmt.Errorf("fetching ETH transfers: %w: %w", ErrEtherscanFetch, err)
 	}
 
 	s.logger.Infow("Fetched ETH transfers", "address", address, "count", len(transactions))
@@ -250,13 +1304,117 @@ func (s *TransferService) fetchAndStoreETHTransfers(ctx context.Context, address
 	// Prepare batch of transfers with preallocated capacity
 	transfers := make([]*storage.Transfer, 0, len(transactions))
 
+	// Highest fetched block, including failed transactions, to advance the fetch cursor
+	var highestBlock int64
+
+	// Hashes of every fetched transaction, to detect the reorged ones
+	fetchedHashes := make(map[string]bool, len(transactions))
+
 	// Process transactions
 	for _, tx := range transactions {
+		fetchedHashes[strings.ToLower(tx.Hash)] = true
+
+		// Parse block number
+		blockNumber, err := strconv.ParseInt(tx.BlockNumber, 10, 64)
+		if err != nil {
+			s.logger.Warnw("Failed to parse block number", "err", err, "blockNumber", tx.BlockNumber)
+			continue
+		}
+
+		highestBlock = max(highestBlock, blockNumber)
+
 		// Skip failed transactions
 		if tx.IsError != "0" {
 			continue
 		}
 
+		// Parse timestamp
+		timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
+		if err != nil {
+			s.logger.Warnw("Failed to parse timestamp", "err", err, "timestamp", tx.TimeStamp)
+			continue
+		}
+
+		// Create transfer record
+		transfer := &storage.Transfer{
+			Hash:         tx.Hash,
+			BlockNumber:  blockNumber,
+			Timestamp:    time.Unix(timestamp, 0),
+			FromAddress:  tx.From,
+			ToAddress:    tx.To,
+			TokenAddress: ethTokenAddress,
+			Amount:       tx.Value,
+		}
+
+		// Add to batch
+		transfers = append(transfers, transfer)
+	}
+
+	// Store transfers in batch
+	inserted, err := s.storeTransfersBatch(ctx, transfers, address, ethTokenAddress, highestBlock)
+	if err != nil {
+		s.logger.Errorw("Failed to store ETH transfers batch", "err", err, "count", len(transfers))
+		return nil, fmt.Errorf("storing ETH transfers batch: %w", err)
+	}
+
+	s.removeReorgedTransfers(ctx, address, ethTokenAddress, fetchedHashes, fromBlock, lastBlock, startTime)
+
+	summary := s.summarizeBatch(transfers, inserted)
+	s.reportProgress(RefreshProgress{
+		Event:    RefreshProgressStored,
+		Address:  address,
+		Kind:     FetchKindETH,
+		Fetched:  len(transfers),
+		Inserted: summary.inserted(),
+	})
+
+	if len(transfers) > 0 {
+		s.logger.Infow("Stored ETH transfers batch", "count", len(transfers), "inserted", summary.inserted())
+	}
+
+	return summary, nil
+}
+
+// fetchAndStoreInternalTransfers fetches and stores ETH moved by internal transactions of a specific address.
+// Failed traces are skipped. It returns a summary of fetched and newly inserted transfers.
+func (s *TransferService) fetchAndStoreInternalTransfers(
+	ctx context.Context, address string, startTime, endTime time.Time,
+) (fetchSummary, error) {
+	// Get the last processed block for internal transfers
+	lastBlock := s.resumeBlock(ctx, address, storage.InternalTransfers, func(ctx context.Context) (int64, error) {
+		return s.store.GetLastProcessedBlockForInternal(ctx, address)
+	})
+
+	fromBlock := s.confirmationStart(lastBlock)
+
+	s.logger.Infow("Fetching internal transfers",
+		"address", address,
+		"startTime", startTime,
+		"endTime", endTime,
+		"lastProcessedBlock", lastBlock,
+		"fromBlock", fromBlock)
+
+	fetchCtx := s.withFetchProgress(ctx, address, FetchKindInternal, "")
+
+	transactions, err := s.etherscanAPI.GetInternalTransfers(fetchCtx, address, startTime, endTime, fromBlock)
+	if err != nil {
+		return nil, fmt.Errorf("fetching internal transfers: %w: %w", ErrEtherscanFetch, err)
+	}
+
+	s.logger.Infow("Fetched internal transfers", "address", address, "count", len(transactions))
+
+	// Prepare batch of transfers with preallocated capacity
+	transfers := make([]*storage.Transfer, 0, len(transactions))
+
+	// Highest fetched block, including failed traces, to advance the fetch cursor
+	var highestBlock int64
+
+	fetchedHashes := make(map[string]bool, len(transactions))
+
+	// Process transactions
+	for _, tx := range transactions {
+		fetchedHashes[strings.ToLower(tx.Hash)] = true
+
 		// Parse block number
 		blockNumber, err := strconv.ParseInt(tx.BlockNumber, 10, 64)
 		if err != nil {
@@ -264,6 +1422,13 @@ func (s *TransferService) fetchAndStoreETHTransfers(ctx context.Context, address
 			continue
 		}
 
+		highestBlock = max(highestBlock, blockNumber)
+
+		// Skip failed traces and contract creations without a recipient
+		if tx.IsError != "0" || tx.To == "" {
+			continue
+		}
+
 		// Parse timestamp
 		timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
 		if err != nil {
@@ -278,8 +1443,9 @@ func (s *TransferService) fetchAndStoreETHTransfers(ctx context.Context, address
 			Timestamp:    time.Unix(timestamp, 0),
 			FromAddress:  tx.From,
 			ToAddress:    tx.To,
-			TokenAddress: "0x0000000000000000000000000000000000000000", // ETH
+			TokenAddress: ethTokenAddress,
 			Amount:       tx.Value,
+			Type:         storage.TransferTypeInternal,
 		}
 
 		// Add to batch
@@ -287,52 +1453,148 @@ func (s *TransferService) fetchAndStoreETHTransfers(ctx context.Context, address
 	}
 
 	// Store transfers in batch
+	inserted, err := s.storeTransfersBatch(ctx, transfers, address, storage.InternalTransfers, highestBlock)
+	if err != nil {
+		s.logger.Errorw("Failed to store internal transfers batch", "err", err, "count", len(transfers))
+		return nil, fmt.Errorf("storing internal transfers batch: %w", err)
+	}
+
+	s.removeReorgedTransfers(ctx, address, storage.InternalTransfers, fetchedHashes, fromBlock, lastBlock, startTime)
+
+	summary := s.summarizeBatch(transfers, inserted)
+	s.reportProgress(RefreshProgress{
+		Event:    RefreshProgressStored,
+		Address:  address,
+		Kind:     FetchKindInternal,
+		Fetched:  len(transfers),
+		Inserted: summary.inserted(),
+	})
+
 	if len(transfers) > 0 {
-		err = s.store.AddTransfersBatch(ctx, transfers)
+		s.logger.Infow("Stored internal transfers batch", "count", len(transfers), "inserted", summary.inserted())
+	}
+
+	return summary, nil
+}
 
+// fetchAndStoreERC20Transfers fetches and stores the ERC20 transfers of an address according to the fetch mode.
+// It returns a summary of fetched and newly inserted transfers.
+func (s *TransferService) fetchAndStoreERC20Transfers(
+	ctx context.Context, address string, startTime, endTime time.Time,
+) (fetchSummary, error) {
+	if s.fetchMode != FetchModeKnownTokens {
+		// An empty token address fetches all tokens
+		return s.fetchAndStoreTokenTransfers(ctx, address, "", startTime, endTime)
+	}
+
+	tokens, err := s.erc20TokenAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(fetchSummary)
+
+	var errs []error
+
+	// Keep fetching the other tokens if one fails, they have their own fetch cursors
+	for _, tokenAddress := range tokens {
+		tokenSummary, err := s.fetchAndStoreTokenTransfers(ctx, address, tokenAddress, startTime, endTime)
 		if err != nil {
-			s.logger.Errorw("Failed to store ETH transfers batch", "err", err, "count", len(transfers))
-			return fmt.Errorf("storing ETH transfers batch: %w", err)
+			errs = append(errs, fmt.Errorf("token %s: %w", tokenAddress, err))
 		}
 
-		s.logger.Infow("Stored ETH transfers batch", "count", len(transfers))
+		summary.merge(tokenSummary)
 	}
 
-	return nil
+	return summary, errors.Join(errs...)
 }
 
-// fetchAndStoreAllERC20Transfers fetches and stores all ERC20 transfers for a specific address
-// in a single query.
-func (s *TransferService) fetchAndStoreAllERC20Transfers(ctx context.Context, address string, startTime, endTime time.Time) error {
-	// Get the last processed block for ERC20 transfers
-	lastBlock, err := s.store.GetLastProcessedBlockForERC20(ctx, address)
+// erc20TokenAddresses returns the addresses of the tokens in the tokens table, except ETH.
+func (s *TransferService) erc20TokenAddresses(ctx context.Context) ([]string, error) {
+	tokens, err := s.store.GetTokens(ctx)
 	if err != nil {
-		s.logger.Warnw("Failed to get last processed block for ERC20, starting from block 0",
-			"address", address,
-			"err", err)
-		// Start from block 0 if we couldn't get the last processed block
-		lastBlock = 0
+		return nil, fmt.Errorf("getting tokens: %w", err)
+	}
+
+	addresses := make([]string, 0, len(tokens))
+
+	for _, token := range tokens {
+		if token.Address != ethTokenAddress {
+			addresses = append(addresses, token.Address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// fetchAndStoreTokenTransfers fetches and stores the ERC20 transfers of tokenAddress for a specific address,
+// or the transfers of all tokens in a single query if tokenAddress is empty.
+// It returns a summary of fetched and newly inserted transfers.
+func (s *TransferService) fetchAndStoreTokenTransfers(
+	ctx context.Context, address, tokenAddress string, startTime, endTime time.Time,
+) (fetchSummary, error) {
+	// Get the last processed block of the fetch
+	cursorToken := storage.AllERC20Tokens
+	fallback := func(ctx context.Context) (int64, error) {
+		return s.store.GetLastProcessedBlockForERC20(ctx, address)
+	}
+
+	if tokenAddress != "" {
+		cursorToken = tokenAddress
+		fallback = func(ctx context.Context) (int64, error) {
+			return s.store.GetLastProcessedBlock(ctx, address, tokenAddress)
+		}
 	}
 
-	s.logger.Infow("Fetching all ERC20 transfers",
+	lastBlock := s.resumeBlock(ctx, address, cursorToken, fallback)
+
+	fromBlock := s.confirmationStart(lastBlock)
+
+	s.logger.Infow("Fetching ERC20 transfers",
 		"address", address,
+		"token", cursorToken,
 		"startTime", startTime,
 		"endTime", endTime,
-		"lastProcessedBlock", lastBlock)
+		"lastProcessedBlock", lastBlock,
+		"fromBlock", fromBlock)
 
-	// Fetch all ERC20 transfers in a single query (empty tokenAddress means all tokens)
-	transactions, err := s.etherscanAPI.GetERC20Transfers(ctx, address, "", startTime, endTime, lastBlock)
+	fetchCtx := s.withFetchProgress(ctx, address, FetchKindERC20, tokenAddress)
+
+	transactions, err := s.etherscanAPI.GetERC20Transfers(fetchCtx, address, tokenAddress, startTime, endTime, fromBlock)
 	if err != nil {
-		return fmt.Errorf("fetching ERC20 transfers: %w", err)
+		return nil, fmt.Errorf("fetching ERC20 transfers: %w: %w", ErrEtherscanFetch, err)
 	}
 
 	s.logger.Infow("Fetched ERC20 transfers", "address", address, "count", len(transactions))
 
+	// Transfers fetched by token address are of a token in the tokens table
+	checkKnown := tokenAddress == "" && (s.ingestionFilter.OnlyKnownTokens || s.ingestionFilter.AutoAddTokens)
+
+	var knownTokens map[string]bool
+	if checkKnown {
+		knownTokens, err = s.knownTokens(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Prepare batch of transfers with preallocated capacity
 	transfers := make([]*storage.Transfer, 0, len(transactions))
+	skipped := 0
+
+	// Unknown tokens of the stored transfers, keyed by lowercase address
+	unknownTokens := make(map[string]etherscan.ERC20Transaction)
+
+	// Highest fetched block to advance the fetch cursor
+	var highestBlock int64
+
+	// Hashes of every fetched transaction, including the skipped ones, to detect the reorged ones
+	fetchedHashes := make(map[string]bool, len(transactions))
 
 	// Process transactions
 	for _, tx := range transactions {
+		fetchedHashes[strings.ToLower(tx.Hash)] = true
+
 		// Parse block number
 		blockNumber, err := strconv.ParseInt(tx.BlockNumber, 10, 64)
 		if err != nil {
@@ -340,6 +1602,18 @@ func (s *TransferService) fetchAndStoreAllERC20Transfers(ctx context.Context, ad
 			continue
 		}
 
+		highestBlock = max(highestBlock, blockNumber)
+
+		// Skip zero-value and spam token transfers
+		txToken := strings.ToLower(tx.ContractAddress)
+		unknown := checkKnown && !knownTokens[txToken]
+
+		if (s.ingestionFilter.SkipZeroValue && tx.Value == "0") ||
+			(s.ingestionFilter.OnlyKnownTokens && unknown) {
+			skipped++
+			continue
+		}
+
 		// Parse timestamp
 		timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
 		if err != nil {
@@ -360,18 +1634,39 @@ func (s *TransferService) fetchAndStoreAllERC20Transfers(ctx context.Context, ad
 
 		// Add to batch
 		transfers = append(transfers, transfer)
+
+		if s.ingestionFilter.AutoAddTokens && unknown {
+			unknownTokens[txToken] = tx
+		}
 	}
 
+	s.addUnknownTokens(ctx, unknownTokens)
+
 	// Store transfers in batch
-	if len(transfers) > 0 {
-		err = s.store.AddTransfersBatch(ctx, transfers)
-		if err != nil {
-			s.logger.Errorw("Failed to store ERC20 transfers batch", "err", err, "count", len(transfers))
-			return fmt.Errorf("storing ERC20 transfers batch: %w", err)
-		}
+	inserted, err := s.storeTransfersBatch(ctx, transfers, address, cursorToken, highestBlock)
+	if err != nil {
+		s.logger.Errorw("Failed to store ERC20 transfers batch", "err", err, "count", len(transfers))
+		return nil, fmt.Errorf("storing ERC20 transfers batch: %w", err)
+	}
 
-		s.logger.Infow("Stored ERC20 transfers batch", "count", len(transfers))
+	s.removeReorgedTransfers(ctx, address, cursorToken, fetchedHashes, fromBlock, lastBlock, startTime)
+
+	summary := s.summarizeBatch(transfers, inserted)
+	s.reportProgress(RefreshProgress{
+		Event:        RefreshProgressStored,
+		Address:      address,
+		Kind:         FetchKindERC20,
+		TokenAddress: tokenAddress,
+		Fetched:      len(transfers),
+		Inserted:     summary.inserted(),
+	})
+
+	if len(transfers) > 0 || skipped > 0 {
+		s.logger.Infow("Stored ERC20 transfers batch",
+			"count", len(transfers),
+			"inserted", summary.inserted(),
+			"skipped", skipped)
 	}
 
-	return nil
+	return summary, nil
 }
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/etherscan"
+	"github.com/ductm54/transfer-track/internal/storage"
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"go.uber.org/zap"
+)
+
+const testMigrationPath = "../../migrations"
+
+// newTestStore returns a storage on a fresh development DB.
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	return storage.New(testutil.NewTestDB(t, testMigrationPath), zap.NewNop().Sugar())
+}
+
+// newTestService returns a service storing to store, which may be nil for tests that do not use it,
+// with Etherscan requests served by etherscanHandler.
+func newTestService(t *testing.T, store *storage.Storage, etherscanHandler http.HandlerFunc) *TransferService {
+	t.Helper()
+
+	srv := httptest.NewServer(etherscanHandler)
+	t.Cleanup(srv.Close)
+
+	s, err := NewTransferService(store, zap.NewNop().Sugar(), etherscan.Config{
+		APIKey:  "test",
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	return s
+}
+
+// writeEtherscanResponse writes an Etherscan response with the given status, message and result.
+func writeEtherscanResponse(t *testing.T, w http.ResponseWriter, status, message string, result any) {
+	t.Helper()
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		t.Errorf("encoding result: %v", err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(etherscan.Response{Status: status, Message: message, Result: raw})
+	if err != nil {
+		t.Errorf("writing response: %v", err)
+	}
+}
+
+// storedTransfers returns the stored transfers ordered by block.
+func storedTransfers(t *testing.T, store *storage.Storage) []storage.ExportedTransfer {
+	t.Helper()
+
+	_, cursor, err := store.GetTransfersForExport(context.Background(), storage.TransferFilter{})
+	if err != nil {
+		t.Fatalf("getting transfers: %v", err)
+	}
+
+	defer func() {
+		if err := cursor.Close(); err != nil {
+			t.Errorf("closing cursor: %v", err)
+		}
+	}()
+
+	var transfers []storage.ExportedTransfer
+
+	err = cursor.ForEach(func(transfer storage.ExportedTransfer) error {
+		transfers = append(transfers, transfer)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("iterating transfers: %v", err)
+	}
+
+	return transfers
+}
+
+func TestFillTokenMetadata(t *testing.T) {
+	const tokenAddress = "0x00000000000000000000000000000000000000c3"
+
+	s := newTestService(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		writeEtherscanResponse(t, w, "1", "OK", []etherscan.ERC20Transaction{{
+			ContractAddress: tokenAddress,
+			TokenSymbol:     "USDC",
+			TokenName:       "USD Coin",
+			TokenDecimal:    "6",
+		}})
+	})
+
+	meta := s.FillTokenMetadata(context.Background(), tokenAddress, TokenMetadata{Name: "Supplied name"})
+
+	if meta.Symbol != "USDC" || meta.Name != "Supplied name" || meta.Decimals == nil || *meta.Decimals != 6 {
+		t.Fatalf("expected the looked up symbol and decimals and the supplied name, got %+v", meta)
+	}
+}
+
+func TestFillTokenMetadataFallback(t *testing.T) {
+	s := newTestService(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	meta := s.FillTokenMetadata(context.Background(), "0x00000000000000000000000000000000000000c3",
+		TokenMetadata{Symbol: "TKN"})
+
+	if meta.Symbol != "TKN" || meta.Decimals == nil || *meta.Decimals != defaultTokenDecimals {
+		t.Fatalf("expected the supplied symbol and %d decimals, got %+v", defaultTokenDecimals, meta)
+	}
+}
+
+// restartService creates a service on store as on a startup with the given refresh flags.
+func restartService(t *testing.T, store *storage.Storage, refreshInterval int, dailyRefreshTime string) *TransferService {
+	t.Helper()
+
+	s, err := NewTransferService(store, zap.NewNop().Sugar(), etherscan.Config{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("creating transfer service: %v", err)
+	}
+
+	s.SeedStartupConfig(context.Background(), refreshInterval, dailyRefreshTime)
+
+	return s
+}
+
+func TestSeededConfigFollowsFlags(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, hours := range []int{2, 3} {
+		s := restartService(t, store, hours, "")
+
+		interval, err := s.GetRefreshInterval(ctx)
+		if err != nil {
+			t.Fatalf("getting refresh interval: %v", err)
+		}
+
+		if interval != hours {
+			t.Fatalf("expected the changed flag %d to be seeded, got %d", hours, interval)
+		}
+	}
+}
+
+func TestOperatorConfigSurvivesRestart(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	s := restartService(t, store, 1, "00:00:00")
+
+	if err := s.UpdateRefreshInterval(ctx, 6); err != nil {
+		t.Fatalf("updating refresh interval: %v", err)
+	}
+
+	if err := s.UpdateDailyRefreshTime(ctx, "12:30:00"); err != nil {
+		t.Fatalf("updating daily refresh time: %v", err)
+	}
+
+	s = restartService(t, store, 1, "00:00:00")
+
+	interval, err := s.GetRefreshInterval(ctx)
+	if err != nil {
+		t.Fatalf("getting refresh interval: %v", err)
+	}
+
+	if interval != 6 {
+		t.Fatalf("expected the operator refresh interval 6 to survive the restart, got %d", interval)
+	}
+
+	dailyRefreshTime, err := s.GetDailyRefreshTime(ctx)
+	if err != nil {
+		t.Fatalf("getting daily refresh time: %v", err)
+	}
+
+	if dailyRefreshTime != "12:30:00" {
+		t.Fatalf("expected the operator daily refresh time 12:30:00 to survive the restart, got %s", dailyRefreshTime)
+	}
+}
+
+func TestEnsureConfigDefaults(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	s := restartService(t, store, 0, "")
+
+	// The migrations do not store the refresh cron, reading it fails until the defaults are stored
+	if _, err := s.GetRefreshCron(ctx); err == nil {
+		t.Fatal("expected the refresh cron to be missing before the defaults are stored")
+	}
+
+	if err := s.EnsureConfigDefaults(ctx); err != nil {
+		t.Fatalf("ensuring config defaults: %v", err)
+	}
+
+	refreshCron, err := s.GetRefreshCron(ctx)
+	if err != nil || refreshCron != "" {
+		t.Fatalf("expected the empty default refresh cron, got %q, %v", refreshCron, err)
+	}
+
+	if err := s.UpdateRefreshCron(ctx, "0 */6 * * *"); err != nil {
+		t.Fatalf("updating refresh cron: %v", err)
+	}
+
+	// A restart keeps the value set by an operator
+	s = restartService(t, store, 0, "")
+
+	if err := s.EnsureConfigDefaults(ctx); err != nil {
+		t.Fatalf("ensuring config defaults again: %v", err)
+	}
+
+	refreshCron, err = s.GetRefreshCron(ctx)
+	if err != nil || refreshCron != "0 */6 * * *" {
+		t.Fatalf("expected the operator refresh cron to be kept, got %q, %v", refreshCron, err)
+	}
+
+	stale, err := s.ShouldRefreshData(ctx)
+	if err != nil || !stale {
+		t.Fatalf("expected the never refreshed data to be stale, got %v, %v", stale, err)
+	}
+}
+
+func TestGetRefreshIntervalInvalidValue(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	s := restartService(t, store, 0, "")
+
+	if err := store.UpdateConfig(ctx, configKeyMinRefreshInterval, "hourly"); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	// A broken value fails instead of silently falling back to the default
+	if _, err := s.GetRefreshInterval(ctx); err == nil {
+		t.Fatal("expected an invalid refresh interval to fail")
+	}
+
+	if _, err := s.ShouldRefreshData(ctx); err == nil {
+		t.Fatal("expected the staleness check to fail with an invalid refresh interval")
+	}
+}
+
+func TestFetchAndStoreForAddress(t *testing.T) {
+	const (
+		other  = "0x00000000000000000000000000000000000000a9"
+		target = "0x00000000000000000000000000000000000000b2"
+		token  = "0x00000000000000000000000000000000000000c3"
+	)
+
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fake := newFakeEtherscan(t)
+	fake.eth = []etherscan.ETHTransaction{
+		ethTransfer("0x01", testSource, target, "1000", 100),
+		ethTransfer("0x02", other, target, "2000", 101),
+	}
+	fake.erc20 = []etherscan.ERC20Transaction{
+		erc20Transfer("0x03", testSource, target, token, "5", 102),
+		erc20Transfer("0x04", testSource, target, token, "7", 103),
+	}
+
+	s := newTestService(t, store, fake.ServeHTTP)
+
+	for _, address := range []string{testSource, other} {
+		if _, _, err := store.AddSourceAddress(ctx, address, storage.AddressLabels{}); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	tokens, err := s.FetchAndStoreForAddress(ctx, testSource)
+	if err != nil {
+		t.Fatalf("fetching address: %v", err)
+	}
+
+	inserted := make(map[string]int)
+	for _, summary := range tokens {
+		inserted[summary.TokenAddress] = summary.Inserted
+	}
+
+	if inserted[ethTokenAddress] != 1 || inserted[token] != 2 {
+		t.Fatalf("expected 1 ETH and 2 token transfers inserted, got %+v", tokens)
+	}
+
+	if fake.requestCount("txlist", other) != 0 {
+		t.Fatalf("expected only the refreshed address to be fetched")
+	}
+
+	_, err = s.FetchAndStoreForAddress(ctx, "0x00000000000000000000000000000000000000ff")
+	if !errors.Is(err, ErrAddressNotTracked) {
+		t.Fatalf("expected ErrAddressNotTracked for an untracked address, got %v", err)
+	}
+}
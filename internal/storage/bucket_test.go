@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetAmountsBucketed(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// Seeds the token and the tracked addresses, the transfers are replaced below
+	seedTotals(t, s)
+
+	if _, err := s.DeleteTransfers(ctx, TransferFilter{}); err != nil {
+		t.Fatalf("deleting transfers: %v", err)
+	}
+
+	// 2024-01-01 is a Monday
+	transfers := []struct {
+		at     time.Time
+		amount string
+	}{
+		{time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), "1"},
+		{time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC), "2"},
+		{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "4"},
+		{time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC), "8"},
+		{time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC), "16"},
+	}
+
+	batch := make([]*Transfer, 0, len(transfers)+1)
+	for i, tr := range transfers {
+		batch = append(batch, &Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    tr.at,
+			FromAddress:  totalsSourceA,
+			ToAddress:    totalsTarget,
+			TokenAddress: totalsToken,
+			Amount:       tr.amount,
+		})
+	}
+
+	// Not from a source to a target, so never counted
+	batch = append(batch, &Transfer{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  1100,
+		Timestamp:    transfers[0].at,
+		FromAddress:  totalsOutsider,
+		ToAddress:    totalsTarget,
+		TokenAddress: totalsToken,
+		Amount:       "32",
+	})
+
+	if _, err := s.AddTransfersBatch(ctx, batch); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval Interval
+		want     map[string]string
+	}{
+		{
+			interval: IntervalDay,
+			want:     map[string]string{"2024-01-01": "3", "2024-01-02": "4", "2024-01-08": "8", "2024-02-01": "16"},
+		},
+		{
+			interval: IntervalWeek,
+			want:     map[string]string{"2024-01-01": "7", "2024-01-08": "8", "2024-01-29": "16"},
+		},
+		{
+			interval: IntervalMonth,
+			want:     map[string]string{"2024-01-01": "15", "2024-02-01": "16"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			amounts, err := s.GetAmountsBucketed(ctx, start, end, tt.interval)
+			if err != nil {
+				t.Fatalf("getting bucketed amounts: %v", err)
+			}
+
+			got := make(map[string]string, len(amounts))
+
+			for i, amount := range amounts {
+				if i > 0 && !amount.BucketStart.After(amounts[i-1].BucketStart) {
+					t.Fatalf("expected buckets in ascending order, got %v", amounts)
+				}
+
+				if amount.TokenAddress != totalsToken || amount.Symbol != "TKN" || amount.Decimals != 6 {
+					t.Fatalf("unexpected token of bucket %+v", amount)
+				}
+
+				got[amount.BucketStart.UTC().Format(time.DateOnly)] = amount.TotalAmount
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("expected buckets %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// The time range bounds the bucketed transfers
+	amounts, err := s.GetAmountsBucketed(ctx, start, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), IntervalDay)
+	if err != nil {
+		t.Fatalf("getting bucketed amounts: %v", err)
+	}
+
+	if len(amounts) != 1 || amounts[0].TotalAmount != "1" {
+		t.Fatalf("expected only the first transfer in range, got %+v", amounts)
+	}
+}
+
+func TestGetAmountsBucketedInvalidInterval(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.GetAmountsBucketed(context.Background(), time.Time{}, time.Now(), "year")
+	if err == nil {
+		t.Fatal("expected an unsupported interval to fail")
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFetchCursor(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const (
+		address = "0x00000000000000000000000000000000000000A1"
+		token   = "0x00000000000000000000000000000000000000c3"
+	)
+
+	// assertCursor fails the test unless the cursor of address and token on chainID is at want,
+	// 0 meaning no cursor
+	assertCursor := func(t *testing.T, tokenAddress string, chainID int, want int64) {
+		t.Helper()
+
+		block, ok, err := s.GetFetchCursor(ctx, address, tokenAddress, chainID)
+		if err != nil {
+			t.Fatalf("getting cursor: %v", err)
+		}
+
+		if ok != (want > 0) || block != want {
+			t.Fatalf("expected cursor %d, got %d (found %v)", want, block, ok)
+		}
+	}
+
+	assertCursor(t, token, 1, 0)
+
+	transfers := testTransfers(2)
+	if _, err := s.AddTransfersBatchWithCursor(ctx, transfers, FetchCursor{
+		Address: address, TokenAddress: token, ChainID: 1, BlockNumber: 1001,
+	}); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	// Addresses are matched case-insensitively
+	assertCursor(t, token, 1, 1001)
+
+	// Cursors are kept per token and chain
+	assertCursor(t, "0x00000000000000000000000000000000000000c4", 1, 0)
+	assertCursor(t, token, 10, 0)
+
+	// A batch without transfers still advances the cursor, e.g. after an empty page
+	if _, err := s.AddTransfersBatchWithCursor(ctx, nil, FetchCursor{
+		Address: address, TokenAddress: token, ChainID: 1, BlockNumber: 2000,
+	}); err != nil {
+		t.Fatalf("advancing cursor: %v", err)
+	}
+
+	assertCursor(t, token, 1, 2000)
+
+	// Cursors never move backwards
+	if _, err := s.AddTransfersBatchWithCursor(ctx, nil, FetchCursor{
+		Address: address, TokenAddress: token, ChainID: 1, BlockNumber: 1500,
+	}); err != nil {
+		t.Fatalf("updating cursor: %v", err)
+	}
+
+	assertCursor(t, token, 1, 2000)
+}
+
+func TestFetchCursorRolledBackWithBatch(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const token = "0x00000000000000000000000000000000000000c3"
+
+	transfers := testTransfers(1)
+	// An amount that is not a number fails the insert, and with it the cursor update
+	transfers[0].Amount = "not a number"
+
+	_, err := s.AddTransfersBatchWithCursor(ctx, transfers, FetchCursor{
+		Address: transfers[0].FromAddress, TokenAddress: token, ChainID: 1, BlockNumber: 1000,
+	})
+	if err == nil {
+		t.Fatal("expected an invalid amount to fail the batch")
+	}
+
+	if _, ok, err := s.GetFetchCursor(ctx, transfers[0].FromAddress, token, 1); err != nil || ok {
+		t.Fatalf("expected the cursor not to be stored, got %v and error %v", ok, err)
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeleteTransfers(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const otherToken = "0x00000000000000000000000000000000000000c4"
+
+	// Transfers 1 to 5 are one second apart, the last two are of another token
+	transfers := testTransfers(5)
+	transfers[3].TokenAddress = otherToken
+	transfers[4].TokenAddress = otherToken
+
+	address := transfers[0].FromAddress
+
+	cursors := []FetchCursor{
+		{Address: address, TokenAddress: otherToken, ChainID: 1, BlockNumber: 1004},
+		{Address: address, TokenAddress: transfers[0].TokenAddress, ChainID: 1, BlockNumber: 1002},
+		{Address: address, TokenAddress: AllERC20Tokens, ChainID: 1, BlockNumber: 1004},
+	}
+	for _, cursor := range cursors {
+		if _, err := s.AddTransfersBatchWithCursor(ctx, transfers, cursor); err != nil {
+			t.Fatalf("adding transfers: %v", err)
+		}
+	}
+
+	start := transfers[0].Timestamp
+
+	deleted, err := s.DeleteTransfers(ctx, TransferFilter{
+		StartTime: start.Add(time.Second),
+		EndTime:   start.Add(2 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("deleting time range: %v", err)
+	}
+
+	if deleted != 2 {
+		t.Fatalf("expected the 2 transfers of the time range to be deleted, got %d", deleted)
+	}
+
+	// The cursors of the fetches of the deleted transfers move back, so that they are fetched again,
+	// the cursor of the other token is kept
+	assertCursor(t, s, address, transfers[0].TokenAddress, 1000)
+	assertCursor(t, s, address, AllERC20Tokens, 1000)
+	assertCursor(t, s, address, otherToken, 1004)
+
+	deleted, err = s.DeleteTransfers(ctx, TransferFilter{TokenAddress: "0x00000000000000000000000000000000000000C4"})
+	if err != nil {
+		t.Fatalf("deleting token: %v", err)
+	}
+
+	if deleted != 2 {
+		t.Fatalf("expected the 2 transfers of the token to be deleted, got %d", deleted)
+	}
+
+	deleted, err = s.DeleteTransfers(ctx, TransferFilter{StartTime: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("deleting empty range: %v", err)
+	}
+
+	if deleted != 0 {
+		t.Fatalf("expected nothing to be deleted outside the stored range, got %d", deleted)
+	}
+
+	counts, err := s.GetTableCounts(ctx)
+	if err != nil {
+		t.Fatalf("getting table counts: %v", err)
+	}
+
+	if counts.Transfers != 1 {
+		t.Fatalf("expected 1 transfer to remain, got %d", counts.Transfers)
+	}
+
+	assertCursor(t, s, address, otherToken, 1002)
+	assertCursor(t, s, address, transfers[0].TokenAddress, 1000)
+}
+
+// assertCursor fails the test unless the fetch cursor of address and token on chain 1 is at block.
+func assertCursor(t *testing.T, s *Storage, address, token string, block int64) {
+	t.Helper()
+
+	lastBlock, ok, err := s.GetFetchCursor(context.Background(), address, token, 1)
+	if err != nil || !ok {
+		t.Fatalf("expected the fetch cursor of token %s, got %v and error %v", token, ok, err)
+	}
+
+	if lastBlock != block {
+		t.Fatalf("expected the fetch cursor of token %s at block %d, got %d", token, block, lastBlock)
+	}
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// TransferFilter filters raw transfers. Empty fields are ignored.
+type TransferFilter struct {
+	StartTime    time.Time
+	EndTime      time.Time
+	StartBlock   int64
+	EndBlock     int64
+	TokenAddress string
+}
+
+// whereClause builds the SQL condition and arguments for the filter.
+func (f TransferFilter) whereClause() (string, []any) {
+	conditions := make([]string, 0, 5)
+	args := make([]any, 0, 5)
+
+	if !f.StartTime.IsZero() {
+		args = append(args, f.StartTime)
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+
+	if !f.EndTime.IsZero() {
+		args = append(args, f.EndTime)
+		conditions = append(conditions, fmt.Sprintf("timestamp <= $%d", len(args)))
+	}
+
+	if f.StartBlock > 0 {
+		args = append(args, f.StartBlock)
+		conditions = append(conditions, fmt.Sprintf("block_number >= $%d", len(args)))
+	}
+
+	if f.EndBlock > 0 {
+		args = append(args, f.EndBlock)
+		conditions = append(conditions, fmt.Sprintf("block_number <= $%d", len(args)))
+	}
+
+	if f.TokenAddress != "" {
+		args = append(args, strings.ToLower(f.TokenAddress))
+		conditions = append(conditions, fmt.Sprintf("token_address = $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// IsEmpty reports whether the filter matches every transfer.
+func (f TransferFilter) IsEmpty() bool {
+	return f.StartTime.IsZero() && f.EndTime.IsZero() && f.StartBlock == 0 && f.EndBlock == 0 && f.TokenAddress == ""
+}
+
+// ExportedTransfer is a raw transfer with the labels of its addresses and the symbol of its token.
+type ExportedTransfer struct {
+	Transfer
+	// FromLabel and ToLabel are the labels of the addresses if they are tracked as source or target
+	// addresses, nil otherwise. The source label is used for addresses tracked as both.
+	FromLabel *string `db:"from_label" json:"from_label"`
+	ToLabel   *string `db:"to_label" json:"to_label"`
+	// TokenSymbol is nil if the token is not catalogued.
+	TokenSymbol *string `db:"token_symbol" json:"token_symbol"`
+}
+
+// TransferCursor iterates over transfers returned by GetTransfersForExport.
+// It must be closed after use.
+type TransferCursor struct {
+	tx     *sqlx.Tx
+	rows   *sqlx.Rows
+	logger *zap.SugaredLogger
+	closed bool
+}
+
+// Next prepares the next transfer for reading with Scan. It returns false when there are no more
+// transfers or an error occurred, which can be checked with Err.
+func (c *TransferCursor) Next() bool {
+	return c.rows.Next()
+}
+
+// Scan reads the current transfer.
+func (c *TransferCursor) Scan() (*ExportedTransfer, error) {
+	var transfer ExportedTransfer
+	if err := c.rows.StructScan(&transfer); err != nil {
+		return nil, fmt.Errorf("scanning transfer: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (c *TransferCursor) Err() error {
+	if err := c.rows.Err(); err != nil {
+		return fmt.Errorf("iterating transfers: %w", err)
+	}
+
+	return nil
+}
+
+// ForEach calls fn for every remaining transfer, stopping at the first error.
+func (c *TransferCursor) ForEach(fn func(ExportedTransfer) error) error {
+	for c.Next() {
+		transfer, err := c.Scan()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(*transfer); err != nil {
+			return err
+		}
+	}
+
+	return c.Err()
+}
+
+// Close releases the rows and the underlying read-only transaction.
+func (c *TransferCursor) Close() error {
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	if err := c.rows.Close(); err != nil {
+		if rollbackErr := c.tx.Rollback(); rollbackErr != nil {
+			c.logger.Errorw("Failed to rollback transaction", "err", rollbackErr)
+		}
+
+		return fmt.Errorf("closing rows: %w", err)
+	}
+
+	if err := c.tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransfersForExport returns the total number of transfers matching the filter and a cursor over
+// them, ordered deterministically by timestamp and id, with the labels of their tracked addresses and
+// the symbol of their catalogued token.
+// The count and the rows are read from the same snapshot so they are consistent with each other.
+func (s *Storage) GetTransfersForExport(ctx context.Context, filter TransferFilter) (int64, *TransferCursor, error) {
+	tx, err := s.readDB().BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	// Ensure transaction is rolled back if an error occurs
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.logger.Errorw("Failed to rollback transaction", "err", rollbackErr)
+			}
+		}
+	}()
+
+	where, args := filter.whereClause()
+
+	var total int64
+
+	err = tx.GetContext(ctx, &total, `SELECT COUNT(*) FROM transfers`+where, args...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("counting transfers: %w", err)
+	}
+
+	// The filter is applied in a subquery since its columns are not qualified with the table
+	query := `
+		SELECT t.id, t.hash, t.block_number, t.timestamp, t.from_address, t.to_address, t.token_address,
+			t.amount, t.type, t.created_at,
+			COALESCE(from_sa.label, from_ta.label) AS from_label,
+			COALESCE(to_sa.label, to_ta.label) AS to_label,
+			tok.symbol AS token_symbol
+		FROM (SELECT * FROM transfers` + where + `) t
+		LEFT JOIN source_addresses from_sa ON from_sa.address = t.from_address
+		LEFT JOIN target_addresses from_ta ON from_ta.address = t.from_address
+		LEFT JOIN source_addresses to_sa ON to_sa.address = t.to_address
+		LEFT JOIN target_addresses to_ta ON to_ta.address = t.to_address
+		LEFT JOIN tokens tok ON tok.address = t.token_address
+		ORDER BY t.timestamp, t.id
+	`
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("querying transfers: %w", err)
+	}
+
+	return total, &TransferCursor{tx: tx, rows: rows, logger: s.logger}, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// exportedTransfers returns the transfers matching filter in export order.
+func exportedTransfers(t *testing.T, s *Storage, filter TransferFilter) []ExportedTransfer {
+	t.Helper()
+
+	_, cursor, err := s.GetTransfersForExport(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("getting transfers for export: %v", err)
+	}
+
+	defer func() {
+		if err := cursor.Close(); err != nil {
+			t.Errorf("closing cursor: %v", err)
+		}
+	}()
+
+	var transfers []ExportedTransfer
+
+	err = cursor.ForEach(func(transfer ExportedTransfer) error {
+		transfers = append(transfers, transfer)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("iterating transfers: %v", err)
+	}
+
+	return transfers
+}
+
+func TestExportTransfersOrdering(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const n = 300
+
+	transfers := testTransfers(n)
+
+	// Insert out of order, the stream must still be ordered by timestamp
+	reversed := slices.Clone(transfers)
+	slices.Reverse(reversed)
+
+	if _, err := s.AddTransfersBatch(ctx, reversed); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	streamed := exportedTransfers(t, s, TransferFilter{})
+
+	if len(streamed) != n {
+		t.Fatalf("expected %d transfers, got %d", n, len(streamed))
+	}
+
+	for i, transfer := range streamed {
+		if transfer.Hash != transfers[i].Hash {
+			t.Fatalf("transfer %d: expected %s, got %s", i, transfers[i].Hash, transfer.Hash)
+		}
+	}
+
+	total, cursor, err := s.GetTransfersForExport(ctx, TransferFilter{StartBlock: 1000 + n - 10})
+	if err != nil {
+		t.Fatalf("getting transfers for export: %v", err)
+	}
+
+	defer func() {
+		if err := cursor.Close(); err != nil {
+			t.Errorf("closing cursor: %v", err)
+		}
+	}()
+
+	if total != 10 {
+		t.Fatalf("expected 10 transfers from block %d, got %d", 1000+n-10, total)
+	}
+}
+
+func TestExportTransfersLabels(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	seedTotals(t, s)
+
+	// A transfer of an uncatalogued token
+	uncatalogued := "0x00000000000000000000000000000000000000c4"
+	if _, err := s.AddTransfersBatch(ctx, []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  2000,
+		Timestamp:    totalsStart.Add(24 * time.Hour),
+		FromAddress:  totalsOutsider,
+		ToAddress:    totalsSourceA,
+		TokenAddress: uncatalogued,
+		Amount:       "1",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	label := func(l string) *string { return &l }
+
+	// In the order of the fixture; untracked addresses and uncatalogued tokens have no label or symbol
+	want := []struct {
+		from, to, symbol *string
+	}{
+		{from: label("Source A"), to: label("Target"), symbol: label("TKN")},
+		{from: label("Source A"), symbol: label("TKN")},
+		{to: label("Target"), symbol: label("TKN")},
+		{from: label("Source A"), to: label("Source B"), symbol: label("TKN")},
+		{to: label("Source A")},
+	}
+
+	streamed := exportedTransfers(t, s, TransferFilter{})
+
+	if len(streamed) != len(want) {
+		t.Fatalf("expected %d transfers, got %d", len(want), len(streamed))
+	}
+
+	equal := func(a, b *string) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+	show := func(v *string) string {
+		if v == nil {
+			return "<nil>"
+		}
+
+		return *v
+	}
+
+	for i, transfer := range streamed {
+		if !equal(transfer.FromLabel, want[i].from) || !equal(transfer.ToLabel, want[i].to) ||
+			!equal(transfer.TokenSymbol, want[i].symbol) {
+			t.Fatalf("transfer %d: expected labels %s, %s and symbol %s, got %s, %s and %s", i,
+				show(want[i].from), show(want[i].to), show(want[i].symbol),
+				show(transfer.FromLabel), show(transfer.ToLabel), show(transfer.TokenSymbol))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAddTransfersBatchChunks(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// 10,000 transfers span two statements; the copy of the first transfer, in the second statement,
+	// is skipped as an existing transfer
+	const n = 10000
+
+	batch := testTransfers(n)
+	duplicate := *batch[0]
+	batch = append(batch, &duplicate)
+
+	if n <= transferInsertChunkSize || n >= 2*transferInsertChunkSize {
+		t.Fatalf("%d transfers do not span exactly two chunks of %d", n, transferInsertChunkSize)
+	}
+
+	inserted, err := s.AddTransfersBatchReturningInserted(ctx, batch)
+	if err != nil {
+		t.Fatalf("adding batch: %v", err)
+	}
+
+	if len(inserted) != n {
+		t.Fatalf("expected %d inserted, got %d", n, len(inserted))
+	}
+
+	if inserted[0] != batch[0] || inserted[n-1] != batch[n-1] {
+		t.Error("expected the inserted transfers in their input order")
+	}
+
+	stats, err := s.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("getting stats: %v", err)
+	}
+
+	if stats.Transfers != n {
+		t.Errorf("expected %d stored transfers, got %d", n, stats.Transfers)
+	}
+}
+
+func BenchmarkAddTransfersBatch(b *testing.B) {
+	s := newTestStorage(b)
+	ctx := context.Background()
+
+	const batchSize = 1000
+
+	template := testTransfers(batchSize)
+
+	for i := 0; b.Loop(); i++ {
+		batch := make([]*Transfer, 0, batchSize)
+
+		for j, transfer := range template {
+			transfer := *transfer
+			transfer.Hash = fmt.Sprintf("0x%064x", i*batchSize+j+1)
+			batch = append(batch, &transfer)
+		}
+
+		if _, err := s.AddTransfersBatch(ctx, batch); err != nil {
+			b.Fatalf("adding batch: %v", err)
+		}
+	}
+}
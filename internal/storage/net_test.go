@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetNetAmounts(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	ctx := context.Background()
+
+	// The target sends 16 to the outsider after receiving 1 and 4
+	if _, err := s.AddTransfersBatch(ctx, []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  1004,
+		Timestamp:    totalsStart.Add(4 * time.Hour),
+		FromAddress:  totalsTarget,
+		ToAddress:    totalsOutsider,
+		TokenAddress: totalsToken,
+		Amount:       "16",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	tests := []struct {
+		name                  string
+		start, end            time.Time
+		inflow, outflow, want string
+	}{
+		{
+			name:  "every transfer",
+			start: totalsStart, end: totalsStart.Add(24 * time.Hour),
+			inflow: "5", outflow: "16", want: "-11",
+		},
+		{
+			name:  "before the outflow",
+			start: totalsStart, end: totalsStart.Add(3 * time.Hour),
+			inflow: "5", outflow: "0", want: "5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amounts, err := s.GetNetAmounts(ctx, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("getting net amounts: %v", err)
+			}
+
+			if len(amounts) != 1 {
+				t.Fatalf("expected the net amount of the test token, got %+v", amounts)
+			}
+
+			got := amounts[0]
+			if got.TokenAddress != totalsToken || got.Inflow != tt.inflow || got.Outflow != tt.outflow || got.NetAmount != tt.want {
+				t.Fatalf("expected inflow %s, outflow %s and net %s, got %+v", tt.inflow, tt.outflow, tt.want, got)
+			}
+		})
+	}
+
+	amounts, err := s.GetNetAmounts(ctx, totalsStart.Add(-48*time.Hour), totalsStart.Add(-24*time.Hour))
+	if err != nil || len(amounts) != 0 {
+		t.Fatalf("expected no net amounts before the transfers, got %+v (error %v)", amounts, err)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestListRefreshRuns(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Three runs an hour apart, the last still running
+	ids := make([]int64, 0, 3)
+
+	for i := range 3 {
+		id, err := s.StartRefreshRun(ctx, start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatalf("starting refresh run: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids[:2] {
+		finished := start.Add(time.Minute)
+		if err := s.FinishRefreshRun(ctx, RefreshRun{ID: id, Status: RefreshRunSucceeded, FinishedAt: &finished}); err != nil {
+			t.Fatalf("finishing refresh run: %v", err)
+		}
+	}
+
+	runs, err := s.ListRefreshRuns(ctx, 2)
+	if err != nil {
+		t.Fatalf("listing refresh runs: %v", err)
+	}
+
+	if len(runs) != 2 || runs[0].ID != ids[2] || runs[1].ID != ids[1] {
+		t.Fatalf("expected the runs %d and %d, newest first, got %+v", ids[2], ids[1], runs)
+	}
+
+	running := runs[0]
+	if running.Status != RefreshRunRunning || running.FinishedAt != nil || running.DurationSeconds != nil {
+		t.Fatalf("expected a running run without finish time and duration, got %+v", running)
+	}
+
+	if len(running.FailedAddresses) != 0 {
+		t.Fatalf("expected no failed addresses of a running run, got %v", running.FailedAddresses)
+	}
+}
+
+func TestFinishUnknownRefreshRun(t *testing.T) {
+	s := newTestStorage(t)
+	finished := time.Now()
+
+	err := s.FinishRefreshRun(context.Background(), RefreshRun{ID: 999999, Status: RefreshRunSucceeded, FinishedAt: &finished})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for an unknown run, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrUnavailable is returned by read methods when the database stays unreachable after retrying.
+var ErrUnavailable = errors.New("database unavailable")
+
+const (
+	// readRetries is the number of times a read failing with a transient error is retried.
+	readRetries = 3
+	// readRetryBackoff is the delay before the first retry of a read, doubled for every further retry.
+	readRetryBackoff = 100 * time.Millisecond
+	// cannotConnectNow is the PostgreSQL error code returned while the server starts up or shuts down.
+	cannotConnectNow = "57P03"
+	// connectionExceptionClass is the PostgreSQL error class of connection failures.
+	connectionExceptionClass = "08"
+)
+
+// isTransientError reports whether err is caused by the database being briefly unreachable, so that
+// the same query may succeed when retried.
+func isTransientError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == cannotConnectNow || pqErr.Code.Class() == connectionExceptionClass
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// withReadRetry runs a read-only query, retrying it with an exponential backoff while it fails with
+// a transient error. If the database is still unreachable after the last retry, or ctx is done
+// while waiting, the error is wrapped with ErrUnavailable. Other errors are returned as is.
+func withReadRetry(ctx context.Context, query func() error) error {
+	backoff := readRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := query()
+		if err == nil || !isTransientError(err) {
+			return err
+		}
+
+		if attempt == readRetries {
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// selectRead is db.SelectContext for read-only queries, retried on transient errors and logged if slow.
+// dest is reset before every attempt, so rows scanned by a failed attempt are not kept.
+func (s *Storage) selectRead(ctx context.Context, db *sqlx.DB, dest any, query string, args ...any) error {
+	defer s.logIfSlow(ctx, db, time.Now(), query, args)
+
+	return withReadRetry(ctx, func() error {
+		slice := reflect.ValueOf(dest).Elem()
+		slice.Set(reflect.Zero(slice.Type()))
+
+		return db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// getRead is db.GetContext for read-only queries, retried on transient errors and logged if slow.
+func (s *Storage) getRead(ctx context.Context, db *sqlx.DB, dest any, query string, args ...any) error {
+	defer s.logIfSlow(ctx, db, time.Now(), query, args)
+
+	return withReadRetry(ctx, func() error {
+		return db.GetContext(ctx, dest, query, args...)
+	})
+}
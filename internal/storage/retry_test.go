@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "connection refused", err: fmt.Errorf("query: %w", syscall.ECONNREFUSED), want: true},
+		{name: "connection reset", err: syscall.ECONNRESET, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "dial", err: &net.OpError{Op: "dial", Err: errors.New("no route to host")}, want: true},
+		{name: "server starting up", err: &pq.Error{Code: cannotConnectNow}, want: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "read", err: &net.OpError{Op: "read", Err: errors.New("timeout")}},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}},
+		{name: "no rows", err: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Fatalf("expected %v to be transient: %v, got %v", tt.err, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithReadRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", wantAttempts: 1},
+		{name: "recovers", failures: 2, err: driver.ErrBadConn, wantAttempts: 3},
+		{
+			name:         "stays unreachable",
+			failures:     readRetries + 1,
+			err:          driver.ErrBadConn,
+			wantAttempts: readRetries + 1,
+			wantErr:      ErrUnavailable,
+		},
+		{name: "not transient", failures: 1, err: sql.ErrNoRows, wantAttempts: 1, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+
+			err := withReadRetry(context.Background(), func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+
+				return nil
+			})
+
+			if attempts != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithReadRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+
+	err := withReadRetry(ctx, func() error {
+		attempts++
+		return driver.ErrBadConn
+	})
+
+	if attempts != 1 || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected a single attempt failing as unavailable, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestReadFromUnreachableDatabase(t *testing.T) {
+	// Nothing listens on port 1, so every connection attempt is refused
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test dbname=test sslmode=disable")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := New(db, zap.NewNop().Sugar())
+
+	if _, err := s.GetSourceAddresses(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected the unreachable database to be unavailable, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func TestQueriesUseConfiguredSchema(t *testing.T) {
+	const schema = "tracking"
+
+	db := testutil.NewTestDBWithSchema(t, testMigrationPath, schema)
+	s := New(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	if _, _, err := s.AddSourceAddress(ctx, totalsSourceA, AddressLabels{}); err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	var count int
+	if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM tracking.source_addresses`); err != nil {
+		t.Fatalf("counting source addresses of the schema: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected the source address to be stored in the %s schema, got %d rows", schema, count)
+	}
+
+	// Neither the tables nor the migrations table are created in the default schema
+	for _, table := range []string{"public.source_addresses", "public.schema_migrations"} {
+		var exists bool
+		if err := db.GetContext(ctx, &exists, `SELECT to_regclass($1) IS NOT NULL`, table); err != nil {
+			t.Fatalf("looking up %s: %v", table, err)
+		}
+
+		if exists {
+			t.Fatalf("expected no table %s", table)
+		}
+	}
+
+	sources, err := s.GetSourceAddresses(ctx)
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	if len(sources) != 1 {
+		t.Fatalf("expected the source address to be read from the %s schema, got %d", schema, len(sources))
+	}
+}
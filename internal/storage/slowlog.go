@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// explainTimeout bounds the EXPLAIN of a slow query.
+const explainTimeout = 5 * time.Second
+
+// slowQueryLog logs the read queries that take longer than a threshold, with their query plan.
+type slowQueryLog struct {
+	threshold time.Duration
+	// explaining holds a token while a query plan is looked up, so that a database slowing every query
+	// down is not burdened with an EXPLAIN per query. Slow queries are logged without their plan meanwhile.
+	explaining chan struct{}
+}
+
+func newSlowQueryLog() *slowQueryLog {
+	return &slowQueryLog{explaining: make(chan struct{}, 1)}
+}
+
+// SetSlowQueryThreshold makes read queries taking at least threshold logged as warnings, with the
+// plan the database chose for them. A non-positive threshold disables the log.
+func (s *Storage) SetSlowQueryThreshold(threshold time.Duration) {
+	s.slowQueries.threshold = threshold
+}
+
+// logIfSlow logs a read query started at started if it took at least the slow query threshold.
+// The query plan is looked up in the background, so the caller is not delayed further.
+func (s *Storage) logIfSlow(ctx context.Context, db *sqlx.DB, started time.Time, query string, args []any) {
+	threshold := s.slowQueries.threshold
+
+	elapsed := time.Since(started)
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	query = compactQuery(query)
+
+	select {
+	case s.slowQueries.explaining <- struct{}{}:
+	default:
+		s.logger.Warnw("Slow query", "duration", elapsed, "threshold", threshold, "query", query)
+		return
+	}
+
+	// The request of the query may be done by the time the plan is looked up
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer func() { <-s.slowQueries.explaining }()
+
+		plan, err := explainQuery(ctx, db, query, args)
+		if err != nil {
+			s.logger.Warnw("Slow query", "duration", elapsed, "threshold", threshold, "query", query,
+				"explainErr", err)
+
+			return
+		}
+
+		s.logger.Warnw("Slow query", "duration", elapsed, "threshold", threshold, "query", query, "plan", plan)
+	}()
+}
+
+// explainQuery returns the plan the database chooses for query with args, without running it.
+func explainQuery(ctx context.Context, db *sqlx.DB, query string, args []any) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+	defer cancel()
+
+	var lines []string
+	if err := db.SelectContext(ctx, &lines, "EXPLAIN "+query, args...); err != nil {
+		return "", fmt.Errorf("explaining query: %w", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// compactQuery collapses the whitespace of a query onto a single line for logging.
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
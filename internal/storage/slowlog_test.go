@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogIfSlow(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		elapsed   time.Duration
+		wantLog   bool
+	}{
+		{name: "disabled", threshold: 0, elapsed: time.Hour},
+		{name: "below threshold", threshold: time.Hour, elapsed: time.Millisecond},
+		{name: "above threshold", threshold: time.Millisecond, elapsed: time.Second, wantLog: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+
+			s := New(nil, zap.New(core).Sugar())
+			s.SetSlowQueryThreshold(tt.threshold)
+
+			// Another plan is being looked up, so the query is logged right away without its plan
+			s.slowQueries.explaining <- struct{}{}
+
+			s.logIfSlow(context.Background(), nil, time.Now().Add(-tt.elapsed), "SELECT\n\t1", nil)
+
+			if logged := logs.Len() > 0; logged != tt.wantLog {
+				t.Fatalf("expected a log %v, got %v", tt.wantLog, logs.All())
+			}
+
+			if !tt.wantLog {
+				return
+			}
+
+			fields := logs.All()[0].ContextMap()
+			if fields["query"] != "SELECT 1" || fields["plan"] != nil {
+				t.Fatalf("expected the compacted query without a plan, got %v", fields)
+			}
+		})
+	}
+}
+
+func TestSlowQueryPlan(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+
+	s := New(testutil.NewTestDB(t, testMigrationPath), zap.New(core).Sugar())
+	s.SetSlowQueryThreshold(time.Nanosecond)
+
+	if _, err := s.GetTokens(context.Background()); err != nil {
+		t.Fatalf("getting tokens: %v", err)
+	}
+
+	// The plan is looked up in the background
+	deadline := time.Now().Add(explainTimeout)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if logs.Len() != 1 {
+		t.Fatalf("expected the slow query to be logged once, got %v", logs.All())
+	}
+
+	if fields := logs.All()[0].ContextMap(); fields["plan"] == nil || fields["explainErr"] != nil {
+		t.Fatalf("expected the query plan to be logged, got %v", fields)
+	}
+}
+
+// BenchmarkGetTotalAmounts aggregates a range of a dataset in which the tracked addresses sent and
+// received a fraction of the transfers, with and without the indexes of the transfer lookups.
+func BenchmarkGetTotalAmounts(b *testing.B) {
+	s := newTestStorage(b)
+	ctx := context.Background()
+
+	if _, err := s.AddToken(ctx, totalsToken, "TKN", "Token", 6); err != nil {
+		b.Fatalf("adding token: %v", err)
+	}
+
+	if _, _, err := s.AddSourceAddress(ctx, totalsSourceA, AddressLabels{}); err != nil {
+		b.Fatalf("adding source address: %v", err)
+	}
+
+	if _, _, err := s.AddTargetAddress(ctx, totalsTarget, AddressLabels{}); err != nil {
+		b.Fatalf("adding target address: %v", err)
+	}
+
+	// One in 100 transfers is from the source to the target
+	const n = 50000
+
+	transfers := make([]*Transfer, 0, n)
+	for i := range n {
+		transfer := &Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    totalsStart.Add(time.Duration(i) * time.Minute),
+			FromAddress:  fmt.Sprintf("0x%040x", i%100+0x1000),
+			ToAddress:    fmt.Sprintf("0x%040x", i%97+0x2000),
+			TokenAddress: totalsToken,
+			Amount:       "1000000",
+		}
+
+		if i%100 == 0 {
+			transfer.FromAddress, transfer.ToAddress = totalsSourceA, totalsTarget
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	if _, err := s.AddTransfersBatch(ctx, transfers); err != nil {
+		b.Fatalf("adding transfers: %v", err)
+	}
+
+	filter := TotalAmountsFilter{StartTime: totalsStart, EndTime: totalsStart.Add(n * time.Minute / 2)}
+
+	run := func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.GetTotalAmounts(ctx, filter); err != nil {
+				b.Fatalf("getting total amounts: %v", err)
+			}
+		}
+	}
+
+	b.Run("indexed", run)
+
+	for _, index := range []string{
+		"transfers_from_token_block_idx", "transfers_to_token_block_idx", "transfers_block_number_idx",
+	} {
+		if _, err := s.db.ExecContext(ctx, "DROP INDEX "+index); err != nil {
+			b.Fatalf("dropping index %s: %v", index, err)
+		}
+	}
+
+	b.Run("unindexed", run)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetStats(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	ctx := context.Background()
+	tokenUpdate := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	// An empty refresh time means no refresh succeeded yet
+	if err := s.UpdateConfig(ctx, "last_eth_update", ""); err != nil {
+		t.Fatalf("clearing last ETH update: %v", err)
+	}
+
+	if err := s.UpdateConfig(ctx, "last_token_update", tokenUpdate.Format(time.RFC3339)); err != nil {
+		t.Fatalf("setting last token update: %v", err)
+	}
+
+	stats, err := s.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("getting stats: %v", err)
+	}
+
+	// The migration catalogues ETH next to the seeded token
+	want := TableCounts{SourceAddresses: 2, TargetAddresses: 1, Tokens: 2, Transfers: 4}
+	if stats.TableCounts != want {
+		t.Errorf("table counts = %+v, want %+v", stats.TableCounts, want)
+	}
+
+	if stats.LastETHUpdate != nil {
+		t.Errorf("last ETH update = %v, want nil", stats.LastETHUpdate)
+	}
+
+	if stats.LastTokenUpdate == nil || !stats.LastTokenUpdate.Equal(tokenUpdate) {
+		t.Errorf("last token update = %v, want %v", stats.LastTokenUpdate, tokenUpdate)
+	}
+}
@@ -4,18 +4,40 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// ErrAlreadyExists is returned when inserting a row that violates a unique constraint.
+var ErrAlreadyExists = errors.New("already exists")
+
+// uniqueViolation is the PostgreSQL error code for unique constraint violations.
+const uniqueViolation = "23505"
+
+const (
+	// maxBindParameters is the maximum number of bind parameters of a PostgreSQL statement.
+	maxBindParameters = 65535
+	// transferInsertColumns is the number of columns set by a transfer insert.
+	transferInsertColumns = 8
+	// transferInsertChunkSize is the number of transfers inserted per statement.
+	transferInsertChunkSize = maxBindParameters / transferInsertColumns
+)
+
 // Storage handles database operations.
 type Storage struct {
-	db     *sqlx.DB
-	logger *zap.SugaredLogger
+	db      *sqlx.DB
+	replica *sqlx.DB
+	logger  *zap.SugaredLogger
+	// totals caches the results of GetTotalAmounts, disabled until SetTotalsCacheTTL is called.
+	totals *totalsCache
+	// slowQueries logs slow read queries, disabled until SetSlowQueryThreshold is called.
+	slowQueries *slowQueryLog
 }
 
 // New creates a new Storage instance.
@@ -23,25 +45,68 @@ func New(db *sqlx.DB, logger *zap.SugaredLogger) *Storage {
 	return &Storage{
 		db:     db,
 		logger: logger,
+		totals: newTotalsCache(),
+
+		slowQueries: newSlowQueryLog(),
+	}
+}
+
+// NewWithReplica creates a new Storage instance that routes heavy read-only queries to a replica.
+// If replica is nil, all queries go to the primary.
+func NewWithReplica(db, replica *sqlx.DB, logger *zap.SugaredLogger) *Storage {
+	store := New(db, logger)
+	store.replica = replica
+
+	return store
+}
+
+// SetTotalsCacheTTL sets how long the results of GetTotalAmounts are cached. A non-positive TTL
+// disables the cache.
+func (s *Storage) SetTotalsCacheTTL(ttl time.Duration) {
+	s.totals.setTTL(ttl)
+}
+
+// readDB returns the connection to use for read-only queries.
+func (s *Storage) readDB() *sqlx.DB {
+	if s.replica != nil {
+		return s.replica
 	}
+
+	return s.db
 }
 
+// addressColumns are the columns of the source_addresses and target_addresses tables.
+const addressColumns = `id, address, label, category, tags, created_at, updated_at`
+
 // SourceAddress represents a source address to track.
 type SourceAddress struct {
-	ID        int64     `db:"id" json:"id"`
-	Address   string    `db:"address" json:"address"`
-	Label     string    `db:"label" json:"label"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID        int64          `db:"id" json:"id"`
+	Address   string         `db:"address" json:"address"`
+	Label     string         `db:"label" json:"label"`
+	Category  string         `db:"category" json:"category"`
+	Tags      pq.StringArray `db:"tags" json:"tags" swaggertype:"array,string"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
 }
 
 // TargetAddress represents a target address to track.
 type TargetAddress struct {
-	ID        int64     `db:"id" json:"id"`
-	Address   string    `db:"address" json:"address"`
-	Label     string    `db:"label" json:"label"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID        int64          `db:"id" json:"id"`
+	Address   string         `db:"address" json:"address"`
+	Label     string         `db:"label" json:"label"`
+	Category  string         `db:"category" json:"category"`
+	Tags      pq.StringArray `db:"tags" json:"tags" swaggertype:"array,string"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// AddressLabels are the descriptive fields of a source or target address.
+type AddressLabels struct {
+	Label string
+	// Category classifies the address, e.g. exchange, vault or multisig. Empty if uncategorized.
+	Category string
+	// Tags are free-form tags of the address.
+	Tags []string
 }
 
 // Token represents an ERC20 token to track.
@@ -65,9 +130,19 @@ type Transfer struct {
 	ToAddress    string    `db:"to_address" json:"to_address"`
 	TokenAddress string    `db:"token_address" json:"token_address"`
 	Amount       string    `db:"amount" json:"amount"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	// Type is TransferTypeNormal or TransferTypeInternal, empty means TransferTypeNormal.
+	Type      string    `db:"type" json:"type"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
+// Transfer types.
+const (
+	// TransferTypeNormal is a transfer by a transaction or an ERC20 transfer event.
+	TransferTypeNormal = "normal"
+	// TransferTypeInternal is an ETH transfer by an internal transaction (trace) of a contract call.
+	TransferTypeInternal = "internal"
+)
+
 // Config represents a system configuration entry.
 type Config struct {
 	ID        int64     `db:"id" json:"id"`
@@ -77,44 +152,86 @@ type Config struct {
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// ConfigHistory represents a single change of a configuration entry.
+type ConfigHistory struct {
+	ID        int64     `db:"id" json:"id"`
+	Key       string    `db:"key" json:"key"`
+	OldValue  *string   `db:"old_value" json:"old_value"`
+	NewValue  string    `db:"new_value" json:"new_value"`
+	ChangedAt time.Time `db:"changed_at" json:"changed_at"`
+}
+
+// ConfigHistoryFilter filters the configuration history listing.
+// Empty fields are ignored.
+type ConfigHistoryFilter struct {
+	Key       string
+	StartTime time.Time
+	EndTime   time.Time
+	Limit     int
+}
+
+// TableCounts holds the number of rows in the main tables.
+type TableCounts struct {
+	SourceAddresses int64 `db:"source_addresses" json:"source_addresses"`
+	TargetAddresses int64 `db:"target_addresses" json:"target_addresses"`
+	Tokens          int64 `db:"tokens" json:"tokens"`
+	Transfers       int64 `db:"transfers" json:"transfers"`
+}
+
 // TokenAmount represents the total amount of a token transferred.
+// Symbol, Name and Decimals are nil for tokens missing from the tokens table.
 type TokenAmount struct {
-	TokenAddress string `db:"token_address" json:"token_address"`
-	Symbol       string `db:"symbol" json:"symbol"`
-	Name         string `db:"name" json:"name"`
-	Decimals     int    `db:"decimals" json:"decimals"`
-	TotalAmount  string `db:"total_amount" json:"total_amount"`
-	// NormalizedAmount is calculated as TotalAmount / 10^Decimals
-	NormalizedAmount string `json:"normalized_amount"`
+	TokenAddress string  `db:"token_address" json:"token_address"`
+	Symbol       *string `db:"symbol" json:"symbol"`
+	Name         *string `db:"name" json:"name"`
+	Decimals     *int    `db:"decimals" json:"decimals"`
+	// TotalAmount is the sum of the raw amounts, empty when only the normalized amount is requested
+	TotalAmount string `db:"total_amount" json:"total_amount,omitempty"`
+	// NormalizedAmount is calculated as TotalAmount / 10^Decimals, empty when the decimals are unknown
+	NormalizedAmount string `db:"normalized_amount" json:"normalized_amount,omitempty"`
+	// USDValue is NormalizedAmount valued in USD, empty when no price is available
+	USDValue string `json:"usd_value,omitempty"`
 }
 
 // AddSourceAddress adds a new source address.
-func (s *Storage) AddSourceAddress(ctx context.Context, address, label string) (*SourceAddress, error) {
+// If the address already exists, its labels are replaced and the existing row is returned.
+// The returned bool reports whether the address was newly inserted.
+func (s *Storage) AddSourceAddress(
+	ctx context.Context, address string, labels AddressLabels,
+) (*SourceAddress, bool, error) {
 	// Normalize address to lowercase
 	address = strings.ToLower(address)
 
+	// xmax is 0 only for rows inserted (not updated) by this statement
 	query := `
-		INSERT INTO source_addresses (address, label, updated_at)
-		VALUES ($1, $2, NOW())
-		RETURNING id, address, label, created_at, updated_at
+		INSERT INTO source_addresses (address, label, category, tags, updated_at)
+		VALUES ($1, $2, $3, COALESCE($4::TEXT[], '{}'), NOW())
+		ON CONFLICT (address) DO UPDATE
+		SET label = EXCLUDED.label, category = EXCLUDED.category, tags = EXCLUDED.tags, updated_at = NOW()
+		RETURNING ` + addressColumns + `, (xmax = 0) AS inserted
 	`
 
-	var result SourceAddress
-	err := s.db.GetContext(ctx, &result, query, address, label)
+	var result struct {
+		SourceAddress
+		Inserted bool `db:"inserted"`
+	}
 
+	err := s.db.GetContext(ctx, &result, query, address, labels.Label, labels.Category, pq.Array(labels.Tags))
 	if err != nil {
-		return nil, fmt.Errorf("adding source address: %w", err)
+		return nil, false, fmt.Errorf("adding source address: %w", err)
 	}
 
-	return &result, nil
+	s.totals.clear()
+
+	return &result.SourceAddress, result.Inserted, nil
 }
 
 // GetSourceAddresses retrieves all source addresses.
 func (s *Storage) GetSourceAddresses(ctx context.Context) ([]SourceAddress, error) {
-	query := `SELECT id, address, label, created_at, updated_at FROM source_addresses ORDER BY id`
+	query := `SELECT ` + addressColumns + ` FROM source_addresses ORDER BY id`
 
 	var addresses []SourceAddress
-	err := s.db.SelectContext(ctx, &addresses, query)
+	err := s.selectRead(ctx, s.db, &addresses, query)
 
 	if err != nil {
 		return nil, fmt.Errorf("getting source addresses: %w", err)
@@ -123,6 +240,21 @@ func (s *Storage) GetSourceAddresses(ctx context.Context) ([]SourceAddress, erro
 	return addresses, nil
 }
 
+// GetSourceAddressByID retrieves a source address by ID.
+// It returns sql.ErrNoRows if the source address does not exist.
+func (s *Storage) GetSourceAddressByID(ctx context.Context, id int64) (*SourceAddress, error) {
+	query := `SELECT ` + addressColumns + ` FROM source_addresses WHERE id = $1`
+
+	var result SourceAddress
+	err := s.getRead(ctx, s.db, &result, query, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting source address %d: %w", id, err)
+	}
+
+	return &result, nil
+}
+
 // DeleteSourceAddress deletes a source address.
 func (s *Storage) DeleteSourceAddress(ctx context.Context, id int64) error {
 	query := `DELETE FROM source_addresses WHERE id = $1`
@@ -132,6 +264,8 @@ func (s *Storage) DeleteSourceAddress(ctx context.Context, id int64) error {
 		return fmt.Errorf("deleting source address: %w", err)
 	}
 
+	s.totals.clear()
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("getting rows affected: %w", err)
@@ -144,33 +278,67 @@ func (s *Storage) DeleteSourceAddress(ctx context.Context, id int64) error {
 	return nil
 }
 
+// DeleteSourceAddressesByIDs deletes source addresses by ID.
+// It returns the IDs that were deleted.
+func (s *Storage) DeleteSourceAddressesByIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	deleted, err := s.deleteAddressesByIDs(ctx, "source_addresses", ids)
+	if err != nil {
+		return nil, fmt.Errorf("deleting source addresses: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// DeleteSourceAddressesByAddresses deletes source addresses by their address.
+// It returns the normalized addresses that were deleted.
+func (s *Storage) DeleteSourceAddressesByAddresses(ctx context.Context, addresses []string) ([]string, error) {
+	deleted, err := s.deleteAddressesByAddresses(ctx, "source_addresses", addresses)
+	if err != nil {
+		return nil, fmt.Errorf("deleting source addresses: %w", err)
+	}
+
+	return deleted, nil
+}
+
 // AddTargetAddress adds a new target address.
-func (s *Storage) AddTargetAddress(ctx context.Context, address, label string) (*TargetAddress, error) {
+// If the address already exists, its labels are replaced and the existing row is returned.
+// The returned bool reports whether the address was newly inserted.
+func (s *Storage) AddTargetAddress(
+	ctx context.Context, address string, labels AddressLabels,
+) (*TargetAddress, bool, error) {
 	// Normalize address to lowercase
 	address = strings.ToLower(address)
 
+	// xmax is 0 only for rows inserted (not updated) by this statement
 	query := `
-		INSERT INTO target_addresses (address, label, updated_at)
-		VALUES ($1, $2, NOW())
-		RETURNING id, address, label, created_at, updated_at
+		INSERT INTO target_addresses (address, label, category, tags, updated_at)
+		VALUES ($1, $2, $3, COALESCE($4::TEXT[], '{}'), NOW())
+		ON CONFLICT (address) DO UPDATE
+		SET label = EXCLUDED.label, category = EXCLUDED.category, tags = EXCLUDED.tags, updated_at = NOW()
+		RETURNING ` + addressColumns + `, (xmax = 0) AS inserted
 	`
 
-	var result TargetAddress
-	err := s.db.GetContext(ctx, &result, query, address, label)
+	var result struct {
+		TargetAddress
+		Inserted bool `db:"inserted"`
+	}
 
+	err := s.db.GetContext(ctx, &result, query, address, labels.Label, labels.Category, pq.Array(labels.Tags))
 	if err != nil {
-		return nil, fmt.Errorf("adding target address: %w", err)
+		return nil, false, fmt.Errorf("adding target address: %w", err)
 	}
 
-	return &result, nil
+	s.totals.clear()
+
+	return &result.TargetAddress, result.Inserted, nil
 }
 
 // GetTargetAddresses retrieves all target addresses.
 func (s *Storage) GetTargetAddresses(ctx context.Context) ([]TargetAddress, error) {
-	query := `SELECT id, address, label, created_at, updated_at FROM target_addresses ORDER BY id`
+	query := `SELECT ` + addressColumns + ` FROM target_addresses ORDER BY id`
 
 	var addresses []TargetAddress
-	err := s.db.SelectContext(ctx, &addresses, query)
+	err := s.selectRead(ctx, s.db, &addresses, query)
 
 	if err != nil {
 		return nil, fmt.Errorf("getting target addresses: %w", err)
@@ -179,6 +347,21 @@ func (s *Storage) GetTargetAddresses(ctx context.Context) ([]TargetAddress, erro
 	return addresses, nil
 }
 
+// GetTargetAddressByID retrieves a target address by ID.
+// It returns sql.ErrNoRows if the target address does not exist.
+func (s *Storage) GetTargetAddressByID(ctx context.Context, id int64) (*TargetAddress, error) {
+	query := `SELECT ` + addressColumns + ` FROM target_addresses WHERE id = $1`
+
+	var result TargetAddress
+	err := s.getRead(ctx, s.db, &result, query, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting target address %d: %w", id, err)
+	}
+
+	return &result, nil
+}
+
 // DeleteTargetAddress deletes a target address.
 func (s *Storage) DeleteTargetAddress(ctx context.Context, id int64) error {
 	query := `DELETE FROM target_addresses WHERE id = $1`
@@ -188,6 +371,8 @@ func (s *Storage) DeleteTargetAddress(ctx context.Context, id int64) error {
 		return fmt.Errorf("deleting target address: %w", err)
 	}
 
+	s.totals.clear()
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("getting rows affected: %w", err)
@@ -200,6 +385,76 @@ func (s *Storage) DeleteTargetAddress(ctx context.Context, id int64) error {
 	return nil
 }
 
+// DeleteTargetAddressesByIDs deletes target addresses by ID.
+// It returns the IDs that were deleted.
+func (s *Storage) DeleteTargetAddressesByIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	deleted, err := s.deleteAddressesByIDs(ctx, "target_addresses", ids)
+	if err != nil {
+		return nil, fmt.Errorf("deleting target addresses: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// DeleteTargetAddressesByAddresses deletes target addresses by their address.
+// It returns the normalized addresses that were deleted.
+func (s *Storage) DeleteTargetAddressesByAddresses(ctx context.Context, addresses []string) ([]string, error) {
+	deleted, err := s.deleteAddressesByAddresses(ctx, "target_addresses", addresses)
+	if err != nil {
+		return nil, fmt.Errorf("deleting target addresses: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// deleteAddressesByIDs deletes rows with the given IDs from an address table.
+func (s *Storage) deleteAddressesByIDs(ctx context.Context, table string, ids []int64) ([]int64, error) {
+	deleted := make([]int64, 0, len(ids))
+	if len(ids) == 0 {
+		return deleted, nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1) RETURNING id`, table)
+
+	err := s.db.SelectContext(ctx, &deleted, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("deleting by ids: %w", err)
+	}
+
+	s.totals.clear()
+
+	return deleted, nil
+}
+
+// deleteAddressesByAddresses deletes rows with the given addresses from an address table.
+func (s *Storage) deleteAddressesByAddresses(ctx context.Context, table string, addresses []string) ([]string, error) {
+	deleted := make([]string, 0, len(addresses))
+	if len(addresses) == 0 {
+		return deleted, nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE address = ANY($1) RETURNING address`, table)
+
+	err := s.db.SelectContext(ctx, &deleted, query, pq.Array(lowerAll(addresses)))
+	if err != nil {
+		return nil, fmt.Errorf("deleting by addresses: %w", err)
+	}
+
+	s.totals.clear()
+
+	return deleted, nil
+}
+
+// lowerAll returns the addresses normalized to lowercase.
+func lowerAll(addresses []string) []string {
+	normalized := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		normalized = append(normalized, strings.ToLower(address))
+	}
+
+	return normalized
+}
+
 // AddToken adds a new token to track.
 func (s *Storage) AddToken(ctx context.Context, address, symbol, name string, decimals int) (*Token, error) {
 	// Normalize address to lowercase
@@ -215,18 +470,31 @@ func (s *Storage) AddToken(ctx context.Context, address, symbol, name string, de
 	err := s.db.GetContext(ctx, &result, query, address, symbol, name, decimals)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("adding token %s: %w", address, ErrAlreadyExists)
+		}
+
 		return nil, fmt.Errorf("adding token: %w", err)
 	}
 
+	s.totals.clear()
+
 	return &result, nil
 }
 
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
 // GetTokens retrieves all tokens.
 func (s *Storage) GetTokens(ctx context.Context) ([]Token, error) {
 	query := `SELECT id, address, symbol, name, decimals, created_at, updated_at FROM tokens ORDER BY id`
 
 	var tokens []Token
-	err := s.db.SelectContext(ctx, &tokens, query)
+	err := s.selectRead(ctx, s.db, &tokens, query)
 
 	if err != nil {
 		return nil, fmt.Errorf("getting tokens: %w", err)
@@ -235,6 +503,47 @@ func (s *Storage) GetTokens(ctx context.Context) ([]Token, error) {
 	return tokens, nil
 }
 
+// GetTokenByID retrieves a token by ID.
+// It returns sql.ErrNoRows if the token does not exist.
+func (s *Storage) GetTokenByID(ctx context.Context, id int64) (*Token, error) {
+	query := `SELECT id, address, symbol, name, decimals, created_at, updated_at FROM tokens WHERE id = $1`
+
+	var result Token
+	err := s.getRead(ctx, s.db, &result, query, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting token %d: %w", id, err)
+	}
+
+	return &result, nil
+}
+
+// UpdateToken updates the symbol, name and decimals of a token. Nil fields are left unchanged
+// and the address is immutable.
+// It returns sql.ErrNoRows if the token does not exist.
+func (s *Storage) UpdateToken(ctx context.Context, id int64, symbol, name *string, decimals *int) (*Token, error) {
+	query := `
+		UPDATE tokens
+		SET symbol = COALESCE($2, symbol),
+			name = COALESCE($3, name),
+			decimals = COALESCE($4, decimals),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, address, symbol, name, decimals, created_at, updated_at
+	`
+
+	var result Token
+	err := s.db.GetContext(ctx, &result, query, id, symbol, name, decimals)
+
+	if err != nil {
+		return nil, fmt.Errorf("updating token %d: %w", id, err)
+	}
+
+	s.totals.clear()
+
+	return &result, nil
+}
+
 // DeleteToken deletes a token.
 func (s *Storage) DeleteToken(ctx context.Context, id int64) error {
 	query := `DELETE FROM tokens WHERE id = $1`
@@ -244,6 +553,8 @@ func (s *Storage) DeleteToken(ctx context.Context, id int64) error {
 		return fmt.Errorf("deleting token: %w", err)
 	}
 
+	s.totals.clear()
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("getting rows affected: %w", err)
@@ -256,16 +567,72 @@ func (s *Storage) DeleteToken(ctx context.Context, id int64) error {
 	return nil
 }
 
+// UncataloguedToken is a token with stored transfers that is missing from the tokens table.
+type UncataloguedToken struct {
+	TokenAddress  string    `db:"token_address" json:"token_address"`
+	TransferCount int64     `db:"transfer_count" json:"transfer_count"`
+	LastTransfer  time.Time `db:"last_transfer" json:"last_transfer"`
+}
+
+// GetUncataloguedTokens retrieves the distinct tokens of the stored transfers that are missing from
+// the tokens table, with their number of transfers, ordered by that number descending.
+func (s *Storage) GetUncataloguedTokens(ctx context.Context) ([]UncataloguedToken, error) {
+	query := `
+		SELECT
+			t.token_address,
+			COUNT(*) as transfer_count,
+			MAX(t.timestamp) as last_transfer
+		FROM transfers t
+		WHERE NOT EXISTS (SELECT 1 FROM tokens tk WHERE tk.address = t.token_address)
+		GROUP BY t.token_address
+		ORDER BY transfer_count DESC, t.token_address
+	`
+
+	var tokens []UncataloguedToken
+	err := s.selectRead(ctx, s.readDB(), &tokens, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting uncatalogued tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
 // AddTransfersBatch adds multiple transfers in a single transaction.
-func (s *Storage) AddTransfersBatch(ctx context.Context, transfers []*Transfer) error {
-	if len(transfers) == 0 {
-		return nil
+// It returns the number of newly inserted transfers; transfers that already exist are skipped.
+func (s *Storage) AddTransfersBatch(ctx context.Context, transfers []*Transfer) (int, error) {
+	inserted, err := s.AddTransfersBatchReturningInserted(ctx, transfers)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(inserted), nil
+}
+
+// AddTransfersBatchReturningInserted adds multiple transfers in a single transaction.
+// It returns the newly inserted transfers; transfers that already exist are skipped.
+func (s *Storage) AddTransfersBatchReturningInserted(ctx context.Context, transfers []*Transfer) ([]*Transfer, error) {
+	return s.addTransfersBatch(ctx, transfers, nil)
+}
+
+// AddTransfersBatchWithCursor adds multiple transfers and advances the fetch cursor in a single transaction,
+// so the cursor never gets ahead of the stored transfers. The cursor only moves forward.
+// It returns the newly inserted transfers; transfers that already exist are skipped.
+func (s *Storage) AddTransfersBatchWithCursor(
+	ctx context.Context, transfers []*Transfer, cursor FetchCursor,
+) ([]*Transfer, error) {
+	return s.addTransfersBatch(ctx, transfers, &cursor)
+}
+
+func (s *Storage) addTransfersBatch(ctx context.Context, transfers []*Transfer, cursor *FetchCursor) ([]*Transfer, error) {
+	if len(transfers) == 0 && cursor == nil {
+		return nil, nil
 	}
 
 	// Start a transaction
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("beginning transaction: %w", err)
+		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
 
 	// Ensure transaction is rolled back if an error occurs
@@ -278,32 +645,69 @@ func (s *Storage) AddTransfersBatch(ctx context.Context, transfers []*Transfer)
 		}
 	}()
 
-	// Prepare the statement
-	stmt, err := tx.PreparexContext(ctx, `
-		INSERT INTO transfers (hash, block_number, timestamp, from_address, to_address, token_address, amount)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (hash, token_address, from_address, to_address) DO NOTHING
-	`)
+	// Normalize addresses to lowercase
+	for _, transfer := range transfers {
+		transfer.FromAddress = strings.ToLower(transfer.FromAddress)
+		transfer.ToAddress = strings.ToLower(transfer.ToAddress)
+		transfer.TokenAddress = strings.ToLower(transfer.TokenAddress)
 
-	if err != nil {
-		return fmt.Errorf("preparing statement: %w", err)
+		if transfer.Type == "" {
+			transfer.Type = TransferTypeNormal
+		}
 	}
 
-	defer func() {
-		if closeErr := stmt.Close(); closeErr != nil {
-			s.logger.Warnw("Failed to close statement", "err", closeErr)
+	var inserted []*Transfer
+
+	// Insert in multi-row statements that stay below Postgres's bind parameter limit
+	for chunkStart := 0; chunkStart < len(transfers); chunkStart += transferInsertChunkSize {
+		chunk := transfers[chunkStart:min(chunkStart+transferInsertChunkSize, len(transfers))]
+
+		var chunkInserted []*Transfer
+
+		chunkInserted, err = insertTransfers(ctx, tx, chunk)
+		if err != nil {
+			return nil, err
 		}
-	}()
 
-	// Execute the statement for each transfer
-	for _, transfer := range transfers {
-		// Normalize addresses to lowercase
-		transfer.FromAddress = strings.ToLower(transfer.FromAddress)
-		transfer.ToAddress = strings.ToLower(transfer.ToAddress)
-		transfer.TokenAddress = strings.ToLower(transfer.TokenAddress)
+		inserted = append(inserted, chunkInserted...)
+	}
+
+	if cursor != nil {
+		if err = upsertFetchCursor(ctx, tx, *cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	s.totals.invalidate(inserted)
+
+	return inserted, nil
+}
+
+// transferKey identifies a transfer by the columns of the transfers unique index.
+type transferKey struct {
+	Hash         string `db:"hash"`
+	TokenAddress string `db:"token_address"`
+	FromAddress  string `db:"from_address"`
+	ToAddress    string `db:"to_address"`
+	Type         string `db:"type"`
+}
+
+// insertTransfers inserts normalized transfers in a single multi-row statement, skipping existing ones,
+// and returns the inserted transfers in their input order.
+func insertTransfers(ctx context.Context, tx *sqlx.Tx, transfers []*Transfer) ([]*Transfer, error) {
+	values := make([]string, 0, len(transfers))
+	args := make([]any, 0, len(transfers)*transferInsertColumns)
 
-		_, err = stmt.ExecContext(
-			ctx,
+	for _, transfer := range transfers {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args,
 			transfer.Hash,
 			transfer.BlockNumber,
 			transfer.Timestamp,
@@ -311,67 +715,541 @@ func (s *Storage) AddTransfersBatch(ctx context.Context, transfers []*Transfer)
 			transfer.ToAddress,
 			transfer.TokenAddress,
 			transfer.Amount,
+			transfer.Type,
 		)
+	}
 
-		if err != nil {
-			return fmt.Errorf("executing statement: %w", err)
+	query := `
+		INSERT INTO transfers (hash, block_number, timestamp, from_address, to_address, token_address, amount, type)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (hash, token_address, from_address, to_address, type) DO NOTHING
+		RETURNING hash, token_address, from_address, to_address, type
+	`
+
+	var keys []transferKey
+	if err := tx.SelectContext(ctx, &keys, query, args...); err != nil {
+		return nil, fmt.Errorf("inserting transfers: %w", err)
+	}
+
+	insertedKeys := make(map[transferKey]bool, len(keys))
+	for _, key := range keys {
+		insertedKeys[key] = true
+	}
+
+	// A transfer repeated in the batch is inserted once, report its first occurrence
+	inserted := make([]*Transfer, 0, len(keys))
+
+	for _, transfer := range transfers {
+		key := transferKey{
+			Hash:         transfer.Hash,
+			TokenAddress: transfer.TokenAddress,
+			FromAddress:  transfer.FromAddress,
+			ToAddress:    transfer.ToAddress,
+			Type:         transfer.Type,
+		}
+
+		if insertedKeys[key] {
+			inserted = append(inserted, transfer)
+			delete(insertedKeys, key)
 		}
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("committing transaction: %w", err)
+	return inserted, nil
+}
+
+// Direction selects which transfers between tracked addresses are aggregated.
+type Direction string
+
+// Supported directions.
+const (
+	// DirectionSourceToTarget selects transfers from source addresses to target addresses.
+	DirectionSourceToTarget Direction = "source_to_target"
+	// DirectionInflow selects transfers to target addresses from any address.
+	DirectionInflow Direction = "inflow"
+	// DirectionOutflow selects transfers from source addresses to any address.
+	DirectionOutflow Direction = "outflow"
+	// DirectionAmongSources selects transfers from a source address to a source address, for address
+	// sets that both send and receive. Every such transfer is fetched, as source addresses are fetched
+	// in both directions.
+	DirectionAmongSources Direction = "among_sources"
+)
+
+// IsValid reports whether d is a supported direction.
+func (d Direction) IsValid() bool {
+	switch d {
+	case DirectionSourceToTarget, DirectionInflow, DirectionOutflow, DirectionAmongSources:
+		return true
+	default:
+		return false
 	}
+}
 
-	return nil
+// UsesSourceAddresses reports whether the transfers selected by d depend on the source addresses.
+func (d Direction) UsesSourceAddresses() bool {
+	return d != DirectionInflow
+}
+
+// UsesTargetAddresses reports whether the transfers selected by d depend on the target addresses.
+func (d Direction) UsesTargetAddresses() bool {
+	return d != DirectionOutflow && d != DirectionAmongSources
 }
 
-// GetTotalAmounts retrieves the total amounts of each token transferred from source addresses to target addresses.
-func (s *Storage) GetTotalAmounts(ctx context.Context, startTime, endTime time.Time) ([]TokenAmount, error) {
+// DeleteTransfers deletes the transfers matching the filter and returns the number deleted.
+// An empty filter deletes every transfer. In the same statement, the fetch cursors of the fetches that
+// stored the deleted transfers are lowered below the earliest deleted block, so the next fetch fetches
+// them again. The cursors of other addresses and tokens are kept.
+func (s *Storage) DeleteTransfers(ctx context.Context, filter TransferFilter) (int64, error) {
+	where, args := filter.whereClause()
+
+	// A transfer is fetched by the fetch of its token and by the fetch of all ERC20 tokens of its
+	// addresses, or by the ETH or internal transactions fetch of its addresses
 	query := `
-		SELECT
-			t.token_address,
-			tk.symbol,
-			tk.name,
-			tk.decimals,
-			SUM(t.amount) as total_amount
-		FROM
-			transfers t
-		JOIN
-			tokens tk ON t.token_address = tk.address
-		WHERE
-			t.from_address IN (SELECT address FROM source_addresses)
-			AND t.to_address IN (SELECT address FROM target_addresses)
-			AND t.timestamp BETWEEN $1 AND $2
-		GROUP BY
-			t.token_address, tk.symbol, tk.name, tk.decimals
-		ORDER BY
-			tk.symbol
+		WITH deleted AS (
+			DELETE FROM transfers` + where + `
+			RETURNING from_address, to_address, token_address, type, block_number
+		), deleted_addresses AS (
+			SELECT from_address AS address, token_address, type, block_number FROM deleted
+			UNION ALL
+			SELECT to_address, token_address, type, block_number FROM deleted
+		), touched AS (
+			SELECT d.address, k.cursor_token, MIN(d.block_number) AS block_number
+			FROM deleted_addresses d
+			CROSS JOIN LATERAL (VALUES
+				(CASE WHEN d.type = 'internal' THEN 'internal' ELSE d.token_address END),
+				(CASE WHEN d.token_address != '0x0000000000000000000000000000000000000000' THEN '*' END)
+			) AS k(cursor_token)
+			WHERE k.cursor_token IS NOT NULL
+			GROUP BY d.address, k.cursor_token
+		), lowered AS (
+			UPDATE fetch_cursors c
+			SET block_number = t.block_number - 1, updated_at = NOW()
+			FROM touched t
+			WHERE c.address = t.address AND c.token_address = t.cursor_token AND c.block_number >= t.block_number
+		)
+		SELECT COUNT(*) FROM deleted
 	`
 
-	var amounts []TokenAmount
-	err := s.db.SelectContext(ctx, &amounts, query, startTime, endTime)
+	var deleted int64
+	if err := s.db.GetContext(ctx, &deleted, query, args...); err != nil {
+		return 0, fmt.Errorf("deleting transfers: %w", err)
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("getting total amounts: %w", err)
+	if deleted > 0 {
+		s.totals.clear()
 	}
 
-	return amounts, nil
+	return deleted, nil
 }
 
-// GetLastProcessedBlock retrieves the last processed block number for a specific address and token.
-// If tokenAddress is empty or "0x0000000000000000000000000000000000000000",
-// it returns the last block for ETH transfers.
-// Otherwise, it returns the last block for the specified ERC20 token transfers.
-func (s *Storage) GetLastProcessedBlock(ctx context.Context, address, tokenAddress string) (int64, error) {
-	// Normalize addresses to lowercase
-	address = strings.ToLower(address)
-	tokenAddress = strings.ToLower(tokenAddress)
+// TotalAmountsFilter filters the transfers aggregated by GetTotalAmounts.
+type TotalAmountsFilter struct {
+	// StartTime and EndTime bound the transfer timestamps. Zero times are ignored.
+	StartTime time.Time
+	EndTime   time.Time
+	// StartBlock and EndBlock bound the transfer block numbers. Zero blocks are ignored.
+	StartBlock int64
+	EndBlock   int64
+	// Direction defaults to DirectionSourceToTarget when empty.
+	Direction Direction
+	// IncludeUnknown includes tokens missing from the tokens table. Amount bounds never match them,
+	// since their decimals are unknown.
+	IncludeUnknown bool
+	// MinAmount and MaxAmount bound the amount of each transfer in normalized units of its token
+	// (amount / 10^decimals). Empty bounds are ignored.
+	MinAmount string
+	MaxAmount string
+	// TokenAddresses, FromAddresses and ToAddresses restrict the aggregation to the listed tokens,
+	// senders and recipients, on top of the direction. Empty lists are ignored.
+	TokenAddresses []string
+	FromAddresses  []string
+	ToAddresses    []string
+	// Category and Tag restrict the aggregation to transfers of which a tracked address of the direction,
+	// i.e. the source address or the target address, or either source address for DirectionAmongSources,
+	// has the category or the tag. Empty values are ignored.
+	Category string
+	Tag      string
+	// NormalizedOnly returns only the normalized amounts, computed exactly in SQL, instead of the raw sums.
+	NormalizedOnly bool
+}
 
-	// Check if we're looking for ETH or ERC20 transfers
-	var query string
+// normalizedSumColumn is the sum of the transfer amounts divided by 10^decimals of their token.
+// Multiplying by 1e-decimals keeps every digit, unlike a numeric division which rounds to a limited
+// scale, and trim_scale drops the trailing zeros like the normalization in Go. It is empty when the
+// decimals are unknown.
+const normalizedSumColumn = `COALESCE(
+				trim_scale(SUM(t.amount) * ('1e-' || tk.decimals)::numeric)::text, ''
+			) as normalized_amount`
+
+// GetTotalAmounts retrieves the total amounts of each token transferred in the direction of the filter,
+// by default from source addresses to target addresses.
+func (s *Storage) GetTotalAmounts(ctx context.Context, filter TotalAmountsFilter) ([]TokenAmount, error) {
+	if amounts, ok := s.totals.get(filter); ok {
+		return amounts, nil
+	}
 
-	var args []any
+	// Taken before querying, so the result is not cached if the data changes meanwhile
+	generation := s.totals.currentGeneration()
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if !filter.StartTime.IsZero() {
+		args = append(args, filter.StartTime)
+		conditions = append(conditions, fmt.Sprintf("t.timestamp >= $%d", len(args)))
+	}
+
+	if !filter.EndTime.IsZero() {
+		args = append(args, filter.EndTime)
+		conditions = append(conditions, fmt.Sprintf("t.timestamp <= $%d", len(args)))
+	}
+
+	if filter.StartBlock > 0 {
+		args = append(args, filter.StartBlock)
+		conditions = append(conditions, fmt.Sprintf("t.block_number >= $%d", len(args)))
+	}
+
+	if filter.EndBlock > 0 {
+		args = append(args, filter.EndBlock)
+		conditions = append(conditions, fmt.Sprintf("t.block_number <= $%d", len(args)))
+	}
+
+	switch filter.Direction {
+	case DirectionSourceToTarget, "":
+		conditions = append(conditions,
+			"t.from_address IN (SELECT address FROM source_addresses)",
+			"t.to_address IN (SELECT address FROM target_addresses)")
+	case DirectionInflow:
+		conditions = append(conditions, "t.to_address IN (SELECT address FROM target_addresses)")
+	case DirectionOutflow:
+		conditions = append(conditions, "t.from_address IN (SELECT address FROM source_addresses)")
+	case DirectionAmongSources:
+		conditions = append(conditions,
+			"t.from_address IN (SELECT address FROM source_addresses)",
+			"t.to_address IN (SELECT address FROM source_addresses)")
+	default:
+		return nil, fmt.Errorf("unsupported direction %q", filter.Direction)
+	}
+
+	if filter.Category != "" || filter.Tag != "" {
+		var labelConditions []string
+
+		if filter.Category != "" {
+			args = append(args, filter.Category)
+			labelConditions = append(labelConditions, fmt.Sprintf("category = $%d", len(args)))
+		}
+
+		if filter.Tag != "" {
+			args = append(args, filter.Tag)
+			labelConditions = append(labelConditions, fmt.Sprintf("$%d = ANY(tags)", len(args)))
+		}
+
+		labelled := strings.Join(labelConditions, " AND ")
+
+		var sides []string
+		if filter.Direction.UsesSourceAddresses() {
+			sides = append(sides, "t.from_address IN (SELECT address FROM source_addresses WHERE "+labelled+")")
+		}
+
+		switch {
+		case filter.Direction == DirectionAmongSources:
+			sides = append(sides, "t.to_address IN (SELECT address FROM source_addresses WHERE "+labelled+")")
+		case filter.Direction.UsesTargetAddresses():
+			sides = append(sides, "t.to_address IN (SELECT address FROM target_addresses WHERE "+labelled+")")
+		}
+
+		conditions = append(conditions, "("+strings.Join(sides, " OR ")+")")
+	}
+
+	addressLists := []struct {
+		column string
+		values []string
+	}{
+		{"t.token_address", filter.TokenAddresses},
+		{"t.from_address", filter.FromAddresses},
+		{"t.to_address", filter.ToAddresses},
+	}
+
+	// Lists are bound as a single array parameter each
+	for _, list := range addressLists {
+		if len(list.values) == 0 {
+			continue
+		}
+
+		args = append(args, pq.Array(lowerAll(list.values)))
+		conditions = append(conditions, fmt.Sprintf("%s = ANY($%d)", list.column, len(args)))
+	}
+
+	// Amount bounds are scaled by the decimals of each transfer's token
+	if filter.MinAmount != "" {
+		args = append(args, filter.MinAmount)
+		conditions = append(conditions, fmt.Sprintf("t.amount >= $%d::numeric * power(10::numeric, tk.decimals)", len(args)))
+	}
+
+	if filter.MaxAmount != "" {
+		args = append(args, filter.MaxAmount)
+		conditions = append(conditions, fmt.Sprintf("t.amount <= $%d::numeric * power(10::numeric, tk.decimals)", len(args)))
+	}
+
+	// A left join keeps transfers of tokens missing from the tokens table, with null metadata
+	join := "JOIN"
+	if filter.IncludeUnknown {
+		join = "LEFT JOIN"
+	}
+
+	amountColumn := "SUM(t.amount) as total_amount"
+	if filter.NormalizedOnly {
+		amountColumn = normalizedSumColumn
+	}
+
+	query := `
+		SELECT
+			t.token_address,
+			tk.symbol,
+			tk.name,
+			tk.decimals,
+			` + amountColumn + `
+		FROM
+			transfers t
+		` + join + `
+			tokens tk ON t.token_address = tk.address
+		WHERE
+			` + strings.Join(conditions, "\n\t\t\tAND ") + `
+		GROUP BY
+			t.token_address, tk.symbol, tk.name, tk.decimals
+		ORDER BY
+			tk.symbol NULLS LAST, t.token_address
+	`
+
+	var amounts []TokenAmount
+	err := s.selectRead(ctx, s.readDB(), &amounts, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting total amounts: %w", err)
+	}
+
+	s.totals.put(filter, amounts, generation)
+
+	return amounts, nil
+}
+
+// Interval is the width of the time buckets of GetAmountsBucketed.
+type Interval string
+
+// Supported intervals.
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+)
+
+// IsValid reports whether i is a supported interval.
+func (i Interval) IsValid() bool {
+	switch i {
+	case IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// BucketedAmount represents the total amount of a token transferred within a time bucket.
+type BucketedAmount struct {
+	// BucketStart is the start of the bucket in UTC. Weeks start on Monday.
+	BucketStart  time.Time `db:"bucket_start" json:"bucket_start"`
+	TokenAddress string    `db:"token_address" json:"token_address"`
+	Symbol       string    `db:"symbol" json:"symbol"`
+	Decimals     int       `db:"decimals" json:"decimals"`
+	TotalAmount  string    `db:"total_amount" json:"total_amount"`
+	// NormalizedAmount is calculated as TotalAmount / 10^Decimals
+	NormalizedAmount string `json:"normalized_amount"`
+}
+
+// GetAmountsBucketed retrieves the total amounts of each token transferred from source addresses to
+// target addresses, grouped by time bucket of the given interval.
+func (s *Storage) GetAmountsBucketed(
+	ctx context.Context, startTime, endTime time.Time, interval Interval,
+) ([]BucketedAmount, error) {
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	query := `
+		SELECT
+			date_trunc($3, t.timestamp, 'UTC') AS bucket_start,
+			t.token_address,
+			tk.symbol,
+			tk.decimals,
+			SUM(t.amount) as total_amount
+		FROM
+			transfers t
+		JOIN
+			tokens tk ON t.token_address = tk.address
+		WHERE
+			t.timestamp BETWEEN $1 AND $2
+			AND t.from_address IN (SELECT address FROM source_addresses)
+			AND t.to_address IN (SELECT address FROM target_addresses)
+		GROUP BY
+			bucket_start, t.token_address, tk.symbol, tk.decimals
+		ORDER BY
+			bucket_start, tk.symbol
+	`
+
+	var amounts []BucketedAmount
+	err := s.selectRead(ctx, s.readDB(), &amounts, query, startTime, endTime, string(interval))
+
+	if err != nil {
+		return nil, fmt.Errorf("getting bucketed amounts: %w", err)
+	}
+
+	return amounts, nil
+}
+
+// NetAmount represents the flow of a token into and out of the target addresses.
+type NetAmount struct {
+	TokenAddress string `db:"token_address" json:"token_address"`
+	Symbol       string `db:"symbol" json:"symbol"`
+	Name         string `db:"name" json:"name"`
+	Decimals     int    `db:"decimals" json:"decimals"`
+	// Inflow is the amount received by target addresses, Outflow the amount they sent.
+	Inflow  string `db:"inflow" json:"inflow"`
+	Outflow string `db:"outflow" json:"outflow"`
+	// NetAmount is Inflow minus Outflow, negative if more was sent than received.
+	NetAmount string `db:"net_amount" json:"net_amount"`
+	// NormalizedNetAmount is calculated as NetAmount / 10^Decimals
+	NormalizedNetAmount string `json:"normalized_net_amount"`
+}
+
+// GetNetAmounts retrieves the inflow into and the outflow out of target addresses of each token within
+// the time range. Transfers between two target addresses count as both and cancel out.
+func (s *Storage) GetNetAmounts(ctx context.Context, startTime, endTime time.Time) ([]NetAmount, error) {
+	query := `
+		SELECT
+			token_address,
+			symbol,
+			name,
+			decimals,
+			inflow,
+			outflow,
+			inflow - outflow as net_amount
+		FROM (
+			SELECT
+				t.token_address,
+				tk.symbol,
+				tk.name,
+				tk.decimals,
+				SUM(CASE WHEN t.to_address IN (SELECT address FROM target_addresses)
+					THEN t.amount ELSE 0 END) as inflow,
+				SUM(CASE WHEN t.from_address IN (SELECT address FROM target_addresses)
+					THEN t.amount ELSE 0 END) as outflow
+			FROM
+				transfers t
+			JOIN
+				tokens tk ON t.token_address = tk.address
+			WHERE
+				t.timestamp BETWEEN $1 AND $2
+				AND (t.to_address IN (SELECT address FROM target_addresses)
+					OR t.from_address IN (SELECT address FROM target_addresses))
+			GROUP BY
+				t.token_address, tk.symbol, tk.name, tk.decimals
+		) flows
+		ORDER BY
+			symbol
+	`
+
+	var amounts []NetAmount
+	err := s.selectRead(ctx, s.readDB(), &amounts, query, startTime, endTime)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting net amounts: %w", err)
+	}
+
+	return amounts, nil
+}
+
+// IsTrackedAddress reports whether the address is a source or target address.
+func (s *Storage) IsTrackedAddress(ctx context.Context, address string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM source_addresses WHERE address = $1)
+			OR EXISTS (SELECT 1 FROM target_addresses WHERE address = $1)
+	`
+
+	var tracked bool
+	err := s.getRead(ctx, s.db, &tracked, query, strings.ToLower(address))
+
+	if err != nil {
+		return false, fmt.Errorf("checking tracked address %s: %w", address, err)
+	}
+
+	return tracked, nil
+}
+
+// GetTableCounts retrieves the number of rows in the main tables.
+func (s *Storage) GetTableCounts(ctx context.Context) (*TableCounts, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM source_addresses) as source_addresses,
+			(SELECT COUNT(*) FROM target_addresses) as target_addresses,
+			(SELECT COUNT(*) FROM tokens) as tokens,
+			(SELECT COUNT(*) FROM transfers) as transfers
+	`
+
+	var counts TableCounts
+	err := s.getRead(ctx, s.readDB(), &counts, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting table counts: %w", err)
+	}
+
+	return &counts, nil
+}
+
+// Stats summarizes the stored data and when it was last refreshed.
+type Stats struct {
+	TableCounts
+	// LastETHUpdate and LastTokenUpdate are the times of the last successful ETH and ERC20 refresh,
+	// nil if no refresh succeeded yet.
+	LastETHUpdate   *time.Time `db:"last_eth_update" json:"last_eth_update"`
+	LastTokenUpdate *time.Time `db:"last_token_update" json:"last_token_update"`
+}
+
+// GetStats retrieves the table counts and the last refresh times.
+func (s *Storage) GetStats(ctx context.Context) (*Stats, error) {
+	// Refresh times are stored as RFC3339 config values by the transfer service
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM source_addresses) as source_addresses,
+			(SELECT COUNT(*) FROM target_addresses) as target_addresses,
+			(SELECT COUNT(*) FROM tokens) as tokens,
+			(SELECT COUNT(*) FROM transfers) as transfers,
+			(SELECT NULLIF(value, '')::timestamptz FROM config WHERE key = 'last_eth_update') as last_eth_update,
+			(SELECT NULLIF(value, '')::timestamptz FROM config WHERE key = 'last_token_update') as last_token_update
+	`
+
+	var stats Stats
+	err := s.getRead(ctx, s.readDB(), &stats, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetLastProcessedBlock retrieves the last processed block number for a specific address and token.
+// If tokenAddress is empty or "0x0000000000000000000000000000000000000000",
+// it returns the last block for ETH transfers.
+// Otherwise, it returns the last block for the specified ERC20 token transfers.
+func (s *Storage) GetLastProcessedBlock(ctx context.Context, address, tokenAddress string) (int64, error) {
+	// Normalize addresses to lowercase
+	address = strings.ToLower(address)
+	tokenAddress = strings.ToLower(tokenAddress)
+
+	// Check if we're looking for ETH or ERC20 transfers
+	var query string
+
+	var args []any
 
 	if tokenAddress == "" || tokenAddress == "0x0000000000000000000000000000000000000000" {
 		// For ETH transfers
@@ -380,6 +1258,7 @@ func (s *Storage) GetLastProcessedBlock(ctx context.Context, address, tokenAddre
 			FROM transfers
 			WHERE (from_address = $1 OR to_address = $1)
 			AND token_address = '0x0000000000000000000000000000000000000000'
+			AND type = 'normal'
 		`
 		args = []any{address}
 	} else {
@@ -394,7 +1273,7 @@ func (s *Storage) GetLastProcessedBlock(ctx context.Context, address, tokenAddre
 	}
 
 	var lastBlock int64
-	err := s.db.GetContext(ctx, &lastBlock, query, args...)
+	err := s.getRead(ctx, s.db, &lastBlock, query, args...)
 
 	if err != nil {
 		return 0, fmt.Errorf("getting last processed block for address %s and token %s: %w", address, tokenAddress, err)
@@ -403,6 +1282,133 @@ func (s *Storage) GetLastProcessedBlock(ctx context.Context, address, tokenAddre
 	return lastBlock, nil
 }
 
+// GetLastProcessedBlockForInternal retrieves the last processed block number of internal transfers for a specific address.
+func (s *Storage) GetLastProcessedBlockForInternal(ctx context.Context, address string) (int64, error) {
+	query := `
+		SELECT COALESCE(MAX(block_number), 0) as last_block
+		FROM transfers
+		WHERE (from_address = $1 OR to_address = $1)
+		AND type = 'internal'
+	`
+
+	var lastBlock int64
+	err := s.getRead(ctx, s.db, &lastBlock, query, strings.ToLower(address))
+
+	if err != nil {
+		return 0, fmt.Errorf("getting last processed block for internal transfers for address %s: %w", address, err)
+	}
+
+	return lastBlock, nil
+}
+
+// TransferActivity summarizes the stored transfers of an address fetched by one kind of fetch.
+type TransferActivity struct {
+	// Count is the number of transfers since the requested time.
+	Count int64 `db:"count"`
+	// LastTimestamp is the time of the latest transfer, nil if none is stored.
+	LastTimestamp *time.Time `db:"last_timestamp"`
+}
+
+// GetTransferActivity summarizes the stored transfers sent or received by address that are fetched
+// with the fetch cursor token cursorToken: the zero address for ETH, InternalTransfers, AllERC20Tokens
+// or the address of an ERC20 token.
+func (s *Storage) GetTransferActivity(
+	ctx context.Context, address, cursorToken string, since time.Time,
+) (*TransferActivity, error) {
+	kindCondition, args := fetchKindCondition(cursorToken, []any{strings.ToLower(address), since})
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE timestamp >= $2) as count,
+			MAX(timestamp) as last_timestamp
+		FROM transfers
+		WHERE (from_address = $1 OR to_address = $1)
+		AND ` + kindCondition
+
+	var activity TransferActivity
+	err := s.getRead(ctx, s.readDB(), &activity, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting transfer activity for address %s: %w", address, err)
+	}
+
+	return &activity, nil
+}
+
+// fetchKindCondition returns the SQL condition selecting the transfers fetched with the fetch cursor
+// token cursorToken, and args extended with its arguments.
+func fetchKindCondition(cursorToken string, args []any) (string, []any) {
+	switch cursorToken = strings.ToLower(cursorToken); cursorToken {
+	case AllERC20Tokens:
+		return "token_address != '0x0000000000000000000000000000000000000000'", args
+	case InternalTransfers:
+		return "type = 'internal'", args
+	case "", "0x0000000000000000000000000000000000000000":
+		return "token_address = '0x0000000000000000000000000000000000000000' AND type = 'normal'", args
+	default:
+		args = append(args, cursorToken)
+		return fmt.Sprintf("token_address = $%d", len(args)), args
+	}
+}
+
+// GetTransferHashes returns the distinct hashes of the stored transfers sent or received by address
+// that are fetched with the fetch cursor token cursorToken, as for GetTransferActivity, within blocks
+// fromBlock to toBlock and not older than since.
+func (s *Storage) GetTransferHashes(
+	ctx context.Context, address, cursorToken string, fromBlock, toBlock int64, since time.Time,
+) ([]string, error) {
+	kindCondition, args := fetchKindCondition(cursorToken,
+		[]any{strings.ToLower(address), fromBlock, toBlock, since})
+
+	query := `
+		SELECT DISTINCT hash
+		FROM transfers
+		WHERE (from_address = $1 OR to_address = $1)
+		AND block_number BETWEEN $2 AND $3
+		AND timestamp >= $4
+		AND ` + kindCondition
+
+	var hashes []string
+	if err := s.selectRead(ctx, s.db, &hashes, query, args...); err != nil {
+		return nil, fmt.Errorf("getting transfer hashes for address %s: %w", address, err)
+	}
+
+	return hashes, nil
+}
+
+// DeleteTransfersByHash deletes the transfers with one of hashes sent or received by address that are
+// fetched with the fetch cursor token cursorToken, as for GetTransferActivity, within blocks fromBlock
+// to toBlock, e.g. transfers of transactions reorged out of the chain. It returns the deleted transfers.
+// Unlike DeleteTransfers, the fetch cursors are not reset, as the transfers are not to be fetched again.
+func (s *Storage) DeleteTransfersByHash(
+	ctx context.Context, address, cursorToken string, hashes []string, fromBlock, toBlock int64,
+) ([]*Transfer, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	kindCondition, args := fetchKindCondition(cursorToken,
+		[]any{strings.ToLower(address), pq.Array(hashes), fromBlock, toBlock})
+
+	query := `
+		DELETE FROM transfers
+		WHERE (from_address = $1 OR to_address = $1)
+		AND hash = ANY($2)
+		AND block_number BETWEEN $3 AND $4
+		AND ` + kindCondition + `
+		RETURNING id, hash, block_number, timestamp, from_address, to_address, token_address, amount, type, created_at
+	`
+
+	var deleted []*Transfer
+	if err := s.db.SelectContext(ctx, &deleted, query, args...); err != nil {
+		return nil, fmt.Errorf("deleting transfers by hash for address %s: %w", address, err)
+	}
+
+	s.totals.invalidate(deleted)
+
+	return deleted, nil
+}
+
 // GetLastProcessedBlockForERC20 retrieves the minimum last processed block number for a specific address across all ERC20 tokens.
 // This is useful for fetching all ERC20 transfers in a single query.
 func (s *Storage) GetLastProcessedBlockForERC20(ctx context.Context, address string) (int64, error) {
@@ -423,7 +1429,7 @@ func (s *Storage) GetLastProcessedBlockForERC20(ctx context.Context, address str
 	`
 
 	var lastBlock int64
-	err := s.db.GetContext(ctx, &lastBlock, query, address)
+	err := s.getRead(ctx, s.db, &lastBlock, query, address)
 
 	if err != nil {
 		return 0, fmt.Errorf("getting last processed block for ERC20 tokens for address %s: %w", address, err)
@@ -432,12 +1438,74 @@ func (s *Storage) GetLastProcessedBlockForERC20(ctx context.Context, address str
 	return lastBlock, nil
 }
 
+// Token addresses of fetch cursors that do not belong to a single token.
+const (
+	// AllERC20Tokens is the token address of the fetch cursor of the fetch of all ERC20 tokens of an address.
+	AllERC20Tokens = "*"
+	// InternalTransfers is the token address of the fetch cursor of the internal transactions of an address.
+	InternalTransfers = "internal"
+)
+
+// FetchCursor is the highest block processed by a fetch of an address and token on a chain.
+type FetchCursor struct {
+	Address      string `db:"address"`
+	TokenAddress string `db:"token_address"`
+	ChainID      int    `db:"chain_id"`
+	BlockNumber  int64  `db:"block_number"`
+}
+
+// GetFetchCursor retrieves the highest processed block of a fetch.
+// It returns false if the fetch has no cursor yet.
+func (s *Storage) GetFetchCursor(ctx context.Context, address, tokenAddress string, chainID int) (int64, bool, error) {
+	query := `
+		SELECT block_number
+		FROM fetch_cursors
+		WHERE address = $1 AND token_address = $2 AND chain_id = $3
+	`
+
+	var blockNumber int64
+	err := s.getRead(ctx, s.db, &blockNumber, query, strings.ToLower(address), strings.ToLower(tokenAddress), chainID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+
+		return 0, false, fmt.Errorf("getting fetch cursor for address %s and token %s: %w", address, tokenAddress, err)
+	}
+
+	return blockNumber, true, nil
+}
+
+// upsertFetchCursor advances a fetch cursor within tx, it never moves a cursor backwards.
+func upsertFetchCursor(ctx context.Context, tx *sqlx.Tx, cursor FetchCursor) error {
+	query := `
+		INSERT INTO fetch_cursors (address, token_address, chain_id, block_number, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (address, token_address, chain_id) DO UPDATE
+		SET block_number = GREATEST(fetch_cursors.block_number, EXCLUDED.block_number),
+			updated_at = NOW()
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		strings.ToLower(cursor.Address),
+		strings.ToLower(cursor.TokenAddress),
+		cursor.ChainID,
+		cursor.BlockNumber)
+
+	if err != nil {
+		return fmt.Errorf("updating fetch cursor for address %s and token %s: %w", cursor.Address, cursor.TokenAddress, err)
+	}
+
+	return nil
+}
+
 // GetConfig retrieves a configuration value.
 func (s *Storage) GetConfig(ctx context.Context, key string) (string, error) {
 	query := `SELECT value FROM config WHERE key = $1`
 
 	var value string
-	err := s.db.GetContext(ctx, &value, query, key)
+	err := s.getRead(ctx, s.db, &value, query, key)
 
 	if err != nil {
 		return "", fmt.Errorf("getting config %s: %w", key, err)
@@ -446,36 +1514,279 @@ func (s *Storage) GetConfig(ctx context.Context, key string) (string, error) {
 	return value, nil
 }
 
+// GetAllConfig retrieves all configuration values keyed by name.
+func (s *Storage) GetAllConfig(ctx context.Context) (map[string]string, error) {
+	query := `SELECT id, key, value, created_at, updated_at FROM config ORDER BY key`
+
+	var rows []Config
+	err := s.selectRead(ctx, s.db, &rows, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting all config: %w", err)
+	}
+
+	config := make(map[string]string, len(rows))
+	for _, row := range rows {
+		config[row.Key] = row.Value
+	}
+
+	return config, nil
+}
+
 // UpdateConfig updates a configuration value.
+// Every change of the value is recorded in the config_history table.
 func (s *Storage) UpdateConfig(ctx context.Context, key, value string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	// Ensure transaction is rolled back if an error occurs
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				s.logger.Errorw("Failed to rollback transaction", "err", rollbackErr)
+			}
+		}
+	}()
+
+	// Lock the current row so concurrent updates record a consistent history
+	var oldValue *string
+
+	err = tx.GetContext(ctx, &oldValue, `SELECT value FROM config WHERE key = $1 FOR UPDATE`, key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("getting config %s: %w", key, err)
+	}
+
 	query := `
-		UPDATE config
-		SET value = $2, updated_at = NOW()
-		WHERE key = $1
+		INSERT INTO config (key, value)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
 	`
 
-	result, err := s.db.ExecContext(ctx, query, key, value)
-	if err != nil {
+	if _, err = tx.ExecContext(ctx, query, key, value); err != nil {
 		return fmt.Errorf("updating config %s: %w", key, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if oldValue == nil || *oldValue != value {
+		historyQuery := `
+			INSERT INTO config_history (key, old_value, new_value)
+			VALUES ($1, $2, $3)
+		`
+
+		if _, err = tx.ExecContext(ctx, historyQuery, key, oldValue, value); err != nil {
+			return fmt.Errorf("recording config history %s: %w", key, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SeedConfig stores a startup value of a configuration key unless the value was set through
+// UpdateConfig, e.g. by an operator through the API, which is recorded in the config_history table.
+// Values only inserted by the migrations, EnsureDefaults or an earlier seed are replaced. Seeding is
+// not a change by an operator, so no history is recorded and the next startup can seed another value.
+// It returns whether the value was stored.
+func (s *Storage) SeedConfig(ctx context.Context, key, value string) (bool, error) {
+	query := `
+		INSERT INTO config (key, value)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+		WHERE NOT EXISTS (SELECT 1 FROM config_history WHERE config_history.key = EXCLUDED.key)
+		RETURNING key
+	`
+
+	var seeded []string
+	if err := s.db.SelectContext(ctx, &seeded, query, key, value); err != nil {
+		return false, fmt.Errorf("seeding config %s: %w", key, err)
+	}
+
+	return len(seeded) > 0, nil
+}
+
+// EnsureDefaults inserts the default value of every config key that is not set yet, leaving existing
+// values untouched, and returns the inserted keys. Inserting defaults is not a change of the value, so
+// no history is recorded.
+func (s *Storage) EnsureDefaults(ctx context.Context, defaults map[string]string) ([]string, error) {
+	inserted := make([]string, 0, len(defaults))
+	if len(defaults) == 0 {
+		return inserted, nil
+	}
+
+	keys := make([]string, 0, len(defaults))
+	values := make([]string, 0, len(defaults))
+
+	for key, value := range defaults {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	query := `
+		INSERT INTO config (key, value)
+		SELECT * FROM unnest($1::TEXT[], $2::TEXT[])
+		ON CONFLICT (key) DO NOTHING
+		RETURNING key
+	`
+
+	if err := s.db.SelectContext(ctx, &inserted, query, pq.Array(keys), pq.Array(values)); err != nil {
+		return nil, fmt.Errorf("inserting default config: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// GetConfigHistory retrieves configuration changes matching the filter, newest first.
+func (s *Storage) GetConfigHistory(ctx context.Context, filter ConfigHistoryFilter) ([]ConfigHistory, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 4)
+
+	if filter.Key != "" {
+		args = append(args, filter.Key)
+		conditions = append(conditions, fmt.Sprintf("key = $%d", len(args)))
+	}
+
+	if !filter.StartTime.IsZero() {
+		args = append(args, filter.StartTime)
+		conditions = append(conditions, fmt.Sprintf("changed_at >= $%d", len(args)))
+	}
+
+	if !filter.EndTime.IsZero() {
+		args = append(args, filter.EndTime)
+		conditions = append(conditions, fmt.Sprintf("changed_at <= $%d", len(args)))
+	}
+
+	query := `SELECT id, key, old_value, new_value, changed_at FROM config_history`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY changed_at DESC, id DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	history := make([]ConfigHistory, 0)
+	err := s.selectRead(ctx, s.readDB(), &history, query, args...)
+
 	if err != nil {
-		return fmt.Errorf("getting rows affected: %w", err)
+		return nil, fmt.Errorf("getting config history: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		// Insert if not exists
-		insertQuery := `
-			INSERT INTO config (key, value)
-			VALUES ($1, $2)
-		`
+	return history, nil
+}
 
-		_, err := s.db.ExecContext(ctx, insertQuery, key, value)
-		if err != nil {
-			return fmt.Errorf("inserting config %s: %w", key, err)
-		}
+// Refresh run statuses.
+const (
+	RefreshRunRunning   = "running"
+	RefreshRunSucceeded = "succeeded"
+	RefreshRunFailed    = "failed"
+)
+
+// refreshRunColumns are the selected columns of a RefreshRun.
+const refreshRunColumns = `id, status, started_at, finished_at, source_addresses, inserted, error, failed_addresses,
+	EXTRACT(EPOCH FROM finished_at - started_at)::float8 AS duration_seconds`
+
+// RefreshRun records a full refresh of the transfers of all source addresses.
+type RefreshRun struct {
+	ID         int64      `db:"id" json:"id"`
+	Status     string     `db:"status" json:"status"`
+	StartedAt  time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	// DurationSeconds is nil while the refresh is running.
+	DurationSeconds *float64 `db:"duration_seconds" json:"duration_seconds,omitempty"`
+	// SourceAddresses is the number of source addresses fetched.
+	SourceAddresses int `db:"source_addresses" json:"source_addresses"`
+	// Inserted is the number of newly stored transfers.
+	Inserted int    `db:"inserted" json:"inserted"`
+	Error    string `db:"error" json:"error,omitempty"`
+	// FailedAddresses are the source addresses of which any fetch failed.
+	FailedAddresses pq.StringArray `db:"failed_addresses" json:"failed_addresses" swaggertype:"array,string"`
+}
+
+// StartRefreshRun records a running refresh started at startedAt and returns its ID.
+func (s *Storage) StartRefreshRun(ctx context.Context, startedAt time.Time) (int64, error) {
+	query := `
+		INSERT INTO refresh_runs (status, started_at)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+
+	var id int64
+	err := s.db.GetContext(ctx, &id, query, RefreshRunRunning, startedAt)
+
+	if err != nil {
+		return 0, fmt.Errorf("starting refresh run: %w", err)
+	}
+
+	return id, nil
+}
+
+// FinishRefreshRun records the outcome of the refresh run with the ID of run.
+func (s *Storage) FinishRefreshRun(ctx context.Context, run RefreshRun) error {
+	query := `
+		UPDATE refresh_runs
+		SET status = $2, finished_at = $3, source_addresses = $4, inserted = $5, error = $6,
+			failed_addresses = COALESCE($7::TEXT[], '{}')
+		WHERE id = $1
+	`
+
+	result, err := s.db.ExecContext(ctx, query,
+		run.ID, run.Status, run.FinishedAt, run.SourceAddresses, run.Inserted, run.Error, run.FailedAddresses)
+	if err != nil {
+		return fmt.Errorf("finishing refresh run %d: %w", run.ID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("finishing refresh run %d: %w", run.ID, sql.ErrNoRows)
 	}
 
 	return nil
 }
+
+// GetLastRefreshRun retrieves the most recently started refresh run.
+// It returns sql.ErrNoRows if no refresh was recorded.
+func (s *Storage) GetLastRefreshRun(ctx context.Context) (*RefreshRun, error) {
+	query := `SELECT ` + refreshRunColumns + `
+		FROM refresh_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var run RefreshRun
+	err := s.getRead(ctx, s.readDB(), &run, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("getting last refresh run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// ListRefreshRuns retrieves the limit most recently started refresh runs, newest first.
+func (s *Storage) ListRefreshRuns(ctx context.Context, limit int) ([]RefreshRun, error) {
+	query := `SELECT ` + refreshRunColumns + `
+		FROM refresh_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT $1
+	`
+
+	runs := make([]RefreshRun, 0)
+	err := s.selectRead(ctx, s.readDB(), &runs, query, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("listing refresh runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,379 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ductm54/transfer-track/internal/testutil"
+	"go.uber.org/zap"
+)
+
+const testMigrationPath = "../../migrations"
+
+func newTestStorage(t testing.TB) *Storage {
+	t.Helper()
+
+	return New(testutil.NewTestDB(t, testMigrationPath), zap.NewNop().Sugar())
+}
+
+// testTransfers returns n distinct transfers of the test token from the test source address.
+func testTransfers(n int) []*Transfer {
+	transfers := make([]*Transfer, 0, n)
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range n {
+		transfers = append(transfers, &Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    timestamp.Add(time.Duration(i) * time.Second),
+			FromAddress:  "0x00000000000000000000000000000000000000a1",
+			ToAddress:    "0x00000000000000000000000000000000000000b2",
+			TokenAddress: "0x00000000000000000000000000000000000000c3",
+			Amount:       "1000000",
+		})
+	}
+
+	return transfers
+}
+
+func TestAddTransfersBatchSkipsExisting(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	batch := testTransfers(3)
+
+	inserted, err := s.AddTransfersBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("adding batch: %v", err)
+	}
+
+	if inserted != len(batch) {
+		t.Fatalf("first insert: expected %d inserted, got %d", len(batch), inserted)
+	}
+
+	inserted, err = s.AddTransfersBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("adding batch again: %v", err)
+	}
+
+	if inserted != 0 {
+		t.Fatalf("second insert: expected 0 inserted, got %d", inserted)
+	}
+}
+
+func TestAddSourceAddressUpsert(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const address = "0x00000000000000000000000000000000000000A1"
+
+	added, inserted, err := s.AddSourceAddress(ctx, address, AddressLabels{Label: "hot wallet"})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	if !inserted {
+		t.Fatalf("first add: expected the address to be inserted")
+	}
+
+	if added.Address != "0x00000000000000000000000000000000000000a1" {
+		t.Fatalf("expected the address to be lowercased, got %s", added.Address)
+	}
+
+	relabeled, inserted, err := s.AddSourceAddress(ctx, address, AddressLabels{Label: "cold wallet"})
+	if err != nil {
+		t.Fatalf("adding source address again: %v", err)
+	}
+
+	if inserted {
+		t.Fatalf("second add: expected the existing address to be updated")
+	}
+
+	if relabeled.ID != added.ID {
+		t.Fatalf("expected the existing row %d, got %d", added.ID, relabeled.ID)
+	}
+
+	if relabeled.Label != "cold wallet" {
+		t.Fatalf("expected label %q, got %q", "cold wallet", relabeled.Label)
+	}
+
+	addresses, err := s.GetSourceAddresses(ctx)
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	if len(addresses) != 1 || addresses[0].Label != "cold wallet" {
+		t.Fatalf("expected a single relabeled address, got %+v", addresses)
+	}
+}
+
+func TestAddTargetAddressUpsert(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const address = "0x00000000000000000000000000000000000000b2"
+
+	added, inserted, err := s.AddTargetAddress(ctx, address, AddressLabels{Label: "exchange"})
+	if err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	if !inserted {
+		t.Fatalf("first add: expected the address to be inserted")
+	}
+
+	relabeled, inserted, err := s.AddTargetAddress(ctx, address, AddressLabels{Label: "bridge"})
+	if err != nil {
+		t.Fatalf("adding target address again: %v", err)
+	}
+
+	if inserted || relabeled.ID != added.ID || relabeled.Label != "bridge" {
+		t.Fatalf("expected row %d relabeled to bridge, got inserted=%v %+v", added.ID, inserted, relabeled)
+	}
+}
+
+func TestDeleteSourceAddressesMixedBatch(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	first, _, err := s.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a1", AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	_, _, err = s.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a2", AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	deletedIDs, err := s.DeleteSourceAddressesByIDs(ctx, []int64{first.ID, first.ID + 1000})
+	if err != nil {
+		t.Fatalf("deleting by ids: %v", err)
+	}
+
+	if len(deletedIDs) != 1 || deletedIDs[0] != first.ID {
+		t.Fatalf("expected only id %d deleted, got %v", first.ID, deletedIDs)
+	}
+
+	deletedAddresses, err := s.DeleteSourceAddressesByAddresses(ctx, []string{
+		"0x00000000000000000000000000000000000000A2",
+		"0x00000000000000000000000000000000000000ff",
+	})
+	if err != nil {
+		t.Fatalf("deleting by addresses: %v", err)
+	}
+
+	if len(deletedAddresses) != 1 || deletedAddresses[0] != "0x00000000000000000000000000000000000000a2" {
+		t.Fatalf("expected only the lowercased existing address deleted, got %v", deletedAddresses)
+	}
+}
+
+func TestGetByID(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	token, err := s.AddToken(ctx, "0x00000000000000000000000000000000000000c3", "TKN", "Token", 6)
+	if err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	source, _, err := s.AddSourceAddress(ctx, "0x00000000000000000000000000000000000000a1", AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding source address: %v", err)
+	}
+
+	target, _, err := s.AddTargetAddress(ctx, "0x00000000000000000000000000000000000000b2", AddressLabels{})
+	if err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	gotToken, err := s.GetTokenByID(ctx, token.ID)
+	if err != nil || gotToken.Address != token.Address {
+		t.Fatalf("expected token %s, got %+v, %v", token.Address, gotToken, err)
+	}
+
+	gotSource, err := s.GetSourceAddressByID(ctx, source.ID)
+	if err != nil || gotSource.Address != source.Address {
+		t.Fatalf("expected source address %s, got %+v, %v", source.Address, gotSource, err)
+	}
+
+	gotTarget, err := s.GetTargetAddressByID(ctx, target.ID)
+	if err != nil || gotTarget.Address != target.Address {
+		t.Fatalf("expected target address %s, got %+v, %v", target.Address, gotTarget, err)
+	}
+
+	const missingID = 999999
+
+	if _, err := s.GetTokenByID(ctx, missingID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing token: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := s.GetSourceAddressByID(ctx, missingID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing source address: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := s.GetTargetAddressByID(ctx, missingID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing target address: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestUpdateToken(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	token, err := s.AddToken(ctx, "0x00000000000000000000000000000000000000c3", "TKN", "Token", 6)
+	if err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	symbol, decimals := "NEW", 18
+
+	updated, err := s.UpdateToken(ctx, token.ID, &symbol, nil, &decimals)
+	if err != nil {
+		t.Fatalf("updating token: %v", err)
+	}
+
+	if updated.Symbol != "NEW" || updated.Name != "Token" || updated.Decimals != 18 || updated.Address != token.Address {
+		t.Fatalf("expected symbol and decimals updated and name and address kept, got %+v", updated)
+	}
+
+	unchanged, err := s.UpdateToken(ctx, token.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("updating token without changes: %v", err)
+	}
+
+	if unchanged.Symbol != updated.Symbol || unchanged.Name != updated.Name || unchanged.Decimals != updated.Decimals {
+		t.Fatalf("expected a no-op update to keep %+v, got %+v", updated, unchanged)
+	}
+
+	if _, err := s.UpdateToken(ctx, token.ID+1000, &symbol, nil, nil); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing token: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestSeedConfig(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const key = "min_refresh_interval_hours"
+
+	for _, value := range []string{"2", "3"} {
+		seeded, err := s.SeedConfig(ctx, key, value)
+		if err != nil {
+			t.Fatalf("seeding %s: %v", value, err)
+		}
+
+		if !seeded {
+			t.Fatalf("expected %s to replace a seeded value", value)
+		}
+	}
+
+	if err := s.UpdateConfig(ctx, key, "6"); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	seeded, err := s.SeedConfig(ctx, key, "1")
+	if err != nil {
+		t.Fatalf("seeding after update: %v", err)
+	}
+
+	value, err := s.GetConfig(ctx, key)
+	if err != nil {
+		t.Fatalf("getting config: %v", err)
+	}
+
+	if seeded || value != "6" {
+		t.Fatalf("expected the updated value 6 to be kept, got seeded=%v value=%s", seeded, value)
+	}
+}
+
+func TestEnsureDefaults(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// The migrations store the refresh interval, the other keys are new
+	defaults := map[string]string{
+		"min_refresh_interval_hours": "5",
+		"refresh_cron":               "",
+		"test_key":                   "default",
+	}
+
+	inserted, err := s.EnsureDefaults(ctx, defaults)
+	if err != nil {
+		t.Fatalf("ensuring defaults: %v", err)
+	}
+
+	slices.Sort(inserted)
+
+	if !slices.Equal(inserted, []string{"refresh_cron", "test_key"}) {
+		t.Fatalf("expected only the missing keys to be inserted, got %v", inserted)
+	}
+
+	if err := s.UpdateConfig(ctx, "test_key", "changed"); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	// Defaults are stored once, later runs keep every value
+	inserted, err = s.EnsureDefaults(ctx, defaults)
+	if err != nil {
+		t.Fatalf("ensuring defaults again: %v", err)
+	}
+
+	if len(inserted) != 0 {
+		t.Fatalf("expected no keys to be inserted again, got %v", inserted)
+	}
+
+	want := map[string]string{"min_refresh_interval_hours": "1", "refresh_cron": "", "test_key": "changed"}
+	for key, value := range want {
+		got, err := s.GetConfig(ctx, key)
+		if err != nil {
+			t.Fatalf("getting %s: %v", key, err)
+		}
+
+		if got != value {
+			t.Fatalf("expected %s=%q, got %q", key, value, got)
+		}
+	}
+
+	// Storing a default is not a change of the value
+	history, err := s.GetConfigHistory(ctx, ConfigHistoryFilter{Key: "refresh_cron"})
+	if err != nil {
+		t.Fatalf("getting config history: %v", err)
+	}
+
+	if len(history) != 0 {
+		t.Fatalf("expected no history of a default, got %+v", history)
+	}
+}
+
+func TestGetAllConfig(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	values := map[string]string{
+		"min_refresh_interval_hours": "4",
+		"daily_refresh_time":         "06:00:00",
+		"test_key":                   "test value",
+	}
+
+	for key, value := range values {
+		if err := s.UpdateConfig(ctx, key, value); err != nil {
+			t.Fatalf("updating %s: %v", key, err)
+		}
+	}
+
+	config, err := s.GetAllConfig(ctx)
+	if err != nil {
+		t.Fatalf("getting all config: %v", err)
+	}
+
+	for key, value := range values {
+		if config[key] != value {
+			t.Fatalf("expected %s=%q, got %q", key, value, config[key])
+		}
+	}
+}
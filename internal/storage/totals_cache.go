@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTotalsCacheTTL is the default time the results of GetTotalAmounts are cached.
+const DefaultTotalsCacheTTL = 5 * time.Minute
+
+const (
+	// totalsCacheMaxEntries bounds the number of cached total amounts.
+	totalsCacheMaxEntries = 256
+	// totalsCacheMinAge is how long ago the time range of total amounts must end for them to be cached.
+	// Ranges ending now, such as the default range of the API, get a new end on every request, so
+	// caching them would only evict the entries of fixed ranges.
+	totalsCacheMinAge = time.Minute
+)
+
+// totalsCacheEntry is the cached result of GetTotalAmounts for a filter.
+type totalsCacheEntry struct {
+	filter    TotalAmountsFilter
+	amounts   []TokenAmount
+	expiresAt time.Time
+}
+
+// totalsCache caches the results of GetTotalAmounts, which aggregate every transfer of their range,
+// for a TTL. Entries are dropped when transfers are inserted within their range and on every other
+// change of the data they depend on. Changes made by other instances sharing the database are only
+// picked up once the entries expire. As an instance serves a single chain, the chain is not part of
+// the key.
+type totalsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*totalsCacheEntry
+	// generation is incremented on every change of the data, so that results queried before a change
+	// are not cached after it.
+	generation uint64
+}
+
+func newTotalsCache() *totalsCache {
+	return &totalsCache{entries: make(map[string]*totalsCacheEntry)}
+}
+
+// setTTL sets how long results are cached. A non-positive TTL disables the cache.
+func (c *totalsCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+	clear(c.entries)
+}
+
+// currentGeneration returns the generation to pass to put for a result queried from now on.
+func (c *totalsCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// get returns a copy of the cached result of filter, if any.
+func (c *totalsCache) get(filter TotalAmountsFilter) ([]TokenAmount, bool) {
+	key := totalsCacheKey(filter)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return append([]TokenAmount(nil), entry.amounts...), true
+}
+
+// put caches a copy of the result of filter queried at generation, unless the data changed since,
+// the cache is disabled or the time range ends less than totalsCacheMinAge ago.
+func (c *totalsCache) put(filter TotalAmountsFilter, amounts []TokenAmount, generation uint64) {
+	now := time.Now()
+	if !filter.EndTime.IsZero() && filter.EndTime.After(now.Add(-totalsCacheMinAge)) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 || generation != c.generation {
+		return
+	}
+
+	if len(c.entries) >= totalsCacheMaxEntries {
+		c.evict(now)
+	}
+
+	c.entries[totalsCacheKey(filter)] = &totalsCacheEntry{
+		filter:    filter,
+		amounts:   append([]TokenAmount(nil), amounts...),
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// evict drops the expired entries, or the entry expiring first if none has. c.mu must be held.
+func (c *totalsCache) evict(now time.Time) {
+	var (
+		first    string
+		firstExp time.Time
+	)
+
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+
+		if first == "" || entry.expiresAt.Before(firstExp) {
+			first, firstExp = key, entry.expiresAt
+		}
+	}
+
+	if len(c.entries) >= totalsCacheMaxEntries {
+		delete(c.entries, first)
+	}
+}
+
+// invalidate drops the entries of which the time and block ranges contain any of the inserted transfers.
+func (c *totalsCache) invalidate(inserted []*Transfer) {
+	if len(inserted) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	for key, entry := range c.entries {
+		for _, transfer := range inserted {
+			if entry.filter.inRange(transfer) {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// clear drops every entry.
+func (c *totalsCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+}
+
+// inRange reports whether the transfer is within the time and block ranges of the filter. The other
+// conditions are ignored, so a transfer may be in range without being aggregated.
+func (f TotalAmountsFilter) inRange(transfer *Transfer) bool {
+	return (f.StartTime.IsZero() || !transfer.Timestamp.Before(f.StartTime)) &&
+		(f.EndTime.IsZero() || !transfer.Timestamp.After(f.EndTime)) &&
+		(f.StartBlock <= 0 || transfer.BlockNumber >= f.StartBlock) &&
+		(f.EndBlock <= 0 || transfer.BlockNumber <= f.EndBlock)
+}
+
+// totalsCacheKey identifies the result of a filter. Times are compared as instants, regardless of
+// their location.
+func totalsCacheKey(f TotalAmountsFilter) string {
+	return strings.Join([]string{
+		fmt.Sprint(f.StartTime.UnixNano(), f.StartTime.IsZero()),
+		fmt.Sprint(f.EndTime.UnixNano(), f.EndTime.IsZero()),
+		fmt.Sprint(f.StartBlock, f.EndBlock),
+		string(f.Direction),
+		fmt.Sprint(f.IncludeUnknown, f.NormalizedOnly),
+		f.MinAmount,
+		f.MaxAmount,
+		strings.Join(f.TokenAddresses, ","),
+		strings.Join(f.FromAddresses, ","),
+		strings.Join(f.ToAddresses, ","),
+		f.Category,
+		f.Tag,
+	}, "|")
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestTotalsCache(t *testing.T) {
+	filter := TotalAmountsFilter{StartTime: totalsStart, EndTime: totalsStart.Add(24 * time.Hour)}
+	amounts := []TokenAmount{{TokenAddress: totalsToken, TotalAmount: "1"}}
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newTotalsCache()
+		c.put(filter, amounts, c.currentGeneration())
+
+		if _, ok := c.get(filter); ok {
+			t.Fatalf("expected nothing cached before a TTL is set")
+		}
+	})
+
+	t.Run("hit", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Minute)
+		c.put(filter, amounts, c.currentGeneration())
+
+		// The same range in another location is the same key
+		got, ok := c.get(TotalAmountsFilter{
+			StartTime: filter.StartTime.In(time.FixedZone("UTC+7", 7*3600)),
+			EndTime:   filter.EndTime,
+		})
+		if !ok || len(got) != 1 || got[0].TotalAmount != "1" {
+			t.Fatalf("expected the cached amounts, got %+v (cached: %v)", got, ok)
+		}
+
+		// Callers may modify the returned amounts without affecting the cache
+		got[0].TotalAmount = "2"
+		if got, _ := c.get(filter); got[0].TotalAmount != "1" {
+			t.Fatalf("expected the cached amounts to be copied, got %+v", got)
+		}
+
+		if _, ok := c.get(TotalAmountsFilter{StartTime: filter.StartTime, EndTime: filter.EndTime, Category: "vault"}); ok {
+			t.Fatalf("expected other filters not to be cached")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Nanosecond)
+		c.put(filter, amounts, c.currentGeneration())
+		time.Sleep(time.Millisecond)
+
+		if _, ok := c.get(filter); ok {
+			t.Fatalf("expected expired amounts not to be served")
+		}
+	})
+
+	t.Run("recent range", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Minute)
+
+		recent := TotalAmountsFilter{StartTime: filter.StartTime, EndTime: time.Now()}
+		c.put(recent, amounts, c.currentGeneration())
+
+		if _, ok := c.get(recent); ok {
+			t.Fatalf("expected a range ending now not to be cached")
+		}
+	})
+
+	t.Run("changed while querying", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Minute)
+
+		generation := c.currentGeneration()
+		c.invalidate([]*Transfer{{Timestamp: totalsStart.Add(48 * time.Hour)}})
+		c.put(filter, amounts, generation)
+
+		if _, ok := c.get(filter); ok {
+			t.Fatalf("expected amounts queried before a change not to be cached")
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Minute)
+		c.put(filter, amounts, c.currentGeneration())
+
+		c.invalidate([]*Transfer{{Timestamp: totalsStart.Add(48 * time.Hour)}})
+		if _, ok := c.get(filter); !ok {
+			t.Fatalf("expected a transfer outside the range to keep the amounts cached")
+		}
+
+		c.invalidate([]*Transfer{{Timestamp: totalsStart.Add(time.Hour)}})
+		if _, ok := c.get(filter); ok {
+			t.Fatalf("expected a transfer within the range to drop the amounts")
+		}
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		c := newTotalsCache()
+		c.setTTL(time.Minute)
+
+		for i := range totalsCacheMaxEntries + 1 {
+			c.put(TotalAmountsFilter{StartBlock: int64(i + 1)}, amounts, c.currentGeneration())
+		}
+
+		if len(c.entries) != totalsCacheMaxEntries {
+			t.Fatalf("expected %d entries, got %d", totalsCacheMaxEntries, len(c.entries))
+		}
+
+		if _, ok := c.get(TotalAmountsFilter{StartBlock: 1}); ok {
+			t.Fatalf("expected the entry expiring first to be evicted")
+		}
+	})
+}
+
+func TestGetTotalAmountsCached(t *testing.T) {
+	s := newTestStorage(t)
+	s.SetTotalsCacheTTL(time.Minute)
+	seedTotals(t, s)
+
+	ctx := context.Background()
+	filter := TotalAmountsFilter{StartTime: totalsStart, EndTime: totalsStart.Add(24 * time.Hour)}
+
+	assertTotal(t, s, filter, "1")
+
+	// Another instance sharing the database does not invalidate the cache of this one, so a second
+	// identical request still serves the cached total
+	other := New(s.db, zap.NewNop().Sugar())
+	if _, err := other.AddTransfersBatch(ctx, []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  2000,
+		Timestamp:    totalsStart.Add(12 * time.Hour),
+		FromAddress:  totalsSourceA,
+		ToAddress:    totalsTarget,
+		TokenAddress: totalsToken,
+		Amount:       "16",
+	}}); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	assertTotal(t, s, filter, "1")
+
+	// A transfer inserted through this instance within the range drops the cached total
+	if _, err := s.AddTransfersBatch(ctx, []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 101),
+		BlockNumber:  2001,
+		Timestamp:    totalsStart.Add(13 * time.Hour),
+		FromAddress:  totalsSourceA,
+		ToAddress:    totalsTarget,
+		TokenAddress: totalsToken,
+		Amount:       "32",
+	}}); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	assertTotal(t, s, filter, "49")
+}
@@ -0,0 +1,472 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Addresses of the totals fixture.
+const (
+	totalsSourceA  = "0x00000000000000000000000000000000000000a1"
+	totalsSourceB  = "0x00000000000000000000000000000000000000a2"
+	totalsTarget   = "0x00000000000000000000000000000000000000b2"
+	totalsOutsider = "0x00000000000000000000000000000000000000d4"
+	totalsToken    = "0x00000000000000000000000000000000000000c3"
+)
+
+// totalsStart is the timestamp of the first transfer of the totals fixture.
+var totalsStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// seedTotals stores transfers of the test token, one hour and one block apart from block 1000, whose
+// amounts are distinct powers of two so that every sum identifies the transfers it includes:
+//
+//	1: source A -> target
+//	2: source A -> outsider
+//	4: outsider -> target
+//	8: source A -> source B
+func seedTotals(t *testing.T, s *Storage) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := s.AddToken(ctx, totalsToken, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	sources := map[string]AddressLabels{
+		totalsSourceA: {Label: "Source A", Category: "exchange", Tags: []string{"hot"}},
+		totalsSourceB: {Label: "Source B", Category: "vault"},
+	}
+	for address, labels := range sources {
+		if _, _, err := s.AddSourceAddress(ctx, address, labels); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	if _, _, err := s.AddTargetAddress(ctx, totalsTarget, AddressLabels{Label: "Target", Category: "exchange"}); err != nil {
+		t.Fatalf("adding target address: %v", err)
+	}
+
+	transfers := []struct {
+		from, to, amount string
+	}{
+		{totalsSourceA, totalsTarget, "1"},
+		{totalsSourceA, totalsOutsider, "2"},
+		{totalsOutsider, totalsTarget, "4"},
+		{totalsSourceA, totalsSourceB, "8"},
+	}
+
+	batch := make([]*Transfer, 0, len(transfers))
+	for i, tr := range transfers {
+		batch = append(batch, &Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    totalsStart.Add(time.Duration(i) * time.Hour),
+			FromAddress:  tr.from,
+			ToAddress:    tr.to,
+			TokenAddress: totalsToken,
+			Amount:       tr.amount,
+		})
+	}
+
+	if _, err := s.AddTransfersBatch(ctx, batch); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+}
+
+// totalOf returns the total amount of token in amounts, or "" if the token is missing.
+func totalOf(amounts []TokenAmount, token string) string {
+	for _, amount := range amounts {
+		if amount.TokenAddress == token {
+			return amount.TotalAmount
+		}
+	}
+
+	return ""
+}
+
+// assertTotal fails t unless the filter aggregates the test token to want, "" meaning no row.
+func assertTotal(t *testing.T, s *Storage, filter TotalAmountsFilter, want string) {
+	t.Helper()
+
+	amounts, err := s.GetTotalAmounts(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("getting total amounts: %v", err)
+	}
+
+	if got := totalOf(amounts, totalsToken); got != want {
+		t.Fatalf("expected total %q, got %q", want, got)
+	}
+}
+
+func TestGetTotalAmountsDirections(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	tests := []struct {
+		direction Direction
+		want      string
+	}{
+		{direction: "", want: "1"},
+		{direction: DirectionSourceToTarget, want: "1"},
+		{direction: DirectionInflow, want: "5"},
+		{direction: DirectionOutflow, want: "11"},
+		{direction: DirectionAmongSources, want: "8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.direction), func(t *testing.T) {
+			assertTotal(t, s, TotalAmountsFilter{Direction: tt.direction}, tt.want)
+		})
+	}
+}
+
+func TestGetTotalAmountsAmongSources(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// A single set of addresses, tracked as sources without any target
+	set := []string{
+		"0x00000000000000000000000000000000000000e1",
+		"0x00000000000000000000000000000000000000e2",
+		"0x00000000000000000000000000000000000000e3",
+	}
+
+	for i, address := range set {
+		labels := AddressLabels{Category: "treasury"}
+		if i == 2 {
+			labels = AddressLabels{Category: "ops", Tags: []string{"hot"}}
+		}
+
+		if _, _, err := s.AddSourceAddress(ctx, address, labels); err != nil {
+			t.Fatalf("adding source address: %v", err)
+		}
+	}
+
+	if _, err := s.AddToken(ctx, totalsToken, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	// Movements within the set in either direction count, transfers in or out of the set do not
+	transfers := []struct {
+		from, to, amount string
+	}{
+		{set[0], set[1], "1"},
+		{set[1], set[0], "2"},
+		{set[1], set[2], "4"},
+		{set[0], totalsOutsider, "8"},
+		{totalsOutsider, set[2], "16"},
+	}
+
+	batch := make([]*Transfer, 0, len(transfers))
+	for i, tr := range transfers {
+		batch = append(batch, &Transfer{
+			Hash:         fmt.Sprintf("0x%064x", i+1),
+			BlockNumber:  int64(1000 + i),
+			Timestamp:    totalsStart.Add(time.Duration(i) * time.Hour),
+			FromAddress:  tr.from,
+			ToAddress:    tr.to,
+			TokenAddress: totalsToken,
+			Amount:       tr.amount,
+		})
+	}
+
+	if _, err := s.AddTransfersBatch(ctx, batch); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	// Labels match either end of a movement
+	tests := []struct {
+		name   string
+		filter TotalAmountsFilter
+		want   string
+	}{
+		{name: "all", want: "7"},
+		{name: "category", filter: TotalAmountsFilter{Category: "ops"}, want: "4"},
+		{name: "tag", filter: TotalAmountsFilter{Tag: "hot"}, want: "4"},
+		{name: "category of both ends", filter: TotalAmountsFilter{Category: "treasury"}, want: "7"},
+		{name: "no match", filter: TotalAmountsFilter{Category: "vault"}, want: ""},
+		{name: "block range", filter: TotalAmountsFilter{StartBlock: 1001}, want: "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Direction = DirectionAmongSources
+			assertTotal(t, s, tt.filter, tt.want)
+		})
+	}
+}
+
+func TestGetTotalAmountsInvalidDirection(t *testing.T) {
+	s := newTestStorage(t)
+
+	if _, err := s.GetTotalAmounts(context.Background(), TotalAmountsFilter{Direction: "sideways"}); err == nil {
+		t.Fatal("expected an unsupported direction to fail")
+	}
+}
+
+func TestGetTotalAmountsBlockRange(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	// Outflows are the transfers 1, 2 and 8, in blocks 1000, 1001 and 1003
+	tests := []struct {
+		name   string
+		filter TotalAmountsFilter
+		want   string
+	}{
+		{name: "start block", filter: TotalAmountsFilter{StartBlock: 1001}, want: "10"},
+		{name: "end block", filter: TotalAmountsFilter{EndBlock: 1000}, want: "1"},
+		{name: "time only", filter: TotalAmountsFilter{StartTime: totalsStart.Add(2 * time.Hour)}, want: "8"},
+		{
+			name: "block and time",
+			filter: TotalAmountsFilter{
+				StartBlock: 1000,
+				EndBlock:   1001,
+				StartTime:  totalsStart.Add(time.Hour),
+			},
+			want: "2",
+		},
+		{name: "empty block range", filter: TotalAmountsFilter{StartBlock: 2000}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Direction = DirectionOutflow
+			assertTotal(t, s, tt.filter, tt.want)
+		})
+	}
+}
+
+func TestGetTotalAmountsAddressFilters(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	const otherToken = "0x00000000000000000000000000000000000000c4"
+
+	// Outflows are the transfers 1, 2 and 8; inflows are 1 and 4
+	tests := []struct {
+		name   string
+		filter TotalAmountsFilter
+		want   string
+	}{
+		{name: "recipient", filter: TotalAmountsFilter{ToAddresses: []string{totalsOutsider}}, want: "2"},
+		{
+			name:   "recipients in any case",
+			filter: TotalAmountsFilter{ToAddresses: []string{totalsOutsider, strings.ToUpper(totalsSourceB)}},
+			want:   "10",
+		},
+		{
+			name:   "sender on top of the direction",
+			filter: TotalAmountsFilter{Direction: DirectionInflow, FromAddresses: []string{totalsOutsider}},
+			want:   "4",
+		},
+		{name: "token", filter: TotalAmountsFilter{TokenAddresses: []string{totalsToken}}, want: "11"},
+		{name: "other token", filter: TotalAmountsFilter{TokenAddresses: []string{otherToken}}, want: ""},
+		{
+			name: "combined",
+			filter: TotalAmountsFilter{
+				TokenAddresses: []string{totalsToken},
+				FromAddresses:  []string{totalsSourceA},
+				ToAddresses:    []string{totalsTarget},
+			},
+			want: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.Direction == "" {
+				tt.filter.Direction = DirectionOutflow
+			}
+
+			assertTotal(t, s, tt.filter, tt.want)
+		})
+	}
+}
+
+func TestGetTotalAmountsIncludeUnknown(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	const unknownToken = "0x00000000000000000000000000000000000000c9"
+
+	if _, err := s.AddTransfersBatch(context.Background(), []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  1100,
+		Timestamp:    totalsStart,
+		FromAddress:  totalsSourceA,
+		ToAddress:    totalsTarget,
+		TokenAddress: unknownToken,
+		Amount:       "7",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter TotalAmountsFilter
+		want   []string
+	}{
+		{name: "catalogued only", filter: TotalAmountsFilter{}, want: []string{totalsToken}},
+		{name: "include unknown", filter: TotalAmountsFilter{IncludeUnknown: true}, want: []string{totalsToken, unknownToken}},
+		{
+			name:   "amount bound excludes unknown decimals",
+			filter: TotalAmountsFilter{IncludeUnknown: true, MinAmount: "0"},
+			want:   []string{totalsToken},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amounts, err := s.GetTotalAmounts(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("getting total amounts: %v", err)
+			}
+
+			var tokens []string
+			for _, amount := range amounts {
+				tokens = append(tokens, amount.TokenAddress)
+			}
+
+			// Catalogued tokens sort first by symbol, unknown ones last
+			if !slices.Equal(tokens, tt.want) {
+				t.Fatalf("expected tokens %v, got %v", tt.want, tokens)
+			}
+
+			if len(amounts) < 2 {
+				return
+			}
+
+			unknown := amounts[1]
+			if unknown.Symbol != nil || unknown.Name != nil || unknown.Decimals != nil || unknown.TotalAmount != "7" {
+				t.Fatalf("expected the unknown token total of 7 without metadata, got %+v", unknown)
+			}
+		})
+	}
+}
+
+func TestAddressCategoryAndTags(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	sources, err := s.GetSourceAddresses(context.Background())
+	if err != nil {
+		t.Fatalf("getting source addresses: %v", err)
+	}
+
+	want := map[string]AddressLabels{
+		totalsSourceA: {Category: "exchange", Tags: []string{"hot"}},
+		totalsSourceB: {Category: "vault", Tags: []string{}},
+	}
+
+	if len(sources) != len(want) {
+		t.Fatalf("expected %d source addresses, got %d", len(want), len(sources))
+	}
+
+	for _, source := range sources {
+		labels := want[source.Address]
+		if source.Category != labels.Category || !slices.Equal(source.Tags, labels.Tags) {
+			t.Fatalf("expected %s to have category %q and tags %v, got %q and %v",
+				source.Address, labels.Category, labels.Tags, source.Category, source.Tags)
+		}
+	}
+}
+
+func TestGetTotalAmountsLabelFilters(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	// Source A and the target are exchanges, source A is tagged hot and source B is a vault
+	tests := []struct {
+		name   string
+		filter TotalAmountsFilter
+		want   string
+	}{
+		{name: "source to target by category", filter: TotalAmountsFilter{Category: "exchange"}, want: "1"},
+		{name: "source to target by other category", filter: TotalAmountsFilter{Category: "vault"}, want: ""},
+		{
+			name:   "outflow by category",
+			filter: TotalAmountsFilter{Direction: DirectionOutflow, Category: "exchange"},
+			want:   "11",
+		},
+		{
+			name:   "outflow by recipient category only",
+			filter: TotalAmountsFilter{Direction: DirectionOutflow, Category: "vault"},
+			want:   "",
+		},
+		{
+			name:   "inflow by target category",
+			filter: TotalAmountsFilter{Direction: DirectionInflow, Category: "exchange"},
+			want:   "5",
+		},
+		{name: "outflow by tag", filter: TotalAmountsFilter{Direction: DirectionOutflow, Tag: "hot"}, want: "11"},
+		{name: "unknown tag", filter: TotalAmountsFilter{Direction: DirectionOutflow, Tag: "cold"}, want: ""},
+		{
+			name:   "category and tag",
+			filter: TotalAmountsFilter{Direction: DirectionOutflow, Category: "vault", Tag: "hot"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertTotal(t, s, tt.filter, tt.want)
+		})
+	}
+}
+
+func TestGetTotalAmountsNormalizedOnly(t *testing.T) {
+	s := newTestStorage(t)
+	seedTotals(t, s)
+
+	ctx := context.Background()
+
+	const largeToken = "0x00000000000000000000000000000000000000c5"
+
+	if _, err := s.AddToken(ctx, largeToken, "BIG", "Big", 18); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	// More digits than a float64 or a numeric division keeps
+	if _, err := s.AddTransfersBatch(ctx, []*Transfer{{
+		Hash:         fmt.Sprintf("0x%064x", 100),
+		BlockNumber:  1100,
+		Timestamp:    totalsStart,
+		FromAddress:  totalsSourceA,
+		ToAddress:    totalsTarget,
+		TokenAddress: largeToken,
+		Amount:       "123456789012345678901234567890123",
+	}}); err != nil {
+		t.Fatalf("adding transfer: %v", err)
+	}
+
+	amounts, err := s.GetTotalAmounts(ctx, TotalAmountsFilter{Direction: DirectionOutflow, NormalizedOnly: true})
+	if err != nil {
+		t.Fatalf("getting total amounts: %v", err)
+	}
+
+	want := map[string]string{
+		totalsToken: "0.000011",
+		largeToken:  "123456789012345.678901234567890123",
+	}
+
+	if len(amounts) != len(want) {
+		t.Fatalf("expected %d amounts, got %+v", len(want), amounts)
+	}
+
+	for _, amount := range amounts {
+		if amount.TotalAmount != "" {
+			t.Fatalf("expected no raw total of %s, got %q", amount.TokenAddress, amount.TotalAmount)
+		}
+
+		if amount.NormalizedAmount != want[amount.TokenAddress] {
+			t.Fatalf("expected the normalized total of %s to be %s, got %q",
+				amount.TokenAddress, want[amount.TokenAddress], amount.NormalizedAmount)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetUncataloguedTokens(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const (
+		catalogued = "0x00000000000000000000000000000000000000c3"
+		frequent   = "0x00000000000000000000000000000000000000c5"
+		rareA      = "0x00000000000000000000000000000000000000c6"
+		rareB      = "0x00000000000000000000000000000000000000c7"
+	)
+
+	if _, err := s.AddToken(ctx, catalogued, "TKN", "Token", 6); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	transfers := testTransfers(6)
+	for i, token := range []string{catalogued, frequent, frequent, frequent, rareB, rareA} {
+		transfers[i].TokenAddress = token
+	}
+
+	if _, err := s.AddTransfersBatch(ctx, transfers); err != nil {
+		t.Fatalf("adding transfers: %v", err)
+	}
+
+	tokens, err := s.GetUncataloguedTokens(ctx)
+	if err != nil {
+		t.Fatalf("getting uncatalogued tokens: %v", err)
+	}
+
+	// Ordered by the number of transfers descending, then by address
+	want := []UncataloguedToken{
+		{TokenAddress: frequent, TransferCount: 3, LastTransfer: transfers[3].Timestamp},
+		{TokenAddress: rareA, TransferCount: 1, LastTransfer: transfers[5].Timestamp},
+		{TokenAddress: rareB, TransferCount: 1, LastTransfer: transfers[4].Timestamp},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d uncatalogued tokens, got %+v", len(want), tokens)
+	}
+
+	for i, token := range tokens {
+		if token.TokenAddress != want[i].TokenAddress || token.TransferCount != want[i].TransferCount ||
+			!token.LastTransfer.Equal(want[i].LastTransfer) {
+			t.Errorf("expected uncatalogued token %d to be %+v, got %+v", i, want[i], token)
+		}
+	}
+
+	// Cataloguing a token removes it from the list
+	if _, err := s.AddToken(ctx, frequent, "FRQ", "Frequent", 18); err != nil {
+		t.Fatalf("adding token: %v", err)
+	}
+
+	tokens, err = s.GetUncataloguedTokens(ctx)
+	if err != nil {
+		t.Fatalf("getting uncatalogued tokens: %v", err)
+	}
+
+	if len(tokens) != 2 || tokens[0].TokenAddress != rareA || tokens[1].TokenAddress != rareB {
+		t.Fatalf("expected only %s and %s to be uncatalogued, got %+v", rareA, rareB, tokens)
+	}
+}
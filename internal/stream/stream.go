@@ -0,0 +1,184 @@
+// Package stream provides an in-process publish/subscribe of newly stored transfers.
+package stream
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+// DefaultBufferSize is the number of published batches a subscriber may lag behind before it is dropped.
+const DefaultBufferSize = 64
+
+// Filter selects the transfers delivered to a subscriber.
+type Filter struct {
+	// TokenAddresses restricts the transfers to these tokens. Empty matches every token.
+	TokenAddresses []string
+	// Addresses restricts the transfers to those sent from or to one of these addresses.
+	// Empty matches every address.
+	Addresses []string
+}
+
+// NewFilter returns a filter of the given tokens and addresses, compared case-insensitively.
+func NewFilter(tokenAddresses, addresses []string) Filter {
+	return Filter{
+		TokenAddresses: lowerAll(tokenAddresses),
+		Addresses:      lowerAll(addresses),
+	}
+}
+
+// Match reports whether the filter selects the transfer. Transfers are expected to be normalized
+// to lowercase addresses, as they are once stored.
+func (f Filter) Match(transfer *storage.Transfer) bool {
+	if len(f.TokenAddresses) > 0 && !slices.Contains(f.TokenAddresses, transfer.TokenAddress) {
+		return false
+	}
+
+	return len(f.Addresses) == 0 ||
+		slices.Contains(f.Addresses, transfer.FromAddress) ||
+		slices.Contains(f.Addresses, transfer.ToAddress)
+}
+
+// Subscription receives the published transfers matching its filter.
+type Subscription struct {
+	filter  Filter
+	batches chan []*storage.Transfer
+	dropped bool
+}
+
+// Transfers returns the channel of published batches of matching transfers. The channel is closed
+// when the subscription ends: after Unsubscribe, when the broker is closed, or when the subscriber
+// fell too far behind. Dropped reports the latter.
+func (s *Subscription) Transfers() <-chan []*storage.Transfer {
+	return s.batches
+}
+
+// Dropped reports whether the subscription was ended because the subscriber did not keep up.
+// It is only meaningful once the channel of Transfers is closed.
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+// Broker publishes batches of newly stored transfers to its subscribers. A slow subscriber never
+// blocks publishing: once its buffer is full, it is dropped instead.
+type Broker struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	bufferSize    int
+	closed        bool
+}
+
+// NewBroker creates a broker buffering up to bufferSize batches per subscriber.
+// A non-positive size falls back to DefaultBufferSize.
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Broker{
+		subscriptions: make(map[*Subscription]struct{}),
+		bufferSize:    bufferSize,
+	}
+}
+
+// Subscribe starts a subscription to the transfers matching filter. The subscription of a closed
+// broker is ended right away.
+func (b *Broker) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{
+		filter:  filter,
+		batches: make(chan []*storage.Transfer, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.batches)
+		return sub
+	}
+
+	b.subscriptions[sub] = struct{}{}
+
+	return sub
+}
+
+// Unsubscribe ends a subscription. Ending it again is a no-op.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscriptions)
+}
+
+// Publish delivers the transfers matching the filter of each subscriber as one batch.
+// Subscribers whose buffer is full are dropped.
+func (b *Broker) Publish(transfers []*storage.Transfer) {
+	if len(transfers) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscriptions {
+		var matching []*storage.Transfer
+
+		for _, transfer := range transfers {
+			if sub.filter.Match(transfer) {
+				matching = append(matching, transfer)
+			}
+		}
+
+		if len(matching) == 0 {
+			continue
+		}
+
+		select {
+		case sub.batches <- matching:
+		default:
+			sub.dropped = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Close ends every subscription and makes later subscriptions end right away.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for sub := range b.subscriptions {
+		b.remove(sub)
+	}
+}
+
+// remove ends a subscription. b.mu must be held.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscriptions[sub]; !ok {
+		return
+	}
+
+	delete(b.subscriptions, sub)
+	close(sub.batches)
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+
+	return lowered
+}
@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/ductm54/transfer-track/internal/storage"
+)
+
+const (
+	testSource = "0x00000000000000000000000000000000000000a1"
+	testTarget = "0x00000000000000000000000000000000000000b2"
+	testToken  = "0x00000000000000000000000000000000000000c3"
+	otherToken = "0x00000000000000000000000000000000000000c4"
+)
+
+// testTransfer returns a transfer of token from the test source to the test target.
+func testTransfer(hash, token string) *storage.Transfer {
+	return &storage.Transfer{
+		Hash:         hash,
+		FromAddress:  testSource,
+		ToAddress:    testTarget,
+		TokenAddress: token,
+		Amount:       "1",
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	transfer := testTransfer("0x01", testToken)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", filter: NewFilter(nil, nil), want: true},
+		{name: "token", filter: NewFilter([]string{testToken}, nil), want: true},
+		{name: "token in any case", filter: NewFilter([]string{"0x00000000000000000000000000000000000000C3"}, nil), want: true},
+		{name: "other token", filter: NewFilter([]string{otherToken}, nil)},
+		{name: "sender", filter: NewFilter(nil, []string{testSource}), want: true},
+		{name: "recipient", filter: NewFilter(nil, []string{testTarget}), want: true},
+		{name: "other address", filter: NewFilter(nil, []string{otherToken})},
+		{name: "token and address", filter: NewFilter([]string{testToken}, []string{testTarget}), want: true},
+		{name: "other token and address", filter: NewFilter([]string{otherToken}, []string{testTarget})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(transfer); got != tt.want {
+				t.Fatalf("expected match %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPublishDeliversMatchingTransfers(t *testing.T) {
+	broker := NewBroker(0)
+
+	all := broker.Subscribe(NewFilter(nil, nil))
+	tokenOnly := broker.Subscribe(NewFilter([]string{otherToken}, nil))
+
+	broker.Publish([]*storage.Transfer{testTransfer("0x01", testToken), testTransfer("0x02", otherToken)})
+	broker.Publish(nil)
+
+	if batch := <-all.Transfers(); len(batch) != 2 {
+		t.Fatalf("expected both transfers, got %d", len(batch))
+	}
+
+	batch := <-tokenOnly.Transfers()
+	if len(batch) != 1 || batch[0].Hash != "0x02" {
+		t.Fatalf("expected only the transfer of the filtered token, got %+v", batch)
+	}
+
+	// Empty publications and batches without matching transfers are not delivered
+	if len(all.Transfers()) != 0 || len(tokenOnly.Transfers()) != 0 {
+		t.Fatal("expected no further batches")
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	broker := NewBroker(1)
+
+	slow := broker.Subscribe(NewFilter(nil, nil))
+
+	broker.Publish([]*storage.Transfer{testTransfer("0x01", testToken)})
+	broker.Publish([]*storage.Transfer{testTransfer("0x02", testToken)})
+
+	if broker.Subscribers() != 0 {
+		t.Fatalf("expected the slow subscriber to be dropped, got %d subscribers", broker.Subscribers())
+	}
+
+	// The buffered batch is still delivered before the channel is closed
+	if batch := <-slow.Transfers(); len(batch) != 1 || batch[0].Hash != "0x01" {
+		t.Fatalf("expected the buffered batch, got %+v", batch)
+	}
+
+	if _, ok := <-slow.Transfers(); ok || !slow.Dropped() {
+		t.Fatal("expected the subscription to end as dropped")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	broker := NewBroker(0)
+
+	sub := broker.Subscribe(NewFilter(nil, nil))
+	broker.Unsubscribe(sub)
+	broker.Unsubscribe(sub)
+
+	if _, ok := <-sub.Transfers(); ok || sub.Dropped() {
+		t.Fatal("expected the subscription to end without being dropped")
+	}
+
+	if broker.Subscribers() != 0 {
+		t.Fatalf("expected no subscribers, got %d", broker.Subscribers())
+	}
+}
+
+func TestClose(t *testing.T) {
+	broker := NewBroker(0)
+
+	sub := broker.Subscribe(NewFilter(nil, nil))
+	broker.Close()
+
+	if _, ok := <-sub.Transfers(); ok || sub.Dropped() {
+		t.Fatal("expected the subscription to end when the broker is closed")
+	}
+
+	late := broker.Subscribe(NewFilter(nil, nil))
+	if _, ok := <-late.Transfers(); ok {
+		t.Fatal("expected a subscription of a closed broker to end right away")
+	}
+
+	// Publishing to a closed broker is a no-op
+	broker.Publish([]*storage.Transfer{testTransfer("0x01", testToken)})
+}
@@ -3,6 +3,9 @@ package testutil
 
 import (
 	"fmt"
+	"net"
+	"testing"
+	"time"
 
 	"github.com/ductm54/transfer-track/internal/dbutil"
 	_ "github.com/golang-migrate/migrate/v4/source/file" //nolint:go migrate
@@ -10,9 +13,50 @@ import (
 	_ "github.com/lib/pq" //nolint:sql driver name: "postgres"
 )
 
+// developmentDBAddr is the address of the database server MustNewDevelopmentDB connects to.
+const developmentDBAddr = "127.0.0.1:5432"
+
+// NewTestDB creates a development DB for the test with MustNewDevelopmentDB and drops it once the test
+// and its subtests are done. The test is skipped if no database server listens on the development
+// address, so that the tests that need none still run without Postgres.
+func NewTestDB(t testing.TB, migrationPath string) *sqlx.DB {
+	t.Helper()
+
+	return NewTestDBWithSchema(t, migrationPath, "")
+}
+
+// NewTestDBWithSchema is NewTestDB with the tables migrated into schema, which every connection of
+// the returned DB has as search_path. An empty schema uses the default one.
+func NewTestDBWithSchema(t testing.TB, migrationPath, schema string) *sqlx.DB {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", developmentDBAddr, time.Second)
+	if err != nil {
+		t.Skipf("no development database server at %s: %v", developmentDBAddr, err)
+	}
+
+	_ = conn.Close()
+
+	db, teardown := mustNewDevelopmentDB(migrationPath, schema)
+
+	t.Cleanup(func() {
+		if err := teardown(); err != nil {
+			t.Errorf("tearing down development DB: %v", err)
+		}
+	})
+
+	return db
+}
+
 // MustNewDevelopmentDB creates a new development DB.
 // It also returns a function to teardown it after the test.
 func MustNewDevelopmentDB(migrationPath string) (*sqlx.DB, func() error) {
+	return mustNewDevelopmentDB(migrationPath, "")
+}
+
+// mustNewDevelopmentDB creates a new development DB with its tables in schema, or in the default
+// schema if empty.
+func mustNewDevelopmentDB(migrationPath, schema string) (*sqlx.DB, func() error) {
 	const dbNameLen = 8
 
 	dbName := RandomString(dbNameLen)
@@ -40,21 +84,25 @@ func MustNewDevelopmentDB(migrationPath string) (*sqlx.DB, func() error) {
 		panic(err)
 	}
 
-	dsnWithDB := dbutil.FormatDSN(map[string]any{
+	props := map[string]any{
 		"host":     defaultHost,
 		"port":     defaultPort,
 		"user":     defaultUser,
 		"password": defaultPassword,
 		"sslmode":  "disable",
 		"dbname":   dbName,
-	})
+	}
+
+	if schema != "" {
+		props["options"] = dbutil.SearchPathOption(schema)
+	}
 
-	db, err := dbutil.NewDB(dsnWithDB)
+	db, err := dbutil.NewDB(dbutil.FormatDSN(props))
 	if err != nil {
 		panic(err)
 	}
 
-	m, err := dbutil.RunMigrationUp(db.DB, migrationPath, dbName)
+	m, err := dbutil.RunMigrationUp(db.DB, migrationPath, dbName, schema)
 	if err != nil {
 		panic(err)
 	}
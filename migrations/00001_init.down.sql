@@ -0,0 +1,6 @@
+-- Drop the tables of the transfer tracking system
+DROP TABLE IF EXISTS config;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS tokens;
+DROP TABLE IF EXISTS target_addresses;
+DROP TABLE IF EXISTS source_addresses;
@@ -0,0 +1,10 @@
+-- Append-only history of configuration changes
+CREATE TABLE IF NOT EXISTS config_history (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS config_history_key_changed_at_idx ON config_history(key, changed_at);
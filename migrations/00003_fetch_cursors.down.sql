@@ -0,0 +1,2 @@
+-- Fetches resume from the latest stored transfers again
+DROP TABLE IF EXISTS fetch_cursors;
@@ -0,0 +1,32 @@
+-- Highest block processed by each Etherscan fetch, used to resume fetching.
+-- ETH fetches use the zero token address, the fetch of all ERC20 tokens uses '*'.
+CREATE TABLE IF NOT EXISTS fetch_cursors (
+    address VARCHAR(42) NOT NULL,
+    token_address VARCHAR(42) NOT NULL,
+    chain_id INTEGER NOT NULL,
+    block_number BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (address, token_address, chain_id)
+);
+
+-- Backfill the cursors of source addresses from stored transfers, assuming Ethereum Mainnet.
+-- The ERC20 cursor uses the lowest last block across tokens so no transfer is skipped.
+INSERT INTO fetch_cursors (address, token_address, chain_id, block_number)
+SELECT sa.address, '0x0000000000000000000000000000000000000000', 1, MAX(t.block_number)
+FROM source_addresses sa
+JOIN transfers t ON (t.from_address = sa.address OR t.to_address = sa.address)
+WHERE t.token_address = '0x0000000000000000000000000000000000000000'
+GROUP BY sa.address
+ON CONFLICT DO NOTHING;
+
+INSERT INTO fetch_cursors (address, token_address, chain_id, block_number)
+SELECT address, '*', 1, MIN(last_block)
+FROM (
+    SELECT sa.address, t.token_address, MAX(t.block_number) AS last_block
+    FROM source_addresses sa
+    JOIN transfers t ON (t.from_address = sa.address OR t.to_address = sa.address)
+    WHERE t.token_address != '0x0000000000000000000000000000000000000000'
+    GROUP BY sa.address, t.token_address
+) AS token_blocks
+GROUP BY address
+ON CONFLICT DO NOTHING;
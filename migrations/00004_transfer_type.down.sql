@@ -0,0 +1,6 @@
+-- Internal transfers cannot be told apart from normal ones without their type, so they are deleted
+DROP INDEX IF EXISTS transfers_hash_token_from_to_type_key;
+DELETE FROM transfers WHERE type <> 'normal';
+ALTER TABLE transfers ADD CONSTRAINT transfers_hash_token_address_from_address_to_address_key
+    UNIQUE (hash, token_address, from_address, to_address);
+ALTER TABLE transfers DROP COLUMN IF EXISTS type;
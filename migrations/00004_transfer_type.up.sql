@@ -0,0 +1,7 @@
+-- Distinguish normal transactions from internal transactions (traces) moving ETH
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'normal';
+
+-- An internal transaction may move ETH between the same addresses as its parent transaction
+ALTER TABLE transfers DROP CONSTRAINT IF EXISTS transfers_hash_token_address_from_address_to_address_key;
+CREATE UNIQUE INDEX IF NOT EXISTS transfers_hash_token_from_to_type_key
+    ON transfers(hash, token_address, from_address, to_address, type);
@@ -0,0 +1,12 @@
+-- Outcome of each full refresh of the transfers of all source addresses
+CREATE TABLE IF NOT EXISTS refresh_runs (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(16) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    source_addresses INTEGER NOT NULL DEFAULT 0,
+    inserted INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS refresh_runs_started_at_idx ON refresh_runs(started_at);
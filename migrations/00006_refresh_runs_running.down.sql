@@ -0,0 +1,3 @@
+-- Runs that never finished are recorded as finishing when they started
+UPDATE refresh_runs SET finished_at = started_at WHERE finished_at IS NULL;
+ALTER TABLE refresh_runs ALTER COLUMN finished_at SET NOT NULL;
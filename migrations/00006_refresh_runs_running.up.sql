@@ -0,0 +1,3 @@
+-- Refresh runs are recorded when they start, so running ones have no finish time yet.
+-- Runs interrupted by a restart stay 'running'.
+ALTER TABLE refresh_runs ALTER COLUMN finished_at DROP NOT NULL;
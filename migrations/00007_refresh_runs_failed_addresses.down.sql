@@ -0,0 +1 @@
+ALTER TABLE refresh_runs DROP COLUMN IF EXISTS failed_addresses;
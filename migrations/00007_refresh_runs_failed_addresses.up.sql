@@ -0,0 +1,2 @@
+-- Source addresses of which any fetch failed, so that failed refreshes can be told apart by address
+ALTER TABLE refresh_runs ADD COLUMN IF NOT EXISTS failed_addresses TEXT[] NOT NULL DEFAULT '{}';
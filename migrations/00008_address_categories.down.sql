@@ -0,0 +1,5 @@
+ALTER TABLE source_addresses DROP COLUMN IF EXISTS category;
+ALTER TABLE source_addresses DROP COLUMN IF EXISTS tags;
+
+ALTER TABLE target_addresses DROP COLUMN IF EXISTS category;
+ALTER TABLE target_addresses DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,6 @@
+-- Categories (e.g. exchange, vault, multisig) and free-form tags of the tracked addresses, for filtering
+ALTER TABLE source_addresses ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE source_addresses ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE target_addresses ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE target_addresses ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS transfers_block_number_idx;
+DROP INDEX IF EXISTS transfers_to_token_block_idx;
+DROP INDEX IF EXISTS transfers_from_token_block_idx;
@@ -0,0 +1,8 @@
+-- The last processed block of an address and token is the highest block of its transfers sent or
+-- received, which Postgres looks up by combining one index per side. The indexes also serve the total
+-- amounts of inflows and outflows, which only filter on one side.
+CREATE INDEX IF NOT EXISTS transfers_from_token_block_idx ON transfers(from_address, token_address, block_number);
+CREATE INDEX IF NOT EXISTS transfers_to_token_block_idx ON transfers(to_address, token_address, block_number);
+
+-- Total amounts of a block range
+CREATE INDEX IF NOT EXISTS transfers_block_number_idx ON transfers(block_number);
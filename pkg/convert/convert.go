@@ -2,6 +2,7 @@
 package convert
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 
@@ -41,6 +42,17 @@ func FloatToWei(amount float64, decimals int64) *big.Int {
 	return r
 }
 
+// WeiToDecimal converts a Wei amount (as big.Int) to an exact decimal value with the specified number of decimals.
+func WeiToDecimal(amount *big.Int, decimals int64) decimal.Decimal {
+	return decimal.NewFromBigInt(amount, -int32(decimals))
+}
+
+// DecimalToWei converts a decimal value to a Wei amount (as big.Int) with the specified number of decimals.
+// Digits beyond the specified number of decimals are truncated.
+func DecimalToWei(amount decimal.Decimal, decimals int64) *big.Int {
+	return amount.Shift(int32(decimals)).BigInt()
+}
+
 // IntToWei converts an int64 value to a Wei amount (as big.Int) with the specified number of decimals.
 func IntToWei(amount int64, decimals int64) *big.Int {
 	weiFloat := big.NewInt(amount)
@@ -50,35 +62,39 @@ func IntToWei(amount int64, decimals int64) *big.Int {
 	return amountBig
 }
 
+// NormalizeWei converts a Wei amount (as a decimal string) to a token amount with the specified number
+// of decimals, using exact decimal division. Trailing zeros are trimmed from the result.
+func NormalizeWei(amount string, decimals int) (string, error) {
+	amountDecimal, err := decimal.NewFromString(amount)
+	if err != nil {
+		return "", fmt.Errorf("parsing amount %q: %w", amount, err)
+	}
+
+	return amountDecimal.Shift(-int32(decimals)).String(), nil
+}
+
 // RoundUp rounds a float64 value up to the nearest multiple of tickSize.
+// The value is returned unchanged if tickSize is not positive.
 func RoundUp(value float64, tickSize float64) float64 {
-	decs := int32(math.Abs(math.Round(math.Log10(tickSize))))
-	v := decimal.NewFromFloat(value)
-	rec := v.Round(decs)
-
-	if rec.LessThan(v) {
-		rec = rec.Add(decimal.NewFromFloat(tickSize))
+	if tickSize <= 0 {
+		return value
 	}
 
-	r, _ := rec.Float64()
+	tick := decimal.NewFromFloat(tickSize)
+	r, _ := decimal.NewFromFloat(value).Div(tick).Ceil().Mul(tick).Float64()
 
 	return r
 }
 
 // RoundDown rounds a float64 value down to the nearest multiple of tickSize.
+// The value is returned unchanged if tickSize is not positive.
 func RoundDown(value float64, tickSize float64) float64 {
-	decs := int32(math.Abs(math.Round(math.Log10(tickSize))))
-	v := decimal.NewFromFloat(value)
-	rec := v.Round(decs)
-
-	if rec.GreaterThan(v) {
-		rec = rec.Sub(decimal.NewFromFloat(tickSize))
-		if rec.IsNegative() {
-			rec = decimal.NewFromInt(0)
-		}
+	if tickSize <= 0 {
+		return value
 	}
 
-	r, _ := rec.Float64()
+	tick := decimal.NewFromFloat(tickSize)
+	r, _ := decimal.NewFromFloat(value).Div(tick).Floor().Mul(tick).Float64()
 
 	return r
 }
@@ -0,0 +1,140 @@
+package convert
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNormalizeWei(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals int
+		want     string
+	}{
+		{name: "6 decimals", amount: "1500000", decimals: 6, want: "1.5"},
+		{name: "6 decimals fraction", amount: "1", decimals: 6, want: "0.000001"},
+		{name: "8 decimals", amount: "123456789", decimals: 8, want: "1.23456789"},
+		{name: "8 decimals whole", amount: "2100000000000000", decimals: 8, want: "21000000"},
+		{name: "18 decimals", amount: "1000000000000000000", decimals: 18, want: "1"},
+		{name: "18 decimals smallest unit", amount: "1", decimals: 18, want: "0.000000000000000001"},
+		{
+			name:     "18 decimals beyond float precision",
+			amount:   "123456789012345678901234567890123456789",
+			decimals: 18,
+			want:     "123456789012345678901.234567890123456789",
+		},
+		{
+			name:     "uint256 max",
+			amount:   "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+			decimals: 18,
+			want:     "115792089237316195423570985008687907853269984665640564039457.584007913129639935",
+		},
+		{name: "zero", amount: "0", decimals: 18, want: "0"},
+		{name: "no decimals", amount: "42", decimals: 0, want: "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeWei(tt.amount, tt.decimals)
+			if err != nil {
+				t.Fatalf("normalizing %s: %v", tt.amount, err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeWeiInvalidAmount(t *testing.T) {
+	for _, amount := range []string{"", "abc", "1e18x", "0x10"} {
+		if got, err := NormalizeWei(amount, 18); err == nil {
+			t.Fatalf("expected %q to fail, got %s", amount, got)
+		}
+	}
+}
+
+func TestWeiToDecimalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals int64
+		want     string
+	}{
+		// 2^53 + 1 is the smallest integer float64 cannot represent
+		{name: "beyond float64 mantissa", amount: "9007199254740993", decimals: 0, want: "9007199254740993"},
+		{name: "18 decimals", amount: "1000000000000000001", decimals: 18, want: "1.000000000000000001"},
+		{
+			name:     "uint256 max",
+			amount:   "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+			decimals: 18,
+			want:     "115792089237316195423570985008687907853269984665640564039457.584007913129639935",
+		},
+		{name: "6 decimals", amount: "123456789012345678", decimals: 6, want: "123456789012.345678"},
+		{name: "zero", amount: "0", decimals: 18, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, ok := new(big.Int).SetString(tt.amount, 10)
+			if !ok {
+				t.Fatalf("parsing %s", tt.amount)
+			}
+
+			d := WeiToDecimal(amount, tt.decimals)
+			if d.String() != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, d.String())
+			}
+
+			if back := DecimalToWei(d, tt.decimals); back.Cmp(amount) != 0 {
+				t.Fatalf("round trip: expected %s, got %s", amount, back)
+			}
+		})
+	}
+}
+
+func TestDecimalToWeiTruncates(t *testing.T) {
+	got := DecimalToWei(decimal.RequireFromString("1.2345679"), 6)
+	if got.String() != "1234567" {
+		t.Fatalf("expected digits beyond 6 decimals to be truncated, got %s", got)
+	}
+}
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		tickSize float64
+		wantUp   float64
+		wantDown float64
+	}{
+		{name: "quarter tick", value: 1.1, tickSize: 0.25, wantUp: 1.25, wantDown: 1},
+		{name: "quarter tick multiple", value: 1.75, tickSize: 0.25, wantUp: 1.75, wantDown: 1.75},
+		{name: "unit tick", value: 2.4, tickSize: 1, wantUp: 3, wantDown: 2},
+		{name: "tick of 5", value: 12, tickSize: 5, wantUp: 15, wantDown: 10},
+		{name: "tick of 100", value: 1234.5, tickSize: 100, wantUp: 1300, wantDown: 1200},
+		{name: "tick of 100 multiple", value: 1200, tickSize: 100, wantUp: 1200, wantDown: 1200},
+		{name: "thousandth tick", value: 0.12345, tickSize: 0.001, wantUp: 0.124, wantDown: 0.123},
+		// 0.3 / 0.1 is 2.9999999999999996 in float64, which floors to 2
+		{name: "float drift", value: 0.3, tickSize: 0.1, wantUp: 0.3, wantDown: 0.3},
+		{name: "negative value", value: -1.1, tickSize: 0.25, wantUp: -1, wantDown: -1.25},
+		{name: "zero tick", value: 1.23, tickSize: 0, wantUp: 1.23, wantDown: 1.23},
+		{name: "negative tick", value: 1.23, tickSize: -1, wantUp: 1.23, wantDown: 1.23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundUp(tt.value, tt.tickSize); got != tt.wantUp {
+				t.Fatalf("RoundUp(%v, %v): expected %v, got %v", tt.value, tt.tickSize, tt.wantUp, got)
+			}
+
+			if got := RoundDown(tt.value, tt.tickSize); got != tt.wantDown {
+				t.Fatalf("RoundDown(%v, %v): expected %v, got %v", tt.value, tt.tickSize, tt.wantDown, got)
+			}
+		})
+	}
+}